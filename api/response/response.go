@@ -63,22 +63,61 @@ func Created(w http.ResponseWriter, data any) {
 	response(w, http.StatusCreated, data)
 }
 
+// Accepted writes an HTTP Accepted status, for a request whose outcome is left
+// to something else, such as an emailed link.
+func Accepted(w http.ResponseWriter, data any) {
+	response(w, http.StatusAccepted, data)
+}
+
 func MultiStatus(w http.ResponseWriter, data any) {
 	response(w, http.StatusMultiStatus, data)
 }
 
+// NotModified writes an HTTP Not Modified status, which has no body.
+func NotModified(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotModified)
+}
+
 func BadRequest(w http.ResponseWriter, data any) {
 	response(w, http.StatusBadRequest, data)
 }
 
+func Forbidden(w http.ResponseWriter, data any) {
+	response(w, http.StatusForbidden, data)
+}
+
 func NotFound(w http.ResponseWriter, data any) {
 	response(w, http.StatusNotFound, data)
 }
 
+func Conflict(w http.ResponseWriter, data any) {
+	response(w, http.StatusConflict, data)
+}
+
+func PreconditionFailed(w http.ResponseWriter, data any) {
+	response(w, http.StatusPreconditionFailed, data)
+}
+
+func UnsupportedMediaType(w http.ResponseWriter, data any) {
+	response(w, http.StatusUnsupportedMediaType, data)
+}
+
+func UnprocessableEntity(w http.ResponseWriter, data any) {
+	response(w, http.StatusUnprocessableEntity, data)
+}
+
+func PreconditionRequired(w http.ResponseWriter, data any) {
+	response(w, http.StatusPreconditionRequired, data)
+}
+
 func InternalServer(w http.ResponseWriter, data any) {
 	response(w, http.StatusInternalServerError, data)
 }
 
+func ServiceUnavailable(w http.ResponseWriter, data any) {
+	response(w, http.StatusServiceUnavailable, data)
+}
+
 func MethodNotAllowed(w http.ResponseWriter, method string) {
 	response(w, http.StatusMethodNotAllowed, Response{Message: fmt.Sprintf("method '%s' is not supported", method)})
 }
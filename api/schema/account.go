@@ -0,0 +1,38 @@
+package apischema
+
+import "encoding/json"
+
+// Invite is a user who is invited by email to choose their password.
+type Invite struct {
+	RoleID    int    `json:"role_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+}
+
+// AccountToken is a token that was emailed to a user, along with the password
+// they chose to accept an invite or reset their password.
+type AccountToken struct {
+	Token    string `json:"token"`
+	Password string `json:"password,omitempty"`
+}
+
+// ForgotPassword is the email of a user who forgot their password.
+type ForgotPassword struct {
+	Email string `json:"email"`
+}
+
+func NewInvite(data []byte) (Invite, error) {
+	var invite Invite
+	return invite, json.Unmarshal(data, &invite)
+}
+
+func NewAccountToken(data []byte) (AccountToken, error) {
+	var token AccountToken
+	return token, json.Unmarshal(data, &token)
+}
+
+func NewForgotPassword(data []byte) (ForgotPassword, error) {
+	var forgot ForgotPassword
+	return forgot, json.Unmarshal(data, &forgot)
+}
@@ -0,0 +1,10 @@
+package apischema
+
+import "time"
+
+// Archive is when and by whom an item, storage or unit of measurement was
+// archived. It is only in the representation of an archived one.
+type Archive struct {
+	ArchivedAt time.Time `json:"archived_at"`
+	ArchivedBy int       `json:"archived_by"`
+}
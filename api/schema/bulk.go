@@ -0,0 +1,53 @@
+package apischema
+
+// Statuses of a record in a bulk request or an import.
+const (
+	RowCreated string = "created"
+	RowUpdated string = "updated"
+	RowSkipped string = "skipped"
+	RowFailed  string = "error"
+)
+
+type (
+	// BulkReport is the result of each record of a bulk request. If the request
+	// is atomic (all-or-nothing), nothing is committed when a record fails.
+	BulkReport struct {
+		Atomic    bool        `json:"atomic"`
+		Committed bool        `json:"committed"`
+		Total     int         `json:"total"`
+		Created   int         `json:"created"`
+		Updated   int         `json:"updated"`
+		Skipped   int         `json:"skipped"`
+		Failed    int         `json:"failed"`
+		Rows      []RowResult `json:"rows"`
+	}
+
+	// RowResult is the result of a single record. Row is the index of the record
+	// in the request array, or the line of the record in a CSV.
+	RowResult struct {
+		Row    int    `json:"row"`
+		Status string `json:"status"`
+		ID     int64  `json:"id,omitempty"`
+		Error  string `json:"error,omitempty"`
+	}
+)
+
+// Add records the result of a row and updates the totals of the report.
+func (r *BulkReport) Add(row RowResult) {
+	r.Total++
+	r.Rows = append(r.Rows, row)
+
+	switch row.Status {
+	case RowCreated:
+		r.Created++
+
+	case RowUpdated:
+		r.Updated++
+
+	case RowSkipped:
+		r.Skipped++
+
+	case RowFailed:
+		r.Failed++
+	}
+}
@@ -0,0 +1,9 @@
+package apischema
+
+// ImportReport is the result of each row of an import. An import is always
+// atomic.
+type ImportReport struct {
+	Kind   string `json:"kind"`
+	DryRun bool   `json:"dry_run"`
+	BulkReport
+}
@@ -4,8 +4,10 @@ import "time"
 
 type Item struct {
 	ID           int       `json:"id"`
+	SKU          string    `json:"sku,omitempty"`
 	Name         string    `json:"name"`
 	Description  string    `json:"description,omitempty"`
+	Category     string    `json:"category,omitempty"`
 	Quantity     int       `json:"quantity"`
 	UnitPrice    float64   `json:"unit_price"`
 	UoMID        int       `json:"uom_id"`
@@ -14,8 +16,32 @@ type Item struct {
 	CreatedBy    int       `json:"created_by"`
 	DateCreated  time.Time `json:"date_created"`
 	DateModified time.Time `json:"date_modified,omitempty"`
+
+	*Archive
 }
 
 func NewItem(data []byte) ([]Item, error) {
 	return unmarshal[Item](data)
 }
+
+type ItemBarcode struct {
+	ID      int    `json:"id"`
+	ItemID  int    `json:"item_id"`
+	UoMID   int    `json:"uom_id,omitempty"`
+	Barcode string `json:"barcode"`
+	Type    string `json:"type,omitempty"`
+}
+
+func NewItemBarcode(data []byte) ([]ItemBarcode, error) {
+	return unmarshal[ItemBarcode](data)
+}
+
+// ItemLookup is the item identified by a scanned barcode or SKU and the unit
+// of measurement the code stands for.
+type ItemLookup struct {
+	Item        Item    `json:"item"`
+	UOM         UOM     `json:"uom"`
+	Factor      float64 `json:"factor"`
+	Barcode     string  `json:"barcode,omitempty"`
+	BarcodeType string  `json:"barcode_type,omitempty"`
+}
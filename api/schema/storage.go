@@ -5,6 +5,8 @@ type Storage struct {
 	Code        string `json:"code"`
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
+
+	*Archive
 }
 
 func NewStorage(data []byte) ([]Storage, error) {
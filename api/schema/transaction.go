@@ -8,17 +8,19 @@ import (
 
 type (
 	Transaction struct {
-		ID           int         `json:"id"`
-		Reference    string      `json:"reference"`
-		Orderlines   []Orderline `json:"orderlines"`
-		Amount       float64     `json:"amount"`
-		Type         string      `json:"type,omitempty"`
-		IsCancelled  bool        `json:"is_cancelled"`
-		Note         string      `json:"note,omitempty"`
-		CreatedBy    int         `json:"created_by"`
-		UpdatedBy    int         `json:"updated_by,omitempty"`
-		DateCreated  time.Time   `json:"date_created"`
-		DateModified time.Time   `json:"date_modified,omitzero"`
+		ID                int         `json:"id"`
+		Reference         string      `json:"reference"`
+		Partner           string      `json:"partner,omitempty"`
+		ExternalReference string      `json:"external_reference,omitempty"`
+		Orderlines        []Orderline `json:"orderlines"`
+		Amount            float64     `json:"amount"`
+		Type              string      `json:"type,omitempty"`
+		IsCancelled       bool        `json:"is_cancelled"`
+		Note              string      `json:"note,omitempty"`
+		CreatedBy         int         `json:"created_by"`
+		UpdatedBy         int         `json:"updated_by,omitempty"`
+		DateCreated       time.Time   `json:"date_created"`
+		DateModified      time.Time   `json:"date_modified,omitzero"`
 	}
 
 	Orderline struct {
@@ -26,6 +28,9 @@ type (
 		TransactionID int       `json:"transaction_id"`
 		ItemID        int       `json:"item_id"`
 		Quantity      int       `json:"quantity"`
+		UoMID         int       `json:"uom_id,omitempty"`
+		BaseQuantity  int       `json:"base_quantity,omitempty"`
+		BaseUoMID     int       `json:"base_uom_id,omitempty"`
 		UnitPrice     float64   `json:"unit_price"`
 		TotalAmount   float64   `json:"total_amount"`
 		Note          string    `json:"note,omitempty"`
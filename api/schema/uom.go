@@ -4,6 +4,8 @@ type UOM struct {
 	ID   int    `json:"id"`
 	Code string `json:"code"`
 	Name string `json:"name"`
+
+	*Archive
 }
 
 func NewUOM(data []byte) ([]UOM, error) {
@@ -0,0 +1,13 @@
+package apischema
+
+type UOMConversion struct {
+	ID            int     `json:"id"`
+	ItemID        int     `json:"item_id"`
+	UoMID         int     `json:"uom_id"`
+	Factor        float64 `json:"factor"`
+	ContainsUoMID int     `json:"contains_uom_id,omitempty"`
+}
+
+func NewUOMConversion(data []byte) ([]UOMConversion, error) {
+	return unmarshal[UOMConversion](data)
+}
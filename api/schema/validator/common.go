@@ -94,6 +94,15 @@ func ValidateUOM(input []byte) (bool, []string) {
 	return isValid(input, basePath+"uoms.json")
 }
 
+// ValidateUOMConversion validates the input JSON against the uom conversions schema.
+//
+// Returns:
+//   - bool: 'true' if the input is valid, 'false' otherwise.
+//   - []string: A list of error message if the validation fails.
+func ValidateUOMConversion(input []byte) (bool, []string) {
+	return isValid(input, basePath+"uom_conversions.json")
+}
+
 // ValidateItem validates the input JSON against the items schema.
 //
 // Returns:
@@ -103,6 +112,15 @@ func ValidateItem(input []byte) (bool, []string) {
 	return isValid(input, basePath+"items.json")
 }
 
+// ValidateItemBarcode validates the input JSON against the item barcodes schema.
+//
+// Returns:
+//   - bool: 'true' if the input is valid, 'false' otherwise.
+//   - []string: A list of error message if the validation fails.
+func ValidateItemBarcode(input []byte) (bool, []string) {
+	return isValid(input, basePath+"item_barcodes.json")
+}
+
 // ValidateTransaction validates the input JSON against the transaction schema.
 //
 // Returns:
@@ -120,3 +138,12 @@ func ValidateTransaction(input []byte) (bool, []string) {
 func ValidateNote(input []byte) (bool, []string) {
 	return isValid(input, basePath+"note.json")
 }
+
+// ValidateWebhook validates the input JSON against the webhooks schema.
+//
+// Returns:
+//   - bool: 'true' if the input is valid, 'false' otherwise.
+//   - []string: A list of error message if the validation fails.
+func ValidateWebhook(input []byte) (bool, []string) {
+	return isValid(input, basePath+"webhooks.json")
+}
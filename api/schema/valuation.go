@@ -0,0 +1,37 @@
+package apischema
+
+import "time"
+
+type (
+	Valuation struct {
+		AsOf       time.Time           `json:"as_of"`
+		Method     string              `json:"method"`
+		Currency   string              `json:"currency,omitempty"`
+		Quantity   int                 `json:"quantity"`
+		Value      float64             `json:"value"`
+		Items      []ItemValuation     `json:"items"`
+		Storages   []StorageValuation  `json:"storages"`
+		Categories []CategoryValuation `json:"categories"`
+	}
+
+	ItemValuation struct {
+		ItemID      int     `json:"item_id"`
+		Name        string  `json:"name"`
+		Category    string  `json:"category,omitempty"`
+		Quantity    int     `json:"quantity"`
+		Value       float64 `json:"value"`
+		AverageCost float64 `json:"average_cost"`
+	}
+
+	StorageValuation struct {
+		StorageID int     `json:"storage_id"`
+		Quantity  int     `json:"quantity"`
+		Value     float64 `json:"value"`
+	}
+
+	CategoryValuation struct {
+		Category string  `json:"category"`
+		Quantity int     `json:"quantity"`
+		Value    float64 `json:"value"`
+	}
+)
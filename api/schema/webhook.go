@@ -0,0 +1,33 @@
+package apischema
+
+import "time"
+
+type (
+	// Webhook is a URL that the inventory events are POSTed to. The secret signs
+	// the deliveries and is only returned when the webhook is created.
+	Webhook struct {
+		ID          int       `json:"id"`
+		URL         string    `json:"url"`
+		Secret      string    `json:"secret,omitempty"`
+		EventTypes  []string  `json:"event_types,omitempty"`
+		IsActive    bool      `json:"is_active"`
+		DateCreated time.Time `json:"date_created,omitzero"`
+	}
+
+	WebhookDelivery struct {
+		ID             int64     `json:"id"`
+		WebhookID      int       `json:"webhook_id"`
+		EventID        int64     `json:"event_id"`
+		Status         string    `json:"status"`
+		Attempts       int       `json:"attempts"`
+		NextAttemptAt  time.Time `json:"next_attempt_at"`
+		ResponseStatus int       `json:"response_status,omitempty"`
+		LastError      string    `json:"last_error,omitempty"`
+		DateCreated    time.Time `json:"date_created"`
+		DateModified   time.Time `json:"date_modified,omitzero"`
+	}
+)
+
+func NewWebhook(data []byte) ([]Webhook, error) {
+	return unmarshal[Webhook](data)
+}
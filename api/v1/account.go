@@ -0,0 +1,580 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/mail"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/password"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+// tokenLength is the number of random bytes of an emailed token.
+const tokenLength int = 32
+
+// mailer sends the emails of the accounts. It writes them to the log unless
+// another sender is set with UseMailer.
+var mailer mail.Sender = mail.NewLog(config.DefaultMailFrom)
+
+// UseMailer sets the sender of the emails, e.g. an SMTP server. It must be
+// called before the server is started.
+func UseMailer(sender mail.Sender) { mailer = sender }
+
+// resets are the password resets that are emailed after their request was
+// responded to.
+var resets sync.WaitGroup
+
+// WaitPasswordResets waits for the password resets being emailed, so that they
+// are sent before the database is closed.
+func WaitPasswordResets() error {
+	resets.Wait()
+	return nil
+}
+
+// accountEmail is the subject and the text of the email of a token, by its
+// purpose.
+var accountEmail = map[string]struct{ subject, text string }{
+	schema.TokenInvite: {
+		subject: "You are invited to %s",
+		text:    "You were invited to %s. Choose your password to accept the invite.",
+	},
+	schema.TokenVerification: {
+		subject: "Verify your email for %s",
+		text:    "Confirm that this is your email for %s.",
+	},
+	schema.TokenReset: {
+		subject: "Reset your password for %s",
+		text:    "A password reset was requested for your account on %s.",
+	},
+}
+
+func accountHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, acceptInvite):
+		acceptUserInvite(w, r)
+
+	case strings.HasSuffix(r.URL.Path, inviteUser):
+		inviteUserAccount(w, r)
+
+	case strings.HasSuffix(r.URL.Path, confirmEmail):
+		confirmUserEmail(w, r)
+
+	case strings.HasSuffix(r.URL.Path, verifyEmail):
+		verifyUserEmail(w, r)
+
+	case strings.HasSuffix(r.URL.Path, forgotPassword):
+		forgotUserPassword(w, r)
+
+	case strings.HasSuffix(r.URL.Path, resetPassword):
+		resetUserPassword(w, r)
+	}
+}
+
+// inviteUserAccount handles the HTTP request to invite a user by email. The user
+// is created inactive and without a password, and is emailed a token to choose
+// their password with. Inviting a user who has not accepted their invite yet
+// sends them a new one, which revokes the previous one. It writes an HTTP
+// Conflict status if the email or the name belongs to another user.
+func inviteUserAccount(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+		log.Panic()
+	}()
+
+	body, err := requestutils.ReadBody(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	invite, err := requestutils.Unmarshal(r.URL.Path, body, apischema.NewInvite)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err, "failed to unmarshal request body"))
+		return
+	}
+
+	err = validateInvite(invite)
+	if err != nil {
+		log.Error(err, "invalid invite", log.KVs(log.Map{"request": string(body), "path": r.URL.Path}))
+		response.BadRequest(w, response.NewError(err))
+
+		return
+	}
+
+	user, err := store.Users.GetByEmail(invite.Email)
+	if err != nil {
+		log.Error(err, "failed to retrieve user", log.KVs(log.Map{"email": invite.Email, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve user"))
+
+		return
+	}
+
+	success := response.Success
+
+	switch {
+	case user.ID != 0 && !pending(user):
+		err := fmt.Errorf("a user with the email '%s' already exists", invite.Email)
+		response.Conflict(w, response.NewError(err))
+
+		return
+
+	case user.ID == 0:
+		user, err = newInvitedUser(invite)
+		if err != nil {
+			log.Error(err, "failed to create user", log.KVs(log.Map{"request": string(body), "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to create user"))
+
+			return
+		}
+
+		if user.ID == 0 {
+			err := fmt.Errorf("a user named '%s %s' already exists", invite.FirstName, invite.LastName)
+			response.Conflict(w, response.NewError(err))
+
+			return
+		}
+
+		success = response.Created
+	}
+
+	err = sendToken(r.Context(), user, dbutils.GetString(user.Email), schema.TokenInvite)
+	if err != nil {
+		log.Error(err, "failed to send invite", log.KVs(log.Map{"id": user.ID, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to send invite"))
+
+		return
+	}
+
+	success(w, userResponse(user))
+}
+
+// validateInvite returns an error if the invite has no name, no valid email or
+// a role that does not exist.
+func validateInvite(invite apischema.Invite) error {
+	if strings.TrimSpace(invite.FirstName) == "" || strings.TrimSpace(invite.LastName) == "" {
+		return errors.New("'first_name' and 'last_name' are required")
+	}
+
+	err := validateEmail(invite.Email)
+	if err != nil {
+		return err
+	}
+
+	return referenced("role_id", invite.RoleID, store.Roles.Get, func(role schema.Role) bool { return role.ID != 0 })
+}
+
+// validateEmail returns an error unless the value is a bare email address, e.g.
+// 'jane@example.com' rather than 'Jane <jane@example.com>'.
+func validateEmail(email string) error {
+	address, err := netmail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return fmt.Errorf("invalid email '%s'", email)
+	}
+
+	return nil
+}
+
+// pending returns true if the user was invited and has not accepted yet.
+func pending(user schema.User) bool {
+	return !user.Active && user.Password == ""
+}
+
+// newInvitedUser creates the inactive user of the invite. It returns the zero
+// user if a user with the same name already exists.
+func newInvitedUser(invite apischema.Invite) (schema.User, error) {
+	batch, err := store.Batches.Begin()
+	if err != nil {
+		return schema.User{}, err
+	}
+
+	// Rollback is a no-op once the batch is committed.
+	defer rollbackBatch(batch)
+
+	id, err := batch.User(schema.User{
+		RoleID:    invite.RoleID,
+		FirstName: invite.FirstName,
+		LastName:  invite.LastName,
+		Email:     dbutils.SetString(invite.Email),
+	})
+
+	if err != nil || id == 0 {
+		return schema.User{}, err
+	}
+
+	err = batch.Commit()
+	if err != nil {
+		return schema.User{}, err
+	}
+
+	return store.Users.Get(int(id))
+}
+
+// acceptUserInvite handles the HTTP request to accept an invite with the token
+// that was emailed and the password the user chose. The user is then active,
+// and their email verified since they received the token there.
+func acceptUserInvite(w http.ResponseWriter, r *http.Request) {
+	redeem(w, r, schema.TokenInvite, func(user schema.User, hash string) (schema.User, []string) {
+		user.Password = hash
+		user.Active = true
+		user.VerifiedEmail = verified(user)
+
+		return user, []string{"password", "is_active", "verified_email"}
+	})
+}
+
+// verifyUserEmail handles the HTTP request to email a user a token to verify
+// their email with. It writes an HTTP Accepted status once the email is sent,
+// or an HTTP OK status if the email is already verified.
+func verifyUserEmail(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	id, err := parameterID(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	if !actor(w, r, "id", id) {
+		return
+	}
+
+	user, err := store.Users.Get(id)
+	if err != nil {
+		log.Error(err, "failed to retrieve user", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve user"))
+
+		return
+	}
+
+	switch {
+	case !user.Email.Valid:
+		response.BadRequest(w, response.NewError(fmt.Errorf("user %d has no email", id)))
+		return
+
+	case user.EmailVerified():
+		response.Success(w, response.New("the email is already verified"))
+		return
+	}
+
+	err = sendToken(r.Context(), user, user.Email.String, schema.TokenVerification)
+	if err != nil {
+		log.Error(err, "failed to send email verification", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to send email verification"))
+
+		return
+	}
+
+	response.Accepted(w, response.New("the email verification was sent"))
+}
+
+// confirmUserEmail handles the HTTP request to verify the email of a user with
+// the token that was emailed. The token only verifies the email it was sent to,
+// so it is rejected once the user has another email.
+func confirmUserEmail(w http.ResponseWriter, r *http.Request) {
+	redeem(w, r, schema.TokenVerification, func(user schema.User, _ string) (schema.User, []string) {
+		user.VerifiedEmail = verified(user)
+		return user, []string{"verified_email"}
+	})
+}
+
+// forgotUserPassword handles the HTTP request to email a user a token to reset
+// their password with. It writes an HTTP Accepted status whether or not the
+// email belongs to an active user, so that it does not tell which emails do.
+// The user is retrieved and emailed after the response is written, so that
+// neither does the time it takes.
+func forgotUserPassword(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+		log.Panic()
+	}()
+
+	body, err := requestutils.ReadBody(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	forgot, err := requestutils.Unmarshal(r.URL.Path, body, apischema.NewForgotPassword)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err, "failed to unmarshal request body"))
+		return
+	}
+
+	err = validateEmail(forgot.Email)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	resets.Add(1)
+
+	go func(ctx context.Context, email, path string) {
+		defer resets.Done()
+		defer log.Panic("email", email, "path", path)
+
+		sendPasswordReset(ctx, email, path)
+	}(context.WithoutCancel(r.Context()), forgot.Email, r.URL.Path)
+
+	response.Accepted(w, response.New("a password reset is sent to the email if it belongs to an active user"))
+}
+
+// sendPasswordReset emails a token to reset their password to the active user
+// of the email, if any. The errors are only logged, since the request was
+// already responded to.
+func sendPasswordReset(ctx context.Context, email, path string) {
+	user, err := store.Users.GetByEmail(email)
+	if err != nil {
+		log.Error(err, "failed to retrieve user", log.KVs(log.Map{"email": email, "path": path}))
+		return
+	}
+
+	if user.ID == 0 || !user.Active {
+		return
+	}
+
+	err = sendToken(ctx, user, user.Email.String, schema.TokenReset)
+	if err != nil {
+		log.Error(err, "failed to send password reset", log.KVs(log.Map{"id": user.ID, "path": path}))
+	}
+}
+
+// resetUserPassword handles the HTTP request to reset the password of a user
+// with the token that was emailed. Only an active user can reset their password.
+func resetUserPassword(w http.ResponseWriter, r *http.Request) {
+	redeem(w, r, schema.TokenReset, func(user schema.User, hash string) (schema.User, []string) {
+		user.Password = hash
+		user.VerifiedEmail = verified(user)
+
+		return user, []string{"password", "verified_email"}
+	})
+}
+
+// redeem handles the HTTP request to redeem a token of the purpose. The change
+// returns the user with the password of the request, if any, and the columns it
+// changed, which are saved as the token is used. The password is checked against
+// the password policy and given to the change hashed, unless the purpose is to
+// verify an email. A token that
+// does not exist, is of another purpose, was used or revoked, or expired is
+// rejected with an HTTP Bad Request status, with the same error for each.
+func redeem(w http.ResponseWriter, r *http.Request, purpose string, change func(schema.User, string) (schema.User, []string)) {
+	defer func() {
+		_ = r.Body.Close()
+		log.Panic()
+	}()
+
+	body, err := requestutils.ReadBody(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	data, err := requestutils.Unmarshal(r.URL.Path, body, apischema.NewAccountToken)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err, "failed to unmarshal request body"))
+		return
+	}
+
+	if purpose != schema.TokenVerification {
+		err = config.Get().PasswordPolicy().Check(data.Password)
+		if err != nil {
+			response.BadRequest(w, response.NewError(err))
+			return
+		}
+
+		data.Password, err = password.Hash(data.Password)
+		if err != nil {
+			log.Error(err, "failed to hash password", log.KV("path", r.URL.Path))
+			response.InternalServer(w, response.NewError(err, "failed to hash password"))
+
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+
+	token, err := store.Tokens.Get(hashToken(data.Token))
+	if err != nil {
+		log.Error(err, "failed to retrieve token", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve token"))
+
+		return
+	}
+
+	user, err := store.Users.Get(token.UserID)
+	if err != nil {
+		log.Error(err, "failed to retrieve user", log.KVs(log.Map{"id": token.UserID, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve user"))
+
+		return
+	}
+
+	if !redeemable(token, user, purpose, now) {
+		response.BadRequest(w, response.NewError(repository.ErrInvalidToken))
+		return
+	}
+
+	// The token, rather than the version of the user, is what the request
+	// must still be valid for.
+	user.Version = 0
+
+	user, columns := change(user, data.Password)
+
+	err = store.Tokens.Redeem(token, now, user, columns...)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidToken) {
+			response.BadRequest(w, response.NewError(err))
+			return
+		}
+
+		log.Error(err, "failed to redeem token", log.KVs(log.Map{"id": user.ID, "purpose": purpose, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to redeem token"))
+
+		return
+	}
+
+	user, err = store.Users.Get(user.ID)
+	if err != nil {
+		log.Error(err, "failed to retrieve user", log.KVs(log.Map{"id": user.ID, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve user"))
+
+		return
+	}
+
+	response.Success(w, userResponse(user))
+}
+
+// redeemable returns true if the token of the purpose can still be used by the
+// user, whose email must still be the one the token was sent to. An invite is
+// only for a user who has not accepted one yet, and the other tokens only for an
+// active user.
+func redeemable(token schema.UserToken, user schema.User, purpose string, now time.Time) bool {
+	if token.ID == 0 || user.ID == 0 || token.Purpose != purpose || token.UsedAt.Valid || token.IsExpired(now) {
+		return false
+	}
+
+	if !user.Email.Valid || !strings.EqualFold(user.Email.String, token.Email) {
+		return false
+	}
+
+	if purpose == schema.TokenInvite {
+		return pending(user)
+	}
+
+	return user.Active
+}
+
+// verified returns the email that the token verifies: the one it was sent to,
+// which redeemable checks is still the email of the user.
+func verified(user schema.User) sql.NullString {
+	return dbutils.SetString(user.Email.String)
+}
+
+// sendToken issues a token of the purpose to the user and emails it to them at
+// the email. Issuing it revokes the tokens of the purpose that were sent before.
+func sendToken(ctx context.Context, user schema.User, email, purpose string) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	cfg := config.Get()
+
+	ttl := map[string]time.Duration{
+		schema.TokenInvite:       cfg.InviteTTL(),
+		schema.TokenVerification: cfg.VerificationTTL(),
+		schema.TokenReset:        cfg.ResetTTL(),
+	}
+
+	expiresAt := time.Now().UTC().Add(ttl[purpose])
+
+	err = store.Tokens.Issue(schema.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		Email:     email,
+		ExpiresAt: expiresAt,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	content := accountEmail[purpose]
+	recipient := netmail.Address{Name: user.FirstName + " " + user.LastName, Address: email}
+
+	body := fmt.Sprintf(
+		"Hello %s,\n\n%s\n\n%s\n\nIt expires on %s. If you did not expect this email, you can ignore it.\n",
+		user.FirstName,
+		fmt.Sprintf(content.text, cfg.AppName()),
+		tokenLink(cfg.AccountLinkURL(), purpose, token),
+		expiresAt.Format(time.RFC1123),
+	)
+
+	return mailer.Send(ctx, mail.Message{
+		To:      recipient.String(),
+		Subject: fmt.Sprintf(content.subject, cfg.AppName()),
+		Body:    body,
+	})
+}
+
+// tokenLink returns the line of the email with the token: the link to the page
+// with the purpose and the token in its query, or the token itself if there is
+// no page.
+func tokenLink(page, purpose, token string) string {
+	if page == "" {
+		return "Use this token: " + token
+	}
+
+	link, err := url.Parse(page)
+	if err != nil {
+		return "Use this token: " + token
+	}
+
+	query := link.Query()
+	query.Set("action", purpose)
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return "Open this link: " + link.String()
+}
+
+// newToken returns a random token that can be written in a URL.
+func newToken() (string, error) {
+	token := make([]byte, tokenLength)
+
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// hashToken returns the SHA-256 hash of the token, which is what is stored.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+)
+
+var (
+	// errUnknownUser is wrapped by the error of an actor that does not exist.
+	errUnknownUser = errors.New("the user does not exist")
+
+	// errInactiveUser is wrapped by the error of an actor that was deactivated.
+	errInactiveUser = errors.New("the user is inactive")
+)
+
+// activeUser returns an error if the user that the member of a request refers
+// to, on whose behalf a record is created or changed, does not exist or is not
+// active. It is the one check of the actors of the API, so that a deactivated
+// user can no longer create, cancel, annotate, import or archive anything.
+func activeUser(member string, userID int) error {
+	user, err := store.Users.Get(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.ID == 0 {
+		return fmt.Errorf("%s %d: %w", member, userID, errUnknownUser)
+	}
+
+	if !user.Active {
+		return fmt.Errorf("%s %d: %w", member, userID, errInactiveUser)
+	}
+
+	return nil
+}
+
+// actor writes the error and returns false if the user of the member cannot
+// act, as activeUser.
+func actor(w http.ResponseWriter, r *http.Request, member string, userID int) bool {
+	err := activeUser(member, userID)
+	if err == nil {
+		return true
+	}
+
+	if !rejectedActor(w, r, err) {
+		log.Error(err, "failed to retrieve user", log.KVs(log.Map{member: userID, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve user"))
+	}
+
+	return false
+}
+
+// rejectedActor writes an HTTP Bad Request status if the error is of a user who
+// does not exist, or an HTTP Forbidden status if it is of a user who is not
+// active, and returns true. It returns false for any other error.
+func rejectedActor(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, errUnknownUser):
+		log.Error(err, "unknown user", log.KV("path", r.URL.Path))
+		response.BadRequest(w, response.NewError(err))
+
+	case errors.Is(err, errInactiveUser):
+		log.Error(err, "inactive user", log.KV("path", r.URL.Path))
+		response.Forbidden(w, response.NewError(err, "activate the user to act on their behalf"))
+
+	default:
+		return false
+	}
+
+	return true
+}
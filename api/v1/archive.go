@@ -0,0 +1,223 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+// archival describes how a resource of record R is archived and restored.
+type archival[R any] struct {
+	// name is the name of the resource in the messages.
+	name string
+
+	// get returns the current record of the ID, and exists reports whether
+	// it was found.
+	get    func(id int) (R, error)
+	exists func(record R) bool
+
+	// archived reports whether the record is archived, and version returns
+	// its version.
+	archived func(record R) bool
+	version  func(record R) int
+
+	// view returns the representation of the record.
+	view func(record R) any
+
+	// archive sets the record as archived by the user, and restore sets it as
+	// not archived again.
+	archive func(id, userID, version int) error
+	restore func(id, version int) error
+}
+
+// itemArchival, storageArchival and uomArchival are the archivals of the items,
+// storages and units of measurement.
+func itemArchival() archival[schema.Item] {
+	return archival[schema.Item]{
+		name:     "item",
+		get:      store.Items.Get,
+		exists:   func(item schema.Item) bool { return item.ID != 0 },
+		archived: func(item schema.Item) bool { return item.ArchivedAt.Valid },
+		version:  func(item schema.Item) int { return item.Version },
+		view:     func(item schema.Item) any { return itemResponse(item) },
+		archive:  store.Items.Archive,
+		restore:  store.Items.Restore,
+	}
+}
+
+func storageArchival() archival[schema.Storage] {
+	return archival[schema.Storage]{
+		name:     "storage",
+		get:      store.Storages.Get,
+		exists:   func(storage schema.Storage) bool { return storage.ID != 0 },
+		archived: func(storage schema.Storage) bool { return storage.ArchivedAt.Valid },
+		version:  func(storage schema.Storage) int { return storage.Version },
+		view:     func(storage schema.Storage) any { return storageResponse(storage) },
+		archive:  store.Storages.Archive,
+		restore:  store.Storages.Restore,
+	}
+}
+
+func uomArchival() archival[schema.UOM] {
+	return archival[schema.UOM]{
+		name:     "uom",
+		get:      store.UOMs.Get,
+		exists:   func(uom schema.UOM) bool { return uom.ID != 0 },
+		archived: func(uom schema.UOM) bool { return uom.ArchivedAt.Valid },
+		version:  func(uom schema.UOM) int { return uom.Version },
+		view:     func(uom schema.UOM) any { return uomResponse(uom) },
+		archive:  store.UOMs.Archive,
+		restore:  store.UOMs.Restore,
+	}
+}
+
+// archiveResource handles the HTTP request to archive the resource of the 'id'
+// query parameter by the user of the 'user_id' query parameter. An archived
+// resource is left out of the lists but kept for the records that refer to it.
+func archiveResource[R any](w http.ResponseWriter, r *http.Request, a archival[R]) {
+	defer log.Panic()
+
+	userID, err := parameterUserID(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	if !actor(w, r, "user_id", userID) {
+		return
+	}
+
+	setArchived(w, r, a, userID)
+}
+
+// restoreResource handles the HTTP request to restore the archived resource of
+// the 'id' query parameter.
+func restoreResource[R any](w http.ResponseWriter, r *http.Request, a archival[R]) {
+	defer log.Panic()
+
+	setArchived(w, r, a, 0)
+}
+
+// setArchived archives the resource of the 'id' query parameter by the user, or
+// restores it if the user is 0, and writes the resource with its ETag. A
+// resource that is already archived, or not archived, is left unchanged.
+func setArchived[R any](w http.ResponseWriter, r *http.Request, a archival[R], userID int) {
+	id, err := parameterID(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	version, ok := precondition(w, r, id)
+	if !ok {
+		return
+	}
+
+	record, err := a.get(id)
+	if err != nil {
+		log.Error(err, "failed to retrieve "+a.name, log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve "+a.name))
+
+		return
+	}
+
+	if !a.exists(record) {
+		response.NotFound(w, response.New(a.name+" not found", map[string]any{"id": id}))
+		return
+	}
+
+	if version != 0 && a.version(record) != version {
+		versionMismatch(w, r, id, repository.ErrVersionMismatch)
+		return
+	}
+
+	if a.archived(record) != (userID != 0) {
+		if userID != 0 {
+			err = a.archive(id, userID, version)
+		} else {
+			err = a.restore(id, version)
+		}
+
+		if err != nil {
+			if versionMismatch(w, r, id, err) {
+				return
+			}
+
+			log.Error(err, "failed to archive or restore "+a.name, log.KVs(log.Map{"id": id, "user_id": userID, "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to archive or restore "+a.name))
+
+			return
+		}
+
+		record, err = a.get(id)
+		if err != nil {
+			log.Error(err, "failed to retrieve "+a.name, log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to retrieve "+a.name))
+
+			return
+		}
+	}
+
+	w.Header().Set("ETag", versionTag(a.version(record)))
+	response.Success(w, a.view(record))
+}
+
+// parameterArchived returns whether the 'archived' query parameter asks for the
+// archived records rather than the others.
+func parameterArchived(r *http.Request) (bool, error) {
+	archivedParam, ok := requestutils.HasQueryParam(r, "archived")
+	if !ok {
+		return false, nil
+	}
+
+	archived, err := strconv.ParseBool(archivedParam)
+	if err != nil {
+		log.Error(err, "failed to parse 'archived' query parameter", log.KVs(log.Map{"archived": archivedParam, "path": r.URL.Path}))
+		return false, errors.New("invalid 'archived' value; must be a boolean")
+	}
+
+	return archived, nil
+}
+
+// archiveResponse returns the archive of the representation of a record, or nil
+// if it is not archived.
+func archiveResponse(archivedAt sql.NullTime, archivedBy sql.NullInt32) *apischema.Archive {
+	if !archivedAt.Valid {
+		return nil
+	}
+
+	return &apischema.Archive{ArchivedAt: archivedAt.Time, ArchivedBy: int(archivedBy.Int32)}
+}
+
+// deleteConflict writes an HTTP Conflict status with what keeps the resource of
+// the ID from being deleted, and returns true, if the error is that it is still
+// referenced or has stock on hand.
+func deleteConflict(w http.ResponseWriter, r *http.Request, id int, err error) bool {
+	var blocked *repository.ReferencedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+
+	details := map[string]any{
+		"id":         id,
+		"references": blocked.References,
+		"message":    "archive it instead",
+	}
+
+	if blocked.Quantity != 0 {
+		details["quantity"] = blocked.Quantity
+	}
+
+	log.Error(err, "the resource cannot be deleted", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+	response.Conflict(w, response.NewError(err, details))
+
+	return true
+}
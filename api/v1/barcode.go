@@ -0,0 +1,328 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/api/schema/validator"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/barcode"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+func barcodeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		getBarcodes(w, r)
+
+	case http.MethodPost:
+		createBarcode(w, r)
+
+	case http.MethodDelete:
+		deleteBarcode(w, r)
+	}
+}
+
+func lookupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		lookupItem(w, r)
+	}
+}
+
+// getBarcodes handles the HTTP request to retrieve the barcodes of an item
+// ('item_id') or a specific barcode ('id').
+func getBarcodes(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	_, hasID := requestutils.HasQueryParam(r, "id")
+	itemIDParam, hasItemID := requestutils.HasQueryParam(r, "item_id")
+
+	if !hasID && !hasItemID {
+		err := errors.New("missing 'id' or 'item_id' in the request query parameter")
+		log.Error(err, "query parameter 'id' or 'item_id' is required", log.KV("path", r.URL.Path))
+		response.BadRequest(w, response.NewError(err))
+
+		return
+	}
+
+	itemID, err := strconv.Atoi(itemIDParam)
+	if hasItemID && err != nil {
+		log.Error(err, "failed to parse 'item_id' query parameter",
+			log.KVs(log.Map{"item_id": itemIDParam, "path": r.URL.Path}))
+
+		response.BadRequest(w, response.NewError(errors.New("invalid 'item_id' value; must be an integer")))
+
+		return
+	}
+
+	list, err := getList(r, store.Barcodes.Get,
+		func() ([]schema.ItemBarcode, error) { return store.Barcodes.List(itemID) })
+	if err != nil {
+		log.Error(err, "failed to retrieve barcodes", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve barcodes"))
+
+		return
+	}
+
+	barcodes := convert.SchemaList(list, func(code schema.ItemBarcode) apischema.ItemBarcode {
+		return apischema.ItemBarcode{
+			ID:      code.ID,
+			ItemID:  code.ItemID,
+			UoMID:   dbutils.GetAsInt(code.UoMID),
+			Barcode: code.Barcode,
+			Type:    code.Type,
+		}
+	})
+
+	response.Success(w, barcodes)
+}
+
+// createBarcode handles the HTTP request to add barcodes to items. The check
+// digit of each barcode is validated and a barcode can only belong to one item,
+// so a barcode that is already assigned fails. A barcode may be for a unit of
+// measurement that the item can be converted to.
+func createBarcode(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+		log.Panic()
+	}()
+
+	body, err := requestutils.ReadBody(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	validationErrors, err := requestutils.ValidateRequest(body, validator.ValidateItemBarcode)
+	if err != nil && len(validationErrors) > 0 {
+		log.Error(err, validationErrors, log.KVs(log.Map{"request": string(body), "path": r.URL.Path}))
+		response.BadRequest(w, response.NewError(err, validationErrors))
+
+		return
+	}
+
+	data, err := requestutils.Unmarshal(r.URL.Path, body, apischema.NewItemBarcode)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err, "failed to unmarshal request body"))
+		return
+	}
+
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	report, err := bulk(atomic, data, func(batch repository.Batch, code apischema.ItemBarcode) (string, int64, error) {
+		// The barcode is stored as scanned, without the surrounding spaces that
+		// the validation ignores.
+		code.Barcode = strings.TrimSpace(code.Barcode)
+
+		kind, err := barcode.Validate(code.Barcode, code.Type)
+		if err != nil {
+			return "", 0, err
+		}
+
+		err = validateBarcodeItem(code)
+		if err != nil {
+			return "", 0, err
+		}
+
+		record := schema.ItemBarcode{
+			ItemID:  code.ItemID,
+			Barcode: code.Barcode,
+			GTIN:    barcode.GTIN(code.Barcode),
+			Type:    kind,
+		}
+
+		if code.UoMID != 0 {
+			record.UoMID = dbutils.SetInt(int32(code.UoMID))
+		}
+
+		id, err := batch.ItemBarcode(record)
+		if err != nil {
+			return "", 0, err
+		}
+
+		if id == 0 {
+			return "", 0, fmt.Errorf("barcode %s is already assigned", code.Barcode)
+		}
+
+		return apischema.RowCreated, id, nil
+	})
+
+	if err != nil {
+		log.Error(err, "failed to create barcodes", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to create barcodes"))
+
+		return
+	}
+
+	bulkResponse(w, report, response.Created)
+}
+
+func deleteBarcode(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	id, err := parameterID(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	affected, err := store.Barcodes.Delete(id)
+	if err != nil {
+		log.Error(err, "failed to delete barcode", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to delete barcode"))
+
+		return
+	}
+
+	response.Success(w, response.New(fmt.Sprintf("%d row(s) affected", affected)))
+}
+
+// lookupItem handles the HTTP request to identify an item by a scanned barcode
+// ('barcode') or its SKU ('sku'). It writes the item with the unit of measurement
+// the code stands for and its conversion factor to the base unit of measurement.
+func lookupItem(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	var (
+		item   schema.Item
+		lookup apischema.ItemLookup
+		uomID  int
+		err    error
+	)
+
+	code, hasBarcode := requestutils.HasQueryParam(r, "barcode")
+	code = strings.TrimSpace(code)
+	sku, hasSKU := requestutils.HasQueryParam(r, "sku")
+
+	switch {
+	case hasBarcode:
+		kind, err := barcode.Validate(code, "")
+		if err != nil {
+			log.Error(err, "invalid barcode", log.KVs(log.Map{"barcode": code, "path": r.URL.Path}))
+			response.BadRequest(w, response.NewError(err))
+
+			return
+		}
+
+		record, err := store.Barcodes.GetByGTIN(barcode.GTIN(code))
+		if err != nil {
+			log.Error(err, "failed to retrieve barcode", log.KVs(log.Map{"barcode": code, "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to retrieve barcode"))
+
+			return
+		}
+
+		if record.ID == 0 {
+			response.NotFound(w, response.New("barcode not found", map[string]any{"barcode": code}))
+			return
+		}
+
+		item, err = store.Items.Get(record.ItemID)
+		if err != nil {
+			log.Error(err, "failed to retrieve item", log.KVs(log.Map{"barcode": code, "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to retrieve item"))
+
+			return
+		}
+
+		uomID = dbutils.GetAsInt(record.UoMID)
+		lookup.Barcode = code
+		lookup.BarcodeType = kind
+
+	case hasSKU:
+		item, err = store.Items.GetBySKU(sku)
+		if err != nil {
+			log.Error(err, "failed to retrieve item", log.KVs(log.Map{"sku": sku, "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to retrieve item"))
+
+			return
+		}
+
+	default:
+		err := errors.New("missing 'barcode' or 'sku' in the request query parameter")
+		log.Error(err, "query parameter 'barcode' or 'sku' is required", log.KV("path", r.URL.Path))
+		response.BadRequest(w, response.NewError(err))
+
+		return
+	}
+
+	if item.ID == 0 {
+		response.NotFound(w, response.New("item not found"))
+		return
+	}
+
+	// Barcodes without a unit of measurement are for the base unit of measurement.
+	lookup.Factor = 1
+	if uomID == 0 || uomID == item.UoMID {
+		uomID = item.UoMID
+
+	} else {
+		conversion, err := store.UOMs.Conversion(item.ID, uomID)
+		if err != nil {
+			log.Error(err, "failed to retrieve uom conversion", log.KVs(log.Map{"item_id": item.ID, "uom_id": uomID}))
+			response.InternalServer(w, response.NewError(err, "failed to retrieve uom conversion"))
+
+			return
+		}
+
+		lookup.Factor = conversion.Factor
+	}
+
+	uom, err := store.UOMs.Get(uomID)
+	if err != nil {
+		log.Error(err, "failed to retrieve uom", log.KVs(log.Map{"uom_id": uomID, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve uom"))
+
+		return
+	}
+
+	lookup.Item = itemResponse(item)
+	lookup.UOM = apischema.UOM{
+		ID:   uom.ID,
+		Code: uom.Code,
+		Name: uom.Name,
+	}
+
+	response.Success(w, lookup)
+}
+
+// validateBarcodeItem checks that the item of the barcode exists and that the
+// unit of measurement of the barcode, if any, is convertible for the item.
+func validateBarcodeItem(code apischema.ItemBarcode) error {
+	item, err := store.Items.Get(code.ItemID)
+	if err != nil {
+		return err
+	}
+
+	if item.ID == 0 {
+		return fmt.Errorf("item %d does not exist", code.ItemID)
+	}
+
+	if code.UoMID == 0 || code.UoMID == item.UoMID {
+		return nil
+	}
+
+	conversion, err := store.UOMs.Conversion(item.ID, code.UoMID)
+	if err != nil {
+		return err
+	}
+
+	if conversion.ID == 0 {
+		return fmt.Errorf("unit of measurement %d: %w", code.UoMID, schema.ErrUOMNotConvertible)
+	}
+
+	return nil
+}
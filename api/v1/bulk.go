@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+// bulk applies each record of a bulk request and reports its result. Each
+// record is applied on its own, so a failed record leaves nothing behind while
+// the others are still committed. If the request is atomic, nothing is
+// committed when any of the records fails.
+func bulk[T any](atomic bool, records []T, fn func(batch repository.Batch, record T) (string, int64, error)) (apischema.BulkReport, error) {
+	report := apischema.BulkReport{Atomic: atomic, Rows: []apischema.RowResult{}}
+
+	batch, err := store.Batches.Begin()
+	if err != nil {
+		return report, err
+	}
+
+	for i, record := range records {
+		var (
+			status string
+			id     int64
+		)
+
+		err := batch.Record(func() (err error) {
+			status, id, err = fn(batch, record)
+			return err
+		})
+
+		if err != nil {
+			report.Add(apischema.RowResult{Row: i, Status: apischema.RowFailed, Error: err.Error()})
+			continue
+		}
+
+		report.Add(apischema.RowResult{Row: i, Status: status, ID: id})
+	}
+
+	if atomic && report.Failed > 0 {
+		rollbackBatch(batch)
+		return report, nil
+	}
+
+	err = batch.Commit()
+	if err != nil {
+		return report, err
+	}
+
+	report.Committed = true
+
+	return report, nil
+}
+
+// bulkResponse writes the report of a bulk request. It is written with the
+// success response if every record succeeded, an HTTP Unprocessable Entity status
+// if nothing was applied, or an HTTP Multi-Status if only some records were.
+func bulkResponse(w http.ResponseWriter, report apischema.BulkReport, success func(http.ResponseWriter, any)) {
+	switch {
+	case report.Failed == 0:
+		success(w, report)
+
+	case !report.Committed || report.Failed == report.Total:
+		response.UnprocessableEntity(w, report)
+
+	default:
+		response.MultiStatus(w, report)
+	}
+}
+
+// parameterAtomic returns whether the bulk request is all-or-nothing ('atomic').
+func parameterAtomic(r *http.Request) (bool, error) {
+	atomicParam, ok := requestutils.HasQueryParam(r, "atomic")
+	if !ok {
+		return false, nil
+	}
+
+	atomic, err := strconv.ParseBool(atomicParam)
+	if err != nil {
+		log.Error(err, "failed to parse 'atomic' query parameter", log.KVs(log.Map{"atomic": atomicParam, "path": r.URL.Path}))
+		return false, errors.New("invalid 'atomic' value; must be a boolean")
+	}
+
+	return atomic, nil
+}
+
+func rollbackBatch(batch repository.Batch) {
+	err := batch.Rollback()
+	if err != nil {
+		log.Error(err, "failed to rollback batch")
+	}
+}
+
+// insertedStatus returns the status of a record inserted if it did not exist.
+func insertedStatus(id int64, err error) (string, int64, error) {
+	if err != nil {
+		return "", 0, err
+	}
+
+	if id == 0 {
+		return apischema.RowSkipped, 0, nil
+	}
+
+	return apischema.RowCreated, id, nil
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/rmarasigan/warehouse-inventory-management/api/response"
 	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
@@ -27,7 +26,7 @@ func currencyHandler(w http.ResponseWriter, r *http.Request) {
 func getCurrencies(w http.ResponseWriter, r *http.Request) {
 	defer log.Panic()
 
-	list, err := getList(r, mysql.GetCurrency, mysql.ListCurrency)
+	list, err := getList(r, store.Currencies.Get, store.Currencies.List)
 	if err != nil {
 		log.Error(err, "failed to retrieve currency")
 		response.InternalServer(w, response.NewError(err, "failed to retrieve currency"))
@@ -59,7 +58,7 @@ func updateCurrency(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := mysql.ActivateCurrency(code)
+	err := store.Currencies.Activate(code)
 	if err != nil {
 		log.Error(err, "failed to activate currency", slog.Any("code", code))
 		response.InternalServer(w, response.NewError(err, "failed to activate currency"))
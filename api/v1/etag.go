@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+// versionTag returns the ETag of a resource of the version.
+func versionTag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// digestTag returns the ETag of a response of the data, which changes whenever
+// any of the data does.
+func digestTag(data any) (string, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(body)
+
+	return strconv.Quote(hex.EncodeToString(sum[:16])), nil
+}
+
+// listResponse writes the records with their ETag: the version of the record
+// if a single one was requested by its ID, and the digest of the list
+// otherwise. It writes an HTTP Not Modified status instead if the
+// 'If-None-Match' header of the request has the ETag.
+func listResponse[T, A any](w http.ResponseWriter, r *http.Request, records []T, version func(T) int, data []A) {
+	tag, err := digestTag(data)
+	if err != nil {
+		log.Error(err, "failed to compute the ETag", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err, "failed to compute the ETag"))
+
+		return
+	}
+
+	if _, ok := requestutils.HasQueryParam(r, "id"); ok && len(records) == 1 {
+		tag = versionTag(version(records[0]))
+	}
+
+	w.Header().Set("ETag", tag)
+
+	if noneMatch(r, tag) {
+		response.NotModified(w)
+		return
+	}
+
+	response.Success(w, data)
+}
+
+// noneMatch reports whether the 'If-None-Match' header of the request has the
+// ETag, or is '*'. The ETags are compared weakly.
+func noneMatch(r *http.Request, tag string) bool {
+	for _, value := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == "*" || value == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// errVersion is returned when the 'If-Match' header of a request does not have
+// the ETag of a version.
+var errVersion = errors.New("the 'If-Match' header must be the ETag of the resource or '*'")
+
+// ifMatch returns the version of the 'If-Match' header of the request that
+// changes or deletes a resource, or 0 if it has none or is '*' for any version.
+// Only a single strong ETag can be matched to a version.
+func ifMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		return 0, fmt.Errorf("%w, got %s", errVersion, value)
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("%w, got %s", errVersion, value)
+	}
+
+	return version, nil
+}
+
+// precondition returns the version that the request changes or deletes the
+// resource of the ID at, or 0 for any version. It writes an HTTP Precondition
+// Required status and returns false if the request has no 'If-Match' header
+// while it is required, and an HTTP Precondition Failed status if the header
+// cannot match a version.
+func precondition(w http.ResponseWriter, r *http.Request, id int) (int, bool) {
+	if r.Header.Get("If-Match") == "" && config.Get().RequireIfMatch() {
+		err := errors.New("the request must have an 'If-Match' header with the ETag of the resource")
+		log.Error(err, "missing If-Match header", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.PreconditionRequired(w, response.NewError(err, map[string]any{"id": id}))
+
+		return 0, false
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		log.Error(err, "invalid If-Match header", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.PreconditionFailed(w, response.NewError(err, map[string]any{"id": id}))
+
+		return 0, false
+	}
+
+	return version, true
+}
+
+// singlePrecondition is precondition for a request that changes the records of
+// its body. An 'If-Match' header only applies to a request of a single record,
+// which it returns the version of.
+func singlePrecondition[T any](w http.ResponseWriter, r *http.Request, records []T, id func(T) int) (int, bool) {
+	if len(records) != 1 {
+		if r.Header.Get("If-Match") != "" || config.Get().RequireIfMatch() {
+			err := errors.New("the 'If-Match' header only applies to a request of a single record")
+			log.Error(err, "invalid If-Match header", log.KVs(log.Map{"records": len(records), "path": r.URL.Path}))
+			response.BadRequest(w, response.NewError(err))
+
+			return 0, false
+		}
+
+		return 0, true
+	}
+
+	return precondition(w, r, id(records[0]))
+}
+
+// versionMismatch writes an HTTP Precondition Failed status and returns true if
+// the error is that the resource of the ID was changed since the version of
+// the request.
+func versionMismatch(w http.ResponseWriter, r *http.Request, id int, err error) bool {
+	if !errors.Is(err, repository.ErrVersionMismatch) {
+		return false
+	}
+
+	log.Error(err, "the resource was changed", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+	response.PreconditionFailed(w, response.NewError(err, map[string]any{"id": id, "etag": r.Header.Get("If-Match")}))
+
+	return true
+}
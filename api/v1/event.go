@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+)
+
+// stockChange is the payload of an 'item.stock_changed' and 'item.low_stock'
+// event.
+type stockChange struct {
+	ItemID           int `json:"item_id"`
+	StorageID        int `json:"storage_id"`
+	TransactionID    int `json:"transaction_id"`
+	PreviousQuantity int `json:"previous_quantity"`
+	Quantity         int `json:"quantity"`
+	Change           int `json:"change"`
+	Threshold        int `json:"threshold,omitempty"`
+}
+
+type (
+	// transactionChange is the payload of a 'transaction.created' and
+	// 'transaction.cancelled' event.
+	transactionChange struct {
+		ID                int               `json:"id"`
+		Reference         string            `json:"reference"`
+		Type              string            `json:"type"`
+		Partner           string            `json:"partner,omitempty"`
+		ExternalReference string            `json:"external_reference,omitempty"`
+		Amount            float64           `json:"amount"`
+		Orderlines        []orderlineChange `json:"orderlines"`
+	}
+
+	orderlineChange struct {
+		ID       int `json:"id"`
+		ItemID   int `json:"item_id"`
+		Quantity int `json:"quantity"`
+	}
+)
+
+// transactionEvent returns the payload of the event of a transaction. The
+// quantities of the orderlines are in the base unit of measurement.
+func transactionEvent(transaction schema.Transaction) transactionChange {
+	return transactionChange{
+		ID:                transaction.ID,
+		Reference:         transaction.Reference,
+		Type:              transaction.Type,
+		Partner:           transaction.Partner,
+		ExternalReference: dbutils.GetString(transaction.ExternalReference),
+		Amount:            dbutils.GetFloat(transaction.Amount),
+		Orderlines: convert.SchemaList(transaction.Orderlines, func(orderline schema.Orderline) orderlineChange {
+			return orderlineChange{
+				ID:       orderline.ID,
+				ItemID:   orderline.ItemID,
+				Quantity: orderline.Quantity,
+			}
+		}),
+	}
+}
+
+// emitEvent writes a domain event to the outbox in the database transaction of
+// the batch, so that it is only dispatched if the change is committed.
+func emitEvent(batch repository.Batch, eventType string, itemID, storageID int, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	eventID, err := uuid.NewV7()
+	if err != nil {
+		eventID = uuid.New()
+	}
+
+	event := schema.OutboxEvent{
+		EventID: eventID.String(),
+		Type:    eventType,
+		Payload: payload,
+	}
+
+	if itemID != 0 {
+		event.ItemID = dbutils.SetInt(int32(itemID))
+	}
+
+	if storageID != 0 {
+		event.StorageID = dbutils.SetInt(int32(storageID))
+	}
+
+	_, err = batch.Event(event)
+
+	return err
+}
+
+// emitStockChange writes the 'item.stock_changed' event of the item, and the
+// 'item.low_stock' event if the stock fell to or below the threshold.
+func emitStockChange(batch repository.Batch, change stockChange) error {
+	change.Change = change.Quantity - change.PreviousQuantity
+
+	err := emitEvent(batch, schema.EventItemStockChanged, change.ItemID, change.StorageID, change)
+	if err != nil {
+		return err
+	}
+
+	threshold := lowStockThreshold()
+	if change.Quantity > threshold || change.PreviousQuantity <= threshold {
+		return nil
+	}
+
+	change.Threshold = threshold
+
+	return emitEvent(batch, schema.EventItemLowStock, change.ItemID, change.StorageID, change)
+}
+
+// lowStockThreshold returns the quantity at or below which an item is low on
+// stock.
+func lowStockThreshold() int {
+	return config.Get().LowStockThreshold()
+}
@@ -0,0 +1,257 @@
+package v1
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+)
+
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		exportRecords(w, r)
+	}
+}
+
+// exportRecords handles the HTTP request to export the items, storages, uoms or
+// stock on hand as a CSV in the same format that is accepted by the import, so
+// that an export can be edited and imported again.
+func exportRecords(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	kind := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	rows, err := exportRows(kind)
+	if err != nil {
+		log.Error(err, "failed to export records", log.KVs(log.Map{"kind": kind, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, fmt.Sprintf("failed to export %s", kind)))
+
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.csv", kind, time.Now().UTC().Format("20060102"))
+
+	w.Header().Set("Content-Type", csvContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	err = writeCSV(w, csvFormats[kind].columns, rows)
+	if err != nil {
+		log.Error(err, "failed to write csv", log.KVs(log.Map{"kind": kind, "path": r.URL.Path}))
+	}
+}
+
+// ExportCSV writes the items, storages, uoms or stock on hand as a CSV in the
+// same format as the export endpoint.
+func ExportCSV(w io.Writer, kind string) error {
+	_, exists := csvFormats[kind]
+	if !exists {
+		return fmt.Errorf("unknown kind '%s'; must be one of %s", kind, strings.Join(CSVKinds(), ", "))
+	}
+
+	rows, err := exportRows(kind)
+	if err != nil {
+		return err
+	}
+
+	return writeCSV(w, csvFormats[kind].columns, rows)
+}
+
+// CSVKinds returns the kinds of record that can be imported and exported.
+func CSVKinds() []string {
+	return slices.Sorted(maps.Keys(csvFormats))
+}
+
+func exportRows(kind string) ([][]string, error) {
+	switch kind {
+	case "items":
+		return exportItems()
+
+	case "storages":
+		return exportStorages()
+
+	case "uoms":
+		return exportUOMs()
+
+	case "stock":
+		return exportStock()
+	}
+
+	return nil, fmt.Errorf("unknown kind '%s'", kind)
+}
+
+func writeCSV(w io.Writer, columns []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(columns)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteAll(rows)
+}
+
+func exportStorages() ([][]string, error) {
+	list, err := exportList(store.Storages.List, store.Storages.Archived, func(s schema.Storage) int { return s.ID })
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(list))
+	for _, storage := range list {
+		rows = append(rows, []string{storage.Code, storage.Name, dbutils.GetString(storage.Description)})
+	}
+
+	return rows, nil
+}
+
+func exportUOMs() ([][]string, error) {
+	list, err := exportList(store.UOMs.List, store.UOMs.Archived, func(u schema.UOM) int { return u.ID })
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(list))
+	for _, uom := range list {
+		rows = append(rows, []string{uom.Code, uom.Name})
+	}
+
+	return rows, nil
+}
+
+// exportItems writes the unit of measurement and storage of the items by their
+// code since the IDs are not portable between databases.
+func exportItems() ([][]string, error) {
+	list, err := exportList(store.Items.List, store.Items.Archived, func(i schema.Item) int { return i.ID })
+	if err != nil {
+		return nil, err
+	}
+
+	uomCodes, storageCodes, err := exportCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(list))
+	for _, item := range list {
+		rows = append(rows, []string{
+			dbutils.GetString(item.SKU),
+			item.Name,
+			dbutils.GetString(item.Description),
+			dbutils.GetString(item.Category),
+			formatAmount(item.UnitPrice),
+			uomCodes[item.UoMID],
+			item.StockStatus,
+			storageCodes[item.StorageID],
+		})
+	}
+
+	return rows, nil
+}
+
+// exportStock writes the stock on hand of the items with their average cost
+// from the inventory valuation, or the unit price if the item has no cost
+// layers.
+func exportStock() ([][]string, error) {
+	list, err := exportList(store.Items.List, store.Items.Archived, func(i schema.Item) int { return i.ID })
+	if err != nil {
+		return nil, err
+	}
+
+	valuation, err := store.Ledger.Valuation(time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make(map[int]schema.Valuation)
+	for _, row := range valuation {
+		cost := costs[row.ItemID]
+		cost.Quantity += row.Quantity
+		cost.Value += row.Value
+		costs[row.ItemID] = cost
+	}
+
+	rows := make([][]string, 0, len(list))
+	for _, item := range list {
+		if item.Quantity <= 0 {
+			continue
+		}
+
+		unitCost := item.UnitPrice
+
+		cost, ok := costs[item.ID]
+		if ok && cost.Quantity > 0 {
+			unitCost = schema.RoundAmount(cost.Value / float64(cost.Quantity))
+		}
+
+		rows = append(rows, []string{
+			dbutils.GetString(item.SKU),
+			item.Name,
+			strconv.Itoa(item.Quantity),
+			formatAmount(unitCost),
+		})
+	}
+
+	return rows, nil
+}
+
+// exportCodes returns the codes of the units of measurement and storages by
+// their ID.
+func exportCodes() (map[int]string, map[int]string, error) {
+	uoms, err := exportList(store.UOMs.List, store.UOMs.Archived, func(u schema.UOM) int { return u.ID })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storages, err := exportList(store.Storages.List, store.Storages.Archived, func(s schema.Storage) int { return s.ID })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uomCodes := make(map[int]string, len(uoms))
+	for _, uom := range uoms {
+		uomCodes[uom.ID] = uom.Code
+	}
+
+	storageCodes := make(map[int]string, len(storages))
+	for _, storage := range storages {
+		storageCodes[storage.ID] = storage.Code
+	}
+
+	return uomCodes, storageCodes, nil
+}
+
+// exportList returns the records along with the archived ones by their ID, so
+// that the export has the storages and units of measurement that the archived
+// items still refer to.
+func exportList[T any](list, archived func() ([]T, error), id func(T) int) ([]T, error) {
+	records, err := list()
+	if err != nil {
+		return nil, err
+	}
+
+	archivedRecords, err := archived()
+	if err != nil {
+		return nil, err
+	}
+
+	records = append(records, archivedRecords...)
+	slices.SortFunc(records, func(a, b T) int { return cmp.Compare(id(a), id(b)) })
+
+	return records, nil
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
@@ -3,17 +3,45 @@ package v1
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/metrics"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
 )
 
+// store is the data access of the handlers. It is backed by MySQL unless
+// another store is set with Use.
+var store = mysql.Store()
+
+// Use sets the data access of the handlers, e.g. an in-memory store in the
+// tests. It must be called before the server is started.
+func Use(s repository.Store) { store = s }
+
+// unknownLabel labels the metrics of the requests to an invalid path or method,
+// so that arbitrary paths and methods do not create new series.
+const unknownLabel string = "unknown"
+
 func Handler(w http.ResponseWriter, r *http.Request, segment string) {
 	defer log.Panic()
 	var method = r.Method
 
+	var (
+		start       = time.Now()
+		label       = segment
+		methodLabel = method
+		recorder    = &statusRecorder{ResponseWriter: w}
+	)
+
+	w = recorder
+	defer func() { metrics.ObserveRequest(label, methodLabel, recorder.Status(), start) }()
+
 	// Validate the method and path
 	if !isValidPathMethod(method, segment) {
+		label, methodLabel = unknownLabel, unknownLabel
+
 		response.BadRequest(w, response.NewError(errors.New("provided path or method is invalid")))
 		return
 	}
@@ -21,24 +49,96 @@ func Handler(w http.ResponseWriter, r *http.Request, segment string) {
 	handlers := map[string]func(http.ResponseWriter, *http.Request){
 		users:             userHandler,
 		activateUser:      userHandler,
+		inviteUser:        accountHandler,
+		acceptInvite:      accountHandler,
+		verifyEmail:       accountHandler,
+		confirmEmail:      accountHandler,
+		forgotPassword:    accountHandler,
+		resetPassword:     accountHandler,
 		roles:             roleHandler,
 		storages:          storageHandler,
+		archiveStorage:    storageHandler,
+		restoreStorage:    storageHandler,
 		uoms:              uomHandler,
+		archiveUOM:        uomHandler,
+		restoreUOM:        uomHandler,
 		currencies:        currencyHandler,
 		activateCurrency:  currencyHandler,
 		items:             itemHandler,
+		archiveItem:       itemHandler,
+		restoreItem:       itemHandler,
+		uomConversions:    uomConversionHandler,
+		itemBarcodes:      barcodeHandler,
+		itemLookup:        lookupHandler,
 		transaction:       transactionHandler,
 		transactionNote:   transactionHandler,
 		transactionCancel: transactionCancelHandler,
 		orderlinesNote:    orderlineHandler,
+		valuationReport:   valuationHandler,
+		itemsImport:       importHandler,
+		storagesImport:    importHandler,
+		uomsImport:        importHandler,
+		stockImport:       importHandler,
+		itemsExport:       exportHandler,
+		storagesExport:    exportHandler,
+		uomsExport:        exportHandler,
+		stockExport:       exportHandler,
+		webhooks:          webhookHandler,
+		webhookDeliveries: deliveryHandler,
+		deliveryRetry:     deliveryRetryHandler,
+		eventStream:       streamHandler,
 	}
 
 	// Handle the request if the segment is valid
 	handler, exists := handlers[segment]
 	if exists {
+		// A retried POST must not create the same records twice.
+		if method == http.MethodPost {
+			handler = idempotent(handler)
+		}
+
 		handler(w, r)
 		return
 	}
 
 	response.NotFound(w, response.New("unrecognized path"))
 }
+
+// statusRecorder keeps the status of the response for the metrics.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	return rec.ResponseWriter.Write(data)
+}
+
+// Flush sends the buffered response, as the event stream does after each event.
+func (rec *statusRecorder) Flush() {
+	flusher, ok := rec.ResponseWriter.(http.Flusher)
+	if ok {
+		flusher.Flush()
+	}
+}
+
+// Status returns the status of the response, or HTTP OK if nothing was written.
+func (rec *statusRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+
+	return rec.status
+}
@@ -34,6 +34,25 @@ func parameterID(r *http.Request) (int, error) {
 	return id, nil
 }
 
+// parameterUserID returns the 'user_id' query parameter.
+func parameterUserID(r *http.Request) (int, error) {
+	userIDParam, ok := requestutils.HasQueryParam(r, "user_id")
+	if !ok {
+		err := errors.New("missing 'user_id' from request query")
+		log.Error(err, "query parameter 'user_id' is required", log.KV("path", r.URL.Path))
+
+		return 0, err
+	}
+
+	userID, err := strconv.Atoi(userIDParam)
+	if err != nil {
+		log.Error(err, "failed to parse 'user_id' query parameter", log.KVs(log.Map{"id": userIDParam, "path": r.URL.Path}))
+		return 0, errors.New("invalid 'user_id' value; must be an integer")
+	}
+
+	return userID, nil
+}
+
 func getList[T any](r *http.Request, get func(id int) (T, error), list func() ([]T, error)) ([]T, error) {
 	// Check if the "id" parameter is provided.
 	idParam, ok := requestutils.HasQueryParam(r, "id")
@@ -100,6 +119,10 @@ func updateNote(w http.ResponseWriter, r *http.Request, set func(id int, shared
 		return
 	}
 
+	if !actor(w, r, "user_id", int(shared.UserID)) {
+		return
+	}
+
 	record := set(id, shared)
 	err = update(record)
 	if err != nil {
@@ -118,3 +141,28 @@ func updateNote(w http.ResponseWriter, r *http.Request, set func(id int, shared
 
 	response.Success(w, nil)
 }
+
+// patchNote handles the HTTP request to change the note of a transaction or an
+// orderline with a JSON Merge Patch of its 'note' and the 'user_id' of the user
+// who changed it. Unlike updateNote, a null or empty note clears it.
+func patchNote[R any](w http.ResponseWriter, r *http.Request, name string, get func(id int) (R, error), view func(R) apischema.Shared,
+	set func(R, apischema.Shared) R, save func(R, ...string) error) {
+	patchResource(w, r, patch[R, apischema.Shared]{
+		name:     name,
+		columns:  map[string]string{"note": "note", "user_id": "updated_by"},
+		notNull:  []string{"user_id"},
+		required: []string{"user_id"},
+		get:      get,
+		exists:   func(record R) bool { return !reflect.ValueOf(record).IsZero() },
+		view:     view,
+		record: func(existing R, shared apischema.Shared) (R, error) {
+			err := activeUser("user_id", int(shared.UserID))
+			if err != nil {
+				return existing, err
+			}
+
+			return set(existing, shared), nil
+		},
+		save: save,
+	})
+}
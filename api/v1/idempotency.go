@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+)
+
+const (
+	idempotencyKeyHeader    string = "Idempotency-Key"
+	idempotentReplayHeader  string = "Idempotent-Replayed"
+	maxIdempotencyKeyLength int    = 255
+)
+
+// idempotent handles a POST request at most once per 'Idempotency-Key' header.
+// The response to the first request is stored and replayed for every retry with
+// the same key and payload until the key expires. A key that is reused with a
+// different payload is rejected with an HTTP Unprocessable Entity status, and
+// a retry while the first request is still in progress with an HTTP Conflict.
+// Requests without the header are handled as is.
+func idempotent(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			handler(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			err := fmt.Errorf("'%s' header must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+			response.BadRequest(w, response.NewError(err))
+
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Error(err, "failed to read request body", log.KV("path", r.URL.Path))
+			response.BadRequest(w, response.NewError(err, "failed to read request body"))
+
+			return
+		}
+
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := schema.IdempotencyKey{
+			Key:         key,
+			Path:        r.URL.Path,
+			RequestHash: requestHash(r, body),
+			ExpiresAt:   time.Now().UTC().Add(idempotencyKeyTTL()),
+		}
+
+		// A key that already expired is replaced.
+		reserved, err := store.IdempotencyKeys.Reserve(record, time.Now().UTC())
+		if err != nil {
+			log.Error(err, "failed to reserve idempotency key", log.KVs(log.Map{"key": key, "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to reserve idempotency key"))
+
+			return
+		}
+
+		if !reserved {
+			replayIdempotentResponse(w, r, record)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		handler(recorder, r)
+
+		// Nothing was done or the request failed, so it can be retried.
+		if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+			err = store.IdempotencyKeys.Release(record.Key, record.Path)
+			if err != nil {
+				log.Error(err, "failed to release idempotency key", log.KVs(log.Map{"key": key, "path": r.URL.Path}))
+			}
+
+			return
+		}
+
+		record.StatusCode = dbutils.SetInt(int32(recorder.status))
+		record.ContentType = dbutils.SetString(recorder.Header().Get("Content-Type"))
+		record.ResponseBody = recorder.body.Bytes()
+
+		err = store.IdempotencyKeys.Save(record)
+		if err != nil {
+			log.Error(err, "failed to save idempotent response", log.KVs(log.Map{"key": key, "path": r.URL.Path}))
+		}
+	}
+}
+
+// replayIdempotentResponse writes the stored response to the request of the key.
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, record schema.IdempotencyKey) {
+	existing, err := store.IdempotencyKeys.Get(record.Key, record.Path)
+	if err != nil {
+		log.Error(err, "failed to retrieve idempotency key", log.KVs(log.Map{"key": record.Key, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve idempotency key"))
+
+		return
+	}
+
+	switch {
+	case existing.ID == 0:
+		// The first request failed and released the key in the meantime.
+		err := errors.New("the request with this idempotency key failed; retry the request")
+		response.Conflict(w, response.NewError(err))
+
+	case existing.RequestHash != record.RequestHash:
+		err := fmt.Errorf("'%s' was already used with a different request", idempotencyKeyHeader)
+		log.Warn("idempotency key reused", log.KVs(log.Map{"key": record.Key, "path": r.URL.Path}))
+		response.UnprocessableEntity(w, response.NewError(err))
+
+	case !existing.StatusCode.Valid:
+		err := errors.New("a request with this idempotency key is still in progress")
+		response.Conflict(w, response.NewError(err))
+
+	default:
+		if existing.ContentType.Valid {
+			w.Header().Set("Content-Type", existing.ContentType.String)
+		}
+
+		w.Header().Set(idempotentReplayHeader, "true")
+		w.WriteHeader(int(existing.StatusCode.Int32))
+		_, _ = w.Write(existing.ResponseBody)
+	}
+}
+
+// requestHash identifies the payload of a request by its method, URL (with the
+// query) and body.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotencyKeyTTL returns how long the response to a request can be replayed.
+func idempotencyKeyTTL() time.Duration {
+	return config.Get().IdempotencyKeyTTL()
+}
+
+// responseRecorder writes the response while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	rec.body.Write(data)
+
+	return rec.ResponseWriter.Write(data)
+}
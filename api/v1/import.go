@@ -0,0 +1,402 @@
+package v1
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+const csvContentType string = "text/csv"
+
+// stockStatuses are the valid stock status of an item.
+var stockStatuses = []string{"in_stock", "low_stock", "out_of_stock", "discontinued"}
+
+// csvFormat describes the columns of a kind of record in CSV import and export.
+type csvFormat struct {
+	// columns are the header of the CSV in the order they are exported.
+	columns []string
+	// required are the columns that must be present in the header of an import.
+	required []string
+	// row imports a single record and returns the status and ID of the record.
+	row func(batch repository.Batch, userID int, record csvRecord) (string, int64, error)
+}
+
+// csvFormats are the formats of the kinds of record that can be imported and
+// exported.
+var csvFormats = map[string]csvFormat{
+	"items": {
+		columns:  []string{"sku", "name", "description", "category", "unit_price", "uom_code", "stock_status", "storage_code"},
+		required: []string{"name", "unit_price", "uom_code", "storage_code"},
+		row:      importItem,
+	},
+	"storages": {
+		columns:  []string{"code", "name", "description"},
+		required: []string{"code", "name"},
+		row:      importStorage,
+	},
+	"uoms": {
+		columns:  []string{"code", "name"},
+		required: []string{"code", "name"},
+		row:      importUOM,
+	},
+	"stock": {
+		columns:  []string{"sku", "name", "quantity", "unit_cost"},
+		required: []string{"quantity"},
+		row:      importStock,
+	},
+}
+
+// csvRecord is a row of the CSV keyed by its column.
+type csvRecord map[string]string
+
+func (r csvRecord) value(column string) string {
+	return strings.TrimSpace(r[column])
+}
+
+func importHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		importRecords(w, r)
+	}
+}
+
+// importRecords handles the HTTP request to import a CSV of items, storages,
+// uoms or opening stock with ImportCSV, unless it is a dry run ('dry_run=true').
+// It writes a report with the status of each row.
+func importRecords(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+		log.Panic()
+	}()
+
+	kind := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != csvContentType {
+			err := fmt.Errorf("unsupported content type '%s'; must be %s", contentType, csvContentType)
+			log.Error(err, "invalid import content type", log.KV("path", r.URL.Path))
+			response.UnsupportedMediaType(w, response.NewError(err))
+
+			return
+		}
+	}
+
+	dryRun := false
+	dryRunParam, ok := requestutils.HasQueryParam(r, "dry_run")
+	if ok {
+		value, err := strconv.ParseBool(dryRunParam)
+		if err != nil {
+			response.BadRequest(w, response.NewError(errors.New("invalid 'dry_run' value; must be a boolean")))
+			return
+		}
+
+		dryRun = value
+	}
+
+	var userID int
+	if kind == "items" {
+		id, err := parameterUserID(r)
+		if err != nil {
+			response.BadRequest(w, response.NewError(err))
+			return
+		}
+
+		userID = id
+	}
+
+	report, err := ImportCSV(r.Body, kind, userID, dryRun)
+	if err != nil {
+		if rejectedActor(w, r, err) {
+			return
+		}
+
+		var invalid *csvError
+		if errors.As(err, &invalid) {
+			log.Error(invalid.err, invalid.message, log.KVs(log.Map{"kind": kind, "path": r.URL.Path}))
+			response.BadRequest(w, response.NewError(invalid.err, invalid.details))
+
+			return
+		}
+
+		log.Error(err, "failed to import", log.KVs(log.Map{"kind": kind, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to import"))
+
+		return
+	}
+
+	if report.Failed > 0 {
+		log.Warn("import rolled back", log.KVs(log.Map{"kind": kind, "failed": report.Failed, "path": r.URL.Path}))
+		response.UnprocessableEntity(w, report)
+
+		return
+	}
+
+	response.Success(w, report)
+}
+
+// csvError is an error of the CSV itself, rather than of one of its rows.
+type csvError struct {
+	err     error
+	message string
+	details any
+}
+
+func (e *csvError) Error() string { return e.err.Error() }
+
+func (e *csvError) Unwrap() error { return e.err }
+
+// ImportCSV imports a CSV of items, storages, uoms or opening stock. The rows
+// are read one at a time and loaded in a single database transaction, which is
+// only committed if every row succeeds and it is not a dry run. It returns the
+// report with the status of each row. The items are created by the user, who
+// must be active.
+func ImportCSV(body io.Reader, kind string, userID int, dryRun bool) (apischema.ImportReport, error) {
+	format, exists := csvFormats[kind]
+	if !exists {
+		err := fmt.Errorf("unknown kind '%s'; must be one of %s", kind, strings.Join(CSVKinds(), ", "))
+		return apischema.ImportReport{}, &csvError{err: err, message: "invalid import kind"}
+	}
+
+	if kind == "items" {
+		err := activeUser("user_id", userID)
+		if err != nil {
+			return apischema.ImportReport{}, err
+		}
+	}
+
+	reader := csv.NewReader(body)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("request body cannot be empty")
+		}
+
+		return apischema.ImportReport{}, &csvError{err: err, message: "failed to read csv header", details: "failed to read csv header"}
+	}
+
+	// Copy the header since the reader reuses its backing array.
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+
+	for _, column := range format.required {
+		if !slices.Contains(columns, column) {
+			err := fmt.Errorf("missing '%s' column", column)
+			return apischema.ImportReport{}, &csvError{err: err, message: "invalid csv header", details: map[string]any{"required": format.required}}
+		}
+	}
+
+	batch, err := store.Batches.Begin()
+	if err != nil {
+		return apischema.ImportReport{}, fmt.Errorf("failed to begin import: %w", err)
+	}
+
+	report := apischema.ImportReport{
+		Kind:       kind,
+		DryRun:     dryRun,
+		BulkReport: apischema.BulkReport{Atomic: true, Rows: []apischema.RowResult{}},
+	}
+
+	for line := 2; ; line++ {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				report.Add(apischema.RowResult{Row: line, Status: apischema.RowFailed, Error: err.Error()})
+				continue
+			}
+
+			rollbackBatch(batch)
+
+			return apischema.ImportReport{}, &csvError{err: err, message: "failed to read csv", details: "failed to read csv"}
+		}
+
+		record := make(csvRecord, len(columns))
+		for i, column := range columns {
+			record[column] = fields[i]
+		}
+
+		status, id, err := format.row(batch, userID, record)
+		if err != nil {
+			report.Add(apischema.RowResult{Row: line, Status: apischema.RowFailed, Error: err.Error()})
+			continue
+		}
+
+		report.Add(apischema.RowResult{Row: line, Status: status, ID: id})
+	}
+
+	if dryRun || report.Failed > 0 {
+		rollbackBatch(batch)
+		return report, nil
+	}
+
+	err = batch.Commit()
+	if err != nil {
+		return apischema.ImportReport{}, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	report.Committed = true
+
+	return report, nil
+}
+
+func importStorage(batch repository.Batch, _ int, record csvRecord) (string, int64, error) {
+	storage := schema.Storage{
+		Code:        record.value("code"),
+		Name:        record.value("name"),
+		Description: dbutils.SetString(record.value("description")),
+	}
+
+	if storage.Code == "" || storage.Name == "" {
+		return "", 0, errors.New("'code' and 'name' are required")
+	}
+
+	return insertedStatus(batch.Storage(storage))
+}
+
+func importUOM(batch repository.Batch, _ int, record csvRecord) (string, int64, error) {
+	uom := schema.UOM{
+		Code: record.value("code"),
+		Name: record.value("name"),
+	}
+
+	if uom.Code == "" || uom.Name == "" {
+		return "", 0, errors.New("'code' and 'name' are required")
+	}
+
+	return insertedStatus(batch.UOM(uom))
+}
+
+func importItem(batch repository.Batch, userID int, record csvRecord) (string, int64, error) {
+	item := schema.Item{
+		SKU:         dbutils.SetString(record.value("sku")),
+		Name:        record.value("name"),
+		Description: dbutils.SetString(record.value("description")),
+		Category:    dbutils.SetString(record.value("category")),
+		StockStatus: record.value("stock_status"),
+		CreatedBy:   userID,
+	}
+
+	if item.Name == "" {
+		return "", 0, errors.New("'name' is required")
+	}
+
+	unitPrice, err := strconv.ParseFloat(record.value("unit_price"), 64)
+	if err != nil || unitPrice < 0 {
+		return "", 0, errors.New("'unit_price' must be a number greater than or equal to 0")
+	}
+
+	item.UnitPrice = unitPrice
+
+	// Items are imported without stock, which is loaded by the opening stock.
+	if item.StockStatus == "" {
+		item.StockStatus = "out_of_stock"
+	}
+
+	if !slices.Contains(stockStatuses, item.StockStatus) {
+		return "", 0, fmt.Errorf("'stock_status' must be one of %s", strings.Join(stockStatuses, ", "))
+	}
+
+	uom, err := batch.UOMByCode(record.value("uom_code"))
+	if err != nil {
+		return "", 0, err
+	}
+
+	if uom.ID == 0 {
+		return "", 0, fmt.Errorf("uom '%s' does not exist", record.value("uom_code"))
+	}
+
+	storage, err := batch.StorageByCode(record.value("storage_code"))
+	if err != nil {
+		return "", 0, err
+	}
+
+	if storage.ID == 0 {
+		return "", 0, fmt.Errorf("storage '%s' does not exist", record.value("storage_code"))
+	}
+
+	item.UoMID = uom.ID
+	item.StorageID = storage.ID
+
+	if item.SKU.Valid {
+		existing, err := batch.ItemBySKU(item.SKU.String)
+		if err != nil {
+			return "", 0, err
+		}
+
+		if existing.ID != 0 && !strings.EqualFold(existing.Name, item.Name) {
+			return "", 0, fmt.Errorf("%s: %w", item.SKU.String, errDuplicateSKU)
+		}
+	}
+
+	return insertedStatus(batch.Item(item))
+}
+
+func importStock(batch repository.Batch, _ int, record csvRecord) (string, int64, error) {
+	var (
+		item schema.Item
+		err  error
+	)
+
+	switch {
+	case record.value("sku") != "":
+		item, err = batch.ItemBySKU(record.value("sku"))
+
+	case record.value("name") != "":
+		item, err = batch.ItemByName(record.value("name"))
+
+	default:
+		return "", 0, errors.New("either 'sku' or 'name' is required")
+	}
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	if item.ID == 0 {
+		return "", 0, errors.New("item does not exist")
+	}
+
+	quantity, err := strconv.Atoi(record.value("quantity"))
+	if err != nil || quantity < 1 {
+		return "", 0, errors.New("'quantity' must be a whole number greater than 0")
+	}
+
+	// The unit cost defaults to the item's unit price.
+	unitCost := item.UnitPrice
+	if record.value("unit_cost") != "" {
+		unitCost, err = strconv.ParseFloat(record.value("unit_cost"), 64)
+		if err != nil || unitCost < 0 {
+			return "", 0, errors.New("'unit_cost' must be a number greater than or equal to 0")
+		}
+	}
+
+	err = batch.OpeningStock(item, quantity, unitCost)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return apischema.RowUpdated, int64(item.ID), nil
+}
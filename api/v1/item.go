@@ -1,13 +1,15 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/rmarasigan/warehouse-inventory-management/api/response"
 	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/api/schema/validator"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
 	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
@@ -24,7 +26,19 @@ func itemHandler(w http.ResponseWriter, r *http.Request) {
 		createItem(w, r)
 
 	case http.MethodPut:
-		updateItem(w, r)
+		switch {
+		case strings.HasSuffix(r.URL.Path, archiveItem):
+			archiveResource(w, r, itemArchival())
+
+		case strings.HasSuffix(r.URL.Path, restoreItem):
+			restoreResource(w, r, itemArchival())
+
+		default:
+			updateItem(w, r)
+		}
+
+	case http.MethodPatch:
+		patchItem(w, r)
 
 	case http.MethodDelete:
 		deleteItem(w, r)
@@ -34,7 +48,18 @@ func itemHandler(w http.ResponseWriter, r *http.Request) {
 func getItems(w http.ResponseWriter, r *http.Request) {
 	defer log.Panic()
 
-	list, err := getList(r, mysql.GetItemByID, mysql.ListItem)
+	archived, err := parameterArchived(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	listItems := store.Items.List
+	if archived {
+		listItems = store.Items.Archived
+	}
+
+	list, err := getList(r, store.Items.Get, listItems)
 	if err != nil {
 		log.Error(err, "failed to retrieve items", log.KV("path", r.URL.Path))
 		response.InternalServer(w, response.NewError(err, "failed to retrieve items"))
@@ -42,23 +67,29 @@ func getItems(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	items := convert.SchemaList(list, func(item schema.Item) apischema.Item {
-		return apischema.Item{
-			ID:           item.ID,
-			Name:         item.Name,
-			Description:  dbutils.GetString(item.Description),
-			Quantity:     item.Quantity,
-			UnitPrice:    item.UnitPrice,
-			UoMID:        item.UoMID,
-			StockStatus:  item.StockStatus,
-			StorageID:    item.StorageID,
-			CreatedBy:    item.CreatedBy,
-			DateCreated:  item.DateCreated,
-			DateModified: dbutils.GetTime(item.DateModified),
-		}
-	})
+	items := convert.SchemaList(list, itemResponse)
 
-	response.Success(w, items)
+	listResponse(w, r, list, func(item schema.Item) int { return item.Version }, items)
+}
+
+// itemResponse converts the item record to its API representation.
+func itemResponse(item schema.Item) apischema.Item {
+	return apischema.Item{
+		ID:           item.ID,
+		SKU:          dbutils.GetString(item.SKU),
+		Name:         item.Name,
+		Description:  dbutils.GetString(item.Description),
+		Category:     dbutils.GetString(item.Category),
+		Quantity:     item.Quantity,
+		UnitPrice:    item.UnitPrice,
+		UoMID:        item.UoMID,
+		StockStatus:  item.StockStatus,
+		StorageID:    item.StorageID,
+		CreatedBy:    item.CreatedBy,
+		DateCreated:  item.DateCreated,
+		DateModified: dbutils.GetTime(item.DateModified),
+		Archive:      archiveResponse(item.ArchivedAt, item.ArchivedBy),
+	}
 }
 
 func createItem(w http.ResponseWriter, r *http.Request) {
@@ -87,10 +118,18 @@ func createItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
 	items := convert.SchemaList(data, func(item apischema.Item) schema.Item {
 		return schema.Item{
+			SKU:         dbutils.SetString(item.SKU),
 			Name:        item.Name,
 			Description: dbutils.SetString(item.Description),
+			Category:    dbutils.SetString(item.Category),
 			Quantity:    item.Quantity,
 			UnitPrice:   item.UnitPrice,
 			UoMID:       item.UoMID,
@@ -100,23 +139,47 @@ func createItem(w http.ResponseWriter, r *http.Request) {
 		}
 	})
 
-	for _, item := range items {
-		_, err := mysql.NewItemIfNotExists(item)
+	report, err := bulk(atomic, items, func(batch repository.Batch, item schema.Item) (string, int64, error) {
+		err := activeUser("created_by", item.CreatedBy)
 		if err != nil {
-			log.Error(err, "failed to create item", log.KVs(log.Map{"item": item, "path": r.URL.Path}))
-			response.InternalServer(w, response.NewError(err,
-				map[string]any{
-					"request": data,
-					"item":    item,
-					"message": "failed to create item",
-				}),
-			)
+			return "", 0, err
+		}
 
-			return
+		err = uniqueSKU(batch, item)
+		if err != nil {
+			return "", 0, err
 		}
+
+		lastInsertID, err := batch.Item(item)
+		if err != nil || lastInsertID == 0 {
+			return insertedStatus(lastInsertID, err)
+		}
+
+		// Record the opening stock of a new item at its unit price.
+		if item.Quantity > 0 {
+			layer := schema.CostLayer{
+				ItemID:   int(lastInsertID),
+				Quantity: item.Quantity,
+				UnitCost: item.UnitPrice,
+			}
+
+			err = batch.ReceiveStock(layer, item.StorageID)
+			if err != nil {
+				return "", 0, fmt.Errorf("failed to record opening stock: %w", err)
+			}
+		}
+
+		return apischema.RowCreated, lastInsertID, nil
+	})
+
+	if err != nil {
+		log.Error(err, "failed to create items", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to create items"))
+
+		return
 	}
 
-	response.Created(w, nil)
+	bulkResponse(w, report, response.Created)
 }
 
 func updateItem(w http.ResponseWriter, r *http.Request) {
@@ -137,35 +200,148 @@ func updateItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
 	items := convert.SchemaList(data, func(item apischema.Item) schema.Item {
 		return schema.Item{
 			ID:          item.ID,
+			SKU:         dbutils.SetString(item.SKU),
 			Name:        item.Name,
 			Description: dbutils.SetString(item.Description),
-			Quantity:    item.Quantity,
+			Category:    dbutils.SetString(item.Category),
 			UnitPrice:   item.UnitPrice,
 			StorageID:   item.StorageID,
 			UoMID:       item.UoMID,
 		}
 	})
 
-	for _, item := range items {
-		err = mysql.UpdateItem(item)
+	version, ok := singlePrecondition(w, r, items, func(item schema.Item) int { return item.ID })
+	if !ok {
+		return
+	}
+
+	// changed is set if the item of the 'If-Match' header was changed since.
+	var changed error
+
+	report, err := bulk(atomic, items, func(batch repository.Batch, item schema.Item) (string, int64, error) {
+		existing, err := batch.ItemByID(item.ID)
 		if err != nil {
-			log.Error(err, "failed to update item", log.KVs(log.Map{"request": data, "item": item, "path": r.URL.Path}))
-			response.InternalServer(w, response.NewError(err,
-				map[string]any{
-					"request": data,
-					"item":    item,
-					"message": "failed to update item",
-				}),
-			)
+			return "", 0, err
+		}
 
-			return
+		if version != 0 && existing.Version != version {
+			changed = repository.ErrVersionMismatch
+			return "", 0, changed
+		}
+
+		if existing.ID == 0 {
+			return "", 0, fmt.Errorf("item %d does not exist", item.ID)
 		}
+
+		item.Version = version
+
+		err = uniqueSKU(batch, item)
+		if err != nil {
+			return "", 0, err
+		}
+
+		err = batch.UpdateItem(item)
+		if err != nil {
+			if errors.Is(err, repository.ErrVersionMismatch) {
+				changed = err
+			}
+
+			return "", 0, err
+		}
+
+		return apischema.RowUpdated, int64(item.ID), nil
+	})
+
+	if err != nil {
+		log.Error(err, "failed to update items", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to update items"))
+
+		return
+	}
+
+	if versionMismatch(w, r, items[0].ID, changed) {
+		return
 	}
 
-	response.Success(w, nil)
+	bulkResponse(w, report, response.Success)
+}
+
+// patchItem handles the HTTP request to change an item with a JSON Merge Patch.
+// Unlike updateItem, a zero price is set and a null description, category or
+// SKU clears it. The quantity cannot be patched, as only the transactions that
+// are recorded in the ledger move the stock.
+func patchItem(w http.ResponseWriter, r *http.Request) {
+	patchResource(w, r, patch[schema.Item, apischema.Item]{
+		name: "item",
+		columns: map[string]string{
+			"sku":         "sku",
+			"name":        "name",
+			"description": "description",
+			"category":    "category",
+			"unit_price":  "unit_price",
+			"uom_id":      "uom_id",
+			"storage_id":  "storage_id",
+		},
+		notNull:  []string{"unit_price", "uom_id", "storage_id"},
+		notEmpty: []string{"name"},
+		get:      store.Items.Get,
+		exists:   func(item schema.Item) bool { return item.ID != 0 },
+		view:     itemResponse,
+		record: func(existing schema.Item, item apischema.Item) (schema.Item, error) {
+			if item.UnitPrice < 0 {
+				return existing, invalid("unit_price of item cannot be negative")
+			}
+
+			err := referenced("storage_id", item.StorageID, store.Storages.Get, func(storage schema.Storage) bool { return storage.ID != 0 })
+			if err != nil {
+				return existing, err
+			}
+
+			err = referenced("uom_id", item.UoMID, store.UOMs.Get, func(uom schema.UOM) bool { return uom.ID != 0 })
+			if err != nil {
+				return existing, err
+			}
+
+			existing.SKU = dbutils.SetString(item.SKU)
+			existing.Name = item.Name
+			existing.Description = dbutils.SetString(item.Description)
+			existing.Category = dbutils.SetString(item.Category)
+			existing.UnitPrice = item.UnitPrice
+			existing.UoMID = item.UoMID
+			existing.StorageID = item.StorageID
+
+			return existing, nil
+		},
+		save: func(item schema.Item, columns ...string) error {
+			batch, err := store.Batches.Begin()
+			if err != nil {
+				return err
+			}
+			defer func() { _ = batch.Rollback() }()
+
+			err = uniqueSKU(batch, item)
+			if err != nil {
+				return err
+			}
+
+			err = batch.PatchItem(item, columns...)
+			if err != nil {
+				return err
+			}
+
+			return batch.Commit()
+		},
+		version: func(item *schema.Item) *int { return &item.Version },
+	})
 }
 
 func deleteItem(w http.ResponseWriter, r *http.Request) {
@@ -177,8 +353,17 @@ func deleteItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	affected, err := mysql.DeleteItem(id)
+	version, ok := precondition(w, r, id)
+	if !ok {
+		return
+	}
+
+	affected, err := store.Items.Delete(id, version)
 	if err != nil {
+		if versionMismatch(w, r, id, err) || deleteConflict(w, r, id, err) {
+			return
+		}
+
 		log.Error(err, "failed to delete item", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
 		response.InternalServer(w, response.NewError(err, "failed to delete item"))
 
@@ -187,3 +372,25 @@ func deleteItem(w http.ResponseWriter, r *http.Request) {
 
 	response.Success(w, response.New(fmt.Sprintf("%d rows(s) affected", affected)))
 }
+
+// errDuplicateSKU is returned when the SKU is already used by another item.
+var errDuplicateSKU = errors.New("sku is already used by another item")
+
+// uniqueSKU checks that the SKU of the item, if it has one, is not used by
+// another item.
+func uniqueSKU(batch repository.Batch, item schema.Item) error {
+	if !item.SKU.Valid {
+		return nil
+	}
+
+	existing, err := batch.ItemBySKU(item.SKU.String)
+	if err != nil {
+		return err
+	}
+
+	if existing.ID != 0 && existing.ID != item.ID {
+		return fmt.Errorf("%s: %w", item.SKU.String, errDuplicateSKU)
+	}
+
+	return nil
+}
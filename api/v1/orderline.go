@@ -4,15 +4,18 @@ import (
 	"net/http"
 
 	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
 )
 
 func orderlineHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodPut {
+	switch r.Method {
+	case http.MethodPut:
 		orderlineNote(w, r)
+
+	case http.MethodPatch:
+		patchOrderlineNote(w, r)
 	}
 }
 
@@ -31,7 +34,25 @@ func orderlineNote(w http.ResponseWriter, r *http.Request) {
 			}
 		},
 		func(record any) error {
-			return mysql.UpdateOrderlineNote(record.(schema.Orderline))
+			return store.Transactions.UpdateOrderlineNote(record.(schema.Orderline))
+		},
+	)
+}
+
+func patchOrderlineNote(w http.ResponseWriter, r *http.Request) {
+	patchNote(w, r, "orderline", store.Transactions.Orderline,
+		func(orderline schema.Orderline) apischema.Shared {
+			return apischema.Shared{
+				UserID: int32(dbutils.GetAsInt(orderline.UpdatedBy)),
+				Note:   dbutils.GetString(orderline.Note),
+			}
+		},
+		func(orderline schema.Orderline, shared apischema.Shared) schema.Orderline {
+			orderline.Note = dbutils.SetString(shared.Note)
+			orderline.UpdatedBy = dbutils.SetInt(shared.UserID)
+
+			return orderline
 		},
+		store.Transactions.PatchOrderline,
 	)
 }
@@ -0,0 +1,387 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+// mergePatchType is the media type of a JSON Merge Patch (RFC 7396).
+const mergePatchType string = "application/merge-patch+json"
+
+// errInvalidPatch is wrapped by the errors of a patched resource that is not
+// valid, as opposed to the errors of the database.
+var errInvalidPatch = errors.New("invalid patch")
+
+// patch describes how a resource of record R and API representation A is
+// changed with a JSON Merge Patch. The patch is applied to the representation of
+// the current record: a member that is absent is left unchanged, a member that
+// is null is cleared, and any other value, including zero, false and an empty
+// string, is set. Only the columns of the members of the patch are written.
+type patch[R, A any] struct {
+	// name is the name of the resource in the messages.
+	name string
+
+	// columns are the members that can be patched and the column each one is
+	// written to. The other members of the representation are read-only.
+	columns map[string]string
+
+	// notNull are the members that cannot be cleared, notEmpty the strings
+	// that cannot be cleared or empty, and required the members that every
+	// patch must have.
+	notNull  []string
+	notEmpty []string
+	required []string
+
+	// get returns the current record of the ID, and exists reports whether
+	// it was found.
+	get    func(id int) (R, error)
+	exists func(record R) bool
+
+	// view returns the representation of the record, and record the record of
+	// the patched representation, or an error that wraps errInvalidPatch if it
+	// is not valid.
+	view   func(record R) A
+	record func(existing R, patched A) (R, error)
+
+	// save writes the columns of the record.
+	save func(record R, columns ...string) error
+
+	// version returns the version of the record, if the resource has one. The
+	// record is then only saved at the version of the 'If-Match' header of the
+	// request, if it has one.
+	version func(record *R) *int
+}
+
+// patchResource handles the HTTP request to change the resource of the 'id'
+// query parameter with a JSON Merge Patch. It writes the patched resource with
+// an HTTP OK status, HTTP Not Found if it does not exist, HTTP Bad Request if
+// the patch or the patched resource is not valid, and HTTP Precondition Failed
+// if it was changed since the version of the 'If-Match' header.
+func patchResource[R, A any](w http.ResponseWriter, r *http.Request, p patch[R, A]) {
+	defer func() {
+		_ = r.Body.Close()
+		log.Panic()
+	}()
+
+	id, err := parameterID(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	var version int
+
+	if p.version != nil {
+		var ok bool
+
+		version, ok = precondition(w, r, id)
+		if !ok {
+			return
+		}
+	}
+
+	if !isMergePatch(r) {
+		err := fmt.Errorf("content type must be %s", mergePatchType)
+		log.Error(err, "unsupported content type", log.KVs(log.Map{"content_type": r.Header.Get("Content-Type"), "path": r.URL.Path}))
+		response.UnsupportedMediaType(w, response.NewError(err))
+
+		return
+	}
+
+	body, err := requestutils.ReadBody(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	members, err := p.members(body)
+	if err != nil {
+		log.Error(err, "invalid "+p.name+" patch", log.KVs(log.Map{"request": string(body), "path": r.URL.Path}))
+		response.BadRequest(w, response.NewError(err))
+
+		return
+	}
+
+	existing, err := p.get(id)
+	if err != nil {
+		log.Error(err, "failed to retrieve "+p.name, log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve "+p.name))
+
+		return
+	}
+
+	if !p.exists(existing) {
+		response.NotFound(w, response.New(p.name+" not found", map[string]any{"id": id}))
+		return
+	}
+
+	if version != 0 && *p.version(&existing) != version {
+		versionMismatch(w, r, id, repository.ErrVersionMismatch)
+		return
+	}
+
+	patched, err := applyMergePatch(p.view(existing), members)
+	if err != nil {
+		log.Error(err, "failed to apply "+p.name+" patch", log.KVs(log.Map{"request": string(body), "path": r.URL.Path}))
+		response.BadRequest(w, response.NewError(err))
+
+		return
+	}
+
+	record, err := p.record(existing, patched)
+	if err != nil {
+		log.Error(err, "invalid "+p.name, log.KVs(log.Map{"request": string(body), "path": r.URL.Path}))
+
+		if errors.Is(err, errInvalidPatch) {
+			response.BadRequest(w, response.NewError(err, map[string]any{"id": id}))
+			return
+		}
+
+		if rejectedActor(w, r, err) {
+			return
+		}
+
+		response.InternalServer(w, response.NewError(err, "failed to validate "+p.name))
+
+		return
+	}
+
+	// An empty patch changes nothing.
+	columns := p.changed(members)
+	if len(columns) == 0 {
+		p.respond(w, existing)
+		return
+	}
+
+	// Without an 'If-Match' header, the patched columns are written whatever
+	// the version of the record is by now.
+	if p.version != nil {
+		*p.version(&record) = version
+	}
+
+	err = p.save(record, columns...)
+	if err != nil {
+		if versionMismatch(w, r, id, err) {
+			return
+		}
+
+		log.Error(err, "failed to patch "+p.name, log.KVs(log.Map{"id": id, "columns": columns, "path": r.URL.Path}))
+
+		if errors.Is(err, errDuplicateSKU) {
+			response.Conflict(w, response.NewError(err, map[string]any{"id": id}))
+			return
+		}
+
+		response.InternalServer(w, response.NewError(err, "failed to patch "+p.name))
+
+		return
+	}
+
+	updated, err := p.get(id)
+	if err != nil {
+		log.Error(err, "failed to retrieve "+p.name, log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve "+p.name))
+
+		return
+	}
+
+	p.respond(w, updated)
+}
+
+// respond writes the representation of the record, with its ETag if it has a
+// version.
+func (p patch[R, A]) respond(w http.ResponseWriter, record R) {
+	if p.version != nil {
+		w.Header().Set("ETag", versionTag(*p.version(&record)))
+	}
+
+	response.Success(w, p.view(record))
+}
+
+// isMergePatch reports whether the body of the request is a JSON Merge Patch.
+// Plain JSON is accepted as well, and so is a request without a content type.
+func isMergePatch(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	return err == nil && (mediaType == mergePatchType || mediaType == "application/json")
+}
+
+// members returns the members of the patch, or an error if it is not an object,
+// changes a read-only member, clears a member that cannot be null or empty or
+// lacks a required member.
+func (p patch[R, A]) members(body []byte) (map[string]json.RawMessage, error) {
+	var members map[string]json.RawMessage
+
+	err := json.Unmarshal(body, &members)
+	if err != nil || members == nil {
+		return nil, errors.New("the patch must be a JSON object")
+	}
+
+	var readOnly, cleared, empty, missing []string
+
+	for _, name := range slices.Sorted(maps.Keys(members)) {
+		var (
+			value     string
+			_, ok     = p.columns[name]
+			isNull    = string(members[name]) == "null"
+			mustExist = slices.Contains(p.notNull, name) || slices.Contains(p.notEmpty, name)
+		)
+
+		switch {
+		case !ok:
+			readOnly = append(readOnly, name)
+
+		case mustExist && isNull:
+			cleared = append(cleared, name)
+
+		case slices.Contains(p.notEmpty, name) && json.Unmarshal(members[name], &value) == nil && strings.TrimSpace(value) == "":
+			empty = append(empty, name)
+		}
+	}
+
+	for _, name := range p.required {
+		if _, ok := members[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	switch {
+	case len(readOnly) > 0:
+		return nil, fmt.Errorf("cannot patch %s of %s; only %s can be patched",
+			strings.Join(readOnly, ", "), p.name, strings.Join(slices.Sorted(maps.Keys(p.columns)), ", "))
+
+	case len(cleared) > 0:
+		return nil, fmt.Errorf("%s of %s cannot be null", strings.Join(cleared, ", "), p.name)
+
+	case len(empty) > 0:
+		return nil, fmt.Errorf("%s of %s cannot be empty", strings.Join(empty, ", "), p.name)
+
+	case len(missing) > 0:
+		return nil, fmt.Errorf("%s of %s is required", strings.Join(missing, ", "), p.name)
+	}
+
+	return members, nil
+}
+
+// changed returns the columns of the members of the patch, in a stable order.
+func (p patch[R, A]) changed(members map[string]json.RawMessage) []string {
+	var columns []string
+
+	for _, name := range slices.Sorted(maps.Keys(members)) {
+		if !slices.Contains(columns, p.columns[name]) {
+			columns = append(columns, p.columns[name])
+		}
+	}
+
+	return columns
+}
+
+// applyMergePatch applies the members of the patch to the representation and
+// returns the patched representation.
+func applyMergePatch[A any](view A, members map[string]json.RawMessage) (A, error) {
+	var (
+		patched  A
+		document map[string]any
+	)
+
+	data, err := json.Marshal(view)
+	if err != nil {
+		return patched, err
+	}
+
+	err = json.Unmarshal(data, &document)
+	if err != nil {
+		return patched, err
+	}
+
+	for name, raw := range members {
+		var value any
+
+		err = json.Unmarshal(raw, &value)
+		if err != nil {
+			return patched, fmt.Errorf("invalid value of %s: %w", name, err)
+		}
+
+		document = mergePatch(document, map[string]any{name: value}).(map[string]any)
+	}
+
+	data, err = json.Marshal(document)
+	if err != nil {
+		return patched, err
+	}
+
+	err = json.Unmarshal(data, &patched)
+	if err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return patched, fmt.Errorf("%s must be of type %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+
+		return patched, err
+	}
+
+	return patched, nil
+}
+
+// mergePatch returns the target with the patch applied as defined by RFC 7396:
+// the members of an object patch are merged into the target recursively, a null
+// member removes the member of the target, and any other patch replaces the
+// target.
+func mergePatch(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	document, ok := target.(map[string]any)
+	if !ok {
+		document = map[string]any{}
+	}
+
+	for name, value := range members {
+		if value == nil {
+			delete(document, name)
+			continue
+		}
+
+		document[name] = mergePatch(document[name], value)
+	}
+
+	return document
+}
+
+// invalid returns an error that wraps errInvalidPatch with the message.
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errInvalidPatch, fmt.Sprintf(format, args...))
+}
+
+// referenced returns an error if the record of the ID that the member refers to
+// does not exist.
+func referenced[T any](member string, id int, get func(id int) (T, error), exists func(T) bool) error {
+	record, err := get(id)
+	if err != nil {
+		return err
+	}
+
+	if !exists(record) {
+		return invalid("%s %d does not exist", member, id)
+	}
+
+	return nil
+}
@@ -1,13 +1,14 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/rmarasigan/warehouse-inventory-management/api/response"
 	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/api/schema/validator"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
@@ -25,6 +26,9 @@ func roleHandler(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPut:
 		updateRole(w, r)
 
+	case http.MethodPatch:
+		patchRole(w, r)
+
 	case http.MethodDelete:
 		deleteRole(w, r)
 	}
@@ -36,7 +40,7 @@ func roleHandler(w http.ResponseWriter, r *http.Request) {
 func getRoles(w http.ResponseWriter, r *http.Request) {
 	defer log.Panic()
 
-	list, err := getList(r, mysql.GetRoleByID, mysql.ListRole)
+	list, err := getList(r, store.Roles.Get, store.Roles.List)
 	if err != nil {
 		log.Error(err, "failed to retrieve roles", log.KV("path", r.URL.Path))
 		response.InternalServer(w, response.NewError(err, "failed to retrieve roles"))
@@ -44,21 +48,23 @@ func getRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	roles := convert.SchemaList(list, func(role schema.Role) apischema.Role {
-		return apischema.Role{
-			ID:   role.ID,
-			Name: role.Name,
-		}
-	})
+	roles := convert.SchemaList(list, roleResponse)
+
+	listResponse(w, r, list, func(role schema.Role) int { return role.Version }, roles)
+}
 
-	response.Success(w, roles)
+// roleResponse converts the role record to its API representation.
+func roleResponse(role schema.Role) apischema.Role {
+	return apischema.Role{
+		ID:   role.ID,
+		Name: role.Name,
+	}
 }
 
-// createRole handles the HTTP request to create a new role. It validates
-// the request body, unmarshals it into a role object, checks if the role
-// already exists in the database, and inserts it if not. If the request
-// body is invalid or the role already exist, it writes an appropriate HTTP
-// status response.
+// createRole handles the HTTP request to create new roles. It validates the
+// request body, unmarshals it into role objects and inserts each role whose
+// name does not exist yet. It writes the result of each role, with an HTTP
+// Multi-Status if only some of them were created.
 func createRole(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		_ = r.Body.Close()
@@ -85,34 +91,36 @@ func createRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
 	roles := convert.SchemaList(data, func(role apischema.Role) schema.Role {
 		return schema.Role{
 			Name: role.Name,
 		}
 	})
 
-	for _, role := range roles {
-		_, err = mysql.NewRoleIfNotExists(role)
-		if err != nil {
-			log.Error(err, "failed to create role", log.KVs(log.Map{"role": role, "path": r.URL.Path}))
-			response.InternalServer(w, response.NewError(err,
-				map[string]any{
-					"request": data,
-					"role":    role,
-					"message": "failed to create role",
-				}),
-			)
+	report, err := bulk(atomic, roles, func(batch repository.Batch, role schema.Role) (string, int64, error) {
+		return insertedStatus(batch.Role(role))
+	})
 
-			return
-		}
+	if err != nil {
+		log.Error(err, "failed to create roles", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to create roles"))
+
+		return
 	}
 
-	response.Created(w, nil)
+	bulkResponse(w, report, response.Created)
 }
 
 // updateRole handles the HTTP request to update/modify role information. It
-// unmarshals the request body into a role object and updates the corresponding
-// fields. If an error occurs, it responds with an HTTP Internal Server Error status.
+// unmarshals the request body into role objects and updates the corresponding
+// fields. It writes the result of each role, with an HTTP Multi-Status if only
+// some of them were updated.
 func updateRole(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		_ = r.Body.Close()
@@ -131,32 +139,86 @@ func updateRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var roles = convert.SchemaList(data, func(role apischema.Role) schema.Role {
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	roles := convert.SchemaList(data, func(role apischema.Role) schema.Role {
 		return schema.Role{
 			ID:   role.ID,
 			Name: role.Name,
 		}
 	})
 
-	for _, role := range roles {
-		err = mysql.UpdateRole(role)
+	version, ok := singlePrecondition(w, r, roles, func(role schema.Role) int { return role.ID })
+	if !ok {
+		return
+	}
+
+	// changed is set if the role of the 'If-Match' header was changed since.
+	var changed error
+
+	report, err := bulk(atomic, roles, func(batch repository.Batch, role schema.Role) (string, int64, error) {
+		existing, err := store.Roles.Get(role.ID)
 		if err != nil {
-			log.Error(err, "failed to update role",
-				log.KVs(log.Map{"request": data, "role": role, "path": r.URL.Path}))
+			return "", 0, err
+		}
 
-			response.InternalServer(w, response.NewError(err,
-				map[string]any{
-					"request": data,
-					"role":    role,
-					"message": "failed to update role",
-				}),
-			)
+		if version != 0 && existing.Version != version {
+			changed = repository.ErrVersionMismatch
+			return "", 0, changed
+		}
 
-			return
+		if existing.ID == 0 {
+			return "", 0, fmt.Errorf("role %d does not exist", role.ID)
+		}
+
+		role.Version = version
+
+		err = batch.UpdateRole(role)
+		if err != nil {
+			if errors.Is(err, repository.ErrVersionMismatch) {
+				changed = err
+			}
+
+			return "", 0, err
 		}
+
+		return apischema.RowUpdated, int64(role.ID), nil
+	})
+
+	if err != nil {
+		log.Error(err, "failed to update roles", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to update roles"))
+
+		return
 	}
 
-	response.Success(w, nil)
+	if versionMismatch(w, r, roles[0].ID, changed) {
+		return
+	}
+
+	bulkResponse(w, report, response.Success)
+}
+
+// patchRole handles the HTTP request to rename a role with a JSON Merge Patch.
+func patchRole(w http.ResponseWriter, r *http.Request) {
+	patchResource(w, r, patch[schema.Role, apischema.Role]{
+		name:     "role",
+		columns:  map[string]string{"name": "name"},
+		notEmpty: []string{"name"},
+		get:      store.Roles.Get,
+		exists:   func(role schema.Role) bool { return role.ID != 0 },
+		view:     roleResponse,
+		record: func(existing schema.Role, role apischema.Role) (schema.Role, error) {
+			existing.Name = role.Name
+			return existing, nil
+		},
+		save:    store.Roles.Patch,
+		version: func(role *schema.Role) *int { return &role.Version },
+	})
 }
 
 // deleteRole handles the HTTP request to delete a role. If an error occurs,
@@ -170,8 +232,17 @@ func deleteRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	affected, err := mysql.DeleteRole(id)
+	version, ok := precondition(w, r, id)
+	if !ok {
+		return
+	}
+
+	affected, err := store.Roles.Delete(id, version)
 	if err != nil {
+		if versionMismatch(w, r, id, err) {
+			return
+		}
+
 		log.Error(err, "failed to delete role", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
 		response.InternalServer(w, response.NewError(err, "failed to delete role"))
 
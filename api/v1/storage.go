@@ -1,13 +1,15 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/rmarasigan/warehouse-inventory-management/api/response"
 	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/api/schema/validator"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
 	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
@@ -24,7 +26,19 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 		createStorage(w, r)
 
 	case http.MethodPut:
-		updateStorage(w, r)
+		switch {
+		case strings.HasSuffix(r.URL.Path, archiveStorage):
+			archiveResource(w, r, storageArchival())
+
+		case strings.HasSuffix(r.URL.Path, restoreStorage):
+			restoreResource(w, r, storageArchival())
+
+		default:
+			updateStorage(w, r)
+		}
+
+	case http.MethodPatch:
+		patchStorage(w, r)
 
 	case http.MethodDelete:
 		deleteStorage(w, r)
@@ -34,7 +48,18 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 func getStorages(w http.ResponseWriter, r *http.Request) {
 	defer log.Panic()
 
-	list, err := getList(r, mysql.GetStorageByID, mysql.ListStorage)
+	archived, err := parameterArchived(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	listStorages := store.Storages.List
+	if archived {
+		listStorages = store.Storages.Archived
+	}
+
+	list, err := getList(r, store.Storages.Get, listStorages)
 	if err != nil {
 		log.Error(err, "failed to retrieve storages", log.KV("path", r.URL.Path))
 		response.InternalServer(w, response.NewError(err, "failed to retrieve storages"))
@@ -42,16 +67,20 @@ func getStorages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	storages := convert.SchemaList(list, func(storage schema.Storage) apischema.Storage {
-		return apischema.Storage{
-			ID:          storage.ID,
-			Code:        storage.Code,
-			Name:        storage.Name,
-			Description: dbutils.GetString(storage.Description),
-		}
-	})
+	storages := convert.SchemaList(list, storageResponse)
+
+	listResponse(w, r, list, func(storage schema.Storage) int { return storage.Version }, storages)
+}
 
-	response.Success(w, storages)
+// storageResponse converts the storage record to its API representation.
+func storageResponse(storage schema.Storage) apischema.Storage {
+	return apischema.Storage{
+		ID:          storage.ID,
+		Code:        storage.Code,
+		Name:        storage.Name,
+		Description: dbutils.GetString(storage.Description),
+		Archive:     archiveResponse(storage.ArchivedAt, storage.ArchivedBy),
+	}
 }
 
 func createStorage(w http.ResponseWriter, r *http.Request) {
@@ -80,32 +109,32 @@ func createStorage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
 	storages := convert.SchemaList(data, func(storage apischema.Storage) schema.Storage {
 		return schema.Storage{
-			ID:          storage.ID,
 			Code:        storage.Code,
 			Name:        storage.Name,
 			Description: dbutils.SetString(storage.Description),
 		}
 	})
 
-	for _, storage := range storages {
-		_, err = mysql.NewStorageIfNotExists(storage)
-		if err != nil {
-			log.Error(err, "failed to create storage", log.KVs(log.Map{"storage": storage, "path": r.URL.Path}))
-			response.InternalServer(w, response.NewError(err,
-				map[string]any{
-					"request": data,
-					"storage": storage,
-					"message": "failed to create storage",
-				}),
-			)
+	report, err := bulk(atomic, storages, func(batch repository.Batch, storage schema.Storage) (string, int64, error) {
+		return insertedStatus(batch.Storage(storage))
+	})
 
-			return
-		}
+	if err != nil {
+		log.Error(err, "failed to create storages", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to create storages"))
+
+		return
 	}
 
-	response.Created(w, nil)
+	bulkResponse(w, report, response.Created)
 }
 
 func updateStorage(w http.ResponseWriter, r *http.Request) {
@@ -126,6 +155,12 @@ func updateStorage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
 	storages := convert.SchemaList(data, func(storage apischema.Storage) schema.Storage {
 		return schema.Storage{
 			ID:          storage.ID,
@@ -135,25 +170,77 @@ func updateStorage(w http.ResponseWriter, r *http.Request) {
 		}
 	})
 
-	for _, storage := range storages {
-		err := mysql.UpdateStorage(storage)
+	version, ok := singlePrecondition(w, r, storages, func(storage schema.Storage) int { return storage.ID })
+	if !ok {
+		return
+	}
+
+	// changed is set if the storage of the 'If-Match' header was changed since.
+	var changed error
+
+	report, err := bulk(atomic, storages, func(batch repository.Batch, storage schema.Storage) (string, int64, error) {
+		existing, err := store.Storages.Get(storage.ID)
 		if err != nil {
-			log.Error(err, "failed to update storage",
-				log.KVs(log.Map{"request": data, "storage": storage, "path": r.URL.Path}))
+			return "", 0, err
+		}
 
-			response.InternalServer(w, response.NewError(err,
-				map[string]any{
-					"request": data,
-					"storage": storage,
-					"message": "failed to update storage",
-				}),
-			)
+		if version != 0 && existing.Version != version {
+			changed = repository.ErrVersionMismatch
+			return "", 0, changed
+		}
 
-			return
+		if existing.ID == 0 {
+			return "", 0, fmt.Errorf("storage %d does not exist", storage.ID)
+		}
+
+		storage.Version = version
+
+		err = batch.UpdateStorage(storage)
+		if err != nil {
+			if errors.Is(err, repository.ErrVersionMismatch) {
+				changed = err
+			}
+
+			return "", 0, err
 		}
+
+		return apischema.RowUpdated, int64(storage.ID), nil
+	})
+
+	if err != nil {
+		log.Error(err, "failed to update storages", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to update storages"))
+
+		return
 	}
 
-	response.Success(w, nil)
+	if versionMismatch(w, r, storages[0].ID, changed) {
+		return
+	}
+
+	bulkResponse(w, report, response.Success)
+}
+
+// patchStorage handles the HTTP request to change a storage with a JSON Merge
+// Patch. Unlike updateStorage, a null description clears it.
+func patchStorage(w http.ResponseWriter, r *http.Request) {
+	patchResource(w, r, patch[schema.Storage, apischema.Storage]{
+		name:     "storage",
+		columns:  map[string]string{"code": "code", "name": "name", "description": "description"},
+		notEmpty: []string{"code", "name"},
+		get:      store.Storages.Get,
+		exists:   func(storage schema.Storage) bool { return storage.ID != 0 },
+		view:     storageResponse,
+		record: func(existing schema.Storage, storage apischema.Storage) (schema.Storage, error) {
+			existing.Code = storage.Code
+			existing.Name = storage.Name
+			existing.Description = dbutils.SetString(storage.Description)
+
+			return existing, nil
+		},
+		save:    store.Storages.Patch,
+		version: func(storage *schema.Storage) *int { return &storage.Version },
+	})
 }
 
 func deleteStorage(w http.ResponseWriter, r *http.Request) {
@@ -165,8 +252,17 @@ func deleteStorage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	affected, err := mysql.DeleteStorage(id)
+	version, ok := precondition(w, r, id)
+	if !ok {
+		return
+	}
+
+	affected, err := store.Storages.Delete(id, version)
 	if err != nil {
+		if versionMismatch(w, r, id, err) || deleteConflict(w, r, id, err) {
+			return
+		}
+
 		log.Error(err, "failed to delete storage", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
 		response.InternalServer(w, response.NewError(err, "failed to delete storage"))
 
@@ -0,0 +1,231 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/stream"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/webhook"
+)
+
+const (
+	lastEventIDHeader string = "Last-Event-ID"
+
+	// streamRetry is how long a client waits before it reconnects, in
+	// milliseconds.
+	streamRetry int = 3000
+)
+
+// Events that are only sent on the stream.
+const (
+	// streamReset is sent when the events after the Last-Event-ID are no longer
+	// buffered. The client should reload the state it keeps.
+	streamReset string = "reset"
+
+	// streamDropped is sent before a client that did not keep up with the events
+	// is disconnected. It can reconnect with the Last-Event-ID to resume.
+	streamDropped string = "dropped"
+)
+
+func streamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		streamEvents(w, r)
+	}
+}
+
+// streamEvents handles the HTTP request to stream the inventory events as they
+// are committed, as Server-Sent Events. The events can be filtered by item
+// ('item_id'), storage ('storage_id') and comma-separated event types ('type').
+// A client that reconnects with the 'Last-Event-ID' header (or 'last_event_id'
+// query parameter) receives the buffered events it missed.
+func streamEvents(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		err := errors.New("streaming is not supported")
+		log.Error(err, "response writer cannot be flushed", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err))
+
+		return
+	}
+
+	filter, err := streamFilter(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	lastEventID, err := parameterLastEventID(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	subscriber, backlog, complete := stream.Subscribe(filter, lastEventID)
+	if subscriber == nil {
+		err := errors.New("event stream is not running")
+		log.Error(err, "failed to subscribe to events", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err))
+
+		return
+	}
+
+	defer stream.Unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+
+	if !complete {
+		writeStreamMessage(w, streamReset, "events after the last event ID are no longer available")
+	}
+
+	for _, event := range backlog {
+		err := writeStreamEvent(w, event)
+		if err != nil {
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(stream.Heartbeat())
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case event, ok := <-subscriber.Events:
+			if !ok {
+				if subscriber.Dropped {
+					log.Warn("slow event stream client dropped", log.KVs(log.Map{"path": r.URL.Path, "remote": r.RemoteAddr}))
+					writeStreamMessage(w, streamDropped, "too many events were queued; reconnect to resume")
+					flusher.Flush()
+				}
+
+				return
+			}
+
+			err := writeStreamEvent(w, event)
+			if err != nil {
+				return
+			}
+
+			flusher.Flush()
+
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": keep-alive\n\n")
+			if err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// writeStreamEvent writes the event with the same body as the webhook
+// deliveries. Its ID is the position of the event to resume the stream from.
+func writeStreamEvent(w http.ResponseWriter, event schema.OutboxEvent) error {
+	data, err := json.Marshal(webhook.Envelope{
+		ID:        event.EventID,
+		Type:      event.Type,
+		CreatedAt: event.DateCreated,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+
+	return err
+}
+
+func writeStreamMessage(w http.ResponseWriter, event, message string) {
+	data, _ := json.Marshal(response.New(message))
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+// streamFilter returns the filter of the stream from the request query.
+func streamFilter(r *http.Request) (stream.Filter, error) {
+	var filter stream.Filter
+
+	params := []struct {
+		name  string
+		value *int
+	}{
+		{"item_id", &filter.ItemID},
+		{"storage_id", &filter.StorageID},
+	}
+
+	for _, p := range params {
+		name, value := p.name, p.value
+
+		param, ok := requestutils.HasQueryParam(r, name)
+		if !ok {
+			continue
+		}
+
+		id, err := strconv.Atoi(param)
+		if err != nil {
+			log.Error(err, "failed to parse '"+name+"' query parameter", log.KVs(log.Map{name: param, "path": r.URL.Path}))
+			return stream.Filter{}, fmt.Errorf("invalid '%s' value; must be an integer", name)
+		}
+
+		*value = id
+	}
+
+	types, ok := requestutils.HasQueryParam(r, "type")
+	if ok {
+		for _, eventType := range strings.Split(types, ",") {
+			eventType = strings.TrimSpace(eventType)
+
+			if !schema.IsValidEventType(eventType) {
+				return stream.Filter{}, fmt.Errorf("unknown event type '%s'; must be one of %s", eventType, strings.Join(schema.EventTypes, ", "))
+			}
+
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+
+	return filter, nil
+}
+
+// parameterLastEventID returns the ID of the last event the client received,
+// from the 'Last-Event-ID' header that browsers send when they reconnect, or
+// the 'last_event_id' query parameter.
+func parameterLastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get(lastEventIDHeader)
+	if value == "" {
+		value, _ = requestutils.HasQueryParam(r, "last_event_id")
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		log.Error(err, "failed to parse last event id", log.KVs(log.Map{"last_event_id": value, "path": r.URL.Path}))
+		return 0, errors.New("invalid 'Last-Event-ID' value; must be a positive integer")
+	}
+
+	return id, nil
+}
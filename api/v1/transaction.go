@@ -2,12 +2,13 @@ package v1
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/rmarasigan/warehouse-inventory-management/api/response"
 	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/api/schema/validator"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
 	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
@@ -25,12 +26,30 @@ func transactionHandler(w http.ResponseWriter, r *http.Request) {
 
 	case http.MethodPut:
 		updateTransactionNote(w, r)
+
+	case http.MethodPatch:
+		patchTransactionNote(w, r)
 	}
 }
 
+// getTransactions handles the HTTP request to retrieve a specific transaction
+// ('id') or all transactions. The transactions can be searched by the start of
+// their external reference ('external_reference'), the partner ('partner') and
+// the type ('type').
 func getTransactions(w http.ResponseWriter, r *http.Request) {
 	defer log.Panic()
-	list, err := getList(r, mysql.GetTransactionByID, mysql.ListTransaction)
+
+	list, err := getList(r, store.Transactions.Get, func() ([]schema.Transaction, error) {
+		externalReference, hasExternalReference := requestutils.HasQueryParam(r, "external_reference")
+		partner, hasPartner := requestutils.HasQueryParam(r, "partner")
+		transactionType, hasType := requestutils.HasQueryParam(r, "type")
+
+		if !hasExternalReference && !hasPartner && !hasType {
+			return store.Transactions.List()
+		}
+
+		return store.Transactions.Search(partner, transactionType, externalReference)
+	})
 	if err != nil {
 		log.Error(err, "failed to retrieve transactions", log.KV("path", r.URL.Path))
 		response.InternalServer(w, response.NewError(err, "failed to retrieve transactions"))
@@ -46,7 +65,10 @@ func getTransactions(w http.ResponseWriter, r *http.Request) {
 						ID:            orderline.ID,
 						TransactionID: orderline.TransactionID,
 						ItemID:        orderline.ItemID,
-						Quantity:      orderline.Quantity,
+						Quantity:      recordedQuantity(orderline),
+						UoMID:         dbutils.GetAsInt(orderline.UoMID),
+						BaseQuantity:  orderline.Quantity,
+						BaseUoMID:     dbutils.GetAsInt(orderline.BaseUoMID),
 						UnitPrice:     dbutils.GetFloat(orderline.UnitPrice),
 						TotalAmount:   dbutils.GetFloat(orderline.TotalAmount),
 						Note:          dbutils.GetString(orderline.Note),
@@ -60,14 +82,17 @@ func getTransactions(w http.ResponseWriter, r *http.Request) {
 			)
 
 			return apischema.Transaction{
-				ID:           transaction.ID,
-				Reference:    transaction.Reference,
-				Orderlines:   orderlines,
-				Note:         dbutils.GetString(transaction.Note),
-				CreatedBy:    transaction.CreatedBy,
-				UpdatedBy:    dbutils.GetAsInt(transaction.UpdatedBy),
-				DateCreated:  transaction.DateCreated,
-				DateModified: dbutils.GetTime(transaction.DateModified),
+				ID:                transaction.ID,
+				Reference:         transaction.Reference,
+				Partner:           transaction.Partner,
+				ExternalReference: dbutils.GetString(transaction.ExternalReference),
+				Orderlines:        orderlines,
+				Type:              transaction.Type,
+				Note:              dbutils.GetString(transaction.Note),
+				CreatedBy:         transaction.CreatedBy,
+				UpdatedBy:         dbutils.GetAsInt(transaction.UpdatedBy),
+				DateCreated:       transaction.DateCreated,
+				DateModified:      dbutils.GetTime(transaction.DateModified),
 			}
 		},
 	)
@@ -101,15 +126,24 @@ func createTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !actor(w, r, "created_by", data.CreatedBy) {
+		return
+	}
+
 	transaction := convert.Schema(data,
 		func(trans apischema.Transaction) schema.Transaction {
 			var amount float64
 
 			orderlines := convert.SchemaList(data.Orderlines,
 				func(orderline apischema.Orderline) schema.Orderline {
+					// Default the total amount to the quantity at the unit price.
+					if orderline.TotalAmount == 0 {
+						orderline.TotalAmount = float64(orderline.Quantity) * orderline.UnitPrice
+					}
+
 					amount += orderline.TotalAmount
 
-					return schema.Orderline{
+					record := schema.Orderline{
 						ItemID:      orderline.ItemID,
 						Quantity:    orderline.Quantity,
 						UnitPrice:   dbutils.SetFloat(orderline.UnitPrice),
@@ -117,15 +151,23 @@ func createTransaction(w http.ResponseWriter, r *http.Request) {
 						Note:        dbutils.SetString(orderline.Note),
 						CreatedBy:   data.CreatedBy,
 					}
+
+					if orderline.UoMID != 0 {
+						record.UoMID = dbutils.SetInt(int32(orderline.UoMID))
+					}
+
+					return record
 				})
 
 			return schema.Transaction{
-				Reference:  data.GenerateReference(),
-				Orderlines: orderlines,
-				Amount:     dbutils.SetFloat(amount),
-				Type:       data.Type,
-				Note:       dbutils.SetString(data.Note),
-				CreatedBy:  data.CreatedBy,
+				Reference:         data.GenerateReference(),
+				Partner:           strings.TrimSpace(data.Partner),
+				ExternalReference: dbutils.SetString(strings.TrimSpace(data.ExternalReference)),
+				Orderlines:        orderlines,
+				Amount:            dbutils.SetFloat(amount),
+				Type:              data.Type,
+				Note:              dbutils.SetString(data.Note),
+				CreatedBy:         data.CreatedBy,
 			}
 		})
 
@@ -143,8 +185,74 @@ func createTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A transaction that was already recorded under the partner's reference is a
+	// duplicate (e.g. the same delivery note imported twice).
+	if transaction.ExternalReference.Valid {
+		existing, err := store.Transactions.GetByExternalReference(transaction.Partner, transaction.Type, transaction.ExternalReference.String)
+		if err != nil {
+			log.Error(err, "failed to validate external reference", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to validate external reference"))
+
+			return
+		}
+
+		if existing.ID != 0 {
+			err := fmt.Errorf("external reference '%s' is already recorded", transaction.ExternalReference.String)
+			log.Error(err, "duplicate external reference", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+			response.Conflict(w, response.NewError(err,
+				map[string]any{
+					"transaction_id": existing.ID,
+					"reference":      existing.Reference,
+				}),
+			)
+
+			return
+		}
+	}
+
+	// Convert the quantity of each orderline to the item's base unit of measurement
+	// before anything is recorded.
+	for i, orderline := range transaction.Orderlines {
+		converted, err := toBaseUOM(orderline)
+		if err != nil {
+			log.Error(err, "failed to convert orderline unit of measurement",
+				log.KVs(log.Map{"request": data, "orderline": orderline, "path": r.URL.Path}))
+
+			details := map[string]any{
+				"message": "failed to convert orderline unit of measurement",
+				"request": data,
+				"item_id": orderline.ItemID,
+			}
+
+			if errors.Is(err, schema.ErrUOMNotConvertible) || errors.Is(err, schema.ErrFractionalQuantity) {
+				response.BadRequest(w, response.NewError(err, details))
+				return
+			}
+
+			response.InternalServer(w, response.NewError(err, details))
+
+			return
+		}
+
+		transaction.Orderlines[i] = converted
+	}
+
+	// The transaction, its stock movements and their events are recorded in a
+	// single database transaction, so that nothing is recorded if any of them
+	// fails.
+	batch, err := store.Batches.Begin()
+	if err != nil {
+		log.Error(err, "failed to begin database transaction", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err, "failed to create transaction"))
+
+		return
+	}
+
+	// Rollback is a no-op once the batch is committed.
+	defer rollbackBatch(batch)
+
 	transactionType := transaction.Type
-	lastInsertID, err := mysql.NewTransaction(transactionType, transaction)
+	lastInsertID, err := batch.NewTransaction(transaction)
 	if err != nil {
 		log.Error(err, "failed to create transaction", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
 		response.InternalServer(w, response.NewError(err,
@@ -157,11 +265,13 @@ func createTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, orderline := range transaction.Orderlines {
+	transaction.ID = int(lastInsertID)
+
+	for i, orderline := range transaction.Orderlines {
 		orderline.TransactionID = int(lastInsertID)
 
-		// Fetch the item information.
-		item, err := mysql.GetItemByID(orderline.ItemID)
+		// Fetch the item information and lock it until the transaction is recorded.
+		item, err := batch.ItemForUpdate(orderline.ItemID)
 		if err != nil {
 			log.Error(err, "failed to fetch orderline item",
 				log.KVs(log.Map{"request": data, "orderline": orderline, "path": r.URL.Path}))
@@ -179,6 +289,24 @@ func createTransaction(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// An archived item must be restored before its stock is moved again.
+		if item.ArchivedAt.Valid {
+			err := fmt.Errorf("item %d is archived", item.ID)
+			log.Error(err, "failed to process transaction",
+				log.KVs(log.Map{"request": data, "orderline": orderline, "path": r.URL.Path}))
+
+			response.Conflict(w, response.NewError(err,
+				map[string]any{
+					"message":          "restore the item before it is in a transaction",
+					"request":          data,
+					"item_id":          orderline.ItemID,
+					"transaction_type": transactionType,
+				}),
+			)
+
+			return
+		}
+
 		// Do not process and return an error when the requested quantity exceeds the
 		// available stock.
 		if transactionType == "outbound" && orderline.Quantity > item.Quantity {
@@ -199,7 +327,7 @@ func createTransaction(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Create a new orderline for the said transaction.
-		_, err = mysql.NewOrderline(transactionType, orderline)
+		orderlineID, err := batch.NewOrderline(transactionType, orderline)
 		if err != nil {
 			log.Error(err, "failed to create a new orderline",
 				log.KVs(log.Map{
@@ -226,9 +354,10 @@ func createTransaction(w http.ResponseWriter, r *http.Request) {
 		// Updates the item's quantity based on the transaction type:
 		// - inbound:  item.Quantity + orderline.Quantity
 		// - outbound: item.Quantity - orderline.Quantity
+		previousQuantity := item.Quantity
 		item.UpdateQuantity(transactionType, orderline.Quantity)
 
-		err = mysql.UpdateItem(item)
+		err = batch.UpdateItemQuantity(item)
 		if err != nil {
 			log.Error(err, "failed to update item", log.KVs(log.Map{"request": data, "item": item, "path": r.URL.Path}))
 			response.InternalServer(w, response.NewError(err,
@@ -243,6 +372,61 @@ func createTransaction(w http.ResponseWriter, r *http.Request) {
 
 			return
 		}
+
+		// Record the cost of the stock movement for the inventory valuation.
+		orderline.ID = int(orderlineID)
+		transaction.Orderlines[i] = orderline
+
+		err = recordValuation(batch, transactionType, orderline, item)
+		if err != nil {
+			log.Error(err, "failed to record inventory valuation",
+				log.KVs(log.Map{"request": data, "orderline": orderline, "path": r.URL.Path}))
+
+			response.InternalServer(w, response.NewError(err,
+				map[string]any{
+					"message":          "failed to record inventory valuation",
+					"request":          data,
+					"item_id":          item.ID,
+					"transaction_id":   lastInsertID,
+					"transaction_type": transactionType,
+				}),
+			)
+
+			return
+		}
+
+		err = emitStockChange(batch, stockChange{
+			ItemID:           item.ID,
+			StorageID:        item.StorageID,
+			TransactionID:    transaction.ID,
+			PreviousQuantity: previousQuantity,
+			Quantity:         item.Quantity,
+		})
+
+		if err != nil {
+			log.Error(err, "failed to record stock change event",
+				log.KVs(log.Map{"request": data, "orderline": orderline, "path": r.URL.Path}))
+
+			response.InternalServer(w, response.NewError(err, "failed to record stock change event"))
+
+			return
+		}
+	}
+
+	err = emitEvent(batch, schema.EventTransactionCreated, 0, 0, transactionEvent(transaction))
+	if err != nil {
+		log.Error(err, "failed to record transaction event", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to record transaction event"))
+
+		return
+	}
+
+	err = batch.Commit()
+	if err != nil {
+		log.Error(err, "failed to commit transaction", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to create transaction"))
+
+		return
 	}
 
 	response.Success(w, nil)
@@ -263,7 +447,36 @@ func updateTransactionNote(w http.ResponseWriter, r *http.Request) {
 			}
 		},
 		func(record any) error {
-			return mysql.UpdateTransactionNote(record.(schema.Transaction))
+			return store.Transactions.UpdateNote(record.(schema.Transaction))
 		},
 	)
 }
+
+func patchTransactionNote(w http.ResponseWriter, r *http.Request) {
+	patchNote(w, r, "transaction", store.Transactions.Get,
+		func(transaction schema.Transaction) apischema.Shared {
+			return apischema.Shared{
+				UserID: int32(dbutils.GetAsInt(transaction.UpdatedBy)),
+				Note:   dbutils.GetString(transaction.Note),
+			}
+		},
+		func(transaction schema.Transaction, shared apischema.Shared) schema.Transaction {
+			transaction.Note = dbutils.SetString(shared.Note)
+			transaction.UpdatedBy = dbutils.SetInt(shared.UserID)
+
+			return transaction
+		},
+		store.Transactions.Patch,
+	)
+}
+
+// recordedQuantity returns the quantity in the unit of measurement the orderline
+// was recorded in. Orderlines recorded before the unit of measurement conversion
+// only have the base quantity.
+func recordedQuantity(orderline schema.Orderline) int {
+	if !orderline.UoMQuantity.Valid {
+		return orderline.Quantity
+	}
+
+	return int(orderline.UoMQuantity.Int32)
+}
@@ -4,14 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/rmarasigan/warehouse-inventory-management/api/response"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
-	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
 )
 
 func transactionCancelHandler(w http.ResponseWriter, r *http.Request) {
@@ -29,24 +27,17 @@ func cancelTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDParam, ok := requestutils.HasQueryParam(r, "user_id")
-	if !ok {
-		err := errors.New("missing 'user_id' from request query")
-		log.Error(err, "query parameter 'user_id' is required", log.KV("path", r.URL.Path))
+	userID, err := parameterUserID(r)
+	if err != nil {
 		response.BadRequest(w, response.NewError(err))
-
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDParam)
-	if err != nil {
-		log.Error(err, "failed to parse 'user_id' query parameter", log.KVs(log.Map{"id": userIDParam, "path": r.URL.Path}))
-		response.BadRequest(w, response.NewError(errors.New("invalid 'user_id' value; must be an integer")))
-
+	if !actor(w, r, "user_id", userID) {
 		return
 	}
 
-	transaction, err := mysql.GetTransactionByID(id)
+	transaction, err := store.Transactions.Get(id)
 	if err != nil {
 		log.Error(err, "failed to retrieve transaction", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
 		response.InternalServer(w, response.NewError(err, "failed to retrieve transaction "+fmt.Sprint(id)))
@@ -54,6 +45,16 @@ func cancelTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if transaction.ID == 0 {
+		response.NotFound(w, response.New("transaction not found", map[string]any{"id": id}))
+		return
+	}
+
+	if dbutils.GetBool(transaction.IsCancelled) {
+		response.Conflict(w, response.NewError(repository.ErrAlreadyCancelled, map[string]any{"id": id}))
+		return
+	}
+
 	type FailedOrderline struct {
 		TransactionID int              `json:"transaction_id"`
 		ItemID        int              `json:"item_id,omitempty"`
@@ -64,82 +65,168 @@ func cancelTransaction(w http.ResponseWriter, r *http.Request) {
 
 	var failed []FailedOrderline
 
-	for _, orderline := range transaction.Orderlines {
-		itemID := orderline.ItemID
-		orderlineID := orderline.ID
+	// The orderlines are reversed in a single database transaction along with
+	// the cancellation and its events. Each orderline is reversed on its own, so
+	// that all the ones that fail are reported, but the transaction is only
+	// cancelled if none of them does.
+	batch, err := store.Batches.Begin()
+	if err != nil {
+		log.Error(err, "failed to begin database transaction", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err, "failed to cancel transaction"))
 
-		// Fetch the item information.
-		item, err := mysql.GetItemByID(itemID)
-		if err != nil {
-			log.Error(err, "failed to fetch item id",
-				log.KVs(log.Map{
-					"path":           r.URL.Path,
-					"item_id":        itemID,
-					"transaction_id": transaction.ID,
-				}),
-			)
+		return
+	}
 
-			failed = append(failed, FailedOrderline{
-				TransactionID: id,
-				ItemID:        itemID,
-				OrderlineID:   orderlineID,
-				Reason:        "failed to fetch item: " + err.Error(),
-			})
+	// Rollback is a no-op once the batch is committed.
+	defer rollbackBatch(batch)
+
+	// The transaction is marked cancelled first, so that a concurrent request
+	// that cancels it as well waits for this one and then finds it cancelled,
+	// rather than reversing its stock movements a second time.
+	transaction.IsCancelled = dbutils.SetBool(true)
+	transaction.UpdatedBy = dbutils.SetInt(int32(userID))
 
-			continue
+	err = batch.CancelTransaction(transaction)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyCancelled) {
+			response.Conflict(w, response.NewError(err, map[string]any{"id": id}))
+			return
 		}
 
-		// Update the item quantity based on the transaction type.
-		item.UpdateCancelledQuantity(transaction.Type, orderline.Quantity)
-		err = mysql.UpdateItem(item)
-		if err != nil {
-			log.Error(err, "failed to update item quantity",
-				log.KVs(log.Map{
-					"path":           r.URL.Path,
-					"item_id":        item.ID,
-					"transaction_id": transaction.ID,
-				}),
-			)
+		log.Error(err, "failed to cancel transaction", log.KVs(log.Map{"path": r.URL.Path, "transaction": transaction.ID}))
+		response.InternalServer(w, response.NewError(err, "failed to cancel transaction"))
 
-			failed = append(failed, FailedOrderline{
-				TransactionID: id,
-				ItemID:        itemID,
-				OrderlineID:   orderlineID,
-				Reason:        "failed to update item quantity: " + err.Error(),
+		return
+	}
+
+	for _, orderline := range transaction.Orderlines {
+		itemID := orderline.ItemID
+		orderlineID := orderline.ID
+
+		var reason string
+
+		err := batch.Record(func() error {
+			// Fetch the item information and lock it until the cancellation is recorded.
+			item, err := batch.ItemForUpdate(itemID)
+			if err != nil {
+				log.Error(err, "failed to fetch item id",
+					log.KVs(log.Map{
+						"path":           r.URL.Path,
+						"item_id":        itemID,
+						"transaction_id": transaction.ID,
+					}),
+				)
+
+				reason = "failed to fetch item: "
+				return err
+			}
+
+			// Update the item quantity based on the transaction type.
+			previousQuantity := item.Quantity
+			item.UpdateCancelledQuantity(transaction.Type, orderline.Quantity)
+
+			// The units received by an inbound orderline cannot be returned
+			// once they were issued.
+			if item.Quantity < 0 {
+				reason = "not enough stock to reverse: "
+				return fmt.Errorf("item %d has %d on hand, fewer than the %d received", item.ID, previousQuantity, orderline.Quantity)
+			}
+
+			err = batch.UpdateItemQuantity(item)
+			if err != nil {
+				log.Error(err, "failed to update item quantity",
+					log.KVs(log.Map{
+						"path":           r.URL.Path,
+						"item_id":        item.ID,
+						"transaction_id": transaction.ID,
+					}),
+				)
+
+				reason = "failed to update item quantity: "
+				return err
+			}
+
+			// Reverse the cost of the stock movement for the inventory valuation.
+			err = reverseValuation(batch, transaction.Type, orderline, item)
+			if err != nil {
+				log.Error(err, "failed to reverse inventory valuation",
+					log.KVs(log.Map{
+						"path":           r.URL.Path,
+						"item_id":        item.ID,
+						"transaction_id": transaction.ID,
+						"orderline_id":   orderline.ID,
+					}),
+				)
+
+				reason = "failed to reverse inventory valuation: "
+				return err
+			}
+
+			orderline.IsVoided = dbutils.SetBool(true)
+			orderline.UpdatedBy = dbutils.SetInt(int32(userID))
+
+			err = batch.CancelOrderline(orderline)
+			if err != nil {
+				log.Error(err, "failed to cancel orderline", log.KVs(
+					log.Map{
+						"path":           r.URL.Path,
+						"item_id":        item.ID,
+						"transaction_id": transaction.ID,
+						"orderline_id":   orderline.ID,
+					}),
+				)
+
+				reason = "failed to cancel orderline: "
+				return err
+			}
+
+			err = emitStockChange(batch, stockChange{
+				ItemID:           item.ID,
+				StorageID:        item.StorageID,
+				TransactionID:    transaction.ID,
+				PreviousQuantity: previousQuantity,
+				Quantity:         item.Quantity,
 			})
 
-			continue
-		}
+			if err != nil {
+				log.Error(err, "failed to record stock change event", log.KVs(
+					log.Map{
+						"path":           r.URL.Path,
+						"item_id":        item.ID,
+						"transaction_id": transaction.ID,
+						"orderline_id":   orderline.ID,
+					}),
+				)
 
-		orderline.IsVoided = dbutils.SetBool(true)
-		orderline.UpdatedBy = dbutils.SetInt(int32(userID))
+				reason = "failed to record stock change event: "
+			}
 
-		err = mysql.CancelOrderline(orderline)
-		if err != nil {
-			log.Error(err, "failed to cancel orderline", log.KVs(
-				log.Map{
-					"path":           r.URL.Path,
-					"item_id":        item.ID,
-					"transaction_id": transaction.ID,
-					"orderline_id":   orderline.ID,
-				}),
-			)
+			return err
+		})
 
+		if err != nil {
 			failed = append(failed, FailedOrderline{
 				TransactionID: id,
 				ItemID:        itemID,
 				OrderlineID:   orderlineID,
-				Reason:        "failed to cancel orderline: " + err.Error(),
+				Reason:        reason + err.Error(),
 			})
-
-			continue
 		}
 	}
 
-	transaction.IsCancelled = dbutils.SetBool(true)
-	transaction.UpdatedBy = dbutils.SetInt(int32(userID))
+	if len(failed) > 0 {
+		err := errors.New("the transaction was not cancelled")
+		log.Error(err, "failed to reverse orderlines", log.KV("errors", failed))
+		response.UnprocessableEntity(w, response.NewError(err, map[string]any{"failed": failed}))
+
+		return
+	}
+
+	err = emitEvent(batch, schema.EventTransactionCancelled, 0, 0, transactionEvent(transaction))
+	if err == nil {
+		err = batch.Commit()
+	}
 
-	err = mysql.CancelTransaction(transaction)
 	if err != nil {
 		log.Error(err, "failed to cancel transaction", log.KVs(
 			log.Map{"path": r.URL.Path, "transaction": transaction.ID}))
@@ -155,14 +242,6 @@ func cancelTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(failed) > 0 {
-		err := errors.New("orderlines update not fully successful")
-		log.Error(err, "failed to update orderlines", log.KV("errors", failed))
-		response.MultiStatus(w, response.NewError(err, map[string]any{"failed": failed}))
-
-		return
-	}
-
 	response.Success(w, response.New("successfully updated",
 		map[string]any{
 			"transaction_id": transaction.ID,
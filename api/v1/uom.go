@@ -1,13 +1,15 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/rmarasigan/warehouse-inventory-management/api/response"
 	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/api/schema/validator"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
@@ -23,7 +25,19 @@ func uomHandler(w http.ResponseWriter, r *http.Request) {
 		createUOM(w, r)
 
 	case http.MethodPut:
-		updateUOM(w, r)
+		switch {
+		case strings.HasSuffix(r.URL.Path, archiveUOM):
+			archiveResource(w, r, uomArchival())
+
+		case strings.HasSuffix(r.URL.Path, restoreUOM):
+			restoreResource(w, r, uomArchival())
+
+		default:
+			updateUOM(w, r)
+		}
+
+	case http.MethodPatch:
+		patchUOM(w, r)
 
 	case http.MethodDelete:
 		deleteUOM(w, r)
@@ -33,7 +47,18 @@ func uomHandler(w http.ResponseWriter, r *http.Request) {
 func getUOMs(w http.ResponseWriter, r *http.Request) {
 	defer log.Panic()
 
-	list, err := getList(r, mysql.GetUOMByID, mysql.ListUOM)
+	archived, err := parameterArchived(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	listUOMs := store.UOMs.List
+	if archived {
+		listUOMs = store.UOMs.Archived
+	}
+
+	list, err := getList(r, store.UOMs.Get, listUOMs)
 	if err != nil {
 		log.Error(err, "failed to retrieve uoms", log.KV("path", r.URL.Path))
 		response.InternalServer(w, response.NewError(err, "failed to retrieve uoms"))
@@ -41,15 +66,20 @@ func getUOMs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	uoms := convert.SchemaList(list, func(uom schema.UOM) apischema.UOM {
-		return apischema.UOM{
-			ID:   uom.ID,
-			Code: uom.Code,
-			Name: uom.Name,
-		}
-	})
+	uoms := convert.SchemaList(list, uomResponse)
+
+	listResponse(w, r, list, func(uom schema.UOM) int { return uom.Version }, uoms)
+}
 
-	response.Success(w, uoms)
+// uomResponse converts the unit of measurement record to its API
+// representation.
+func uomResponse(uom schema.UOM) apischema.UOM {
+	return apischema.UOM{
+		ID:      uom.ID,
+		Code:    uom.Code,
+		Name:    uom.Name,
+		Archive: archiveResponse(uom.ArchivedAt, uom.ArchivedBy),
+	}
 }
 
 func createUOM(w http.ResponseWriter, r *http.Request) {
@@ -78,31 +108,31 @@ func createUOM(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
 	uoms := convert.SchemaList(data, func(uom apischema.UOM) schema.UOM {
 		return schema.UOM{
-			ID:   uom.ID,
 			Code: uom.Code,
 			Name: uom.Name,
 		}
 	})
 
-	for _, uom := range uoms {
-		_, err = mysql.NewUOMIfNotExists(uom)
-		if err != nil {
-			log.Error(err, "failed to create new uom", log.KVs(log.Map{"uom": uom, "path": r.URL.Path}))
-			response.InternalServer(w, response.NewError(err,
-				map[string]any{
-					"request": data,
-					"uom":     uom,
-					"message": "failed to create new uom",
-				}),
-			)
+	report, err := bulk(atomic, uoms, func(batch repository.Batch, uom schema.UOM) (string, int64, error) {
+		return insertedStatus(batch.UOM(uom))
+	})
 
-			return
-		}
+	if err != nil {
+		log.Error(err, "failed to create uoms", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to create uoms"))
+
+		return
 	}
 
-	response.Created(w, nil)
+	bulkResponse(w, report, response.Created)
 }
 
 func updateUOM(w http.ResponseWriter, r *http.Request) {
@@ -123,6 +153,12 @@ func updateUOM(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
 	uoms := convert.SchemaList(data, func(uom apischema.UOM) schema.UOM {
 		return schema.UOM{
 			ID:   uom.ID,
@@ -131,23 +167,76 @@ func updateUOM(w http.ResponseWriter, r *http.Request) {
 		}
 	})
 
-	for _, uom := range uoms {
-		err = mysql.UpdateUOM(uom)
+	version, ok := singlePrecondition(w, r, uoms, func(uom schema.UOM) int { return uom.ID })
+	if !ok {
+		return
+	}
+
+	// changed is set if the unit of measurement of the 'If-Match' header was changed since.
+	var changed error
+
+	report, err := bulk(atomic, uoms, func(batch repository.Batch, uom schema.UOM) (string, int64, error) {
+		existing, err := store.UOMs.Get(uom.ID)
 		if err != nil {
-			log.Error(err, "failed to update uom", log.KVs(log.Map{"request": data, "uom": uom, "path": r.URL.Path}))
-			response.InternalServer(w, response.NewError(err,
-				map[string]any{
-					"request": data,
-					"uom":     uom,
-					"message": "failed to update uom",
-				}),
-			)
+			return "", 0, err
+		}
 
-			return
+		if version != 0 && existing.Version != version {
+			changed = repository.ErrVersionMismatch
+			return "", 0, changed
 		}
+
+		if existing.ID == 0 {
+			return "", 0, fmt.Errorf("unit of measurement %d does not exist", uom.ID)
+		}
+
+		uom.Version = version
+
+		err = batch.UpdateUOM(uom)
+		if err != nil {
+			if errors.Is(err, repository.ErrVersionMismatch) {
+				changed = err
+			}
+
+			return "", 0, err
+		}
+
+		return apischema.RowUpdated, int64(uom.ID), nil
+	})
+
+	if err != nil {
+		log.Error(err, "failed to update uoms", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to update uoms"))
+
+		return
 	}
 
-	response.Success(w, nil)
+	if versionMismatch(w, r, uoms[0].ID, changed) {
+		return
+	}
+
+	bulkResponse(w, report, response.Success)
+}
+
+// patchUOM handles the HTTP request to change a unit of measurement with a JSON
+// Merge Patch.
+func patchUOM(w http.ResponseWriter, r *http.Request) {
+	patchResource(w, r, patch[schema.UOM, apischema.UOM]{
+		name:     "uom",
+		columns:  map[string]string{"code": "code", "name": "name"},
+		notEmpty: []string{"code", "name"},
+		get:      store.UOMs.Get,
+		exists:   func(uom schema.UOM) bool { return uom.ID != 0 },
+		view:     uomResponse,
+		record: func(existing schema.UOM, uom apischema.UOM) (schema.UOM, error) {
+			existing.Code = uom.Code
+			existing.Name = uom.Name
+
+			return existing, nil
+		},
+		save:    store.UOMs.Patch,
+		version: func(uom *schema.UOM) *int { return &uom.Version },
+	})
 }
 
 func deleteUOM(w http.ResponseWriter, r *http.Request) {
@@ -159,8 +248,17 @@ func deleteUOM(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	affected, err := mysql.DeleteUOM(id)
+	version, ok := precondition(w, r, id)
+	if !ok {
+		return
+	}
+
+	affected, err := store.UOMs.Delete(id, version)
 	if err != nil {
+		if versionMismatch(w, r, id, err) || deleteConflict(w, r, id, err) {
+			return
+		}
+
 		log.Error(err, "failed to delete uom", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
 		response.InternalServer(w, response.NewError(err, "failed to delete uom"))
 
@@ -0,0 +1,345 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/api/schema/validator"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+func uomConversionHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		getUOMConversions(w, r)
+
+	case http.MethodPost:
+		createUOMConversion(w, r)
+
+	case http.MethodPut:
+		updateUOMConversion(w, r)
+
+	case http.MethodPatch:
+		patchUOMConversion(w, r)
+
+	case http.MethodDelete:
+		deleteUOMConversion(w, r)
+	}
+}
+
+// getUOMConversions handles the HTTP request to retrieve the unit of measurement
+// conversions of an item ('item_id') or a specific conversion ('id').
+func getUOMConversions(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	_, hasID := requestutils.HasQueryParam(r, "id")
+	itemIDParam, hasItemID := requestutils.HasQueryParam(r, "item_id")
+
+	if !hasID && !hasItemID {
+		err := errors.New("missing 'id' or 'item_id' in the request query parameter")
+		log.Error(err, "query parameter 'id' or 'item_id' is required", log.KV("path", r.URL.Path))
+		response.BadRequest(w, response.NewError(err))
+
+		return
+	}
+
+	itemID, err := strconv.Atoi(itemIDParam)
+	if hasItemID && err != nil {
+		log.Error(err, "failed to parse 'item_id' query parameter",
+			log.KVs(log.Map{"item_id": itemIDParam, "path": r.URL.Path}))
+
+		response.BadRequest(w, response.NewError(errors.New("invalid 'item_id' value; must be an integer")))
+
+		return
+	}
+
+	list, err := getList(r, store.Conversions.Get,
+		func() ([]schema.UOMConversion, error) { return store.Conversions.List(itemID) })
+	if err != nil {
+		log.Error(err, "failed to retrieve uom conversions", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve uom conversions"))
+
+		return
+	}
+
+	conversions := convert.SchemaList(list, conversionResponse)
+
+	response.Success(w, conversions)
+}
+
+// conversionResponse converts the unit of measurement conversion record to its
+// API representation.
+func conversionResponse(conversion schema.UOMConversion) apischema.UOMConversion {
+	return apischema.UOMConversion{
+		ID:     conversion.ID,
+		ItemID: conversion.ItemID,
+		UoMID:  conversion.UoMID,
+		Factor: conversion.Factor,
+	}
+}
+
+// createUOMConversion handles the HTTP request to define how many of the item's
+// base unit of measurement are contained in another unit of measurement. The
+// factor may be given relative to another convertible unit of measurement of
+// the item ('contains_uom_id') to describe packaging hierarchies, e.g. 1 CS
+// contains 12 BX.
+func createUOMConversion(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+		log.Panic()
+	}()
+
+	body, err := requestutils.ReadBody(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	validationErrors, err := requestutils.ValidateRequest(body, validator.ValidateUOMConversion)
+	if err != nil && len(validationErrors) > 0 {
+		log.Error(err, validationErrors, log.KVs(log.Map{"request": string(body), "path": r.URL.Path}))
+		response.BadRequest(w, response.NewError(err, validationErrors))
+
+		return
+	}
+
+	data, err := requestutils.Unmarshal(r.URL.Path, body, apischema.NewUOMConversion)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err, "failed to unmarshal request body"))
+		return
+	}
+
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	report, err := bulk(atomic, data, func(batch repository.Batch, conversion apischema.UOMConversion) (string, int64, error) {
+		record, err := baseConversion(conversion)
+		if err != nil {
+			return "", 0, err
+		}
+
+		return insertedStatus(batch.UOMConversion(record))
+	})
+
+	if err != nil {
+		log.Error(err, "failed to create uom conversions", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to create uom conversions"))
+
+		return
+	}
+
+	bulkResponse(w, report, response.Created)
+}
+
+// updateUOMConversion handles the HTTP request to change the factor of existing
+// unit of measurement conversions. The item and the unit of measurement of a
+// conversion cannot be changed.
+func updateUOMConversion(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+		log.Panic()
+	}()
+
+	body, err := requestutils.ReadBody(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	data, err := requestutils.Unmarshal(r.URL.Path, body, apischema.NewUOMConversion)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err, "failed to unmarshal request body"))
+		return
+	}
+
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	report, err := bulk(atomic, data, func(batch repository.Batch, conversion apischema.UOMConversion) (string, int64, error) {
+		existing, err := store.Conversions.Get(conversion.ID)
+		if err != nil {
+			return "", 0, err
+		}
+
+		if existing.ID == 0 {
+			return "", 0, fmt.Errorf("uom conversion %d does not exist", conversion.ID)
+		}
+
+		conversion.ItemID = existing.ItemID
+		conversion.UoMID = existing.UoMID
+
+		record, err := baseConversion(conversion)
+		if err != nil {
+			return "", 0, err
+		}
+
+		record.ID = existing.ID
+
+		err = batch.UpdateUOMConversion(record)
+		if err != nil {
+			return "", 0, err
+		}
+
+		return apischema.RowUpdated, int64(record.ID), nil
+	})
+
+	if err != nil {
+		log.Error(err, "failed to update uom conversions", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to update uom conversions"))
+
+		return
+	}
+
+	bulkResponse(w, report, response.Success)
+}
+
+// patchUOMConversion handles the HTTP request to change the factor of a unit of
+// measurement conversion with a JSON Merge Patch. The factor is relative to the
+// item's base unit of measurement.
+func patchUOMConversion(w http.ResponseWriter, r *http.Request) {
+	patchResource(w, r, patch[schema.UOMConversion, apischema.UOMConversion]{
+		name:    "uom conversion",
+		columns: map[string]string{"factor": "factor"},
+		notNull: []string{"factor"},
+		get:     store.Conversions.Get,
+		exists:  func(conversion schema.UOMConversion) bool { return conversion.ID != 0 },
+		view:    conversionResponse,
+		record: func(existing schema.UOMConversion, conversion apischema.UOMConversion) (schema.UOMConversion, error) {
+			record, err := baseConversion(conversion)
+			if err != nil {
+				return existing, fmt.Errorf("%w: %w", errInvalidPatch, err)
+			}
+
+			record.ID = existing.ID
+
+			return record, nil
+		},
+		save: store.Conversions.Patch,
+	})
+}
+
+func deleteUOMConversion(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	id, err := parameterID(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	affected, err := store.Conversions.Delete(id)
+	if err != nil {
+		log.Error(err, "failed to delete uom conversion", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to delete uom conversion"))
+
+		return
+	}
+
+	response.Success(w, response.New(fmt.Sprintf("%d row(s) affected", affected)))
+}
+
+// baseConversion validates the conversion against the item and resolves its
+// factor to the item's base unit of measurement.
+func baseConversion(conversion apischema.UOMConversion) (schema.UOMConversion, error) {
+	if conversion.Factor <= 0 {
+		return schema.UOMConversion{}, errors.New("factor must be greater than zero")
+	}
+
+	item, err := store.Items.Get(conversion.ItemID)
+	if err != nil {
+		return schema.UOMConversion{}, err
+	}
+
+	if item.ID == 0 {
+		return schema.UOMConversion{}, fmt.Errorf("item %d does not exist", conversion.ItemID)
+	}
+
+	if conversion.UoMID == item.UoMID {
+		return schema.UOMConversion{}, errors.New("the item's base unit of measurement cannot be converted")
+	}
+
+	uom, err := store.UOMs.Get(conversion.UoMID)
+	if err != nil {
+		return schema.UOMConversion{}, err
+	}
+
+	if uom.ID == 0 {
+		return schema.UOMConversion{}, fmt.Errorf("unit of measurement %d does not exist", conversion.UoMID)
+	}
+
+	factor := conversion.Factor
+
+	// Resolve a factor that is relative to another unit of measurement of the
+	// item (e.g. 1 CS = 12 BX and 1 BX = 12 EA, then 1 CS = 144 EA).
+	if conversion.ContainsUoMID != 0 && conversion.ContainsUoMID != item.UoMID {
+		contained, err := store.UOMs.Conversion(item.ID, conversion.ContainsUoMID)
+		if err != nil {
+			return schema.UOMConversion{}, err
+		}
+
+		if contained.ID == 0 {
+			return schema.UOMConversion{}, fmt.Errorf("unit of measurement %d: %w", conversion.ContainsUoMID, schema.ErrUOMNotConvertible)
+		}
+
+		factor *= contained.Factor
+	}
+
+	return schema.UOMConversion{
+		ItemID: item.ID,
+		UoMID:  conversion.UoMID,
+		Factor: factor,
+	}, nil
+}
+
+// toBaseUOM converts the quantity of the orderline from the unit of measurement
+// it was recorded in to the item's base unit of measurement. The stock is always
+// kept in the base unit of measurement.
+func toBaseUOM(orderline schema.Orderline) (schema.Orderline, error) {
+	item, err := store.Items.Get(orderline.ItemID)
+	if err != nil {
+		return orderline, err
+	}
+
+	if item.ID == 0 {
+		return orderline, fmt.Errorf("item %d does not exist", orderline.ItemID)
+	}
+
+	uomQuantity := orderline.Quantity
+	orderline.UoMQuantity = dbutils.SetInt(int32(uomQuantity))
+
+	if !orderline.UoMID.Valid || int(orderline.UoMID.Int32) == item.UoMID {
+		orderline.UoMID = dbutils.SetInt(int32(item.UoMID))
+		return orderline, nil
+	}
+
+	conversion, err := store.UOMs.Conversion(item.ID, int(orderline.UoMID.Int32))
+	if err != nil {
+		return orderline, err
+	}
+
+	if conversion.ID == 0 {
+		return orderline, fmt.Errorf("unit of measurement %d of item %d: %w", orderline.UoMID.Int32, item.ID, schema.ErrUOMNotConvertible)
+	}
+
+	orderline.Quantity, err = conversion.ToBase(uomQuantity)
+	if err != nil {
+		return orderline, fmt.Errorf("%d of unit of measurement %d of item %d: %w", uomQuantity, orderline.UoMID.Int32, item.ID, err)
+	}
+
+	return orderline, nil
+}
@@ -1,17 +1,20 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/rmarasigan/warehouse-inventory-management/api/response"
 	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/api/schema/validator"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
 	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/password"
 	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
 )
 
@@ -31,45 +34,83 @@ func userHandler(w http.ResponseWriter, r *http.Request) {
 			updateUser(w, r)
 		}
 
+	case http.MethodPatch:
+		patchUser(w, r)
+
 	case http.MethodDelete:
 		deleteUser(w, r)
 	}
 }
 
 // getUsers handles the HTTP request to retrieve a list of users. It writes
-// the list of users to the HTTP response with an HTTP OK status. If an error
-// occurs, it writes an HTTP Internal Server Error status.
+// the list of users to the HTTP response with an HTTP OK status. With the
+// 'status' query parameter, only the active or inactive users are listed. If an
+// error occurs, it writes an HTTP Internal Server Error status.
 func getUsers(w http.ResponseWriter, r *http.Request) {
 	defer log.Panic()
 
-	list, err := getList(r, mysql.GetUserByID, mysql.ListUser)
+	listUsers, err := parameterStatus(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	list, err := getList(r, store.Users.Get, listUsers)
 	if err != nil {
 		log.Error(err, "failed to retrieve users", log.KV("path", r.URL.Path))
 		response.InternalServer(w, response.NewError(err, "failed to retrieve users"))
+
+		return
 	}
 
-	users := convert.SchemaList(list, func(user schema.User) apischema.User {
-		return apischema.User{
-			ID:           user.ID,
-			RoleID:       user.RoleID,
-			FirstName:    user.FirstName,
-			LastName:     user.LastName,
-			Email:        dbutils.GetString(user.Email),
-			LastLogin:    dbutils.GetString(user.LastLogin),
-			Active:       user.Active,
-			DateCreated:  user.DateCreated,
-			DateModified: dbutils.GetTime(user.DateModified),
-		}
-	})
+	users := convert.SchemaList(list, userResponse)
+
+	listResponse(w, r, list, func(user schema.User) int { return user.Version }, users)
+}
+
+// parameterStatus returns the list of the users of the 'status' query parameter,
+// either 'active' or 'inactive', or of all the users if it is not given.
+func parameterStatus(r *http.Request) (func() ([]schema.User, error), error) {
+	status, ok := requestutils.HasQueryParam(r, "status")
+	if !ok {
+		return store.Users.List, nil
+	}
+
+	switch status {
+	case "active":
+		return func() ([]schema.User, error) { return store.Users.ListByActive(true) }, nil
+
+	case "inactive":
+		return func() ([]schema.User, error) { return store.Users.ListByActive(false) }, nil
+	}
+
+	err := fmt.Errorf("invalid 'status' value '%s'; must be 'active' or 'inactive'", status)
+	log.Error(err, "failed to parse 'status' query parameter", log.KV("path", r.URL.Path))
+
+	return nil, err
+}
 
-	response.Success(w, users)
+// userResponse converts the user record to its API representation.
+func userResponse(user schema.User) apischema.User {
+	return apischema.User{
+		ID:            user.ID,
+		RoleID:        user.RoleID,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Email:         dbutils.GetString(user.Email),
+		EmailVerified: user.EmailVerified(),
+		LastLogin:     dbutils.GetString(user.LastLogin),
+		Active:        user.Active,
+		DateCreated:   user.DateCreated,
+		DateModified:  dbutils.GetTime(user.DateModified),
+	}
 }
 
-// createUser handles the HTTP request to create a new user. It validates
-// the request body, unmarshals it into a user object, checks if the user
-// already exists in the database, and inserts it if not. If the request
-// body is invalid or the user already exists, it writes an appropriate
-// HTTP status response.
+// createUser handles the HTTP request to create new users. It validates
+// the request body, unmarshals it into a list of users, and inserts each
+// user that does not exist yet. It writes the status of each user: created,
+// skipped if the user already exists, or the error if it failed. With
+// 'atomic=true' no user is created unless all of them can be.
 func createUser(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		_ = r.Body.Close()
@@ -96,6 +137,12 @@ func createUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	atomic, err := parameterAtomic(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
 	users := convert.SchemaList(data, func(user apischema.User) schema.User {
 		return schema.User{
 			RoleID:    user.RoleID,
@@ -107,40 +154,40 @@ func createUser(w http.ResponseWriter, r *http.Request) {
 		}
 	})
 
-	for _, user := range users {
-		existing, err := mysql.UserExists(user)
-		if err != nil {
-			log.Error(err, "failed to validate if user exists",
-				log.KVs(log.Map{"request": string(body), "user": user, "path": r.URL.Path}))
+	// The passwords are checked and hashed before the batch begins, so that the
+	// slow hashing does not hold its database transaction open. A user whose
+	// password is invalid fails its row.
+	type newUser struct {
+		user schema.User
+		err  error
+	}
 
-			response.InternalServer(w, response.NewError(err,
-				map[string]any{
-					"user":    user,
-					"message": "failed to validate if user exists",
-				}),
-			)
+	policy := config.Get().PasswordPolicy()
+	records := make([]newUser, len(users))
 
-			return
+	for i, user := range users {
+		records[i] = newUser{user: user, err: policy.Check(user.Password)}
+		if records[i].err == nil {
+			records[i].user.Password, records[i].err = password.Hash(user.Password)
 		}
+	}
 
-		if !existing {
-			_, err = mysql.NewUser(user)
-			if err != nil {
-				log.Error(err, "failed to create new user", log.KVs(log.Map{"user": user, "path": r.URL.Path}))
-				response.InternalServer(w, response.NewError(err,
-					map[string]any{
-						"request": data,
-						"user":    user,
-						"message": "failed to create new user",
-					}),
-				)
-
-				return
-			}
+	report, err := bulk(atomic, records, func(batch repository.Batch, record newUser) (string, int64, error) {
+		if record.err != nil {
+			return "", 0, record.err
 		}
+
+		return insertedStatus(batch.User(record.user))
+	})
+
+	if err != nil {
+		log.Error(err, "failed to create users", log.KVs(log.Map{"request": data, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to create users"))
+
+		return
 	}
 
-	response.Created(w, nil)
+	bulkResponse(w, report, response.Created)
 }
 
 // updateUser handles the HTTP request to update/modify user information.
@@ -175,9 +222,42 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 		}
 	})
 
+	// An empty password is left unchanged, so only a new one is checked and
+	// hashed.
+	for i, user := range users {
+		if user.Password == "" {
+			continue
+		}
+
+		err := config.Get().PasswordPolicy().Check(user.Password)
+		if err != nil {
+			response.BadRequest(w, response.NewError(err, map[string]any{"id": user.ID}))
+			return
+		}
+
+		users[i].Password, err = password.Hash(user.Password)
+		if err != nil {
+			log.Error(err, "failed to hash password", log.KVs(log.Map{"id": user.ID, "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to hash password"))
+
+			return
+		}
+	}
+
+	version, ok := singlePrecondition(w, r, users, func(user schema.User) int { return user.ID })
+	if !ok {
+		return
+	}
+
 	for _, user := range users {
-		err = mysql.UpdateUser(user)
+		user.Version = version
+
+		err = store.Users.Update(user)
 		if err != nil {
+			if versionMismatch(w, r, user.ID, err) {
+				return
+			}
+
 			log.Error(err, "failed to update user", log.KVs(log.Map{"request": data, "user": user, "path": r.URL.Path}))
 			response.InternalServer(w, response.NewError(err,
 				map[string]any{
@@ -194,6 +274,60 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, nil)
 }
 
+// patchUser handles the HTTP request to change a user with a JSON Merge Patch.
+// Unlike updateUser, a null email clears it. The password is never written in
+// the response.
+func patchUser(w http.ResponseWriter, r *http.Request) {
+	patchResource(w, r, patch[schema.User, apischema.User]{
+		name: "user",
+		columns: map[string]string{
+			"role_id":    "role_id",
+			"first_name": "first_name",
+			"last_name":  "last_name",
+			"email":      "email",
+			"password":   "password",
+		},
+		notNull:  []string{"role_id"},
+		notEmpty: []string{"first_name", "last_name", "password"},
+		get:      store.Users.Get,
+		exists:   func(user schema.User) bool { return user.ID != 0 },
+		view:     userResponse,
+		record: func(existing schema.User, user apischema.User) (schema.User, error) {
+			// The representation has no password, so it is empty unless it is
+			// patched.
+			if user.Password == "" {
+				user.Password = existing.Password
+
+			} else {
+				err := config.Get().PasswordPolicy().Check(user.Password)
+				if err != nil {
+					return existing, invalid("%s", err)
+				}
+
+				user.Password, err = password.Hash(user.Password)
+				if err != nil {
+					return existing, err
+				}
+			}
+
+			err := referenced("role_id", user.RoleID, store.Roles.Get, func(role schema.Role) bool { return role.ID != 0 })
+			if err != nil {
+				return existing, err
+			}
+
+			existing.RoleID = user.RoleID
+			existing.FirstName = user.FirstName
+			existing.LastName = user.LastName
+			existing.Email = dbutils.SetString(user.Email)
+			existing.Password = user.Password
+
+			return existing, nil
+		},
+		save:    store.Users.Patch,
+		version: func(user *schema.User) *int { return &user.Version },
+	})
+}
+
 func activateUserAccount(w http.ResponseWriter, r *http.Request) {
 	defer log.Panic()
 
@@ -203,7 +337,7 @@ func activateUserAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = mysql.ActivateUser(id)
+	err = store.Users.Activate(id)
 	if err != nil {
 		log.Error(err, "failed to activate user account", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
 		response.InternalServer(w, response.NewError(err, "failed to activate user account"))
@@ -225,8 +359,17 @@ func deleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = mysql.DeleteUser(id)
+	version, ok := precondition(w, r, id)
+	if !ok {
+		return
+	}
+
+	err = store.Users.Deactivate(id, version)
 	if err != nil {
+		if versionMismatch(w, r, id, err) {
+			return
+		}
+
 		log.Error(err, "failed to delete user", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
 		response.InternalServer(w, response.NewError(err, "failed to delete user"))
 
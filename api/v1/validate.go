@@ -10,32 +10,89 @@ import (
 const (
 	users             string = "users"
 	activateUser      string = users + "/activate"
+	inviteUser        string = users + "/invite"
+	acceptInvite      string = inviteUser + "/accept"
+	verifyEmail       string = users + "/verify-email"
+	confirmEmail      string = verifyEmail + "/confirm"
+	forgotPassword    string = users + "/forgot-password"
+	resetPassword     string = users + "/reset-password"
 	roles             string = "roles"
 	storages          string = "storages"
+	archiveStorage    string = storages + "/archive"
+	restoreStorage    string = storages + "/restore"
 	uoms              string = "uoms"
+	archiveUOM        string = uoms + "/archive"
+	restoreUOM        string = uoms + "/restore"
 	currencies        string = "currencies"
 	activateCurrency  string = currencies + "/activate"
 	items             string = "items"
+	archiveItem       string = items + "/archive"
+	restoreItem       string = items + "/restore"
+	uomConversions    string = items + "/conversions"
+	itemBarcodes      string = items + "/barcodes"
+	itemLookup        string = items + "/lookup"
 	transaction       string = "transactions"
 	transactionNote   string = transaction + "/note"
 	orderlinesNote    string = transaction + "/orderline-note"
 	transactionCancel string = transaction + "/cancel"
+	reports           string = "reports"
+	valuationReport   string = reports + "/valuation"
+	itemsImport       string = "import/items"
+	storagesImport    string = "import/storages"
+	uomsImport        string = "import/uoms"
+	stockImport       string = "import/stock"
+	itemsExport       string = "export/items"
+	storagesExport    string = "export/storages"
+	uomsExport        string = "export/uoms"
+	stockExport       string = "export/stock"
+	webhooks          string = "webhooks"
+	webhookDeliveries string = webhooks + "/deliveries"
+	deliveryRetry     string = webhookDeliveries + "/retry"
+	eventStream       string = "events/stream"
 )
 
 func isValidPathMethod(method, segment string) bool {
 	var valid = map[string][]string{
-		users:             {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		users:             {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
 		activateUser:      {http.MethodPut},
-		roles:             {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
-		storages:          {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
-		uoms:              {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		inviteUser:        {http.MethodPost},
+		acceptInvite:      {http.MethodPost},
+		verifyEmail:       {http.MethodPost},
+		confirmEmail:      {http.MethodPost},
+		forgotPassword:    {http.MethodPost},
+		resetPassword:     {http.MethodPost},
+		roles:             {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		storages:          {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		archiveStorage:    {http.MethodPut},
+		restoreStorage:    {http.MethodPut},
+		uoms:              {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		archiveUOM:        {http.MethodPut},
+		restoreUOM:        {http.MethodPut},
 		currencies:        {http.MethodGet},
 		activateCurrency:  {http.MethodPut},
-		items:             {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		items:             {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		archiveItem:       {http.MethodPut},
+		restoreItem:       {http.MethodPut},
+		uomConversions:    {http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		itemBarcodes:      {http.MethodGet, http.MethodPost, http.MethodDelete},
+		itemLookup:        {http.MethodGet},
 		transaction:       {http.MethodGet, http.MethodPost},
-		transactionNote:   {http.MethodPut},
-		orderlinesNote:    {http.MethodPut},
+		transactionNote:   {http.MethodPut, http.MethodPatch},
+		orderlinesNote:    {http.MethodPut, http.MethodPatch},
 		transactionCancel: {http.MethodPut},
+		valuationReport:   {http.MethodGet},
+		itemsImport:       {http.MethodPost},
+		storagesImport:    {http.MethodPost},
+		uomsImport:        {http.MethodPost},
+		stockImport:       {http.MethodPost},
+		itemsExport:       {http.MethodGet},
+		storagesExport:    {http.MethodGet},
+		uomsExport:        {http.MethodGet},
+		stockExport:       {http.MethodGet},
+		webhooks:          {http.MethodGet, http.MethodPost, http.MethodDelete},
+		webhookDeliveries: {http.MethodGet},
+		deliveryRetry:     {http.MethodPut},
+		eventStream:       {http.MethodGet},
 	}
 
 	methods, exist := valid[segment]
@@ -0,0 +1,229 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+// uncategorized groups the items that do not have a category.
+const uncategorized string = "uncategorized"
+
+func valuationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		getValuation(w, r)
+	}
+}
+
+// getValuation handles the HTTP request to retrieve the value of the stock on
+// hand as of a specific date, defaulting to the current date. It writes the
+// totals per item, storage and category with an HTTP OK status.
+func getValuation(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	asOf := time.Now().UTC()
+	before := asOf.Add(time.Nanosecond)
+
+	asOfParam, ok := requestutils.HasQueryParam(r, "as_of")
+	if ok {
+		var err error
+
+		asOf, before, err = parseAsOf(asOfParam)
+		if err != nil {
+			log.Error(err, "failed to parse 'as_of' query parameter",
+				log.KVs(log.Map{"as_of": asOfParam, "path": r.URL.Path}))
+
+			response.BadRequest(w, response.NewError(errors.New("invalid 'as_of' value; must be a date (YYYY-MM-DD) or RFC3339 timestamp")))
+
+			return
+		}
+	}
+
+	list, err := store.Ledger.Valuation(before)
+	if err != nil {
+		log.Error(err, "failed to retrieve inventory valuation", log.KVs(log.Map{"as_of": asOf, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve inventory valuation"))
+
+		return
+	}
+
+	currency, err := store.Currencies.Active()
+	if err != nil {
+		log.Error(err, "failed to retrieve active currency", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve active currency"))
+
+		return
+	}
+
+	response.Success(w, summarizeValuation(list, asOf, valuationMethod(), currency.Code))
+}
+
+// summarizeValuation totals the stock on hand and its value per item, storage
+// and category.
+func summarizeValuation(list []schema.Valuation, asOf time.Time, method, currency string) apischema.Valuation {
+	var (
+		items      []apischema.ItemValuation
+		storages   []apischema.StorageValuation
+		categories []apischema.CategoryValuation
+
+		itemIndex     = make(map[int]int)
+		storageIndex  = make(map[int]int)
+		categoryIndex = make(map[string]int)
+	)
+
+	valuation := apischema.Valuation{
+		AsOf:     asOf,
+		Method:   method,
+		Currency: currency,
+	}
+
+	for _, row := range list {
+		value := schema.RoundAmount(row.Value)
+
+		valuation.Quantity += row.Quantity
+		valuation.Value += value
+
+		index, exists := itemIndex[row.ItemID]
+		if !exists {
+			index = len(items)
+			itemIndex[row.ItemID] = index
+
+			items = append(items, apischema.ItemValuation{
+				ItemID:   row.ItemID,
+				Name:     row.Name,
+				Category: dbutils.GetString(row.Category),
+			})
+		}
+
+		items[index].Quantity += row.Quantity
+		items[index].Value += value
+
+		index, exists = storageIndex[row.StorageID]
+		if !exists {
+			index = len(storages)
+			storageIndex[row.StorageID] = index
+
+			storages = append(storages, apischema.StorageValuation{StorageID: row.StorageID})
+		}
+
+		storages[index].Quantity += row.Quantity
+		storages[index].Value += value
+
+		category := dbutils.GetString(row.Category)
+		if category == "" {
+			category = uncategorized
+		}
+
+		index, exists = categoryIndex[category]
+		if !exists {
+			index = len(categories)
+			categoryIndex[category] = index
+
+			categories = append(categories, apischema.CategoryValuation{Category: category})
+		}
+
+		categories[index].Quantity += row.Quantity
+		categories[index].Value += value
+	}
+
+	for i := range items {
+		items[i].Value = schema.RoundAmount(items[i].Value)
+
+		if items[i].Quantity > 0 {
+			items[i].AverageCost = schema.RoundAmount(items[i].Value / float64(items[i].Quantity))
+		}
+	}
+
+	for i := range storages {
+		storages[i].Value = schema.RoundAmount(storages[i].Value)
+	}
+
+	for i := range categories {
+		categories[i].Value = schema.RoundAmount(categories[i].Value)
+	}
+
+	valuation.Value = schema.RoundAmount(valuation.Value)
+	valuation.Items = append([]apischema.ItemValuation{}, items...)
+	valuation.Storages = append([]apischema.StorageValuation{}, storages...)
+	valuation.Categories = append([]apischema.CategoryValuation{}, categories...)
+
+	return valuation
+}
+
+// parseAsOf parses either a date or an RFC3339 timestamp. It returns the time
+// of the valuation and the time before which the movements are included, so
+// that a date includes all the movements until the end of that day (UTC) and a
+// timestamp the ones until that instant.
+func parseAsOf(value string) (time.Time, time.Time, error) {
+	date, err := time.Parse(time.DateOnly, value)
+	if err == nil {
+		end := date.AddDate(0, 0, 1)
+		return end.Add(-time.Nanosecond), end, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return timestamp, timestamp.Add(time.Nanosecond), nil
+}
+
+// valuationMethod returns the configured inventory valuation method.
+func valuationMethod() string {
+	return config.Get().ValuationMethod()
+}
+
+// recordValuation records the cost of the stock movement of an orderline. An
+// inbound orderline adds a cost layer at its total amount per base unit, or at
+// the item's unit price if it has none, while an outbound orderline consumes
+// the cost layers.
+func recordValuation(batch repository.Batch, transactionType string, orderline schema.Orderline, item schema.Item) error {
+	switch transactionType {
+	case "inbound":
+		unitCost := item.UnitPrice
+
+		// The unit price is for the unit of measurement the orderline was
+		// recorded in, so the cost is taken per base unit.
+		totalAmount := dbutils.GetFloat(orderline.TotalAmount)
+		if totalAmount > 0 && orderline.Quantity > 0 {
+			unitCost = totalAmount / float64(orderline.Quantity)
+		}
+
+		return batch.ReceiveStock(schema.CostLayer{
+			ItemID:      item.ID,
+			OrderlineID: dbutils.SetInt(int32(orderline.ID)),
+			Quantity:    orderline.Quantity,
+			UnitCost:    unitCost,
+		}, item.StorageID)
+
+	case "outbound":
+		_, err := batch.IssueStock(valuationMethod(), orderline, item.StorageID)
+		return err
+	}
+
+	return nil
+}
+
+// reverseValuation reverses the cost of the stock movement of a cancelled
+// orderline.
+func reverseValuation(batch repository.Batch, transactionType string, orderline schema.Orderline, item schema.Item) error {
+	switch transactionType {
+	case "inbound":
+		return batch.ReverseReceipt(valuationMethod(), orderline, item.StorageID)
+
+	case "outbound":
+		return batch.ReverseIssue(orderline, item.StorageID)
+	}
+
+	return nil
+}
@@ -0,0 +1,301 @@
+package v1
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	apischema "github.com/rmarasigan/warehouse-inventory-management/api/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/api/schema/validator"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/convert"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	requestutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/request_utils"
+)
+
+// webhookSecretLength is the number of random bytes of a generated secret.
+const webhookSecretLength int = 32
+
+func webhookHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		getWebhooks(w, r)
+
+	case http.MethodPost:
+		createWebhook(w, r)
+
+	case http.MethodDelete:
+		deleteWebhook(w, r)
+	}
+}
+
+func deliveryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		getDeliveries(w, r)
+	}
+}
+
+func deliveryRetryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		retryDelivery(w, r)
+	}
+}
+
+// getWebhooks handles the HTTP request to retrieve the webhooks. The secrets
+// are never returned.
+func getWebhooks(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	list, err := getList(r, store.Webhooks.Get, store.Webhooks.List)
+	if err != nil {
+		log.Error(err, "failed to retrieve webhooks", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve webhooks"))
+
+		return
+	}
+
+	webhooks := convert.SchemaList(list, func(webhook schema.Webhook) apischema.Webhook {
+		return apischema.Webhook{
+			ID:          webhook.ID,
+			URL:         webhook.URL,
+			EventTypes:  eventTypes(webhook.EventTypes),
+			IsActive:    webhook.IsActive,
+			DateCreated: webhook.DateCreated,
+		}
+	})
+
+	response.Success(w, webhooks)
+}
+
+// createWebhook handles the HTTP request to register webhooks. A webhook
+// without event types receives all the events. A secret is generated if none
+// is given, and it is returned only once.
+func createWebhook(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+		log.Panic()
+	}()
+
+	body, err := requestutils.ReadBody(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	validationErrors, err := requestutils.ValidateRequest(body, validator.ValidateWebhook)
+	if err != nil && len(validationErrors) > 0 {
+		log.Error(err, validationErrors, log.KVs(log.Map{"request": string(body), "path": r.URL.Path}))
+		response.BadRequest(w, response.NewError(err, validationErrors))
+
+		return
+	}
+
+	data, err := requestutils.Unmarshal(r.URL.Path, body, apischema.NewWebhook)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err, "failed to unmarshal request body"))
+		return
+	}
+
+	var created []apischema.Webhook
+
+	for _, webhook := range data {
+		err := validateWebhook(webhook)
+		if err != nil {
+			log.Error(err, "invalid webhook", log.KVs(log.Map{"url": webhook.URL, "path": r.URL.Path}))
+			response.BadRequest(w, response.NewError(err, map[string]any{"url": webhook.URL}))
+
+			return
+		}
+
+		if webhook.Secret == "" {
+			webhook.Secret, err = generateSecret()
+			if err != nil {
+				log.Error(err, "failed to generate webhook secret", log.KV("path", r.URL.Path))
+				response.InternalServer(w, response.NewError(err, "failed to generate webhook secret"))
+
+				return
+			}
+		}
+
+		record := schema.Webhook{
+			URL:        webhook.URL,
+			Secret:     webhook.Secret,
+			EventTypes: dbutils.SetString(strings.Join(webhook.EventTypes, ",")),
+			IsActive:   true,
+		}
+
+		lastInsertID, err := store.Webhooks.Create(record)
+		if err != nil {
+			log.Error(err, "failed to create webhook", log.KVs(log.Map{"url": webhook.URL, "path": r.URL.Path}))
+			response.InternalServer(w, response.NewError(err, "failed to create webhook"))
+
+			return
+		}
+
+		created = append(created, apischema.Webhook{
+			ID:         int(lastInsertID),
+			URL:        record.URL,
+			Secret:     record.Secret,
+			EventTypes: webhook.EventTypes,
+			IsActive:   record.IsActive,
+		})
+	}
+
+	response.Created(w, created)
+}
+
+func deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	id, err := parameterID(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	affected, err := store.Webhooks.Delete(id)
+	if err != nil {
+		log.Error(err, "failed to delete webhook", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to delete webhook"))
+
+		return
+	}
+
+	response.Success(w, response.New(fmt.Sprintf("%d row(s) affected", affected)))
+}
+
+// getDeliveries handles the HTTP request to retrieve the deliveries of the
+// events, optionally of a specific 'status' and 'webhook_id'. The deliveries
+// with the 'dead' status are the ones that gave up after the maximum attempts.
+func getDeliveries(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	status, _ := requestutils.HasQueryParam(r, "status")
+	if status != "" && !slices.Contains([]string{schema.DeliveryPending, schema.DeliveryDelivered, schema.DeliveryDead}, status) {
+		err := fmt.Errorf("invalid 'status' value; must be one of %s, %s or %s",
+			schema.DeliveryPending, schema.DeliveryDelivered, schema.DeliveryDead)
+
+		response.BadRequest(w, response.NewError(err))
+
+		return
+	}
+
+	var webhookID int
+
+	webhookIDParam, ok := requestutils.HasQueryParam(r, "webhook_id")
+	if ok {
+		id, err := strconv.Atoi(webhookIDParam)
+		if err != nil {
+			log.Error(err, "failed to parse 'webhook_id' query parameter",
+				log.KVs(log.Map{"webhook_id": webhookIDParam, "path": r.URL.Path}))
+
+			response.BadRequest(w, response.NewError(errors.New("invalid 'webhook_id' value; must be an integer")))
+
+			return
+		}
+
+		webhookID = id
+	}
+
+	list, err := store.Webhooks.Deliveries(status, webhookID)
+	if err != nil {
+		log.Error(err, "failed to retrieve webhook deliveries", log.KV("path", r.URL.Path))
+		response.InternalServer(w, response.NewError(err, "failed to retrieve webhook deliveries"))
+
+		return
+	}
+
+	deliveries := convert.SchemaList(list, func(delivery schema.WebhookDelivery) apischema.WebhookDelivery {
+		return apischema.WebhookDelivery{
+			ID:             delivery.ID,
+			WebhookID:      delivery.WebhookID,
+			EventID:        delivery.EventID,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			ResponseStatus: dbutils.GetAsInt(delivery.ResponseStatus),
+			LastError:      dbutils.GetString(delivery.LastError),
+			DateCreated:    delivery.DateCreated,
+			DateModified:   dbutils.GetTime(delivery.DateModified),
+		}
+	})
+
+	if deliveries == nil {
+		deliveries = []apischema.WebhookDelivery{}
+	}
+
+	response.Success(w, deliveries)
+}
+
+// retryDelivery handles the HTTP request to attempt a dead delivery again.
+func retryDelivery(w http.ResponseWriter, r *http.Request) {
+	defer log.Panic()
+
+	id, err := parameterID(r)
+	if err != nil {
+		response.BadRequest(w, response.NewError(err))
+		return
+	}
+
+	affected, err := store.Webhooks.Retry(int64(id))
+	if err != nil {
+		log.Error(err, "failed to retry webhook delivery", log.KVs(log.Map{"id": id, "path": r.URL.Path}))
+		response.InternalServer(w, response.NewError(err, "failed to retry webhook delivery"))
+
+		return
+	}
+
+	if affected == 0 {
+		response.NotFound(w, response.New("dead delivery not found", map[string]any{"id": id}))
+		return
+	}
+
+	response.Success(w, response.New("delivery queued for retry", map[string]any{"id": id}))
+}
+
+// validateWebhook checks that the URL of the webhook is an absolute HTTP(S) URL
+// and that the event types are known.
+func validateWebhook(webhook apischema.Webhook) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("invalid webhook url '%s'; must be an absolute http or https url", webhook.URL)
+	}
+
+	for _, eventType := range webhook.EventTypes {
+		if !schema.IsValidEventType(eventType) {
+			return fmt.Errorf("unknown event type '%s'; must be one of %s", eventType, strings.Join(schema.EventTypes, ", "))
+		}
+	}
+
+	return nil
+}
+
+// eventTypes splits the comma-separated event types of a webhook.
+func eventTypes(value sql.NullString) []string {
+	if !value.Valid || value.String == "" {
+		return nil
+	}
+
+	return strings.Split(value.String, ",")
+}
+
+// generateSecret returns a random hex-encoded secret to sign the deliveries.
+func generateSecret() (string, error) {
+	secret := make([]byte, webhookSecretLength)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
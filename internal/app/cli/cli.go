@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// Exit codes of the commands.
+const (
+	ExitOK    int = 0
+	ExitError int = 1
+	ExitUsage int = 2
+)
+
+const (
+	program           string = "warehouse-inventory-management"
+	DefaultConfigFile string = "wim-config.yaml"
+)
+
+// command is a command of the CLI. A command either runs or groups the
+// commands under it.
+type command struct {
+	name        string
+	args        string
+	summary     string
+	description string
+	flags       func(flags *flag.FlagSet)
+	run         func(env *environment, args []string) error
+	commands    []*command
+}
+
+// environment is what the commands run with.
+type environment struct {
+	config string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// usageError is an error in how a command was called.
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+
+func usagef(format string, args ...any) error {
+	return usageError{err: fmt.Errorf(format, args...)}
+}
+
+// Run runs the command of the arguments (without the program name) and
+// returns its exit code: ExitOK on success, ExitUsage if the command was called
+// incorrectly and ExitError if it failed. Without a command, the server is
+// started.
+func Run(args []string) int {
+	env := &environment{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	return run(env, root(), args)
+}
+
+func run(env *environment, root *command, args []string) int {
+	global := flag.NewFlagSet(program, flag.ContinueOnError)
+	global.SetOutput(io.Discard)
+	global.StringVar(&env.config, "config", DefaultConfigFile, "")
+	global.Func("set", "", config.SetFlag)
+
+	err := global.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		printHelp(env.stdout, root, nil)
+		return ExitOK
+	}
+
+	if err != nil {
+		fmt.Fprintf(env.stderr, "%s\n\n", err)
+		printHelp(env.stderr, root, nil)
+
+		return ExitUsage
+	}
+
+	args = global.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	// Find the command, which may be under a group of commands.
+	var (
+		cmd  = root
+		path []string
+	)
+
+	for len(cmd.commands) > 0 {
+		if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			help := len(args) > 0
+			out := env.stderr
+			if help {
+				out = env.stdout
+			}
+
+			printHelp(out, cmd, path)
+
+			if help {
+				return ExitOK
+			}
+
+			return ExitUsage
+		}
+
+		next := find(cmd, args[0])
+		if next == nil {
+			fmt.Fprintf(env.stderr, "unknown command '%s'\n\n", strings.Join(append(path, args[0]), " "))
+			printHelp(env.stderr, cmd, path)
+
+			return ExitUsage
+		}
+
+		cmd = next
+		path = append(path, cmd.name)
+		args = args[1:]
+	}
+
+	flags := flag.NewFlagSet(strings.Join(path, " "), flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&env.config, "config", env.config, "path of the configuration `file`")
+	flags.Func("set", "override a configuration `key=value` (e.g. mysql.port=3307); can be repeated", config.SetFlag)
+
+	if cmd.flags != nil {
+		cmd.flags(flags)
+	}
+
+	err = flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		printCommandHelp(env.stdout, cmd, path, flags)
+		return ExitOK
+	}
+
+	if err != nil {
+		fmt.Fprintf(env.stderr, "%s\n\n", err)
+		printCommandHelp(env.stderr, cmd, path, flags)
+
+		return ExitUsage
+	}
+
+	err = cmd.run(env, flags.Args())
+	if err != nil {
+		var usage usageError
+		if errors.As(err, &usage) {
+			fmt.Fprintf(env.stderr, "%s\n\n", err)
+			printCommandHelp(env.stderr, cmd, path, flags)
+
+			return ExitUsage
+		}
+
+		trail.Error(err.Error())
+
+		return ExitError
+	}
+
+	return ExitOK
+}
+
+func find(group *command, name string) *command {
+	for _, cmd := range group.commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+// printHelp writes the help of a group of commands.
+func printHelp(w io.Writer, group *command, path []string) {
+	name := strings.TrimSpace(program + " [--config FILE] [--set KEY=VALUE] " + strings.Join(path, " "))
+
+	if group.description != "" {
+		fmt.Fprintf(w, "%s\n\n", group.description)
+	}
+
+	fmt.Fprintf(w, "Usage:\n  %s <command> [flags] [arguments]\n\nCommands:\n", name)
+
+	for _, cmd := range group.commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(w, "\nRun '%s <command> --help' for the flags of a command.\n", name)
+
+	if len(path) == 0 {
+		fmt.Fprintf(w, "\nExit codes:\n  %d  success\n  %d  the command failed\n  %d  the command was called incorrectly\n", ExitOK, ExitError, ExitUsage)
+	}
+}
+
+// printCommandHelp writes the help of a command and its flags.
+func printCommandHelp(w io.Writer, cmd *command, path []string, flags *flag.FlagSet) {
+	usage := program + " " + strings.Join(path, " ") + " [flags]"
+	if cmd.args != "" {
+		usage += " " + cmd.args
+	}
+
+	fmt.Fprintf(w, "%s\n\nUsage:\n  %s\n", cmd.description, usage)
+
+	fmt.Fprintf(w, "\nFlags:\n")
+	flags.VisitAll(func(f *flag.Flag) {
+		name := "--" + f.Name
+
+		value, usage := flag.UnquoteUsage(f)
+		if value != "" {
+			name += " " + strings.ToUpper(value)
+		}
+
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			usage += fmt.Sprintf(" (default %q)", f.DefValue)
+		}
+
+		fmt.Fprintf(w, "  %-22s %s\n", name, usage)
+	})
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRoot returns commands that record how they were called.
+func testRoot(calls *[]string) *command {
+	var verbose bool
+
+	record := func(name string, err error) func(env *environment, args []string) error {
+		return func(env *environment, args []string) error {
+			*calls = append(*calls, strings.TrimSpace(name+" "+strings.Join(args, " ")))
+			if verbose {
+				*calls = append(*calls, "verbose")
+			}
+
+			return err
+		}
+	}
+
+	return &command{
+		description: "Test commands.",
+		commands: []*command{
+			{name: "serve", summary: "Serve", description: "Serve.", run: record("serve", nil)},
+			{name: "fail", summary: "Fail", description: "Fail.", run: record("fail", errors.New("failed"))},
+			{name: "misuse", summary: "Misuse", description: "Misuse.", run: record("misuse", usagef("bad arguments"))},
+			{
+				name:        "db",
+				summary:     "Database",
+				description: "Database commands.",
+				commands: []*command{
+					{
+						name:        "migrate",
+						args:        "<up | down>",
+						summary:     "Migrate",
+						description: "Migrate the database.",
+						flags:       func(flags *flag.FlagSet) { flags.BoolVar(&verbose, "verbose", false, "print more") },
+						run:         record("db migrate", nil),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		calls  string
+		config string
+		stdout string
+		stderr string
+	}{
+		{name: "serve by default", code: ExitOK, calls: "serve", config: DefaultConfigFile},
+		{name: "command", args: []string{"serve"}, code: ExitOK, calls: "serve"},
+		{name: "global config", args: []string{"--config", "other.yaml", "serve"}, code: ExitOK, calls: "serve", config: "other.yaml"},
+		{name: "command config", args: []string{"db", "migrate", "--config=db.yaml", "up"}, code: ExitOK, calls: "db migrate up", config: "db.yaml"},
+		{name: "command flag", args: []string{"db", "migrate", "--verbose", "down", "2"}, code: ExitOK, calls: "db migrate down 2|verbose"},
+		{name: "failure", args: []string{"fail"}, code: ExitError, calls: "fail"},
+		{name: "usage error", args: []string{"misuse"}, code: ExitUsage, calls: "misuse", stderr: "bad arguments"},
+		{name: "unknown command", args: []string{"deploy"}, code: ExitUsage, stderr: "unknown command 'deploy'"},
+		{name: "unknown subcommand", args: []string{"db", "drop"}, code: ExitUsage, stderr: "unknown command 'db drop'"},
+		{name: "missing subcommand", args: []string{"db"}, code: ExitUsage, stderr: "Database commands."},
+		{name: "unknown flag", args: []string{"--verbose"}, code: ExitUsage, stderr: "flag provided but not defined"},
+		{name: "unknown command flag", args: []string{"serve", "--verbose"}, code: ExitUsage, stderr: "flag provided but not defined"},
+		{name: "invalid --set", args: []string{"--set", "nope=1"}, code: ExitUsage, stderr: "unknown configuration key 'nope'"},
+		{name: "help", args: []string{"--help"}, code: ExitOK, stdout: "Exit codes:"},
+		{name: "help command", args: []string{"help"}, code: ExitOK, stdout: "Commands:\n  serve"},
+		{name: "group help", args: []string{"db", "--help"}, code: ExitOK, stdout: "Database commands."},
+		{name: "command help", args: []string{"db", "migrate", "-h"}, code: ExitOK, stdout: "--verbose"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				calls          []string
+				stdout, stderr bytes.Buffer
+			)
+
+			env := &environment{stdout: &stdout, stderr: &stderr}
+
+			code := run(env, testRoot(&calls), test.args)
+			if code != test.code {
+				t.Errorf("run(%v) = %d, want %d (stderr: %s)", test.args, code, test.code, stderr.String())
+			}
+
+			if got := strings.Join(calls, "|"); got != test.calls {
+				t.Errorf("run(%v) called %q, want %q", test.args, got, test.calls)
+			}
+
+			if test.config != "" && env.config != test.config {
+				t.Errorf("config = %s, want %s", env.config, test.config)
+			}
+
+			if !strings.Contains(stdout.String(), test.stdout) {
+				t.Errorf("stdout = %q, want %q", stdout.String(), test.stdout)
+			}
+
+			if !strings.Contains(stderr.String(), test.stderr) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), test.stderr)
+			}
+		})
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	var stdout bytes.Buffer
+
+	code := run(&environment{stdout: &stdout, stderr: &stdout}, root(), []string{"--help"})
+	if code != ExitOK {
+		t.Fatalf("run(--help) = %d, want %d", code, ExitOK)
+	}
+
+	for _, cmd := range root().commands {
+		if !strings.Contains(stdout.String(), "  "+cmd.name+" ") {
+			t.Errorf("help = %s, want the command %s", stdout.String(), cmd.name)
+		}
+	}
+
+	stdout.Reset()
+
+	code = run(&environment{stdout: &stdout, stderr: &stdout}, root(), []string{"user", "create", "--help"})
+	if code != ExitOK || !strings.Contains(stdout.String(), "--email") || !strings.Contains(stdout.String(), "--config FILE") {
+		t.Errorf("run(user create --help) = %d, %s, want the flags of the command", code, stdout.String())
+	}
+}
+
+func TestConfigPrint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wim-config.yaml")
+	if err := os.WriteFile(path, []byte("mysql:\n  password: hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	code := run(&environment{stdout: &stdout, stderr: &stderr}, root(), []string{"--config", path, "config", "print"})
+	if code != ExitOK {
+		t.Fatalf("run(config print) = %d, want %d (stderr: %s)", code, ExitOK, stderr.String())
+	}
+
+	if strings.Contains(stdout.String(), "hunter2") || !strings.Contains(stdout.String(), "[REDACTED]") {
+		t.Errorf("config print = %s, want the password redacted", stdout.String())
+	}
+
+	code = run(&environment{stdout: &stdout, stderr: &stderr}, root(), []string{"--config", path + ".missing", "config", "print"})
+	if code != ExitError {
+		t.Errorf("run(config print) with a missing file = %d, want %d", code, ExitError)
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("from-a-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdin, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	var stderr bytes.Buffer
+
+	password, err := readPassword(&environment{stdin: stdin, stderr: &stderr})
+	if err != nil || password != "from-a-file" {
+		t.Errorf("readPassword() = %q, %v, want from-a-file", password, err)
+	}
+
+	if stderr.Len() > 0 {
+		t.Errorf("readPassword() prompted %q, want no prompt when the input is not a terminal", stderr.String())
+	}
+
+	tests := []struct {
+		input string
+		want  string
+		err   bool
+	}{
+		{input: "correct-horse\r\nsecond line\n", want: "correct-horse"},
+		{input: "correct-horse", want: "correct-horse"},
+		{input: "\n", err: true},
+		{input: "", err: true},
+		{input: "short\n", err: true},
+	}
+
+	for _, test := range tests {
+		password, err := readPassword(&environment{stdin: strings.NewReader(test.input), stderr: &stderr})
+		if (err != nil) != test.err || password != test.want {
+			t.Errorf("readPassword(%q) = %q, %v, want %q (error %t)", test.input, password, err, test.want, test.err)
+		}
+	}
+}
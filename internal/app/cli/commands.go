@@ -0,0 +1,578 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	v1 "github.com/rmarasigan/warehouse-inventory-management/api/v1"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/db"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/password"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+	"golang.org/x/term"
+)
+
+// root returns the commands of the CLI.
+func root() *command {
+	return &command{
+		description: "Warehouse Inventory Management keeps track of the items, their stock and\nthe transactions that move them.",
+		commands: []*command{
+			{
+				name:        "serve",
+				summary:     "Start the HTTP server (the default command)",
+				description: "Start the HTTP server. It stops gracefully on SIGINT or SIGTERM.",
+				run:         serve,
+			},
+			{
+				name:        "db",
+				summary:     "Initialize, migrate and seed the database",
+				description: "Manage the database of the configuration file.",
+				commands: []*command{
+					{
+						name:        "init",
+						summary:     "Create the database, apply the migrations and seed it",
+						description: "Create the database if it does not exist, apply the pending migrations\nand seed the roles, units of measurement and currencies of the configuration.",
+						run:         dbInit,
+					},
+					{
+						name:        "migrate",
+						args:        "<up | down [n] | status | redo>",
+						summary:     "Apply, revert or list the schema migrations",
+						description: "Apply the pending migrations (up), revert the last n migrations (down),\nlist the state of each migration (status) or revert and re-apply the last\nmigration (redo).",
+						run:         dbMigrate,
+					},
+					{
+						name:        "seed",
+						summary:     "Seed the roles, units of measurement and currencies",
+						description: "Seed the roles, units of measurement and currencies of the configuration.\nThe existing records are left as they are.",
+						run:         dbSeed,
+					},
+				},
+			},
+			{
+				name:        "config",
+				summary:     "Validate and print the configuration",
+				description: "Manage the configuration.",
+				commands: []*command{
+					{
+						name:        "print",
+						summary:     "Print the configuration with its secrets redacted",
+						description: "Load the configuration from the defaults, the file, the WIM_* environment\nvariables and the --set flags, validate it and print it as YAML with its\nsecrets redacted.",
+						run:         configPrint,
+					},
+				},
+			},
+			{
+				name:        "user",
+				summary:     "Create users and reset their password",
+				description: "Manage the users.",
+				commands: []*command{
+					{
+						name:        "create",
+						summary:     "Create a user",
+						description: "Create an active user. The password is read from the standard input.",
+						flags:       userCreateFlags,
+						run:         userCreate,
+					},
+					{
+						name:        "reset-password",
+						summary:     "Reset the password of a user",
+						description: "Reset the password of a user. The password is read from the standard input.",
+						flags:       userResetPasswordFlags,
+						run:         userResetPassword,
+					},
+				},
+			},
+			{
+				name:        "stock",
+				summary:     "Check the stock on hand",
+				description: "Manage the stock on hand.",
+				commands: []*command{
+					{
+						name:        "reconcile",
+						summary:     "Compare the stock on hand with the inventory ledger",
+						description: "List the items whose stock on hand is not the sum of their movements in\nthe inventory ledger. With --fix, their stock on hand is set to the sum of\nthe ledger. It exits with 1 if there are discrepancies left.",
+						flags:       stockReconcileFlags,
+						run:         stockReconcile,
+					},
+				},
+			},
+			{
+				name:        "export",
+				args:        "<" + strings.Join(v1.CSVKinds(), " | ") + ">",
+				summary:     "Export records as CSV",
+				description: "Export the records of a kind as CSV.",
+				flags:       exportFlags,
+				run:         export,
+			},
+			{
+				name:        "import",
+				args:        "<" + strings.Join(v1.CSVKinds(), " | ") + "> [file | -]",
+				summary:     "Import records from CSV",
+				description: "Import the records of a kind from a CSV file, or from the standard input\nif the file is '-' or missing. The rows are imported in one transaction\nthat is only committed if every row succeeds. It prints the report of the\nimport and exits with 1 if any row failed.",
+				flags:       importFlags,
+				run:         importCSV,
+			},
+		},
+	}
+}
+
+// Flags of the commands.
+var (
+	userFirstName string
+	userLastName  string
+	userEmail     string
+	userRole      string
+	userAdmin     bool
+	userID        int
+
+	reconcileFix bool
+
+	exportOutput string
+
+	importDryRun bool
+	importUserID int
+)
+
+func userCreateFlags(flags *flag.FlagSet) {
+	flags.StringVar(&userFirstName, "first-name", "", "first name of the user")
+	flags.StringVar(&userLastName, "last-name", "", "last name of the user")
+	flags.StringVar(&userEmail, "email", "", "email of the user")
+	flags.StringVar(&userRole, "role", "", "`name` of the role of the user")
+	flags.BoolVar(&userAdmin, "admin", false, "give the user the admin role")
+}
+
+func userResetPasswordFlags(flags *flag.FlagSet) {
+	flags.StringVar(&userEmail, "email", "", "email of the user")
+	flags.IntVar(&userID, "id", 0, "id of the user")
+}
+
+func stockReconcileFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&reconcileFix, "fix", false, "set the stock on hand to the sum of the ledger")
+}
+
+func exportFlags(flags *flag.FlagSet) {
+	flags.StringVar(&exportOutput, "output", "", "write the CSV to `file` instead of the standard output")
+}
+
+func importFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&importDryRun, "dry-run", false, "validate the rows without committing them")
+	flags.IntVar(&importUserID, "user-id", 0, "`id` of the user that creates the records")
+}
+
+func serve(env *environment, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	return app.StartServer(env.config)
+}
+
+func dbInit(env *environment, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	return db.Initialize(env.config)
+}
+
+func dbMigrate(env *environment, args []string) error {
+	if len(args) == 0 {
+		return usagef("missing migrate command; must be one of up, down, status, redo")
+	}
+
+	switch args[0] {
+	case "up", "status", "redo":
+		if len(args) > 1 {
+			return usagef("unexpected arguments: %s", strings.Join(args[1:], " "))
+		}
+
+	case "down":
+		if len(args) > 2 {
+			return usagef("unexpected arguments: %s", strings.Join(args[2:], " "))
+		}
+
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return usagef("invalid number of migrations '%s'; must be a positive integer", args[1])
+			}
+		}
+
+	default:
+		return usagef("unknown migrate command '%s'; must be one of up, down, status, redo", args[0])
+	}
+
+	return db.Migrate(env.config, args...)
+}
+
+func dbSeed(env *environment, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	return db.Seed(env.config)
+}
+
+func configPrint(env *environment, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	cfg, err := config.Load(env.config)
+	if err != nil {
+		return err
+	}
+
+	data, err := cfg.YAML()
+	if err != nil {
+		return err
+	}
+
+	_, err = env.stdout.Write(data)
+
+	return err
+}
+
+func userCreate(env *environment, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	if userFirstName == "" || userLastName == "" {
+		return usagef("--first-name and --last-name are required")
+	}
+
+	if userAdmin && userRole != "" {
+		return usagef("--admin and --role cannot be used together")
+	}
+
+	if userAdmin {
+		userRole = "admin"
+	}
+
+	if userRole == "" {
+		return usagef("--role or --admin is required")
+	}
+
+	return withDatabase(env, func() error {
+		role, err := mysql.GetRoleByName(userRole)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the role: %w", err)
+		}
+
+		if role.ID == 0 {
+			return fmt.Errorf("role '%s' does not exist; run 'db seed' to seed the roles of the configuration", userRole)
+		}
+
+		user := schema.User{
+			RoleID:    role.ID,
+			FirstName: userFirstName,
+			LastName:  userLastName,
+			Email:     sql.NullString{String: userEmail, Valid: userEmail != ""},
+			Active:    true,
+		}
+
+		exists, err := mysql.UserExists(user)
+		if err != nil {
+			return fmt.Errorf("failed to check the user: %w", err)
+		}
+
+		if exists {
+			return fmt.Errorf("user '%s %s' already exists", user.FirstName, user.LastName)
+		}
+
+		user.Password, err = readPasswordHash(env)
+		if err != nil {
+			return err
+		}
+
+		id, err := mysql.NewUser(user)
+		if err != nil {
+			return fmt.Errorf("failed to create the user: %w", err)
+		}
+
+		trail.OK("Created user %d with the '%s' role.", id, role.Name)
+
+		return nil
+	})
+}
+
+func userResetPassword(env *environment, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	if (userEmail == "") == (userID == 0) {
+		return usagef("one of --email or --id is required")
+	}
+
+	return withDatabase(env, func() error {
+		var (
+			user schema.User
+			err  error
+		)
+
+		if userEmail != "" {
+			user, err = mysql.GetUserByEmail(userEmail)
+		} else {
+			user, err = mysql.GetUserByID(userID)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to fetch the user: %w", err)
+		}
+
+		if user.ID == 0 {
+			return errors.New("user does not exist")
+		}
+
+		hash, err := readPasswordHash(env)
+		if err != nil {
+			return err
+		}
+
+		err = mysql.UpdateUserPassword(user.ID, hash)
+		if err != nil {
+			return fmt.Errorf("failed to reset the password: %w", err)
+		}
+
+		trail.OK("Reset the password of user %d.", user.ID)
+
+		return nil
+	})
+}
+
+func stockReconcile(env *environment, args []string) error {
+	if len(args) > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	return withDatabase(env, func() error {
+		discrepancies, err := mysql.StockDiscrepancies()
+		if err != nil {
+			return fmt.Errorf("failed to reconcile the stock: %w", err)
+		}
+
+		if len(discrepancies) == 0 {
+			trail.OK("The stock on hand of every item matches the inventory ledger.")
+			return nil
+		}
+
+		writer := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ITEM\tNAME\tON HAND\tLEDGER\tDIFFERENCE")
+
+		for _, d := range discrepancies {
+			fmt.Fprintf(writer, "%d\t%s\t%d\t%d\t%+d\n", d.ItemID, d.Name, d.Quantity, d.LedgerQuantity, d.Quantity-d.LedgerQuantity)
+		}
+
+		err = writer.Flush()
+		if err != nil {
+			return err
+		}
+
+		if !reconcileFix {
+			return fmt.Errorf("%d item(s) do not match the inventory ledger; run with --fix to correct them", len(discrepancies))
+		}
+
+		err = fixStock(discrepancies)
+		if err != nil {
+			return err
+		}
+
+		trail.OK("Corrected the stock on hand of %d item(s).", len(discrepancies))
+
+		return nil
+	})
+}
+
+// fixStock sets the stock on hand of the items to the sum of their ledger in
+// one transaction.
+func fixStock(discrepancies []schema.StockDiscrepancy) error {
+	batch, err := mysql.NewBatch()
+	if err != nil {
+		return fmt.Errorf("failed to begin the transaction: %w", err)
+	}
+	defer batch.Rollback()
+
+	for _, d := range discrepancies {
+		item, err := batch.ItemForUpdate(d.ItemID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch item %d: %w", d.ItemID, err)
+		}
+
+		item.Quantity = d.LedgerQuantity
+
+		err = batch.UpdateItemQuantity(item)
+		if err != nil {
+			return fmt.Errorf("failed to correct item %d: %w", d.ItemID, err)
+		}
+	}
+
+	err = batch.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit the corrections: %w", err)
+	}
+
+	return nil
+}
+
+func export(env *environment, args []string) error {
+	if len(args) != 1 {
+		return usagef("expected one kind; must be one of %s", strings.Join(v1.CSVKinds(), ", "))
+	}
+
+	return withDatabase(env, func() error {
+		if exportOutput == "" {
+			return v1.ExportCSV(env.stdout, args[0])
+		}
+
+		file, err := os.Create(exportOutput)
+		if err != nil {
+			return err
+		}
+
+		err = v1.ExportCSV(file, args[0])
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		return file.Close()
+	})
+}
+
+func importCSV(env *environment, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return usagef("expected a kind and an optional file")
+	}
+
+	return withDatabase(env, func() error {
+		body := env.stdin
+
+		if len(args) == 2 && args[1] != "-" {
+			file, err := os.Open(args[1])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			body = file
+		}
+
+		report, err := v1.ImportCSV(body, args[0], importUserID, importDryRun)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(env.stdout)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(report)
+		if err != nil {
+			return err
+		}
+
+		if report.Failed > 0 {
+			return fmt.Errorf("%d of %d row(s) failed to import", report.Failed, report.Total)
+		}
+
+		return nil
+	})
+}
+
+// withDatabase connects to the database of the configuration, checks that its
+// schema is up to date and runs the function.
+func withDatabase(env *environment, fn func() error) error {
+	log.Init()
+
+	_, err := config.Load(env.config)
+	if err != nil {
+		return err
+	}
+
+	err = mysql.Connect()
+	if err != nil {
+		return err
+	}
+	defer mysql.Close()
+
+	err = db.CheckMigrations(context.Background())
+	if err != nil {
+		return fmt.Errorf("schema is not up to date:\n%w", err)
+	}
+
+	return fn()
+}
+
+// readPassword reads the password from the first line of the standard input,
+// prompting for it if the input is a terminal. The password must follow the
+// password policy of the loaded configuration.
+func readPassword(env *environment) (string, error) {
+	line, err := readLine(env)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the password: %w", err)
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password cannot be empty")
+	}
+
+	err = config.Get().PasswordPolicy().Check(password)
+	if err != nil {
+		return "", err
+	}
+
+	return password, nil
+}
+
+// readPasswordHash reads the password like readPassword and returns its hash,
+// which is what is stored.
+func readPasswordHash(env *environment) (string, error) {
+	secret, err := readPassword(env)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := password.Hash(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash the password: %w", err)
+	}
+
+	return hash, nil
+}
+
+// readLine reads a line of the standard input. On a terminal, it prompts for
+// the password and does not echo what is typed.
+func readLine(env *environment) (string, error) {
+	if file, ok := env.stdin.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		fmt.Fprint(env.stderr, "Password: ")
+		defer fmt.Fprintln(env.stderr)
+
+		line, err := term.ReadPassword(int(file.Fd()))
+
+		return string(line), err
+	}
+
+	line, err := bufio.NewReader(env.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return line, nil
+}
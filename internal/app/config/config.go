@@ -1,73 +1,470 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/rmarasigan/warehouse-inventory-management/internal/mail"
 	"gopkg.in/yaml.v3"
 )
 
 // Default values for the server configuration.
 const (
-	DefaultPort            string = "8080"
+	DefaultPort            int    = 8080
 	DefaultIP              string = "0.0.0.0"
 	DefaultApplicationName string = "Warehouse Inventory Management"
 	DefaultDatabaseHost    string = "127.0.0.1"
-	DefaultDatabasePort    string = "3306"
+	DefaultDatabasePort    int    = 3306
 	DefaultDatabaseName    string = "wim_db"
 	DefaultDatabaseUser    string = "root"
+	DefaultDatabaseCharset string = "utf8mb4"
+	DefaultDatabaseTZ      string = "UTC"
+	DefaultDatabaseTLS     string = TLSDisabled
+	DefaultValuationMethod string = "fifo"
+
+	DefaultIdempotencyKeyTTL           time.Duration = 24 * time.Hour
+	DefaultIdempotencyKeyPurgeInterval time.Duration = time.Hour
+
+	DefaultLowStockThreshold int = 10
+
+	DefaultWebhookPollInterval time.Duration = 5 * time.Second
+	DefaultWebhookMaxAttempts  int           = 8
+	DefaultWebhookBackoff      time.Duration = 30 * time.Second
+	DefaultWebhookMaxBackoff   time.Duration = time.Hour
+	DefaultWebhookTimeout      time.Duration = 10 * time.Second
+
+	DefaultEventStreamPollInterval  time.Duration = time.Second
+	DefaultEventStreamBufferSize    int           = 1000
+	DefaultEventStreamClientBuffer  int           = 64
+	DefaultEventStreamHeartbeat     time.Duration = 15 * time.Second
+	DefaultEventStreamCommitTimeout time.Duration = 30 * time.Second
+
+	DefaultPasswordMinLength int = 8
+
+	DefaultInviteTTL       time.Duration = 72 * time.Hour
+	DefaultVerificationTTL time.Duration = 24 * time.Hour
+	DefaultResetTTL        time.Duration = time.Hour
+
+	DefaultMailSender  string        = mail.SenderLog
+	DefaultMailFrom    string        = "Warehouse Inventory Management <wim@localhost>"
+	DefaultMailPath    string        = "wim-mail.txt"
+	DefaultSMTPPort    int           = 587
+	DefaultSMTPTLS     string        = mail.TLSStartTLS
+	DefaultSMTPTimeout time.Duration = 10 * time.Second
+
+	DefaultShutdownDrainDelay      time.Duration = 0
+	DefaultShutdownHTTPTimeout     time.Duration = 15 * time.Second
+	DefaultShutdownWorkersTimeout  time.Duration = 10 * time.Second
+	DefaultShutdownDatabaseTimeout time.Duration = 5 * time.Second
+
+	DefaultDatabaseConnectTimeout  time.Duration = 10 * time.Second
+	DefaultDatabaseMaxOpenConns    int           = 5
+	DefaultDatabaseMaxIdleConns    int           = 5
+	DefaultDatabaseConnMaxLifetime time.Duration = 270 * time.Second
+	DefaultDatabaseRetryAttempts   int           = 5
+	DefaultDatabaseRetryBackoff    time.Duration = 500 * time.Millisecond
+	DefaultDatabaseRetryMaxBackoff time.Duration = 5 * time.Second
+
+	DefaultDatabaseDriver    string        = DriverMySQL
+	DefaultSQLitePath        string        = "wim.db"
+	DefaultSQLiteBusyTimeout time.Duration = 5 * time.Second
+
+	DefaultPostgresPort    int    = 5432
+	DefaultPostgresUser    string = "postgres"
+	DefaultPostgresSSLMode string = SSLModeDisable
+)
 
-	DBHostKey     string = "db_host"
-	DBPortKey     string = "db_port"
-	DBNameKey     string = "db_name"
-	DBUserKey     string = "db_user"
-	DBPasswordKey string = "db_password"
+// Database drivers the application can run on.
+const (
+	DriverMySQL    string = "mysql"
+	DriverSQLite   string = "sqlite"
+	DriverPostgres string = "postgres"
 )
 
-type config struct {
-	Application Application `yaml:"application,omitempty"`
-	MySQL       MySQL       `yaml:"mysql,omitempty"`
+// SSL modes of the PostgreSQL connection.
+const (
+	SSLModeDisable    string = "disable"
+	SSLModeRequire    string = "require"
+	SSLModeVerifyCA   string = "verify-ca"
+	SSLModeVerifyFull string = "verify-full"
+)
+
+// TLS modes of the MySQL connection.
+const (
+	TLSDisabled   string = "disabled"
+	TLSPreferred  string = "preferred"
+	TLSSkipVerify string = "skip-verify"
+	TLSVerifyFull string = "verify-full"
+)
+
+// Config is the configuration of the application. It is layered from the
+// defaults, the YAML file, the 'WIM_*' environment variables and the flags, in
+// that order.
+type Config struct {
+	Driver      string      `yaml:"driver"`
+	Application Application `yaml:"application"`
+	MySQL       MySQL       `yaml:"mysql"`
+	SQLite      SQLite      `yaml:"sqlite"`
+	Postgres    Postgres    `yaml:"postgres"`
 }
 
 // Application holds the application-specific configuration details.
 type Application struct {
-	Name              string              `yaml:"name,omitempty"`
-	Host              string              `yaml:"host,omitempty"`
-	Port              string              `yaml:"port,omitempty"`
-	Role              []string            `yaml:"role,omitempty"`
-	Currency          []Currency          `yaml:"currency,omitempty"`
-	UnitOfMeasurement []UnitOfMeasurement `yaml:"unit_of_measurement,omitempty"`
+	Name              string              `yaml:"name"`
+	Host              string              `yaml:"host"`
+	Port              int                 `yaml:"port"`
+	Role              []string            `yaml:"role"`
+	ValuationMethod   string              `yaml:"valuation_method"`
+	IdempotencyKey    IdempotencyKey      `yaml:"idempotency_key"`
+	LowStockThreshold int                 `yaml:"low_stock_threshold"`
+	RequireIfMatch    bool                `yaml:"require_if_match"`
+	Webhook           Webhook             `yaml:"webhook"`
+	EventStream       EventStream         `yaml:"event_stream"`
+	Shutdown          Shutdown            `yaml:"shutdown"`
+	PasswordPolicy    PasswordPolicy      `yaml:"password_policy"`
+	Account           Account             `yaml:"account"`
+	Mail              Mail                `yaml:"mail"`
+	Currency          []Currency          `yaml:"currency"`
+	UnitOfMeasurement []UnitOfMeasurement `yaml:"unit_of_measurement"`
+}
+
+// IdempotencyKey holds how long the 'Idempotency-Key' of a request can be
+// replayed and how often the expired keys are deleted.
+type IdempotencyKey struct {
+	TTL           Duration `yaml:"ttl"`
+	PurgeInterval Duration `yaml:"purge_interval"`
+}
+
+// Webhook holds how the events are delivered to the webhooks: how often the
+// outbox is polled, how many times a delivery is attempted before it is dead,
+// the initial and maximum delay between the attempts, and the timeout of each
+// attempt.
+type Webhook struct {
+	PollInterval Duration `yaml:"poll_interval"`
+	MaxAttempts  int      `yaml:"max_attempts"`
+	Backoff      Duration `yaml:"backoff"`
+	MaxBackoff   Duration `yaml:"max_backoff"`
+	Timeout      Duration `yaml:"timeout"`
+}
+
+// EventStream holds how the committed events are streamed to the clients: how
+// often the outbox is polled, how many of the latest events are kept to resume
+// a stream from, how many events are queued for a client before it is dropped
+// as too slow, how often an idle stream is kept alive, and how long an event
+// that is committed after the later ones is waited for.
+type EventStream struct {
+	PollInterval  Duration `yaml:"poll_interval"`
+	BufferSize    int      `yaml:"buffer_size"`
+	ClientBuffer  int      `yaml:"client_buffer"`
+	Heartbeat     Duration `yaml:"heartbeat"`
+	CommitTimeout Duration `yaml:"commit_timeout"`
+}
+
+// Shutdown holds how the server is shut down: how long it keeps accepting
+// requests after the readiness probe starts failing, and how long it waits to
+// drain the in-flight HTTP requests, to stop the background workers and to
+// close the database pool.
+type Shutdown struct {
+	DrainDelay      Duration `yaml:"drain_delay"`
+	HTTPTimeout     Duration `yaml:"http_timeout"`
+	WorkersTimeout  Duration `yaml:"workers_timeout"`
+	DatabaseTimeout Duration `yaml:"database_timeout"`
+}
+
+// PasswordPolicy holds what a password must have: a minimum length, in
+// characters, and whether it needs an uppercase letter, a lowercase letter, a
+// digit and a symbol.
+type PasswordPolicy struct {
+	MinLength     int  `yaml:"min_length"`
+	RequireUpper  bool `yaml:"require_upper"`
+	RequireLower  bool `yaml:"require_lower"`
+	RequireDigit  bool `yaml:"require_digit"`
+	RequireSymbol bool `yaml:"require_symbol"`
+}
+
+// Account holds how long the emailed tokens to accept an invite, verify an
+// email and reset a password can be used, and the page of the client that the
+// emails link to with the token. The emails only have the token if there is no
+// such page.
+type Account struct {
+	InviteTTL       Duration `yaml:"invite_ttl"`
+	VerificationTTL Duration `yaml:"verification_ttl"`
+	ResetTTL        Duration `yaml:"reset_ttl"`
+	LinkURL         string   `yaml:"link_url"`
+}
+
+// Mail holds how the emails are sent: the sender is one of log (written to the
+// log), file (appended to the file at the path) or smtp, and from is the
+// address they are sent from.
+type Mail struct {
+	Sender string `yaml:"sender"`
+	From   string `yaml:"from"`
+	Path   string `yaml:"path"`
+	SMTP   SMTP   `yaml:"smtp"`
+}
+
+// SMTP holds the SMTP server of the smtp sender. The TLS mode is one of none,
+// starttls or tls (implicit TLS, usually on port 465), and the timeout limits
+// the sending of an email.
+type SMTP struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password" secret:"true"`
+	TLS      string   `yaml:"tls"`
+	Timeout  Duration `yaml:"timeout"`
 }
 
 type Currency struct {
-	Code   string `yaml:"code,omitempty"`
-	Symbol string `yaml:"symbol,omitempty"`
-	Active bool   `yaml:"active,omitempty"`
+	Code   string `yaml:"code"`
+	Symbol string `yaml:"symbol"`
+	Active bool   `yaml:"active"`
 }
 
 type UnitOfMeasurement struct {
-	Code string `yaml:"code,omitempty"`
-	Name string `yaml:"name,omitempty"`
+	Code string `yaml:"code"`
+	Name string `yaml:"name"`
 }
 
-// MySQL holds the MySQL database-specific configuration details.
+// MySQL holds the MySQL database-specific configuration details. The server is
+// reached through the Unix socket, if one is given, or else through TCP at the
+// host and port. The charset is set on every connection and the timestamps are
+// read and written in the timezone.
 type MySQL struct {
-	Host         string `yaml:"host,omitempty"`
-	Port         string `yaml:"port,omitempty"`
-	DatabaseName string `yaml:"database_name,omitempty"`
-	Username     string `yaml:"username,omitempty"`
-	Password     string `yaml:"password,omitempty"`
+	Host         string       `yaml:"host"`
+	Port         int          `yaml:"port"`
+	Socket       string       `yaml:"socket"`
+	DatabaseName string       `yaml:"database_name"`
+	Username     string       `yaml:"username"`
+	Password     string       `yaml:"password" secret:"true"`
+	Charset      string       `yaml:"charset"`
+	Collation    string       `yaml:"collation"`
+	Timezone     string       `yaml:"timezone"`
+	TLS          MySQLTLS     `yaml:"tls"`
+	Timeout      MySQLTimeout `yaml:"timeout"`
+	Pool         MySQLPool    `yaml:"pool"`
+	Retry        MySQLRetry   `yaml:"retry"`
+}
+
+// MySQLTLS holds how the connection is encrypted. The mode is one of disabled,
+// preferred (encrypted if the server supports it, without verifying its
+// certificate), skip-verify (encrypted without verifying the certificate) or
+// verify-full (encrypted and the certificate verified against the CA, or the
+// system roots, and the server name). A client certificate and key can be given
+// for the servers that require it.
+type MySQLTLS struct {
+	Mode       string `yaml:"mode"`
+	CAFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
+}
+
+// MySQLTimeout holds how long a connection can take to be established and how
+// long a read or write on it can take. A zero read or write timeout waits
+// without a limit.
+type MySQLTimeout struct {
+	Connect Duration `yaml:"connect"`
+	Read    Duration `yaml:"read"`
+	Write   Duration `yaml:"write"`
+}
+
+// MySQLPool holds how many connections are opened and kept idle, and how long
+// a connection is reused and kept idle. A zero max_open_conns, conn_max_lifetime
+// or conn_max_idle_time has no limit, while a zero max_idle_conns keeps no idle
+// connection.
+type MySQLPool struct {
+	MaxOpenConns    int      `yaml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time"`
+}
+
+// MySQLRetry holds how many times the server is reached on startup before
+// giving up, and the initial and maximum delay between the attempts.
+type MySQLRetry struct {
+	Attempts   int      `yaml:"attempts"`
+	Backoff    Duration `yaml:"backoff"`
+	MaxBackoff Duration `yaml:"max_backoff"`
+}
+
+// SQLite holds the SQLite database-specific configuration details: the path of
+// the database file, which is created if it does not exist, and how long a
+// write waits for another one to finish before it fails.
+type SQLite struct {
+	Path        string   `yaml:"path"`
+	BusyTimeout Duration `yaml:"busy_timeout"`
+}
+
+// Postgres holds the PostgreSQL database-specific configuration details. The
+// SSL mode is one of disable, require (encrypted without verifying the
+// certificate), verify-ca (the certificate verified against the CA) or
+// verify-full (the CA and the host name verified). The pool and the retry on
+// startup are the same as MySQL's.
+type Postgres struct {
+	Host           string     `yaml:"host"`
+	Port           int        `yaml:"port"`
+	DatabaseName   string     `yaml:"database_name"`
+	Username       string     `yaml:"username"`
+	Password       string     `yaml:"password" secret:"true"`
+	SSLMode        string     `yaml:"sslmode"`
+	SSLRootCert    string     `yaml:"sslrootcert"`
+	ConnectTimeout Duration   `yaml:"connect_timeout"`
+	Pool           MySQLPool  `yaml:"pool"`
+	Retry          MySQLRetry `yaml:"retry"`
+}
+
+// Duration is a time.Duration that is written as a Go duration (e.g. 5s, 1h)
+// in the configuration.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.Set(node.Value)
+}
+
+func (d Duration) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+// Set parses a Go duration.
+func (d *Duration) Set(value string) error {
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("invalid duration '%s'; must be a Go duration (e.g. 5s, 1h)", value)
+	}
+
+	*d = Duration(duration)
+
+	return nil
 }
 
-// Load reads the configuration from a YAML file at the given path and loads it.
-// Returns an error if the file is not found, cannot be read, or contains invalid
-// YAML.
+// current is the configuration that was loaded last.
+var current atomic.Pointer[Config]
+
+// Default returns the default configuration.
+func Default() *Config {
+	return &Config{
+		Driver: DefaultDatabaseDriver,
+		Application: Application{
+			Name:            DefaultApplicationName,
+			Host:            DefaultIP,
+			Port:            DefaultPort,
+			ValuationMethod: DefaultValuationMethod,
+			IdempotencyKey: IdempotencyKey{
+				TTL:           Duration(DefaultIdempotencyKeyTTL),
+				PurgeInterval: Duration(DefaultIdempotencyKeyPurgeInterval),
+			},
+			LowStockThreshold: DefaultLowStockThreshold,
+			Webhook: Webhook{
+				PollInterval: Duration(DefaultWebhookPollInterval),
+				MaxAttempts:  DefaultWebhookMaxAttempts,
+				Backoff:      Duration(DefaultWebhookBackoff),
+				MaxBackoff:   Duration(DefaultWebhookMaxBackoff),
+				Timeout:      Duration(DefaultWebhookTimeout),
+			},
+			EventStream: EventStream{
+				PollInterval:  Duration(DefaultEventStreamPollInterval),
+				BufferSize:    DefaultEventStreamBufferSize,
+				ClientBuffer:  DefaultEventStreamClientBuffer,
+				Heartbeat:     Duration(DefaultEventStreamHeartbeat),
+				CommitTimeout: Duration(DefaultEventStreamCommitTimeout),
+			},
+			Shutdown: Shutdown{
+				DrainDelay:      Duration(DefaultShutdownDrainDelay),
+				HTTPTimeout:     Duration(DefaultShutdownHTTPTimeout),
+				WorkersTimeout:  Duration(DefaultShutdownWorkersTimeout),
+				DatabaseTimeout: Duration(DefaultShutdownDatabaseTimeout),
+			},
+			PasswordPolicy: PasswordPolicy{MinLength: DefaultPasswordMinLength},
+			Account: Account{
+				InviteTTL:       Duration(DefaultInviteTTL),
+				VerificationTTL: Duration(DefaultVerificationTTL),
+				ResetTTL:        Duration(DefaultResetTTL),
+			},
+			Mail: Mail{
+				Sender: DefaultMailSender,
+				From:   DefaultMailFrom,
+				Path:   DefaultMailPath,
+				SMTP: SMTP{
+					Port:    DefaultSMTPPort,
+					TLS:     DefaultSMTPTLS,
+					Timeout: Duration(DefaultSMTPTimeout),
+				},
+			},
+		},
+		MySQL: MySQL{
+			Host:         DefaultDatabaseHost,
+			Port:         DefaultDatabasePort,
+			DatabaseName: DefaultDatabaseName,
+			Username:     DefaultDatabaseUser,
+			Charset:      DefaultDatabaseCharset,
+			Timezone:     DefaultDatabaseTZ,
+			TLS:          MySQLTLS{Mode: DefaultDatabaseTLS},
+			Timeout:      MySQLTimeout{Connect: Duration(DefaultDatabaseConnectTimeout)},
+			Pool: MySQLPool{
+				MaxOpenConns:    DefaultDatabaseMaxOpenConns,
+				MaxIdleConns:    DefaultDatabaseMaxIdleConns,
+				ConnMaxLifetime: Duration(DefaultDatabaseConnMaxLifetime),
+			},
+			Retry: MySQLRetry{
+				Attempts:   DefaultDatabaseRetryAttempts,
+				Backoff:    Duration(DefaultDatabaseRetryBackoff),
+				MaxBackoff: Duration(DefaultDatabaseRetryMaxBackoff),
+			},
+		},
+		SQLite: SQLite{
+			Path:        DefaultSQLitePath,
+			BusyTimeout: Duration(DefaultSQLiteBusyTimeout),
+		},
+		Postgres: Postgres{
+			Host:           DefaultDatabaseHost,
+			Port:           DefaultPostgresPort,
+			DatabaseName:   DefaultDatabaseName,
+			Username:       DefaultPostgresUser,
+			SSLMode:        DefaultPostgresSSLMode,
+			ConnectTimeout: Duration(DefaultDatabaseConnectTimeout),
+			Pool: MySQLPool{
+				MaxOpenConns:    DefaultDatabaseMaxOpenConns,
+				MaxIdleConns:    DefaultDatabaseMaxIdleConns,
+				ConnMaxLifetime: Duration(DefaultDatabaseConnMaxLifetime),
+			},
+			Retry: MySQLRetry{
+				Attempts:   DefaultDatabaseRetryAttempts,
+				Backoff:    Duration(DefaultDatabaseRetryBackoff),
+				MaxBackoff: Duration(DefaultDatabaseRetryMaxBackoff),
+			},
+		},
+	}
+}
+
+// Get returns the configuration that was loaded last, or the default
+// configuration if none was loaded.
+func Get() *Config {
+	cfg := current.Load()
+	if cfg == nil {
+		return Default()
+	}
+
+	return cfg
+}
+
+// Load reads the configuration from a YAML file at the given path over the
+// defaults, then applies the 'WIM_*' environment variables and the flags set
+// with SetFlag, and validates it. Returns an error if the file is not found,
+// cannot be read or contains invalid YAML or unknown keys, and an error that
+// lists every invalid value otherwise.
 //
 // Parameter:
 //   - file: It should include the full path and filename.
-func Load(file string) (*config, error) {
+func Load(file string) (*Config, error) {
 	if strings.TrimSpace(file) == "" {
 		return nil, errors.New("file path not provided")
 	}
@@ -77,112 +474,210 @@ func Load(file string) (*config, error) {
 		return nil, fmt.Errorf("error reading file at '%s': %w", file, err)
 	}
 
-	var cfg config
-	err = yaml.Unmarshal(data, &cfg)
-	if err != nil {
+	cfg := Default()
+
+	// Unknown keys are rejected so that a misspelled key is not silently
+	// replaced by its default value.
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
 		return nil, fmt.Errorf("error unmarshalling YAML from file '%s': %w", file, err)
 	}
 
-	// Initialize a new cache
-	NewCache()
+	errs := cfg.applyEnv(os.LookupEnv)
+	errs = append(errs, cfg.applyFlags()...)
+	errs = append(errs, cfg.validate()...)
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
 
-	// Cache the database config values
-	SetCache(DBHostKey, cfg.DatabaseHost())
-	SetCache(DBPortKey, cfg.DatabasePort())
-	SetCache(DBNameKey, cfg.DatabaseName())
-	SetCache(DBUserKey, cfg.DatabaseUser())
-	SetCache(DBPasswordKey, cfg.DatabasePassword())
+	current.Store(cfg)
 
-	return &cfg, nil
+	return cfg, nil
 }
 
-// AppName returns the application name from the configuration. It uses
-// default value if the application name is not provided in the configuration.
-func (cfg config) AppName() string {
-	if strings.TrimSpace(cfg.Application.Name) == "" {
-		cfg.Application.Name = DefaultApplicationName
+// AppName returns the application name.
+func (cfg *Config) AppName() string { return cfg.Application.Name }
+
+// DatabaseDriver returns the driver of the database: 'mysql', 'sqlite' or
+// 'postgres'.
+func (cfg *Config) DatabaseDriver() string { return cfg.Driver }
+
+// Database returns the settings of the MySQL connection.
+func (cfg *Config) Database() MySQL { return cfg.MySQL }
+
+// DatabaseName returns the name of the database: the MySQL or PostgreSQL
+// database, or the path of the SQLite database file.
+func (cfg *Config) DatabaseName() string {
+	switch cfg.Driver {
+	case DriverSQLite:
+		return cfg.SQLite.Path
+
+	case DriverPostgres:
+		return cfg.Postgres.DatabaseName
 	}
 
-	return cfg.Application.Name
+	return cfg.MySQL.DatabaseName
 }
 
-// DatabaseHost returns the MySQL database host from the configuration.
-// It uses default value (127.0.0.1) if the database host is not provided
-// in the configuration.
-func (cfg config) DatabaseHost() string {
-	if strings.TrimSpace(cfg.MySQL.Host) == "" {
-		cfg.MySQL.Host = DefaultDatabaseHost
-	}
+// SQLiteDatabase returns the settings of the SQLite connection.
+func (cfg *Config) SQLiteDatabase() SQLite { return cfg.SQLite }
+
+// PostgresDatabase returns the settings of the PostgreSQL connection.
+func (cfg *Config) PostgresDatabase() Postgres { return cfg.Postgres }
+
+// ValuationMethod returns the inventory valuation method, either 'fifo' or
+// 'average'.
+func (cfg *Config) ValuationMethod() string { return cfg.Application.ValuationMethod }
 
-	return cfg.MySQL.Host
+// IdempotencyKeyTTL returns how long the response to a request with an
+// 'Idempotency-Key' can be replayed.
+func (cfg *Config) IdempotencyKeyTTL() time.Duration {
+	return time.Duration(cfg.Application.IdempotencyKey.TTL)
 }
 
-// DatabasePort returns the MySQL database port from the configuration.
-// It uses default value (3306) if the database port is not provided in
-// the configuration.
-func (cfg config) DatabasePort() string {
-	if strings.TrimSpace(cfg.MySQL.Port) == "" {
-		cfg.MySQL.Port = DefaultDatabasePort
-	}
+// IdempotencyKeyPurgeInterval returns how often the expired idempotency keys
+// are deleted.
+func (cfg *Config) IdempotencyKeyPurgeInterval() time.Duration {
+	return time.Duration(cfg.Application.IdempotencyKey.PurgeInterval)
+}
+
+// LowStockThreshold returns the quantity at or below which an item is low on
+// stock.
+func (cfg *Config) LowStockThreshold() int { return cfg.Application.LowStockThreshold }
 
-	return cfg.MySQL.Port
+// RequireIfMatch returns whether the requests that change or delete a user,
+// role, item, storage or unit of measurement must have an 'If-Match' header.
+func (cfg *Config) RequireIfMatch() bool { return cfg.Application.RequireIfMatch }
+
+// WebhookPollInterval returns how often the outbox is polled for events to
+// deliver.
+func (cfg *Config) WebhookPollInterval() time.Duration {
+	return time.Duration(cfg.Application.Webhook.PollInterval)
 }
 
-// DatabaseName returns the MySQL database name from the configuration.
-// It uses default value (wim_db) if the database name is not provided
-// in the configuration.
-func (cfg config) DatabaseName() string {
-	if strings.TrimSpace(cfg.MySQL.DatabaseName) == "" {
-		cfg.MySQL.DatabaseName = DefaultDatabaseName
-	}
+// WebhookMaxAttempts returns how many times the delivery of an event is
+// attempted before it is dead.
+func (cfg *Config) WebhookMaxAttempts() int { return cfg.Application.Webhook.MaxAttempts }
 
-	return cfg.MySQL.DatabaseName
+// WebhookBackoff returns the delay after the first failed delivery attempt,
+// which doubles on every attempt.
+func (cfg *Config) WebhookBackoff() time.Duration {
+	return time.Duration(cfg.Application.Webhook.Backoff)
 }
 
-// DatabaseUser returns the MySQL database username. It uses default
-// value (root) if the username is not provided in the configuration.
-func (cfg config) DatabaseUser() string {
-	if strings.TrimSpace(cfg.MySQL.Username) == "" {
-		cfg.MySQL.Username = DefaultDatabaseUser
-	}
+// WebhookMaxBackoff returns the maximum delay between two delivery attempts.
+func (cfg *Config) WebhookMaxBackoff() time.Duration {
+	return time.Duration(cfg.Application.Webhook.MaxBackoff)
+}
 
-	return cfg.MySQL.Username
+// WebhookTimeout returns the timeout of a delivery attempt.
+func (cfg *Config) WebhookTimeout() time.Duration {
+	return time.Duration(cfg.Application.Webhook.Timeout)
 }
 
-// DatabasePassword returns the MySQL database password from the configuration.
-func (cfg config) DatabasePassword() string {
-	return cfg.MySQL.Password
+// EventStreamPollInterval returns how often the outbox is polled for events to
+// stream.
+func (cfg *Config) EventStreamPollInterval() time.Duration {
+	return time.Duration(cfg.Application.EventStream.PollInterval)
 }
 
-// ServerAddress returns the server address in the format "host:port".
-// It uses default values if the host or port is not provided in the configuration.
-func (cfg config) ServerAddress() string {
-	host := cfg.Application.Host
-	port := cfg.Application.Port
+// EventStreamBufferSize returns how many of the latest events are kept to
+// resume a stream from.
+func (cfg *Config) EventStreamBufferSize() int { return cfg.Application.EventStream.BufferSize }
+
+// EventStreamClientBuffer returns how many events are queued for a client
+// before it is dropped.
+func (cfg *Config) EventStreamClientBuffer() int { return cfg.Application.EventStream.ClientBuffer }
+
+// EventStreamHeartbeat returns how often an idle stream is kept alive.
+func (cfg *Config) EventStreamHeartbeat() time.Duration {
+	return time.Duration(cfg.Application.EventStream.Heartbeat)
+}
+
+// EventStreamCommitTimeout returns how long an event ID that is skipped is
+// waited for before its transaction is taken to be rolled back.
+func (cfg *Config) EventStreamCommitTimeout() time.Duration {
+	return time.Duration(cfg.Application.EventStream.CommitTimeout)
+}
 
-	switch {
-	case host != "" && port != "":
-		return fmt.Sprintf("%s:%s", host, port)
+// ShutdownDrainDelay returns how long the server keeps accepting requests after
+// the readiness probe starts failing, for the load balancers to notice.
+func (cfg *Config) ShutdownDrainDelay() time.Duration {
+	return time.Duration(cfg.Application.Shutdown.DrainDelay)
+}
+
+// ShutdownHTTPTimeout returns how long the in-flight HTTP requests can take to
+// finish on shutdown.
+func (cfg *Config) ShutdownHTTPTimeout() time.Duration {
+	return time.Duration(cfg.Application.Shutdown.HTTPTimeout)
+}
+
+// ShutdownWorkersTimeout returns how long the background workers can take to
+// stop on shutdown.
+func (cfg *Config) ShutdownWorkersTimeout() time.Duration {
+	return time.Duration(cfg.Application.Shutdown.WorkersTimeout)
+}
+
+// ShutdownDatabaseTimeout returns how long the database pool can take to close
+// on shutdown.
+func (cfg *Config) ShutdownDatabaseTimeout() time.Duration {
+	return time.Duration(cfg.Application.Shutdown.DatabaseTimeout)
+}
+
+// PasswordPolicy returns what the password of a user must have.
+func (cfg *Config) PasswordPolicy() PasswordPolicy { return cfg.Application.PasswordPolicy }
 
-	case host == "" && port != "":
-		return fmt.Sprintf("%s:%s", DefaultIP, port)
+// InviteTTL returns how long the token to accept an invite can be used.
+func (cfg *Config) InviteTTL() time.Duration { return time.Duration(cfg.Application.Account.InviteTTL) }
 
-	case host != "" && port == "":
-		return fmt.Sprintf("%s:%s", host, DefaultPort)
+// VerificationTTL returns how long the token to verify an email can be used.
+func (cfg *Config) VerificationTTL() time.Duration {
+	return time.Duration(cfg.Application.Account.VerificationTTL)
+}
 
-	default:
-		return fmt.Sprintf("%s:%s", DefaultIP, DefaultPort)
+// ResetTTL returns how long the token to reset a password can be used.
+func (cfg *Config) ResetTTL() time.Duration { return time.Duration(cfg.Application.Account.ResetTTL) }
+
+// AccountLinkURL returns the page of the client that the account emails link
+// to, or an empty string if they only have the token.
+func (cfg *Config) AccountLinkURL() string { return cfg.Application.Account.LinkURL }
+
+// Mail returns the options of the sender of the emails.
+func (cfg *Config) Mail() mail.Options {
+	smtp := cfg.Application.Mail.SMTP
+
+	return mail.Options{
+		Sender: cfg.Application.Mail.Sender,
+		From:   cfg.Application.Mail.From,
+		Path:   cfg.Application.Mail.Path,
+		SMTP: mail.SMTPOptions{
+			Host:     smtp.Host,
+			Port:     smtp.Port,
+			Username: smtp.Username,
+			Password: smtp.Password,
+			TLS:      smtp.TLS,
+			Timeout:  time.Duration(smtp.Timeout),
+		},
 	}
 }
 
-func (cfg config) Role() []string {
+// ServerAddress returns the server address in the format "host:port".
+func (cfg *Config) ServerAddress() string {
+	return fmt.Sprintf("%s:%d", cfg.Application.Host, cfg.Application.Port)
+}
+
+func (cfg *Config) Role() []string {
 	return cfg.Application.Role
 }
 
-func (cfg config) Currency() []Currency {
+func (cfg *Config) Currency() []Currency {
 	return cfg.Application.Currency
 }
 
-func (cfg config) UnitOfMeasurement() []UnitOfMeasurement {
+func (cfg *Config) UnitOfMeasurement() []UnitOfMeasurement {
 	return cfg.Application.UnitOfMeasurement
 }
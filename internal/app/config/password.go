@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	hashing "github.com/rmarasigan/warehouse-inventory-management/internal/utils/password"
+)
+
+// Check returns an error that lists what the password is missing, or nil if it
+// follows the policy.
+func (p PasswordPolicy) Check(password string) error {
+	var missing []string
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		missing = append(missing, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+
+	// The bytes after the maximum length would not be part of the hash.
+	if len(password) > hashing.MaxLength {
+		missing = append(missing, fmt.Sprintf("at most %d bytes", hashing.MaxLength))
+	}
+
+	classes := []struct {
+		required bool
+		name     string
+		is       func(rune) bool
+	}{
+		{p.RequireUpper, "an uppercase letter", unicode.IsUpper},
+		{p.RequireLower, "a lowercase letter", unicode.IsLower},
+		{p.RequireDigit, "a digit", unicode.IsDigit},
+		{p.RequireSymbol, "a symbol", func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) }},
+	}
+
+	for _, class := range classes {
+		if class.required && !strings.ContainsFunc(password, class.is) {
+			missing = append(missing, class.name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the password must have %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables that override the
+// configuration, e.g. 'WIM_MYSQL_PASSWORD' overrides 'mysql.password'. With the
+// 'FileSuffix', e.g. 'WIM_MYSQL_PASSWORD_FILE', the value is read from the file
+// it names instead, which is how secrets are usually mounted.
+const (
+	EnvPrefix  string = "WIM_"
+	FileSuffix string = "_FILE"
+)
+
+// redacted replaces the value of a secret when the configuration is printed.
+const redacted string = "[REDACTED]"
+
+// setting is a value of the configuration that can be set from an environment
+// variable or a flag, named by the path of its YAML keys (e.g. 'mysql.port').
+type setting struct {
+	key    string
+	value  reflect.Value
+	secret bool
+}
+
+// env returns the name of the environment variable of the setting.
+func (s setting) env() string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_").Replace(s.key))
+}
+
+// set parses the value into the setting. A list of strings is separated by
+// commas.
+func (s setting) set(value string) error {
+	if duration, ok := s.value.Addr().Interface().(*Duration); ok {
+		return duration.Set(value)
+	}
+
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(value)
+
+	case reflect.Int:
+		number, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid integer '%s'", value)
+		}
+
+		s.value.SetInt(int64(number))
+
+	case reflect.Bool:
+		boolean, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid boolean '%s'", value)
+		}
+
+		s.value.SetBool(boolean)
+
+	case reflect.Slice:
+		var list []string
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+
+		s.value.Set(reflect.ValueOf(list))
+	}
+
+	return nil
+}
+
+// settings returns the values of the configuration that can be set from an
+// environment variable or a flag. The lists of records (e.g. the currencies)
+// can only be set in the file.
+func (cfg *Config) settings() []setting {
+	var list []setting
+
+	var walk func(value reflect.Value, prefix string)
+	walk = func(value reflect.Value, prefix string) {
+		for i := range value.NumField() {
+			field := value.Type().Field(i)
+			key := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+
+			switch {
+			case field.Type == reflect.TypeFor[Duration]():
+				list = append(list, setting{key: key, value: value.Field(i)})
+
+			case field.Type.Kind() == reflect.Struct:
+				walk(value.Field(i), key+".")
+
+			case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() != reflect.String:
+				continue
+
+			default:
+				list = append(list, setting{key: key, value: value.Field(i), secret: field.Tag.Get("secret") == "true"})
+			}
+		}
+	}
+
+	walk(reflect.ValueOf(cfg).Elem(), "")
+
+	return list
+}
+
+// Keys returns the keys of the configuration that can be set with an
+// environment variable or a flag.
+func Keys() []string {
+	var keys []string
+	for _, s := range Default().settings() {
+		keys = append(keys, s.key)
+	}
+
+	return keys
+}
+
+// applyEnv sets the values of the 'WIM_*' environment variables, reading the
+// value of a '_FILE' variable from its file. It returns an error for each value
+// that cannot be set.
+func (cfg *Config) applyEnv(lookup func(string) (string, bool)) []error {
+	var errs []error
+
+	for _, s := range cfg.settings() {
+		name := s.env()
+
+		value, isSet := lookup(name)
+		file, isFile := lookup(name + FileSuffix)
+
+		if isSet && isFile {
+			errs = append(errs, fmt.Errorf("%s: both %s and %s are set", s.key, name, name+FileSuffix))
+			continue
+		}
+
+		if isFile {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: failed to read %s: %w", s.key, name+FileSuffix, err))
+				continue
+			}
+
+			value, isSet = strings.TrimRight(string(data), "\r\n"), true
+		}
+
+		if !isSet {
+			continue
+		}
+
+		err := s.set(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", s.key, name, err))
+		}
+	}
+
+	return errs
+}
+
+// flags are the values set with SetFlag, which override the file and the
+// environment variables.
+var flags = struct {
+	sync.Mutex
+	values [][2]string
+}{}
+
+// SetFlag sets the value of a key of the configuration from a 'key=value'
+// flag. The value is applied over the file and the environment variables when
+// the configuration is loaded.
+func SetFlag(flag string) error {
+	key, value, found := strings.Cut(flag, "=")
+	if !found {
+		return fmt.Errorf("invalid value '%s'; must be key=value", flag)
+	}
+
+	key = strings.TrimSpace(key)
+	if !slices.Contains(Keys(), key) {
+		return fmt.Errorf("unknown configuration key '%s'", key)
+	}
+
+	flags.Lock()
+	defer flags.Unlock()
+
+	flags.values = append(flags.values, [2]string{key, value})
+
+	return nil
+}
+
+// applyFlags sets the values of the flags. It returns an error for each value
+// that cannot be set.
+func (cfg *Config) applyFlags() []error {
+	flags.Lock()
+	defer flags.Unlock()
+
+	var errs []error
+
+	settings := cfg.settings()
+	for _, flag := range flags.values {
+		i := slices.IndexFunc(settings, func(s setting) bool { return s.key == flag[0] })
+
+		err := settings[i].set(flag[1])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: --set: %w", flag[0], err))
+		}
+	}
+
+	return errs
+}
+
+// Redacted returns a copy of the configuration whose secrets are replaced, so
+// that it can be printed.
+func (cfg *Config) Redacted() *Config {
+	clone := *cfg
+	clone.Application.Role = slices.Clone(cfg.Application.Role)
+	clone.Application.Currency = slices.Clone(cfg.Application.Currency)
+	clone.Application.UnitOfMeasurement = slices.Clone(cfg.Application.UnitOfMeasurement)
+
+	for _, s := range clone.settings() {
+		if s.secret && s.value.String() != "" {
+			s.value.SetString(redacted)
+		}
+	}
+
+	return &clone
+}
+
+// YAML returns the configuration, with its secrets redacted, as YAML.
+func (cfg *Config) YAML() ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+
+	err := encoder.Encode(cfg.Redacted())
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
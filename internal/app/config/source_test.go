@@ -0,0 +1,242 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// load writes the YAML to a file and loads it with the environment variables
+// and the '--set' flags.
+func load(t *testing.T, file string, env map[string]string, set ...string) (*Config, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "wim-config.yaml")
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+
+	t.Cleanup(func() { flags.values = nil })
+
+	for _, flag := range set {
+		if err := SetFlag(flag); err != nil {
+			t.Fatalf("SetFlag(%q) error = %v", flag, err)
+		}
+	}
+
+	return Load(path)
+}
+
+func TestLoadLayers(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		set  []string
+		port int
+		host string
+	}{
+		{
+			name: "defaults",
+			port: DefaultDatabasePort,
+			host: DefaultDatabaseHost,
+		},
+		{
+			name: "file over defaults",
+			file: "mysql:\n  port: 3307\n",
+			port: 3307,
+			host: DefaultDatabaseHost,
+		},
+		{
+			name: "environment over file",
+			file: "mysql:\n  port: 3307\n  host: file.internal\n",
+			env:  map[string]string{"WIM_MYSQL_PORT": "3308"},
+			port: 3308,
+			host: "file.internal",
+		},
+		{
+			name: "flag over environment",
+			file: "mysql:\n  port: 3307\n",
+			env:  map[string]string{"WIM_MYSQL_PORT": "3308", "WIM_MYSQL_HOST": "env.internal"},
+			set:  []string{"mysql.port=3309"},
+			port: 3309,
+			host: "env.internal",
+		},
+		{
+			name: "last flag wins",
+			set:  []string{"mysql.port=3309", "mysql.port=3310"},
+			port: 3310,
+			host: DefaultDatabaseHost,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := load(t, test.file, test.env, test.set...)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if cfg.MySQL.Port != test.port || cfg.MySQL.Host != test.host {
+				t.Errorf("mysql = %s:%d, want %s:%d", cfg.MySQL.Host, cfg.MySQL.Port, test.host, test.port)
+			}
+		})
+	}
+}
+
+func TestLoadTypes(t *testing.T) {
+	env := map[string]string{
+		"WIM_APPLICATION_REQUIRE_IF_MATCH":      "true",
+		"WIM_APPLICATION_WEBHOOK_POLL_INTERVAL": "250ms",
+	}
+
+	cfg, err := load(t, "", env, "application.role=admin, clerk ,,viewer")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !cfg.RequireIfMatch() || cfg.WebhookPollInterval() != 250*time.Millisecond {
+		t.Errorf("require_if_match = %t, poll_interval = %s, want true and 250ms", cfg.RequireIfMatch(), cfg.WebhookPollInterval())
+	}
+
+	if roles := strings.Join(cfg.Application.Role, "|"); roles != "admin|clerk|viewer" {
+		t.Errorf("role = %s, want admin|clerk|viewer", roles)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secret, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+		err  string
+	}{
+		{
+			name: "value of the file",
+			env:  map[string]string{"WIM_MYSQL_PASSWORD_FILE": secret},
+			want: "from-file",
+		},
+		{
+			name: "both set",
+			env:  map[string]string{"WIM_MYSQL_PASSWORD": "inline", "WIM_MYSQL_PASSWORD_FILE": secret},
+			err:  "both WIM_MYSQL_PASSWORD and WIM_MYSQL_PASSWORD_FILE are set",
+		},
+		{
+			name: "missing file",
+			env:  map[string]string{"WIM_MYSQL_PASSWORD_FILE": secret + ".missing"},
+			err:  "failed to read WIM_MYSQL_PASSWORD_FILE",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := load(t, "", test.env)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("Load() error = %v, want %q", err, test.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if cfg.MySQL.Password != test.want {
+				t.Errorf("mysql.password = %q, want %q", cfg.MySQL.Password, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	file := "application:\n  port: 0\nmysql:\n  tls:\n    mode: always\n"
+	env := map[string]string{"WIM_MYSQL_PORT": "three", "WIM_APPLICATION_WEBHOOK_TIMEOUT": "soon"}
+
+	_, err := load(t, file, env, "application.require_if_match=maybe")
+	if err == nil {
+		t.Fatal("Load() error = nil, want the invalid values")
+	}
+
+	for _, want := range []string{
+		"mysql.port: WIM_MYSQL_PORT: invalid integer 'three'",
+		"application.webhook.timeout: WIM_APPLICATION_WEBHOOK_TIMEOUT: invalid duration 'soon'",
+		"application.require_if_match: --set: invalid boolean 'maybe'",
+		"application.port: must be between 1 and 65535, got 0",
+		"mysql.tls.mode: must be disabled, preferred, skip-verify or verify-full, got 'always'",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	_, err := load(t, "mysql:\n  prot: 3307\n", nil)
+	if err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Errorf("Load() error = %v, want the unknown key", err)
+	}
+
+	err = SetFlag("mysql.prot=3307")
+	if err == nil || !strings.Contains(err.Error(), "unknown configuration key 'mysql.prot'") {
+		t.Errorf("SetFlag() error = %v, want the unknown key", err)
+	}
+
+	err = SetFlag("mysql.port")
+	if err == nil || !strings.Contains(err.Error(), "must be key=value") {
+		t.Errorf("SetFlag() error = %v, want key=value", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+
+	var secrets []string
+	for _, s := range cfg.settings() {
+		if s.secret {
+			s.value.SetString("hunter2-" + s.key)
+			secrets = append(secrets, s.key)
+		}
+	}
+
+	for _, key := range []string{"mysql.password", "postgres.password", "application.mail.smtp.password"} {
+		if !strings.Contains(strings.Join(secrets, " "), key) {
+			t.Errorf("%s is not a secret", key)
+		}
+	}
+
+	data, err := cfg.YAML()
+	if err != nil {
+		t.Fatalf("YAML() error = %v", err)
+	}
+
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("YAML() = %s, want the secrets redacted", data)
+	}
+
+	if count := strings.Count(string(data), redacted); count != len(secrets) {
+		t.Errorf("YAML() has %d redacted values, want %d", count, len(secrets))
+	}
+
+	if cfg.MySQL.Password != "hunter2-mysql.password" {
+		t.Errorf("mysql.password = %q, want the configuration unchanged", cfg.MySQL.Password)
+	}
+
+	// An empty secret is printed as is, so that it is clear that it is not set.
+	data, err = Default().YAML()
+	if err != nil || strings.Contains(string(data), redacted) {
+		t.Errorf("YAML() = %s, %v, want no redacted value", data, err)
+	}
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/mail"
+)
+
+var (
+	// databaseName is a MySQL database name that can be used without quoting.
+	databaseName = regexp.MustCompile(`^[A-Za-z0-9_$]{1,64}$`)
+
+	// postgresName is a PostgreSQL database name that can be used without
+	// quoting.
+	postgresName = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+	// charsetName is a MySQL character set or collation name.
+	charsetName = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+	// currencyCode is an ISO 4217 currency code.
+	currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// validate returns an error for each invalid value of the configuration.
+func (cfg *Config) validate() []error {
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	app := cfg.Application
+
+	check(strings.TrimSpace(app.Name) != "", "application.name", "cannot be empty")
+	check(app.Port > 0 && app.Port <= 65535, "application.port", "must be between 1 and 65535, got %d", app.Port)
+	check(slices.Contains([]string{"fifo", "average"}, app.ValuationMethod), "application.valuation_method",
+		"must be fifo or average, got '%s'", app.ValuationMethod)
+	check(app.LowStockThreshold >= 0, "application.low_stock_threshold", "cannot be negative, got %d", app.LowStockThreshold)
+
+	positive := func(key string, value Duration) {
+		check(value > 0, key, "must be positive, got %s", value)
+	}
+
+	positive("application.idempotency_key.ttl", app.IdempotencyKey.TTL)
+	positive("application.idempotency_key.purge_interval", app.IdempotencyKey.PurgeInterval)
+
+	positive("application.webhook.poll_interval", app.Webhook.PollInterval)
+	check(app.Webhook.MaxAttempts > 0, "application.webhook.max_attempts", "must be positive, got %d", app.Webhook.MaxAttempts)
+	positive("application.webhook.backoff", app.Webhook.Backoff)
+	positive("application.webhook.max_backoff", app.Webhook.MaxBackoff)
+	check(app.Webhook.MaxBackoff >= app.Webhook.Backoff, "application.webhook.max_backoff",
+		"cannot be less than the backoff (%s), got %s", app.Webhook.Backoff, app.Webhook.MaxBackoff)
+	positive("application.webhook.timeout", app.Webhook.Timeout)
+
+	positive("application.event_stream.poll_interval", app.EventStream.PollInterval)
+	check(app.EventStream.BufferSize > 0, "application.event_stream.buffer_size", "must be positive, got %d", app.EventStream.BufferSize)
+	check(app.EventStream.ClientBuffer > 0, "application.event_stream.client_buffer", "must be positive, got %d", app.EventStream.ClientBuffer)
+	positive("application.event_stream.heartbeat", app.EventStream.Heartbeat)
+	positive("application.event_stream.commit_timeout", app.EventStream.CommitTimeout)
+
+	// A zero timeout waits without a limit, so only the drain delay can be zero.
+	check(app.Shutdown.DrainDelay >= 0, "application.shutdown.drain_delay", "cannot be negative, got %s", app.Shutdown.DrainDelay)
+	positive("application.shutdown.http_timeout", app.Shutdown.HTTPTimeout)
+	positive("application.shutdown.workers_timeout", app.Shutdown.WorkersTimeout)
+	positive("application.shutdown.database_timeout", app.Shutdown.DatabaseTimeout)
+
+	check(app.PasswordPolicy.MinLength > 0, "application.password_policy.min_length", "must be positive, got %d", app.PasswordPolicy.MinLength)
+
+	positive("application.account.invite_ttl", app.Account.InviteTTL)
+	positive("application.account.verification_ttl", app.Account.VerificationTTL)
+	positive("application.account.reset_ttl", app.Account.ResetTTL)
+
+	link, err := url.Parse(app.Account.LinkURL)
+	check(app.Account.LinkURL == "" || err == nil && link.IsAbs() && link.Host != "", "application.account.link_url",
+		"must be an absolute URL, got '%s'", app.Account.LinkURL)
+
+	errs = append(errs, app.Mail.validate()...)
+
+	for i, role := range app.Role {
+		check(strings.TrimSpace(role) != "", fmt.Sprintf("application.role[%d]", i), "cannot be empty")
+	}
+
+	for i, currency := range app.Currency {
+		check(currencyCode.MatchString(currency.Code), fmt.Sprintf("application.currency[%d].code", i),
+			"must be a 3-letter ISO 4217 code, got '%s'", currency.Code)
+	}
+
+	for i, uom := range app.UnitOfMeasurement {
+		check(strings.TrimSpace(uom.Code) != "", fmt.Sprintf("application.unit_of_measurement[%d].code", i), "cannot be empty")
+		check(strings.TrimSpace(uom.Name) != "", fmt.Sprintf("application.unit_of_measurement[%d].name", i), "cannot be empty")
+	}
+
+	check(slices.Contains([]string{DriverMySQL, DriverSQLite, DriverPostgres}, cfg.Driver), "driver",
+		"must be %s, %s or %s, got '%s'", DriverMySQL, DriverSQLite, DriverPostgres, cfg.Driver)
+
+	// Only the settings of the database that is used are validated.
+	switch cfg.Driver {
+	case DriverMySQL:
+		errs = append(errs, cfg.MySQL.validate()...)
+
+	case DriverSQLite:
+		check(strings.TrimSpace(cfg.SQLite.Path) != "", "sqlite.path", "cannot be empty")
+		check(cfg.SQLite.BusyTimeout >= 0, "sqlite.busy_timeout", "cannot be negative, got %s", cfg.SQLite.BusyTimeout)
+
+	case DriverPostgres:
+		errs = append(errs, cfg.Postgres.validate()...)
+	}
+
+	return errs
+}
+
+// validate returns an error for each invalid value of the mail settings. The
+// SMTP server is only validated if the emails are sent through it.
+func (m Mail) validate() []error {
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(slices.Contains([]string{mail.SenderLog, mail.SenderFile, mail.SenderSMTP}, m.Sender), "application.mail.sender",
+		"must be %s, %s or %s, got '%s'", mail.SenderLog, mail.SenderFile, mail.SenderSMTP, m.Sender)
+
+	_, err := netmail.ParseAddress(m.From)
+	check(err == nil, "application.mail.from", "must be an email address, got '%s'", m.From)
+
+	switch m.Sender {
+	case mail.SenderFile:
+		check(strings.TrimSpace(m.Path) != "", "application.mail.path", "cannot be empty")
+
+	case mail.SenderSMTP:
+		check(strings.TrimSpace(m.SMTP.Host) != "", "application.mail.smtp.host", "cannot be empty")
+		check(m.SMTP.Port > 0 && m.SMTP.Port <= 65535, "application.mail.smtp.port", "must be between 1 and 65535, got %d", m.SMTP.Port)
+		check(slices.Contains([]string{mail.TLSNone, mail.TLSStartTLS, mail.TLSImplicit}, m.SMTP.TLS), "application.mail.smtp.tls",
+			"must be %s, %s or %s, got '%s'", mail.TLSNone, mail.TLSStartTLS, mail.TLSImplicit, m.SMTP.TLS)
+		check(m.SMTP.Timeout > 0, "application.mail.smtp.timeout", "must be positive, got %s", m.SMTP.Timeout)
+	}
+
+	return errs
+}
+
+// validate returns an error for each invalid value of the MySQL settings.
+func (db MySQL) validate() []error {
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	positive := func(key string, value Duration) {
+		check(value > 0, key, "must be positive, got %s", value)
+	}
+
+	check(strings.TrimSpace(db.Host) != "", "mysql.host", "cannot be empty")
+	check(db.Port > 0 && db.Port <= 65535, "mysql.port", "must be between 1 and 65535, got %d", db.Port)
+	check(databaseName.MatchString(db.DatabaseName), "mysql.database_name",
+		"must be 1 to 64 letters, digits, '_' or '$', got '%s'", db.DatabaseName)
+	check(strings.TrimSpace(db.Username) != "", "mysql.username", "cannot be empty")
+	check(charsetName.MatchString(db.Charset), "mysql.charset", "must be a MySQL character set, got '%s'", db.Charset)
+	check(db.Collation == "" || charsetName.MatchString(db.Collation), "mysql.collation", "must be a MySQL collation, got '%s'", db.Collation)
+
+	_, err := time.LoadLocation(db.Timezone)
+	check(db.Timezone != "" && err == nil, "mysql.timezone", "must be UTC, Local or an IANA time zone, got '%s'", db.Timezone)
+
+	check(slices.Contains([]string{TLSDisabled, TLSPreferred, TLSSkipVerify, TLSVerifyFull}, db.TLS.Mode), "mysql.tls.mode",
+		"must be %s, %s, %s or %s, got '%s'", TLSDisabled, TLSPreferred, TLSSkipVerify, TLSVerifyFull, db.TLS.Mode)
+	check((db.TLS.CertFile == "") == (db.TLS.KeyFile == ""), "mysql.tls", "cert_file and key_file must be given together")
+	check(db.TLS.Mode != TLSDisabled || db.TLS.CAFile == "" && db.TLS.CertFile == "", "mysql.tls",
+		"ca_file, cert_file and key_file require a mode other than %s", TLSDisabled)
+
+	positive("mysql.timeout.connect", db.Timeout.Connect)
+	check(db.Timeout.Read >= 0, "mysql.timeout.read", "cannot be negative, got %s", db.Timeout.Read)
+	check(db.Timeout.Write >= 0, "mysql.timeout.write", "cannot be negative, got %s", db.Timeout.Write)
+
+	errs = append(errs, db.Pool.validate("mysql.pool")...)
+	errs = append(errs, db.Retry.validate("mysql.retry")...)
+
+	return errs
+}
+
+// validate returns an error for each invalid value of the PostgreSQL settings.
+func (db Postgres) validate() []error {
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(strings.TrimSpace(db.Host) != "", "postgres.host", "cannot be empty")
+	check(db.Port > 0 && db.Port <= 65535, "postgres.port", "must be between 1 and 65535, got %d", db.Port)
+	check(postgresName.MatchString(db.DatabaseName), "postgres.database_name",
+		"must be 1 to 63 lowercase letters, digits or '_', starting with a letter or '_', got '%s'", db.DatabaseName)
+	check(strings.TrimSpace(db.Username) != "", "postgres.username", "cannot be empty")
+	check(slices.Contains([]string{SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull}, db.SSLMode), "postgres.sslmode",
+		"must be %s, %s, %s or %s, got '%s'", SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull, db.SSLMode)
+	check(db.SSLMode != SSLModeDisable || db.SSLRootCert == "", "postgres.sslrootcert", "requires a sslmode other than %s", SSLModeDisable)
+	check(db.ConnectTimeout >= Duration(time.Second), "postgres.connect_timeout", "must be at least 1s, got %s", db.ConnectTimeout)
+
+	errs = append(errs, db.Pool.validate("postgres.pool")...)
+	errs = append(errs, db.Retry.validate("postgres.retry")...)
+
+	return errs
+}
+
+// validate returns an error for each invalid value of the pool settings under
+// the key.
+func (pool MySQLPool) validate(key string) []error {
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(pool.MaxOpenConns >= 0, key+".max_open_conns", "cannot be negative, got %d", pool.MaxOpenConns)
+	check(pool.MaxIdleConns >= 0, key+".max_idle_conns", "cannot be negative, got %d", pool.MaxIdleConns)
+	check(pool.MaxOpenConns == 0 || pool.MaxIdleConns <= pool.MaxOpenConns, key+".max_idle_conns",
+		"cannot be more than max_open_conns (%d), got %d", pool.MaxOpenConns, pool.MaxIdleConns)
+	check(pool.ConnMaxLifetime >= 0, key+".conn_max_lifetime", "cannot be negative, got %s", pool.ConnMaxLifetime)
+	check(pool.ConnMaxIdleTime >= 0, key+".conn_max_idle_time", "cannot be negative, got %s", pool.ConnMaxIdleTime)
+
+	return errs
+}
+
+// validate returns an error for each invalid value of the retry settings under
+// the key.
+func (retry MySQLRetry) validate(key string) []error {
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(retry.Attempts > 0, key+".attempts", "must be positive, got %d", retry.Attempts)
+	check(retry.Backoff > 0, key+".backoff", "must be positive, got %s", retry.Backoff)
+	check(retry.MaxBackoff >= retry.Backoff, key+".max_backoff",
+		"cannot be less than the backoff (%s), got %s", retry.Backoff, retry.MaxBackoff)
+
+	return errs
+}
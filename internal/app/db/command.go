@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// Migrate runs a 'migrate' command (up, down, status or redo) against the
+// database of the configuration file.
+func Migrate(file string, args ...string) error {
+	log.Init()
+
+	if len(args) == 0 {
+		return errors.New("missing migrate command; must be one of up, down, status, redo")
+	}
+
+	ctx := context.Background()
+
+	cfg, err := config.Load(file)
+	if err != nil {
+		return err
+	}
+
+	db, err := connect(cfg, cfg.DatabaseName())
+	if err != nil {
+		return err
+	}
+	defer closeConnection(db)
+
+	switch args[0] {
+	case "up":
+		count, err := MigrateUp(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		trail.OK("Applied %d migration(s).", count)
+
+	case "down":
+		n := 1
+
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid number of migrations '%s'; must be a positive integer", args[1])
+			}
+		}
+
+		count, err := MigrateDown(ctx, db, n)
+		if err != nil {
+			return err
+		}
+
+		trail.OK("Reverted %d migration(s).", count)
+
+	case "status":
+		list, err := ListMigrationStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+
+		for _, status := range list {
+			appliedAt := "-"
+			if status.Applied != nil {
+				appliedAt = status.Applied.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Fprintf(writer, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt)
+		}
+
+		return writer.Flush()
+
+	case "redo":
+		return MigrateRedo(ctx, db)
+
+	default:
+		return fmt.Errorf("unknown migrate command '%s'; must be one of up, down, status, redo", args[0])
+	}
+
+	return nil
+}
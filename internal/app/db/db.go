@@ -5,36 +5,65 @@ import (
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/postgres"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/sqlite"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
 )
 
-func database(ctx context.Context, db *sqlx.DB, name string) error {
-	trail.Info("Initializing database...")
-	_, err := db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s;", name))
-	if err != nil {
-		trail.Error("failed to create database: %s", name)
-		return err
+// connect connects to the database of the configuration with the same
+// connection options as the server. For MySQL and PostgreSQL, the database is
+// selected only if a name is given, so that it can be created first.
+func connect(cfg *config.Config, name string) (*sqlx.DB, error) {
+	var (
+		db  *sqlx.DB
+		err error
+	)
+
+	switch cfg.DatabaseDriver() {
+	case config.DriverSQLite:
+		db, err = sqlite.Open(cfg.SQLiteDatabase())
+
+	case config.DriverPostgres:
+		db, err = postgres.Open(cfg.PostgresDatabase(), name)
+
+	default:
+		db, err = mysql.Open(cfg.Database(), name)
 	}
 
-	_, err = db.ExecContext(ctx, fmt.Sprintf("USE %s;", name))
 	if err != nil {
-		trail.Error("failed using database: %s", name)
-		return err
+		trail.Warn("failed to create connection to database")
+		return nil, err
 	}
 
-	trail.OK("Successfully created %s database...", name)
+	return db, nil
+}
 
-	return nil
+func closeConnection(db *sqlx.DB) {
+	err := db.Close()
+	if err != nil {
+		log.Error(err, "failed to close database connection")
+	}
 }
 
-func tables(ctx context.Context, db *sqlx.DB, name, query string) error {
-	_, err := db.ExecContext(ctx, query)
+func database(ctx context.Context, db *sqlx.DB, name string) error {
+	trail.Info("Initializing database...")
+
+	var err error
+	if db.DriverName() == postgres.DriverName {
+		err = postgres.CreateDatabase(ctx, db, name)
+	} else {
+		_, err = db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s;", name))
+	}
+
 	if err != nil {
-		trail.Warn("failed to create table: %s", name)
+		trail.Error("failed to create database: %s", name)
 		return err
 	}
 
-	trail.OK("Successfully created %s table...", name)
+	trail.OK("Successfully created %s database...", name)
 
 	return nil
 }
@@ -2,7 +2,6 @@ package db
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
@@ -12,63 +11,79 @@ import (
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
 )
 
-func Initialize() {
+// Initialize creates the database if it does not exist, applies the pending
+// schema migrations of its driver and seeds the roles, units of measurement
+// and currencies of the configuration file.
+func Initialize(file string) error {
 	log.Init()
-	defer log.Panic()
 
 	ctx := context.Background()
 
 	// Load application configuration from YAML file.
-	cfg, err := config.Load("wim-config.yaml")
+	cfg, err := config.Load(file)
 	if err != nil {
 		trail.Warn("failed to load app configuration")
-		panic(err)
+		return err
 	}
 
-	// Build DSN (Data Source Name) to connect to MySQL server without selecting a database yet.
-	// We leave the database empty (trailing '/') so we can create it if it doesn't exist.
-	//
-	// DSN Options:
-	//  - charset=utf8mb4: Ensures proper UTF-8 support, including emojis and special characters.
-	//  - parseTime=True: Automatically converts MySQL DATE, DATETIME, and TIMESTAMP columns into Go's time.Time.
-	//  - loc=UTC: Interprets MySQL datetime values in UTC for consistency across servers and environments.
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/?charset=utf8mb4&parseTime=True&loc=UTC",
-		cfg.DatabaseUser(),
-		cfg.DatabasePassword(),
-		cfg.DatabaseHost(),
-		cfg.DatabasePort(),
-	)
-
-	// Connect to the MySQL server.
-	db, err := sqlx.Connect("mysql", dsn)
-	if err != nil {
-		trail.Warn("failed to create connection to database")
-		panic(err)
-	}
-	defer func() {
-		err := db.Close()
+	// A SQLite database file is created when it is opened, whereas a MySQL or
+	// PostgreSQL database is created on the server first.
+	if cfg.DatabaseDriver() != config.DriverSQLite {
+		// Connect to the server without selecting a database yet, so that it
+		// can be created if it doesn't exist.
+		server, err := connect(cfg, "")
+		if err != nil {
+			return err
+		}
+
+		// Create the database if it doesn't exist.
+		err = database(ctx, server, cfg.DatabaseName())
+		closeConnection(server)
+
 		if err != nil {
-			log.Error(err, "failed to close database connection")
+			return err
 		}
-	}()
+	}
+
+	db, err := connect(cfg, cfg.DatabaseName())
+	if err != nil {
+		return err
+	}
+	defer closeConnection(db)
 
-	// Create the database if it doesn't exist.
-	err = database(ctx, db, cfg.DatabaseName())
+	// Create the tables and apply the schema changes that are not applied yet.
+	count, err := MigrateUp(ctx, db)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	// Create all tables in the correct order to satisfy foreign key constraints.
-	for _, tableName := range tablesOrder {
-		query := databaseTables[tableName]
+	trail.OK("Applied %d migration(s).", count)
 
-		// Each table is created if it doesn't already exist.
-		err := tables(ctx, db, tableName, query)
-		if err != nil {
-			panic(err)
-		}
+	return seed(db, cfg)
+}
+
+// Seed inserts the roles, units of measurement and currencies of the
+// configuration file that do not exist yet into the migrated database.
+func Seed(file string) error {
+	log.Init()
+
+	cfg, err := config.Load(file)
+	if err != nil {
+		return err
 	}
 
+	db, err := connect(cfg, cfg.DatabaseName())
+	if err != nil {
+		return err
+	}
+	defer closeConnection(db)
+
+	return seed(db, cfg)
+}
+
+// seed inserts the roles, units of measurement and currencies of the
+// configuration that do not exist yet.
+func seed(db *sqlx.DB, cfg *config.Config) error {
 	// Insert roles from configuration (array of strings)
 	if len(cfg.Role()) > 0 {
 		for _, roleName := range cfg.Role() {
@@ -76,7 +91,7 @@ func Initialize() {
 
 			err := insert(db, role, roleInsert, "role")
 			if err != nil {
-				panic(err)
+				return err
 			}
 		}
 
@@ -96,7 +111,7 @@ func Initialize() {
 
 		err := insertList(db, list, uomInsert, "unit_of_measurement")
 		if err != nil {
-			panic(err)
+			return err
 		}
 	}
 
@@ -114,7 +129,9 @@ func Initialize() {
 
 		err := insertList(db, list, currencyInsert, "currency")
 		if err != nil {
-			panic(err)
+			return err
 		}
 	}
+
+	return nil
 }
@@ -0,0 +1,541 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/postgres"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/sqlite"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// migrationFiles are the migrations embedded in the binary, in a directory per
+// database driver (e.g. 'migrations/sqlite'). Each migration is a pair of
+// files, 'NNNN_name.up.sql' and 'NNNN_name.down.sql', where NNNN is its
+// version. The statements of a file are separated by a semicolon at the end of
+// a line, or by a line with only 'END;' in a trigger.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationsTable keeps the migrations that were applied to the database.
+const migrationsTable string = "schema_migrations"
+
+// migrationName matches the file name of a migration.
+var migrationName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// States of a migration.
+const (
+	MigrationApplied  string = "applied"
+	MigrationPending  string = "pending"
+	MigrationDirty    string = "dirty"
+	MigrationModified string = "modified"
+	MigrationUnknown  string = "unknown"
+)
+
+// Migration is a schema migration embedded in the binary. Its checksum is the
+// SHA-256 of the up migration, so that a migration that is changed after it
+// was applied is detected.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus is the state of a migration in the database.
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	State   string
+	Applied *schema.Migration
+}
+
+// Migrations returns the embedded migrations of the database driver, ordered
+// by version.
+func Migrations(driver string) ([]Migration, error) {
+	dir := path.Join("migrations", driver)
+
+	files, err := fs.Glob(migrationFiles, path.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no migrations of the '%s' driver", driver)
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, file := range files {
+		base := path.Base(file)
+
+		match := migrationName.FindStringSubmatch(base)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name '%s'; must be 'NNNN_name.up.sql' or 'NNNN_name.down.sql'", base)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid version of migration '%s'", base)
+		}
+
+		data, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrations '%s' and '%s' have the same version", migration.Name, match[2])
+		}
+
+		switch match[3] {
+		case "up":
+			sum := sha256.Sum256(data)
+
+			migration.Up = string(data)
+			migration.Checksum = hex.EncodeToString(sum[:])
+
+		case "down":
+			migration.Down = string(data)
+		}
+	}
+
+	var migrations []Migration
+
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up migration", migration.Version, migration.Name)
+		}
+
+		if migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s has no down migration", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+
+	return migrations, nil
+}
+
+func migrationsByVersion(driver string) (map[int64]Migration, error) {
+	migrations, err := Migrations(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	return byVersion, nil
+}
+
+// Status returns the state of every embedded migration of the database driver
+// and of the applied migrations that are not embedded (e.g. applied by a newer
+// release).
+func Status(driver string, applied []schema.Migration) ([]MigrationStatus, error) {
+	migrations, err := Migrations(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[int64]schema.Migration, len(applied))
+	for _, record := range applied {
+		records[record.Version] = record
+	}
+
+	var list []MigrationStatus
+
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, State: MigrationPending}
+
+		record, exists := records[migration.Version]
+		if exists {
+			status.Applied = &record
+			delete(records, migration.Version)
+
+			switch {
+			case record.Dirty:
+				status.State = MigrationDirty
+
+			case record.Checksum != migration.Checksum:
+				status.State = MigrationModified
+
+			default:
+				status.State = MigrationApplied
+			}
+		}
+
+		list = append(list, status)
+	}
+
+	for _, record := range records {
+		list = append(list, MigrationStatus{Version: record.Version, Name: record.Name, State: MigrationUnknown, Applied: &record})
+	}
+
+	slices.SortFunc(list, func(a, b MigrationStatus) int { return cmp.Compare(a.Version, b.Version) })
+
+	return list, nil
+}
+
+// Verify returns an error unless every embedded migration was applied cleanly,
+// so that the server does not start on a schema it does not expect.
+func Verify(driver string, applied []schema.Migration) error {
+	list, err := Status(driver, applied)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, status := range list {
+		name := fmt.Sprintf("%04d_%s", status.Version, status.Name)
+
+		switch status.State {
+		case MigrationPending:
+			errs = append(errs, fmt.Errorf("migration %s is pending; run 'db migrate up'", name))
+
+		case MigrationDirty:
+			errs = append(errs, fmt.Errorf("migration %s is dirty; repair the schema by hand and clear its 'dirty' flag in %s", name, migrationsTable))
+
+		case MigrationModified:
+			errs = append(errs, fmt.Errorf("migration %s was changed after it was applied", name))
+
+		case MigrationUnknown:
+			errs = append(errs, fmt.Errorf("migration %s is not known to this release", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CheckMigrations returns an error unless all the migrations of this release
+// were applied cleanly to the database of the application's connection.
+func CheckMigrations(ctx context.Context) error {
+	applied, err := mysql.ListMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the schema migrations: %w", err)
+	}
+
+	return Verify(config.Get().DatabaseDriver(), applied)
+}
+
+// MigrateUp applies the pending migrations in order. It returns the number of
+// migrations that were applied. It refuses to run on a dirty or modified
+// schema.
+func MigrateUp(ctx context.Context, db *sqlx.DB) (int, error) {
+	list, err := ListMigrationStatus(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkClean(list)
+	if err != nil {
+		return 0, err
+	}
+
+	migrations, err := migrationsByVersion(dialect(db))
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+
+	for _, status := range list {
+		if status.State != MigrationPending {
+			continue
+		}
+
+		err := up(ctx, db, migrations[status.Version])
+		if err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// MigrateDown reverts the latest n applied migrations, newest first. It
+// returns the number of migrations that were reverted.
+func MigrateDown(ctx context.Context, db *sqlx.DB, n int) (int, error) {
+	list, err := ListMigrationStatus(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkClean(list)
+	if err != nil {
+		return 0, err
+	}
+
+	migrations, err := migrationsByVersion(dialect(db))
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+
+	for i := len(list) - 1; i >= 0 && count < n; i-- {
+		if list[i].State != MigrationApplied {
+			continue
+		}
+
+		err := down(ctx, db, migrations[list[i].Version])
+		if err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// MigrateRedo reverts the latest applied migration and applies it again.
+func MigrateRedo(ctx context.Context, db *sqlx.DB) error {
+	list, err := ListMigrationStatus(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	err = checkClean(list)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := migrationsByVersion(dialect(db))
+	if err != nil {
+		return err
+	}
+
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i].State != MigrationApplied {
+			continue
+		}
+
+		migration := migrations[list[i].Version]
+
+		err := down(ctx, db, migration)
+		if err != nil {
+			return err
+		}
+
+		return up(ctx, db, migration)
+	}
+
+	return errors.New("no migration has been applied")
+}
+
+// ListMigrationStatus returns the state of the migrations in the database.
+func ListMigrationStatus(ctx context.Context, db *sqlx.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return Status(dialect(db), applied)
+}
+
+// dialect returns the database driver of the connection, as it is named in the
+// configuration.
+func dialect(db *sqlx.DB) string {
+	switch db.DriverName() {
+	case sqlite.DriverName:
+		return config.DriverSQLite
+
+	case postgres.DriverName:
+		return config.DriverPostgres
+	}
+
+	return config.DriverMySQL
+}
+
+// checkClean returns an error if a migration is dirty, was changed after it
+// was applied, or is not known to this release, as running more migrations on
+// such a schema could damage it.
+func checkClean(list []MigrationStatus) error {
+	var errs []error
+
+	for _, status := range list {
+		if status.State != MigrationApplied && status.State != MigrationPending {
+			errs = append(errs, fmt.Errorf("migration %04d_%s is %s", status.Version, status.Name, status.State))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("schema cannot be migrated: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// appliedMigrations retrieves the applied migrations. The table is created if
+// it does not exist yet.
+func appliedMigrations(ctx context.Context, db *sqlx.DB) ([]schema.Migration, error) {
+	var applied []schema.Migration
+
+	query := fmt.Sprintf("SELECT * FROM %s ORDER BY version;", migrationsTable)
+
+	err := db.SelectContext(ctx, &applied, query)
+	if err != nil {
+		if !mysql.IsMissingTable(err) {
+			return nil, err
+		}
+
+		_, err = db.ExecContext(ctx, schemaMigrations)
+		if err != nil {
+			trail.Warn("failed to create table: %s", migrationsTable)
+			return nil, err
+		}
+
+		trail.OK("Successfully created %s table...", migrationsTable)
+	}
+
+	return applied, nil
+}
+
+// up applies the migration. It is recorded as dirty first, since MySQL commits
+// every DDL statement on its own, so that a migration that fails part way
+// through is not applied again on top of the statements that did run.
+func up(ctx context.Context, db *sqlx.DB, migration Migration) error {
+	name := fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+
+	_, err := db.ExecContext(ctx,
+		db.Rebind(fmt.Sprintf("INSERT INTO %s (version, name, checksum, dirty) VALUES (?, ?, ?, TRUE);", migrationsTable)),
+		migration.Version, migration.Name, migration.Checksum,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", name, err)
+	}
+
+	err = execStatements(ctx, db, migration.Up)
+	if err != nil {
+		trail.Error("Migration %s failed and is dirty", name)
+		return fmt.Errorf("failed to apply migration %s: %w", name, err)
+	}
+
+	_, err = db.ExecContext(ctx, db.Rebind(fmt.Sprintf("UPDATE %s SET dirty = FALSE WHERE version = ?;", migrationsTable)), migration.Version)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", name, err)
+	}
+
+	trail.OK("Applied migration %s", name)
+
+	return nil
+}
+
+// down reverts the migration. Like up, it is marked as dirty until all of its
+// statements ran.
+func down(ctx context.Context, db *sqlx.DB, migration Migration) error {
+	name := fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+
+	_, err := db.ExecContext(ctx, db.Rebind(fmt.Sprintf("UPDATE %s SET dirty = TRUE WHERE version = ?;", migrationsTable)), migration.Version)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", name, err)
+	}
+
+	err = execStatements(ctx, db, migration.Down)
+	if err != nil {
+		trail.Error("Migration %s failed and is dirty", name)
+		return fmt.Errorf("failed to revert migration %s: %w", name, err)
+	}
+
+	_, err = db.ExecContext(ctx, db.Rebind(fmt.Sprintf("DELETE FROM %s WHERE version = ?;", migrationsTable)), migration.Version)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", name, err)
+	}
+
+	trail.OK("Reverted migration %s", name)
+
+	return nil
+}
+
+func execStatements(ctx context.Context, db *sqlx.DB, script string) error {
+	for _, statement := range splitStatements(script) {
+		_, err := db.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitStatements splits a script on the semicolons that end a line, except
+// in a body that holds statements of its own: the body of a SQLite trigger,
+// from a line that ends with 'BEGIN' to a line with only 'END;', and a
+// dollar-quoted PostgreSQL function body, between two '$$'. The comment lines
+// are dropped.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    []string
+		trigger    bool
+		quoted     bool
+	)
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current = append(current, line)
+
+		if strings.Count(trimmed, "$$")%2 == 1 {
+			quoted = !quoted
+		}
+
+		var end bool
+
+		switch {
+		case quoted:
+		case trigger:
+			end = strings.EqualFold(trimmed, "END;")
+			trigger = !end
+		case strings.HasSuffix(strings.ToUpper(trimmed), "BEGIN"):
+			trigger = true
+		default:
+			end = strings.HasSuffix(trimmed, ";")
+		}
+
+		if end {
+			statements = append(statements, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+
+	if len(current) > 0 {
+		statements = append(statements, strings.Join(current, "\n"))
+	}
+
+	return statements
+}
@@ -0,0 +1,329 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/sqlite"
+)
+
+var drivers = []string{config.DriverMySQL, config.DriverSQLite, config.DriverPostgres}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "statements and comments",
+			script: "-- A comment; with a semicolon\nCREATE TABLE a (\n    id INT\n);\n\nALTER TABLE a ADD COLUMN b INT;\n",
+			want:   []string{"CREATE TABLE a (\n    id INT\n);", "ALTER TABLE a ADD COLUMN b INT;"},
+		},
+		{
+			name:   "semicolon inside a line",
+			script: "INSERT INTO a (note) VALUES ('x; y');\n",
+			want:   []string{"INSERT INTO a (note) VALUES ('x; y');"},
+		},
+		{
+			name:   "SQLite trigger",
+			script: "CREATE TRIGGER t AFTER UPDATE ON a\nFOR EACH ROW\nBEGIN\n    UPDATE a SET b = 1;\n    UPDATE a SET c = 2;\nEND;\nDROP TABLE b;",
+			want: []string{
+				"CREATE TRIGGER t AFTER UPDATE ON a\nFOR EACH ROW\nBEGIN\n    UPDATE a SET b = 1;\n    UPDATE a SET c = 2;\nEND;",
+				"DROP TABLE b;",
+			},
+		},
+		{
+			name:   "PostgreSQL function",
+			script: "CREATE FUNCTION f() RETURNS TRIGGER AS $$\nBEGIN\n    NEW.b = 1;\n    RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;\nDROP TABLE b;\n",
+			want: []string{
+				"CREATE FUNCTION f() RETURNS TRIGGER AS $$\nBEGIN\n    NEW.b = 1;\n    RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;",
+				"DROP TABLE b;",
+			},
+		},
+		{
+			name:   "last statement without a semicolon",
+			script: "DROP TABLE a;\nDROP TABLE b",
+			want:   []string{"DROP TABLE a;", "DROP TABLE b"},
+		},
+		{
+			name:   "only comments",
+			script: "-- nothing\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := splitStatements(test.script)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	var names []string
+
+	for _, driver := range drivers {
+		migrations, err := Migrations(driver)
+		if err != nil {
+			t.Fatalf("Migrations(%s) error = %v", driver, err)
+		}
+
+		var list []string
+
+		for i, migration := range migrations {
+			if migration.Version != int64(i+1) {
+				t.Errorf("%s: migration %d_%s, want version %d", driver, migration.Version, migration.Name, i+1)
+			}
+
+			if len(migration.Checksum) != 64 || migration.Up == "" || migration.Down == "" {
+				t.Errorf("%s: migration %d_%s has no checksum, up or down", driver, migration.Version, migration.Name)
+			}
+
+			list = append(list, migration.Name)
+		}
+
+		// Every driver has the same migrations, so that a schema is described
+		// by the same versions whichever database it is in.
+		if names == nil {
+			names = list
+		} else if !slices.Equal(list, names) {
+			t.Errorf("%s migrations = %v, want %v", driver, list, names)
+		}
+	}
+
+	_, err := Migrations("oracle")
+	if err == nil {
+		t.Error("Migrations(oracle) error = nil, want no migrations")
+	}
+}
+
+// TestInitialSchema checks that the first migration creates the tables of the
+// release before the migrations were versioned and nothing added since, as it
+// is recorded as applied on a database of that release.
+func TestInitialSchema(t *testing.T) {
+	tables := []string{"role", "users", "unit_of_measurement", "storage", "currency", "item", "transactions", "orderline"}
+	created := regexp.MustCompile(`CREATE TABLE (?:IF NOT EXISTS )?(\w+)`)
+
+	for _, driver := range drivers {
+		migrations, err := Migrations(driver)
+		if err != nil {
+			t.Fatalf("Migrations(%s) error = %v", driver, err)
+		}
+
+		initial := migrations[0]
+
+		var got []string
+		for _, match := range created.FindAllStringSubmatch(initial.Up, -1) {
+			got = append(got, match[1])
+		}
+
+		if !slices.Equal(got, tables) {
+			t.Errorf("%s: %04d_%s creates %v, want %v", driver, initial.Version, initial.Name, got, tables)
+		}
+
+		for _, column := range []string{"sku", "category", "partner", "external_reference", "uom_quantity", "version", "archived_at"} {
+			if regexp.MustCompile(`\b` + column + `\b`).MatchString(initial.Up) {
+				t.Errorf("%s: %04d_%s has the column %s of a later migration", driver, initial.Version, initial.Name, column)
+			}
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	migrations, err := Migrations(config.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied := []schema.Migration{
+		{Version: 1, Name: migrations[0].Name, Checksum: migrations[0].Checksum},
+		{Version: 2, Name: migrations[1].Name, Checksum: "changed"},
+		{Version: 3, Name: migrations[2].Name, Checksum: migrations[2].Checksum, Dirty: true},
+		{Version: 99, Name: "from_a_newer_release", Checksum: "unknown"},
+	}
+
+	list, err := Status(config.DriverSQLite, applied)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	if len(list) != len(migrations)+1 {
+		t.Fatalf("Status() = %d migrations, want %d", len(list), len(migrations)+1)
+	}
+
+	want := map[int64]string{1: MigrationApplied, 2: MigrationModified, 3: MigrationDirty, 4: MigrationPending, 99: MigrationUnknown}
+	for _, status := range list {
+		if state, ok := want[status.Version]; ok && status.State != state {
+			t.Errorf("migration %d = %s, want %s", status.Version, status.State, state)
+		}
+
+		if (status.Applied != nil) != (status.Version <= 3 || status.Version == 99) {
+			t.Errorf("migration %d Applied = %v", status.Version, status.Applied)
+		}
+	}
+
+	if last := list[len(list)-1]; last.Version != 99 || last.Name != "from_a_newer_release" {
+		t.Errorf("last migration = %d_%s, want the unknown migration last", last.Version, last.Name)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	migrations, err := Migrations(config.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var applied []schema.Migration
+	for _, migration := range migrations {
+		applied = append(applied, schema.Migration{Version: migration.Version, Name: migration.Name, Checksum: migration.Checksum})
+	}
+
+	err = Verify(config.DriverSQLite, applied)
+	if err != nil {
+		t.Errorf("Verify() with every migration applied = %v, want nil", err)
+	}
+
+	err = checkClean(mustStatus(t, applied))
+	if err != nil {
+		t.Errorf("checkClean() with every migration applied = %v, want nil", err)
+	}
+
+	broken := slices.Clone(applied[:3])
+	broken[1].Checksum = "changed"
+	broken[2].Dirty = true
+	broken = append(broken, schema.Migration{Version: 99, Name: "from_a_newer_release"})
+
+	err = Verify(config.DriverSQLite, broken)
+	if err == nil {
+		t.Fatal("Verify() = nil, want the pending, modified, dirty and unknown migrations")
+	}
+
+	for _, want := range []string{
+		"migration 0002_" + migrations[1].Name + " was changed after it was applied",
+		"migration 0003_" + migrations[2].Name + " is dirty",
+		"migration 0004_" + migrations[3].Name + " is pending; run 'db migrate up'",
+		"migration 0099_from_a_newer_release is not known to this release",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Verify() = %v, want %q", err, want)
+		}
+	}
+
+	// The pending migrations can be applied, but not on top of a broken one.
+	err = checkClean(mustStatus(t, applied[:3]))
+	if err != nil {
+		t.Errorf("checkClean() with pending migrations = %v, want nil", err)
+	}
+
+	err = checkClean(mustStatus(t, broken))
+	if err == nil || strings.Contains(err.Error(), "pending") || !strings.Contains(err.Error(), "0003_"+migrations[2].Name+" is dirty") {
+		t.Errorf("checkClean() = %v, want the modified, dirty and unknown migrations only", err)
+	}
+}
+
+func mustStatus(t *testing.T, applied []schema.Migration) []MigrationStatus {
+	t.Helper()
+
+	list, err := Status(config.DriverSQLite, applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return list
+}
+
+// TestMigrateSQLite applies the migrations to a database that has the initial
+// schema and an item in stock, reverts them all and applies them again.
+func TestMigrateSQLite(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlite.Open(config.SQLite{Path: filepath.Join(t.TempDir(), "wim.db"), BusyTimeout: config.Duration(time.Second)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migrations, err := Migrations(config.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = appliedMigrations(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = up(ctx, db, migrations[0])
+	if err != nil {
+		t.Fatalf("up(%s) error = %v", migrations[0].Name, err)
+	}
+
+	for _, statement := range []string{
+		"INSERT INTO role (name) VALUES ('admin');",
+		"INSERT INTO users (role_id, first_name, last_name, password) VALUES (1, 'Jane', 'Doe', 'x');",
+		"INSERT INTO unit_of_measurement (code, name) VALUES ('PC', 'Piece');",
+		"INSERT INTO storage (code, name) VALUES ('A1', 'Aisle 1');",
+		"INSERT INTO item (name, quantity, unit_price, uom_id, stock_status, storage_id, created_by) VALUES ('Bolt', 10, 2.5, 1, 'in_stock', 1, 1);",
+	} {
+		_, err = db.Exec(statement)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := MigrateUp(ctx, db)
+	if err != nil || count != len(migrations)-1 {
+		t.Fatalf("MigrateUp() = %d, %v, want %d", count, err, len(migrations)-1)
+	}
+
+	var ledger struct {
+		Quantity int     `db:"quantity"`
+		Amount   float64 `db:"amount"`
+	}
+
+	err = db.Get(&ledger, "SELECT SUM(quantity) AS quantity, SUM(amount) AS amount FROM inventory_ledger WHERE item_id = 1;")
+	if err != nil || ledger.Quantity != 10 || ledger.Amount != 25 {
+		t.Errorf("opening stock = %+v, %v, want 10 units worth 25.00", ledger, err)
+	}
+
+	var item struct {
+		SKU      *string `db:"sku"`
+		Category *string `db:"category"`
+		Version  int     `db:"version"`
+	}
+
+	err = db.Get(&item, "SELECT sku, category, version FROM item WHERE id = 1;")
+	if err != nil || item.SKU != nil || item.Category != nil || item.Version != 1 {
+		t.Errorf("item = %+v, %v, want the added columns", item, err)
+	}
+
+	list, err := ListMigrationStatus(ctx, db)
+	if err != nil || checkClean(list) != nil || list[len(list)-1].State != MigrationApplied {
+		t.Errorf("ListMigrationStatus() after MigrateUp() = %+v, %v, want every migration applied", list, err)
+	}
+
+	count, err = MigrateDown(ctx, db, len(migrations))
+	if err != nil || count != len(migrations) {
+		t.Fatalf("MigrateDown() = %d, %v, want %d", count, err, len(migrations))
+	}
+
+	count, err = MigrateUp(ctx, db)
+	if err != nil || count != len(migrations) {
+		t.Fatalf("MigrateUp() after MigrateDown() = %d, %v, want %d", count, err, len(migrations))
+	}
+
+	err = MigrateRedo(ctx, db)
+	if err != nil {
+		t.Errorf("MigrateRedo() error = %v", err)
+	}
+}
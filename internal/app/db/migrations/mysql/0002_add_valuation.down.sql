@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS inventory_ledger;
+DROP TABLE IF EXISTS cost_layer;
+ALTER TABLE item DROP INDEX idx_category, DROP COLUMN category;
@@ -0,0 +1,46 @@
+-- The inventory valuation: the cost layers of the quantities received at a unit
+-- cost, which the outbound movements consume, and the ledger of the quantity
+-- and value of every movement. The stock of the items that already exist is
+-- recorded as their opening stock at their unit price, and the items can be
+-- grouped by category in the valuation report.
+
+ALTER TABLE item
+    ADD COLUMN category VARCHAR(50) AFTER description,
+    ADD INDEX idx_category (category);
+
+CREATE TABLE cost_layer (
+    id INT NOT NULL AUTO_INCREMENT,
+    item_id INT NOT NULL,
+    orderline_id INT,
+    quantity INT NOT NULL,
+    remaining_quantity INT NOT NULL,
+    unit_cost DECIMAL(14,4) NOT NULL DEFAULT 0.0000,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    INDEX idx_item_remaining (item_id, remaining_quantity),
+    INDEX idx_orderline_id (orderline_id),
+    CONSTRAINT fk_cost_layer_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_cost_layer_orderline FOREIGN KEY (orderline_id) REFERENCES orderline(id)
+);
+
+CREATE TABLE inventory_ledger (
+    id INT NOT NULL AUTO_INCREMENT,
+    item_id INT NOT NULL,
+    storage_id INT NOT NULL,
+    orderline_id INT,
+    quantity INT NOT NULL,
+    amount DECIMAL(14,4) NOT NULL DEFAULT 0.0000,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    INDEX idx_item_date (item_id, date_created),
+    INDEX idx_orderline_id (orderline_id),
+    CONSTRAINT fk_ledger_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_ledger_storage FOREIGN KEY (storage_id) REFERENCES storage(id),
+    CONSTRAINT fk_ledger_orderline FOREIGN KEY (orderline_id) REFERENCES orderline(id)
+);
+
+INSERT INTO cost_layer (item_id, quantity, remaining_quantity, unit_cost)
+SELECT id, quantity, quantity, unit_price FROM item WHERE quantity > 0;
+
+INSERT INTO inventory_ledger (item_id, storage_id, quantity, amount)
+SELECT id, storage_id, quantity, quantity * unit_price FROM item WHERE quantity > 0;
@@ -0,0 +1,3 @@
+ALTER TABLE orderline DROP FOREIGN KEY fk_orderline_uom;
+ALTER TABLE orderline DROP COLUMN uom_quantity, DROP COLUMN uom_id;
+DROP TABLE IF EXISTS uom_conversion;
@@ -0,0 +1,19 @@
+-- The units of measurement that an item can be moved in, by their factor to the
+-- base unit of measurement of the item, and the unit of measurement and
+-- quantity that an orderline was given in.
+
+CREATE TABLE uom_conversion (
+    id INT NOT NULL AUTO_INCREMENT,
+    item_id INT NOT NULL,
+    uom_id INT NOT NULL,
+    factor DECIMAL(18,6) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_item_uom (item_id, uom_id),
+    CONSTRAINT fk_uom_conversion_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_uom_conversion_uom FOREIGN KEY (uom_id) REFERENCES unit_of_measurement(id)
+);
+
+ALTER TABLE orderline
+    ADD COLUMN uom_id INT AFTER quantity,
+    ADD COLUMN uom_quantity INT AFTER uom_id,
+    ADD CONSTRAINT fk_orderline_uom FOREIGN KEY (uom_id) REFERENCES unit_of_measurement(id);
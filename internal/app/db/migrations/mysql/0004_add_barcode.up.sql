@@ -0,0 +1,18 @@
+-- The SKU of the items and the barcodes they are scanned by. A barcode is kept
+-- as a 14-digit GTIN as well, so that it is unique whichever way it is scanned.
+
+ALTER TABLE item ADD COLUMN sku VARCHAR(40) UNIQUE AFTER id;
+
+CREATE TABLE item_barcode (
+    id INT NOT NULL AUTO_INCREMENT,
+    item_id INT NOT NULL,
+    uom_id INT,
+    barcode VARCHAR(14) NOT NULL,
+    gtin CHAR(14) NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_gtin (gtin),
+    INDEX idx_item_id (item_id),
+    CONSTRAINT fk_item_barcode_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_item_barcode_uom FOREIGN KEY (uom_id) REFERENCES unit_of_measurement(id)
+);
@@ -0,0 +1,17 @@
+-- The responses to the requests with an 'Idempotency-Key' header, which are
+-- replayed when the request is retried until they expire.
+
+CREATE TABLE idempotency_key (
+    id INT NOT NULL AUTO_INCREMENT,
+    idempotency_key VARCHAR(255) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT,
+    content_type VARCHAR(100),
+    response_body MEDIUMBLOB,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_key_path (idempotency_key, path),
+    INDEX idx_expires_at (expires_at)
+);
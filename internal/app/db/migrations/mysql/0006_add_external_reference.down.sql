@@ -0,0 +1,5 @@
+ALTER TABLE transactions
+    DROP INDEX idx_external_reference_search,
+    DROP INDEX idx_external_reference,
+    DROP COLUMN external_reference,
+    DROP COLUMN partner;
@@ -0,0 +1,8 @@
+-- The reference of a transaction in the system of its partner (e.g. a purchase
+-- order number), which is unique for the partner and the type of transaction.
+
+ALTER TABLE transactions
+    ADD COLUMN partner VARCHAR(70) NOT NULL DEFAULT '' AFTER reference,
+    ADD COLUMN external_reference VARCHAR(70) AFTER partner,
+    ADD UNIQUE KEY idx_external_reference (partner, type, external_reference),
+    ADD INDEX idx_external_reference_search (external_reference);
@@ -0,0 +1,44 @@
+-- The outbox of the inventory events, written in the same transaction as the
+-- change they describe, and the webhooks they are delivered to.
+
+CREATE TABLE outbox_event (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    event_id CHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    item_id INT,
+    storage_id INT,
+    payload JSON NOT NULL,
+    is_dispatched BOOLEAN NOT NULL DEFAULT FALSE,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_event_id (event_id),
+    INDEX idx_is_dispatched (is_dispatched, id)
+);
+
+CREATE TABLE webhook (
+    id INT NOT NULL AUTO_INCREMENT,
+    url VARCHAR(255) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE webhook_delivery (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    webhook_id INT NOT NULL,
+    event_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    response_status INT,
+    last_error VARCHAR(255),
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_webhook_event (webhook_id, event_id),
+    INDEX idx_status_next_attempt (status, next_attempt_at),
+    CONSTRAINT fk_delivery_webhook FOREIGN KEY (webhook_id) REFERENCES webhook(id) ON DELETE CASCADE,
+    CONSTRAINT fk_delivery_event FOREIGN KEY (event_id) REFERENCES outbox_event(id)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE item DROP COLUMN version;
+ALTER TABLE storage DROP COLUMN version;
+ALTER TABLE unit_of_measurement DROP COLUMN version;
+ALTER TABLE role DROP COLUMN version;
+ALTER TABLE users DROP COLUMN version;
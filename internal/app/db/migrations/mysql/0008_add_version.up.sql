@@ -0,0 +1,9 @@
+-- The version of the records that can be changed through the API. Every update
+-- increments it, and a request with an 'If-Match' header only changes the
+-- record if its version is still the one that the client has seen.
+
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE role ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE unit_of_measurement ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE storage ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE item ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+ALTER TABLE item DROP FOREIGN KEY fk_item_archiver, DROP COLUMN archived_by, DROP COLUMN archived_at;
+ALTER TABLE storage DROP FOREIGN KEY fk_storage_archiver, DROP COLUMN archived_by, DROP COLUMN archived_at;
+ALTER TABLE unit_of_measurement DROP FOREIGN KEY fk_uom_archiver, DROP COLUMN archived_by, DROP COLUMN archived_at;
@@ -0,0 +1,18 @@
+-- The items, storages and units of measurement that are archived rather than
+-- deleted: they are left out of the lists, and an archived item cannot be in a
+-- new transaction, but they are kept for the records that refer to them.
+
+ALTER TABLE unit_of_measurement
+    ADD COLUMN archived_at TIMESTAMP NULL,
+    ADD COLUMN archived_by INT NULL,
+    ADD CONSTRAINT fk_uom_archiver FOREIGN KEY (archived_by) REFERENCES users(id);
+
+ALTER TABLE storage
+    ADD COLUMN archived_at TIMESTAMP NULL,
+    ADD COLUMN archived_by INT NULL,
+    ADD CONSTRAINT fk_storage_archiver FOREIGN KEY (archived_by) REFERENCES users(id);
+
+ALTER TABLE item
+    ADD COLUMN archived_at TIMESTAMP NULL,
+    ADD COLUMN archived_by INT NULL,
+    ADD CONSTRAINT fk_item_archiver FOREIGN KEY (archived_by) REFERENCES users(id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_token;
+ALTER TABLE users DROP COLUMN verified_email;
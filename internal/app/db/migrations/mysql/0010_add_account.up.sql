@@ -0,0 +1,20 @@
+-- The accounts of the users: the email that a user confirmed they own, and the
+-- single-use tokens emailed to accept an invite, verify the email or reset the
+-- password. Only the hash of a token is stored.
+
+ALTER TABLE users ADD COLUMN verified_email VARCHAR(50) NULL;
+
+CREATE TABLE IF NOT EXISTS user_token (
+    id INT NOT NULL AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    purpose VARCHAR(20) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    email VARCHAR(50) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_token_hash (token_hash),
+    INDEX idx_user_purpose (user_id, purpose),
+    CONSTRAINT fk_token_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
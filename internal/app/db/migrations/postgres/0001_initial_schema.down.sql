@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS orderline;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS item;
+DROP TABLE IF EXISTS currency;
+DROP TABLE IF EXISTS storage;
+DROP TABLE IF EXISTS unit_of_measurement;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS role;
+DROP FUNCTION IF EXISTS set_date_modified();
@@ -0,0 +1,141 @@
+-- Tables of the schema in PostgreSQL. The text columns that are looked up by
+-- the values of the requests are CITEXT, so that they compare
+-- case-insensitively like the default collation of MySQL, and the
+-- 'date_modified' columns are kept by triggers in place of 'ON UPDATE
+-- CURRENT_TIMESTAMP'. The timestamps are TIMESTAMPTZ, so that a time of any
+-- offset is compared correctly. The index names are unique in the whole
+-- schema, so they are prefixed with their table.
+
+CREATE EXTENSION IF NOT EXISTS citext;
+
+CREATE OR REPLACE FUNCTION set_date_modified() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.date_modified = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE role (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    name CITEXT NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT role_idx_name UNIQUE (name)
+);
+
+CREATE TABLE users (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    role_id INT NOT NULL,
+    first_name CITEXT NOT NULL,
+    last_name CITEXT NOT NULL,
+    email CITEXT UNIQUE,
+    password VARCHAR(255) NOT NULL,
+    last_login TIMESTAMPTZ NULL,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    is_active BOOLEAN DEFAULT TRUE,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_user_role FOREIGN KEY (role_id) REFERENCES role(id)
+);
+
+CREATE INDEX users_idx_last_name ON users (last_name);
+
+CREATE TABLE unit_of_measurement (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    code CITEXT UNIQUE,
+    name CITEXT NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE storage (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    code CITEXT NOT NULL UNIQUE,
+    name CITEXT NOT NULL,
+    description VARCHAR(50),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX storage_idx_name ON storage (name);
+
+CREATE TABLE currency (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    code CITEXT NOT NULL UNIQUE,
+    symbol VARCHAR(10) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE item (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    name CITEXT NOT NULL,
+    description VARCHAR(100),
+    quantity INT NOT NULL,
+    unit_price DECIMAL(10,2) NOT NULL,
+    uom_id INT NOT NULL,
+    stock_status CITEXT NOT NULL,
+    storage_id INT NOT NULL,
+    created_by INT NOT NULL,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_item_uom FOREIGN KEY (uom_id) REFERENCES unit_of_measurement(id),
+    CONSTRAINT fk_item_storage FOREIGN KEY (storage_id) REFERENCES storage(id),
+    CONSTRAINT fk_item_creator FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX item_idx_uom_id ON item (uom_id);
+CREATE INDEX item_idx_stock_status ON item (stock_status);
+
+CREATE TABLE transactions (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    reference VARCHAR(70) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    type CITEXT NOT NULL,
+    is_cancelled BOOLEAN DEFAULT FALSE,
+    note VARCHAR(255),
+    created_by INT NOT NULL,
+    updated_by INT,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT transactions_idx_reference UNIQUE (reference),
+    CONSTRAINT fk_transaction_creator FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX transactions_idx_created_by ON transactions (created_by);
+CREATE INDEX transactions_idx_updated_by ON transactions (updated_by);
+
+CREATE TABLE orderline (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    transaction_id INT NOT NULL,
+    item_id INT NOT NULL,
+    quantity INT NOT NULL,
+    unit_price DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    total_amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    note VARCHAR(255),
+    is_voided BOOLEAN DEFAULT FALSE,
+    created_by INT NOT NULL,
+    updated_by INT,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_orderline_transaction FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    CONSTRAINT fk_orderline_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_orderline_creator FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX orderline_idx_transaction_id ON orderline (transaction_id);
+CREATE INDEX orderline_idx_item_id ON orderline (item_id);
+CREATE INDEX orderline_idx_created_by ON orderline (created_by);
+CREATE INDEX orderline_idx_updated_by ON orderline (updated_by);
+
+CREATE TRIGGER users_date_modified BEFORE UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION set_date_modified();
+
+CREATE TRIGGER item_date_modified BEFORE UPDATE ON item
+    FOR EACH ROW EXECUTE FUNCTION set_date_modified();
+
+CREATE TRIGGER transactions_date_modified BEFORE UPDATE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION set_date_modified();
+
+CREATE TRIGGER orderline_date_modified BEFORE UPDATE ON orderline
+    FOR EACH ROW EXECUTE FUNCTION set_date_modified();
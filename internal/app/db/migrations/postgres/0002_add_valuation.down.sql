@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS inventory_ledger;
+DROP TABLE IF EXISTS cost_layer;
+ALTER TABLE item DROP COLUMN category;
@@ -0,0 +1,48 @@
+-- The inventory valuation: the cost layers of the quantities received at a unit
+-- cost, which the outbound movements consume, and the ledger of the quantity
+-- and value of every movement. The stock of the items that already exist is
+-- recorded as their opening stock at their unit price, and the items can be
+-- grouped by category in the valuation report.
+
+ALTER TABLE item ADD COLUMN category CITEXT;
+
+CREATE INDEX item_idx_category ON item (category);
+
+CREATE TABLE cost_layer (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    item_id INT NOT NULL,
+    orderline_id INT,
+    quantity INT NOT NULL,
+    remaining_quantity INT NOT NULL,
+    unit_cost DECIMAL(14,4) NOT NULL DEFAULT 0.0000,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_cost_layer_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_cost_layer_orderline FOREIGN KEY (orderline_id) REFERENCES orderline(id)
+);
+
+CREATE INDEX cost_layer_idx_item_remaining ON cost_layer (item_id, remaining_quantity);
+CREATE INDEX cost_layer_idx_orderline_id ON cost_layer (orderline_id);
+
+CREATE TABLE inventory_ledger (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    item_id INT NOT NULL,
+    storage_id INT NOT NULL,
+    orderline_id INT,
+    quantity INT NOT NULL,
+    amount DECIMAL(14,4) NOT NULL DEFAULT 0.0000,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_ledger_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_ledger_storage FOREIGN KEY (storage_id) REFERENCES storage(id),
+    CONSTRAINT fk_ledger_orderline FOREIGN KEY (orderline_id) REFERENCES orderline(id)
+);
+
+CREATE INDEX inventory_ledger_idx_item_date ON inventory_ledger (item_id, date_created);
+CREATE INDEX inventory_ledger_idx_orderline_id ON inventory_ledger (orderline_id);
+
+INSERT INTO cost_layer (item_id, quantity, remaining_quantity, unit_cost)
+SELECT id, quantity, quantity, unit_price FROM item WHERE quantity > 0;
+
+INSERT INTO inventory_ledger (item_id, storage_id, quantity, amount)
+SELECT id, storage_id, quantity, quantity * unit_price FROM item WHERE quantity > 0;
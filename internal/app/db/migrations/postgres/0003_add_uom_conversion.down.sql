@@ -0,0 +1,2 @@
+ALTER TABLE orderline DROP CONSTRAINT fk_orderline_uom, DROP COLUMN uom_quantity, DROP COLUMN uom_id;
+DROP TABLE IF EXISTS uom_conversion;
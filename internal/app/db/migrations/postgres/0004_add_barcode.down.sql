@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS item_barcode;
+ALTER TABLE item DROP COLUMN sku;
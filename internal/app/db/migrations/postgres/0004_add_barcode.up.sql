@@ -0,0 +1,19 @@
+-- The SKU of the items and the barcodes they are scanned by. A barcode is kept
+-- as a 14-digit GTIN as well, so that it is unique whichever way it is scanned.
+
+ALTER TABLE item ADD COLUMN sku CITEXT UNIQUE;
+
+CREATE TABLE item_barcode (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    item_id INT NOT NULL,
+    uom_id INT,
+    barcode VARCHAR(14) NOT NULL,
+    gtin CHAR(14) NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT item_barcode_idx_gtin UNIQUE (gtin),
+    CONSTRAINT fk_item_barcode_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_item_barcode_uom FOREIGN KEY (uom_id) REFERENCES unit_of_measurement(id)
+);
+
+CREATE INDEX item_barcode_idx_item_id ON item_barcode (item_id);
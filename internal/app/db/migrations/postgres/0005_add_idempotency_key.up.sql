@@ -0,0 +1,19 @@
+-- The responses to the requests with an 'Idempotency-Key' header, which are
+-- replayed when the request is retried until they expire. The responses are
+-- BYTEA, as they are written as bytes.
+
+CREATE TABLE idempotency_key (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    idempotency_key VARCHAR(255) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT,
+    content_type VARCHAR(100),
+    response_body BYTEA,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT idempotency_key_idx_key_path UNIQUE (idempotency_key, path)
+);
+
+CREATE INDEX idempotency_key_idx_expires_at ON idempotency_key (expires_at);
@@ -0,0 +1,9 @@
+-- The reference of a transaction in the system of its partner (e.g. a purchase
+-- order number), which is unique for the partner and the type of transaction.
+
+ALTER TABLE transactions
+    ADD COLUMN partner CITEXT NOT NULL DEFAULT '',
+    ADD COLUMN external_reference CITEXT,
+    ADD CONSTRAINT transactions_idx_external_reference UNIQUE (partner, type, external_reference);
+
+CREATE INDEX transactions_idx_external_reference_search ON transactions (external_reference);
@@ -0,0 +1,50 @@
+-- The outbox of the inventory events, written in the same transaction as the
+-- change they describe, and the webhooks they are delivered to. The payloads
+-- are BYTEA, as they are written as bytes.
+
+CREATE TABLE outbox_event (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+    event_id CHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    item_id INT,
+    storage_id INT,
+    payload BYTEA NOT NULL,
+    is_dispatched BOOLEAN NOT NULL DEFAULT FALSE,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT outbox_event_idx_event_id UNIQUE (event_id)
+);
+
+CREATE INDEX outbox_event_idx_is_dispatched ON outbox_event (is_dispatched, id);
+
+CREATE TABLE webhook (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    url VARCHAR(255) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE webhook_delivery (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+    webhook_id INT NOT NULL,
+    event_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    response_status INT,
+    last_error VARCHAR(255),
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT webhook_delivery_idx_webhook_event UNIQUE (webhook_id, event_id),
+    CONSTRAINT fk_delivery_webhook FOREIGN KEY (webhook_id) REFERENCES webhook(id) ON DELETE CASCADE,
+    CONSTRAINT fk_delivery_event FOREIGN KEY (event_id) REFERENCES outbox_event(id)
+);
+
+CREATE INDEX webhook_delivery_idx_status_next_attempt ON webhook_delivery (status, next_attempt_at);
+
+CREATE TRIGGER webhook_delivery_date_modified BEFORE UPDATE ON webhook_delivery
+    FOR EACH ROW EXECUTE FUNCTION set_date_modified();
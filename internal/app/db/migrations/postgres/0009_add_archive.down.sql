@@ -0,0 +1,3 @@
+ALTER TABLE item DROP CONSTRAINT fk_item_archiver, DROP COLUMN archived_by, DROP COLUMN archived_at;
+ALTER TABLE storage DROP CONSTRAINT fk_storage_archiver, DROP COLUMN archived_by, DROP COLUMN archived_at;
+ALTER TABLE unit_of_measurement DROP CONSTRAINT fk_uom_archiver, DROP COLUMN archived_by, DROP COLUMN archived_at;
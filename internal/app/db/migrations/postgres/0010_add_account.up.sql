@@ -0,0 +1,21 @@
+-- The accounts of the users: the email that a user confirmed they own, and the
+-- single-use tokens emailed to accept an invite, verify the email or reset the
+-- password. Only the hash of a token is stored.
+
+ALTER TABLE users ADD COLUMN verified_email CITEXT NULL;
+
+CREATE TABLE user_token (
+    id INT GENERATED BY DEFAULT AS IDENTITY,
+    user_id INT NOT NULL,
+    purpose VARCHAR(20) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    email CITEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ NULL,
+    date_created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    CONSTRAINT user_token_idx_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_token_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX user_token_idx_user_purpose ON user_token (user_id, purpose);
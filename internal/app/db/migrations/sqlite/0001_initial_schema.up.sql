@@ -0,0 +1,134 @@
+-- Tables of the schema in SQLite. The text columns that are looked up by the
+-- values of the requests compare case-insensitively (NOCASE) like the default
+-- collation of MySQL, and the 'date_modified' columns are kept by triggers in
+-- place of 'ON UPDATE CURRENT_TIMESTAMP'. The index names are unique in the
+-- whole database, so they are prefixed with their table.
+
+CREATE TABLE role (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(30) NOT NULL COLLATE NOCASE,
+    CONSTRAINT idx_role_name UNIQUE (name)
+);
+
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    role_id INTEGER NOT NULL,
+    first_name VARCHAR(30) NOT NULL COLLATE NOCASE,
+    last_name VARCHAR(30) NOT NULL COLLATE NOCASE,
+    email VARCHAR(50) UNIQUE COLLATE NOCASE,
+    password VARCHAR(255) NOT NULL,
+    last_login TIMESTAMP NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    is_active BOOLEAN DEFAULT TRUE,
+    CONSTRAINT fk_user_role FOREIGN KEY (role_id) REFERENCES role(id)
+);
+
+CREATE INDEX idx_users_last_name ON users (last_name);
+
+CREATE TABLE unit_of_measurement (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code VARCHAR(10) UNIQUE COLLATE NOCASE,
+    name VARCHAR(30) NOT NULL COLLATE NOCASE
+);
+
+CREATE TABLE storage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code VARCHAR(10) NOT NULL UNIQUE COLLATE NOCASE,
+    name VARCHAR(50) NOT NULL COLLATE NOCASE,
+    description VARCHAR(50)
+);
+
+CREATE INDEX idx_storage_name ON storage (name);
+
+CREATE TABLE currency (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code VARCHAR(10) NOT NULL UNIQUE COLLATE NOCASE,
+    symbol VARCHAR(10) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE
+);
+
+CREATE TABLE item (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(70) NOT NULL COLLATE NOCASE,
+    description VARCHAR(100),
+    quantity INTEGER NOT NULL,
+    unit_price DECIMAL(10,2) NOT NULL,
+    uom_id INTEGER NOT NULL,
+    stock_status VARCHAR(20) NOT NULL COLLATE NOCASE,
+    storage_id INTEGER NOT NULL,
+    created_by INTEGER NOT NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_item_uom FOREIGN KEY (uom_id) REFERENCES unit_of_measurement(id),
+    CONSTRAINT fk_item_storage FOREIGN KEY (storage_id) REFERENCES storage(id),
+    CONSTRAINT fk_item_creator FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_item_uom_id ON item (uom_id);
+CREATE INDEX idx_item_stock_status ON item (stock_status);
+
+CREATE TABLE transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    reference VARCHAR(70) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    type VARCHAR(20) NOT NULL COLLATE NOCASE,
+    is_cancelled BOOLEAN DEFAULT FALSE,
+    note VARCHAR(255),
+    created_by INTEGER NOT NULL,
+    updated_by INTEGER,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT idx_transactions_reference UNIQUE (reference),
+    CONSTRAINT fk_transaction_creator FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_transactions_created_by ON transactions (created_by);
+CREATE INDEX idx_transactions_updated_by ON transactions (updated_by);
+
+CREATE TABLE orderline (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL,
+    item_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    unit_price DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    total_amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    note VARCHAR(255),
+    is_voided BOOLEAN DEFAULT FALSE,
+    created_by INTEGER NOT NULL,
+    updated_by INTEGER,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_orderline_transaction FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+    CONSTRAINT fk_orderline_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_orderline_creator FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_orderline_transaction_id ON orderline (transaction_id);
+CREATE INDEX idx_orderline_item_id ON orderline (item_id);
+CREATE INDEX idx_orderline_created_by ON orderline (created_by);
+CREATE INDEX idx_orderline_updated_by ON orderline (updated_by);
+
+CREATE TRIGGER trg_users_date_modified AFTER UPDATE ON users
+FOR EACH ROW
+BEGIN
+    UPDATE users SET date_modified = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER trg_item_date_modified AFTER UPDATE ON item
+FOR EACH ROW
+BEGIN
+    UPDATE item SET date_modified = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER trg_transactions_date_modified AFTER UPDATE ON transactions
+FOR EACH ROW
+BEGIN
+    UPDATE transactions SET date_modified = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER trg_orderline_date_modified AFTER UPDATE ON orderline
+FOR EACH ROW
+BEGIN
+    UPDATE orderline SET date_modified = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
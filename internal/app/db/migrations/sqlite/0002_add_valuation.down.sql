@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS inventory_ledger;
+DROP TABLE IF EXISTS cost_layer;
+DROP INDEX IF EXISTS idx_item_category;
+ALTER TABLE item DROP COLUMN category;
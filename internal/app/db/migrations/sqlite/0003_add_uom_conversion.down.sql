@@ -0,0 +1,3 @@
+ALTER TABLE orderline DROP COLUMN uom_quantity;
+ALTER TABLE orderline DROP COLUMN uom_id;
+DROP TABLE IF EXISTS uom_conversion;
@@ -0,0 +1,20 @@
+-- The units of measurement that an item can be moved in, by their factor to the
+-- base unit of measurement of the item, and the unit of measurement and
+-- quantity that an orderline was given in.
+--
+-- SQLite cannot drop a column of a foreign key, so the 'uom_id' of the
+-- orderlines has none here and the unit of measurement is checked by the API
+-- instead.
+
+CREATE TABLE uom_conversion (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    item_id INTEGER NOT NULL,
+    uom_id INTEGER NOT NULL,
+    factor DECIMAL(18,6) NOT NULL,
+    CONSTRAINT idx_uom_conversion_item_uom UNIQUE (item_id, uom_id),
+    CONSTRAINT fk_uom_conversion_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_uom_conversion_uom FOREIGN KEY (uom_id) REFERENCES unit_of_measurement(id)
+);
+
+ALTER TABLE orderline ADD COLUMN uom_id INTEGER;
+ALTER TABLE orderline ADD COLUMN uom_quantity INTEGER;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS item_barcode;
+DROP INDEX IF EXISTS idx_item_sku;
+ALTER TABLE item DROP COLUMN sku;
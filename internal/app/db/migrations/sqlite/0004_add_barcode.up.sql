@@ -0,0 +1,21 @@
+-- The SKU of the items and the barcodes they are scanned by. A barcode is kept
+-- as a 14-digit GTIN as well, so that it is unique whichever way it is scanned.
+-- SQLite cannot add a UNIQUE column, so the SKU is unique by an index.
+
+ALTER TABLE item ADD COLUMN sku VARCHAR(40) COLLATE NOCASE;
+
+CREATE UNIQUE INDEX idx_item_sku ON item (sku);
+
+CREATE TABLE item_barcode (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    item_id INTEGER NOT NULL,
+    uom_id INTEGER,
+    barcode VARCHAR(14) NOT NULL,
+    gtin CHAR(14) NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    CONSTRAINT idx_item_barcode_gtin UNIQUE (gtin),
+    CONSTRAINT fk_item_barcode_item FOREIGN KEY (item_id) REFERENCES item(id),
+    CONSTRAINT fk_item_barcode_uom FOREIGN KEY (uom_id) REFERENCES unit_of_measurement(id)
+);
+
+CREATE INDEX idx_item_barcode_item_id ON item_barcode (item_id);
@@ -0,0 +1,17 @@
+-- The responses to the requests with an 'Idempotency-Key' header, which are
+-- replayed when the request is retried until they expire.
+
+CREATE TABLE idempotency_key (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    idempotency_key VARCHAR(255) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INTEGER,
+    content_type VARCHAR(100),
+    response_body BLOB,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    CONSTRAINT idx_idempotency_key_key_path UNIQUE (idempotency_key, path)
+);
+
+CREATE INDEX idx_idempotency_key_expires_at ON idempotency_key (expires_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_external_reference_search;
+DROP INDEX IF EXISTS idx_transactions_external_reference;
+ALTER TABLE transactions DROP COLUMN external_reference;
+ALTER TABLE transactions DROP COLUMN partner;
@@ -0,0 +1,8 @@
+-- The reference of a transaction in the system of its partner (e.g. a purchase
+-- order number), which is unique for the partner and the type of transaction.
+
+ALTER TABLE transactions ADD COLUMN partner VARCHAR(70) NOT NULL DEFAULT '' COLLATE NOCASE;
+ALTER TABLE transactions ADD COLUMN external_reference VARCHAR(70) COLLATE NOCASE;
+
+CREATE UNIQUE INDEX idx_transactions_external_reference ON transactions (partner, type, external_reference);
+CREATE INDEX idx_transactions_external_reference_search ON transactions (external_reference);
@@ -0,0 +1,49 @@
+-- The outbox of the inventory events, written in the same transaction as the
+-- change they describe, and the webhooks they are delivered to.
+
+CREATE TABLE outbox_event (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id CHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    item_id INTEGER,
+    storage_id INTEGER,
+    payload TEXT NOT NULL,
+    is_dispatched BOOLEAN NOT NULL DEFAULT FALSE,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT idx_outbox_event_event_id UNIQUE (event_id)
+);
+
+CREATE INDEX idx_outbox_event_is_dispatched ON outbox_event (is_dispatched, id);
+
+CREATE TABLE webhook (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url VARCHAR(255) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_delivery (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    response_status INTEGER,
+    last_error VARCHAR(255),
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_modified TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT idx_webhook_delivery_webhook_event UNIQUE (webhook_id, event_id),
+    CONSTRAINT fk_delivery_webhook FOREIGN KEY (webhook_id) REFERENCES webhook(id) ON DELETE CASCADE,
+    CONSTRAINT fk_delivery_event FOREIGN KEY (event_id) REFERENCES outbox_event(id)
+);
+
+CREATE INDEX idx_webhook_delivery_status_next_attempt ON webhook_delivery (status, next_attempt_at);
+
+CREATE TRIGGER trg_webhook_delivery_date_modified AFTER UPDATE ON webhook_delivery
+FOR EACH ROW
+BEGIN
+    UPDATE webhook_delivery SET date_modified = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
@@ -0,0 +1,6 @@
+ALTER TABLE item DROP COLUMN archived_by;
+ALTER TABLE item DROP COLUMN archived_at;
+ALTER TABLE storage DROP COLUMN archived_by;
+ALTER TABLE storage DROP COLUMN archived_at;
+ALTER TABLE unit_of_measurement DROP COLUMN archived_by;
+ALTER TABLE unit_of_measurement DROP COLUMN archived_at;
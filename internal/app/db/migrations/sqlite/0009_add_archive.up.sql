@@ -0,0 +1,13 @@
+-- The items, storages and units of measurement that are archived rather than
+-- deleted: they are left out of the lists, and an archived item cannot be in a
+-- new transaction, but they are kept for the records that refer to them.
+--
+-- SQLite cannot drop a column of a foreign key, so 'archived_by' has none here
+-- and the user is checked by the API instead.
+
+ALTER TABLE unit_of_measurement ADD COLUMN archived_at TIMESTAMP NULL;
+ALTER TABLE unit_of_measurement ADD COLUMN archived_by INTEGER NULL;
+ALTER TABLE storage ADD COLUMN archived_at TIMESTAMP NULL;
+ALTER TABLE storage ADD COLUMN archived_by INTEGER NULL;
+ALTER TABLE item ADD COLUMN archived_at TIMESTAMP NULL;
+ALTER TABLE item ADD COLUMN archived_by INTEGER NULL;
@@ -0,0 +1,20 @@
+-- The accounts of the users: the email that a user confirmed they own, and the
+-- single-use tokens emailed to accept an invite, verify the email or reset the
+-- password. Only the hash of a token is stored.
+
+ALTER TABLE users ADD COLUMN verified_email VARCHAR(50) NULL COLLATE NOCASE;
+
+CREATE TABLE user_token (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    purpose VARCHAR(20) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    email VARCHAR(50) NOT NULL COLLATE NOCASE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT idx_user_token_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_token_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_user_token_user_purpose ON user_token (user_id, purpose);
@@ -0,0 +1,214 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// Options holds how long each stage of the shutdown can take: the delay after
+// the shutdown is announced and before the server stops accepting connections
+// (for the load balancers to notice), and the timeouts to drain the in-flight
+// HTTP requests, stop the background workers and close the resources.
+type Options struct {
+	DrainDelay      time.Duration
+	HTTPTimeout     time.Duration
+	WorkersTimeout  time.Duration
+	ResourceTimeout time.Duration
+}
+
+// Manager starts the HTTP server and the background workers and stops them in
+// order: the HTTP server is drained first so that the in-flight requests can
+// still use the workers and the resources, then the workers are stopped and
+// the resources (e.g. the database pool) are closed last.
+type Manager struct {
+	server   *http.Server
+	listener net.Listener
+	options  Options
+
+	ctx         context.Context
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
+
+	announce  []func()
+	resources []resource
+}
+
+// resource is closed after the workers are stopped.
+type resource struct {
+	name  string
+	close func() error
+}
+
+// New returns a manager of the server.
+func New(server *http.Server, options Options) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Manager{
+		server:      server,
+		options:     options,
+		ctx:         ctx,
+		stopWorkers: cancel,
+	}
+}
+
+// Go starts a background worker. Its context is cancelled once the HTTP
+// server is drained, and the worker must return when it is.
+func (m *Manager) Go(name string, worker func(ctx context.Context)) {
+	m.workers.Add(1)
+
+	go func() {
+		defer m.workers.Done()
+		defer log.Panic("worker", name)
+
+		worker(m.ctx)
+	}()
+}
+
+// OnShutdown registers a function that is called as soon as the shutdown
+// begins, before anything is stopped (e.g. to fail the readiness probe).
+func (m *Manager) OnShutdown(fn func()) { m.announce = append(m.announce, fn) }
+
+// OnClose registers a resource that is closed after the workers are stopped.
+// The resources are closed in the reverse order of their registration.
+func (m *Manager) OnClose(name string, fn func() error) {
+	m.resources = append(m.resources, resource{name: name, close: fn})
+}
+
+// Listen binds the address of the server, so that an address that is in use
+// or invalid is reported before the server is started.
+func (m *Manager) Listen() error {
+	listener, err := net.Listen("tcp", m.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", m.server.Addr, err)
+	}
+
+	m.listener = listener
+
+	return nil
+}
+
+// Addr returns the address that the server listens on.
+func (m *Manager) Addr() net.Addr {
+	if m.listener == nil {
+		return nil
+	}
+
+	return m.listener.Addr()
+}
+
+// Serve serves the HTTP requests until the context is cancelled, then shuts
+// everything down. It returns the error that stopped the server, or the errors
+// of the shutdown.
+func (m *Manager) Serve(ctx context.Context) error {
+	if m.listener == nil {
+		err := m.Listen()
+		if err != nil {
+			m.stopWorkers()
+			return errors.Join(err, m.Shutdown())
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- m.server.Serve(m.listener) }()
+
+	select {
+	case err := <-serveErr:
+		// The server stopped on its own, so it could not accept requests.
+		return errors.Join(fmt.Errorf("server stopped: %w", err), m.Shutdown())
+
+	case <-ctx.Done():
+		err := m.Shutdown()
+
+		<-serveErr
+
+		return err
+	}
+}
+
+// Shutdown drains the HTTP server, stops the workers and closes the resources,
+// each within its timeout (a zero timeout waits for as long as it takes). A
+// stage that times out does not prevent the next ones from running.
+func (m *Manager) Shutdown() error {
+	var errs []error
+
+	for _, fn := range m.announce {
+		fn()
+	}
+
+	if m.options.DrainDelay > 0 {
+		time.Sleep(m.options.DrainDelay)
+	}
+
+	trail.Info("Draining HTTP requests...")
+
+	ctx := context.Background()
+	if m.options.HTTPTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, m.options.HTTPTimeout)
+		defer cancel()
+	}
+
+	err := m.server.Shutdown(ctx)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, fmt.Errorf("failed to drain the HTTP server: %w", err))
+
+		// Stop the connections that did not finish in time.
+		m.server.Close()
+	}
+
+	trail.Info("Stopping background workers...")
+	m.stopWorkers()
+
+	err = wait(&m.workers, m.options.WorkersTimeout)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop the workers: %w", err))
+	}
+
+	for i := len(m.resources) - 1; i >= 0; i-- {
+		resource := m.resources[i]
+		trail.Info("Closing %s...", resource.name)
+
+		err := timeout(resource.close, m.options.ResourceTimeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", resource.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// wait waits for the workers to return within the timeout.
+func wait(workers *sync.WaitGroup, limit time.Duration) error {
+	return timeout(func() error {
+		workers.Wait()
+		return nil
+	}, limit)
+}
+
+// timeout runs the function and returns its error, or an error if it does not
+// return within the limit. A zero limit waits for as long as it takes.
+func timeout(fn func() error, limit time.Duration) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+
+	if limit <= 0 {
+		return <-done
+	}
+
+	select {
+	case err := <-done:
+		return err
+
+	case <-time.After(limit):
+		return fmt.Errorf("timed out after %s", limit)
+	}
+}
@@ -0,0 +1,251 @@
+package lifecycle
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder keeps the order in which the stages of the shutdown happened.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.events)
+}
+
+// start listens on a free local port and serves in the background. It returns
+// the base URL of the server, the function that begins the shutdown and the
+// error of Serve.
+func start(t *testing.T, manager *Manager) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	err := manager.Listen()
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	done := make(chan error, 1)
+	go func() { done <- manager.Serve(ctx) }()
+
+	return "http://" + manager.Addr().String(), cancel, done
+}
+
+func served(t *testing.T, done <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-done:
+		return err
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return")
+		return nil
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	var (
+		events   recorder
+		entered  = make(chan struct{})
+		release  = make(chan struct{})
+		workerUp = make(chan struct{})
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+
+		events.add("request")
+		io.WriteString(w, "done")
+	})
+
+	manager := New(&http.Server{Addr: "127.0.0.1:0", Handler: mux}, Options{
+		HTTPTimeout:     5 * time.Second,
+		WorkersTimeout:  5 * time.Second,
+		ResourceTimeout: 5 * time.Second,
+	})
+
+	manager.OnShutdown(func() { events.add("announce") })
+	manager.OnClose("database", func() error {
+		events.add("database")
+		return nil
+	})
+
+	manager.Go("worker", func(ctx context.Context) {
+		close(workerUp)
+		<-ctx.Done()
+
+		events.add("worker")
+	})
+
+	url, shutdown, done := start(t, manager)
+	<-workerUp
+
+	response := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+
+	<-entered
+	shutdown()
+
+	// The in-flight request must be able to finish while shutting down.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	err := served(t, done)
+	if err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	body := <-response
+	if body != "done" {
+		t.Errorf("in-flight response = %q, want %q", body, "done")
+	}
+
+	want := []string{"announce", "request", "worker", "database"}
+	if got := events.list(); !slices.Equal(got, want) {
+		t.Errorf("shutdown order = %v, want %v", got, want)
+	}
+}
+
+func TestListenAddressInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	manager := New(&http.Server{Addr: listener.Addr().String()}, Options{})
+
+	err = manager.Listen()
+	if err == nil {
+		t.Fatal("Listen() error = nil, want the address in use error")
+	}
+
+	if !strings.Contains(err.Error(), listener.Addr().String()) {
+		t.Errorf("Listen() error = %v, want the address", err)
+	}
+}
+
+func TestServeAddressInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	var closed bool
+
+	manager := New(&http.Server{Addr: listener.Addr().String()}, Options{})
+	manager.OnClose("database", func() error {
+		closed = true
+		return nil
+	})
+
+	err = manager.Serve(context.Background())
+	if err == nil {
+		t.Fatal("Serve() error = nil, want the address in use error")
+	}
+
+	if !closed {
+		t.Error("resource was not closed after the startup error")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	var (
+		events  recorder
+		entered = make(chan struct{})
+		release = make(chan struct{})
+	)
+	defer close(release)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+
+	manager := New(&http.Server{Addr: "127.0.0.1:0", Handler: mux}, Options{
+		HTTPTimeout:     50 * time.Millisecond,
+		WorkersTimeout:  50 * time.Millisecond,
+		ResourceTimeout: time.Second,
+	})
+
+	manager.Go("stuck worker", func(ctx context.Context) { <-release })
+	manager.OnClose("database", func() error {
+		events.add("database")
+		return nil
+	})
+
+	url, shutdown, done := start(t, manager)
+
+	go http.Get(url + "/stuck")
+	<-entered
+
+	shutdown()
+
+	err := served(t, done)
+	if err == nil {
+		t.Fatal("Serve() error = nil, want the timeout errors")
+	}
+
+	for _, stage := range []string{"drain the HTTP server", "stop the workers"} {
+		if !strings.Contains(err.Error(), stage) {
+			t.Errorf("Serve() error = %v, want it to report %q", err, stage)
+		}
+	}
+
+	// The resources are still closed when the earlier stages time out.
+	if got := events.list(); !slices.Equal(got, []string{"database"}) {
+		t.Errorf("closed resources = %v, want [database]", got)
+	}
+}
+
+func TestWorkerPanic(t *testing.T) {
+	manager := New(&http.Server{Addr: "127.0.0.1:0", Handler: http.NewServeMux()}, Options{
+		WorkersTimeout: time.Second,
+	})
+
+	manager.Go("panicking worker", func(ctx context.Context) { panic("boom") })
+
+	_, shutdown, done := start(t, manager)
+	shutdown()
+
+	err := served(t, done)
+	if err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+}
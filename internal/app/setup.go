@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,10 +11,18 @@ import (
 	"time"
 
 	"github.com/rmarasigan/warehouse-inventory-management/api"
+	v1 "github.com/rmarasigan/warehouse-inventory-management/api/v1"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/db"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/lifecycle"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/health"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/mail"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/metrics"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/stream"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/webhook"
 )
 
 const (
@@ -30,56 +39,166 @@ const message = "" +
 	FGMagentaB + `/  /_/  /_/  /` + FGRedB + `/  /  / /  / /  /` + "\n" + FGNormal +
 	FGMagentaB + `\_____,_____/` + FGRedB + `/__/__/ /__/ /__/` + FGNormal + "\n\n"
 
-func StartServer() {
+// StartServer starts the HTTP server and the background workers with the
+// configuration file, and serves until an interrupt or termination signal is
+// received. It returns an error if the server cannot be started (e.g. the
+// configuration is invalid, the database cannot be reached or the address is
+// in use) or if it did not shut down cleanly.
+func StartServer(file string) error {
 	log.Init()
-	defer log.Panic()
 
 	// Load the application configuration
-	cfg, err := config.Load("wim-config.yaml")
+	cfg, err := config.Load(file)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	// Connect to the MySQL database
-	mysql.Connect()
+	// Send the account emails through the configured sender
+	sender, err := mail.New(cfg.Mail())
+	if err != nil {
+		return err
+	}
+
+	v1.UseMailer(sender)
+
+	// Connect to the database of the configured driver
+	err = mysql.Connect()
+	if err != nil {
+		return err
+	}
+
+	// Refuse to serve on a schema that is not migrated or is dirty
+	err = db.CheckMigrations(context.Background())
+	if err != nil {
+		return errors.Join(fmt.Errorf("schema is not up to date:\n%w", err), mysql.Close())
+	}
 
 	// Set-up the HTTP server
 	serverAddress := cfg.ServerAddress()
 	server := &http.Server{
 		Addr:    serverAddress,
-		Handler: http.DefaultServeMux,
+		Handler: routes(),
 	}
 
-	// Register the applications handler
-	http.HandleFunc("/api/", api.Handler)
+	// The HTTP server is drained first, then the workers are stopped and the
+	// database pool is closed last, so that the in-flight requests keep their
+	// database connection.
+	manager := lifecycle.New(server, lifecycle.Options{
+		DrainDelay:      cfg.ShutdownDrainDelay(),
+		HTTPTimeout:     cfg.ShutdownHTTPTimeout(),
+		WorkersTimeout:  cfg.ShutdownWorkersTimeout(),
+		ResourceTimeout: cfg.ShutdownDatabaseTimeout(),
+	})
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	manager.OnClose("database connection", mysql.Close)
 
-	// Handle a graceful shutdown
-	go func() {
-		// Wait for interrupt signal to gracefully shutdown
-		<-quit
+	// The password resets that are still being emailed need the database, so
+	// they are waited for before it is closed.
+	manager.OnClose("password reset emails", v1.WaitPasswordResets)
 
-		trail.Info("Shutting down server...")
-		mysql.Close()
+	// Bind the address before anything is started in the background.
+	err = manager.Listen()
+	if err != nil {
+		return errors.Join(err, manager.Shutdown())
+	}
 
-		// Create a context with a timeout for graceful shutdown
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+	// Fail the readiness probe first so that the load balancers drain traffic.
+	manager.OnShutdown(health.Shutdown)
+
+	// The event streams never finish on their own, so they are ended as soon as
+	// the server starts draining.
+	server.RegisterOnShutdown(stream.Close)
+
+	// Expose the stock gauges in the metrics
+	metrics.RegisterStock(func() (float64, float64, error) {
+		return mysql.StockSummary(cfg.LowStockThreshold())
+	})
+
+	// Delete the expired idempotency keys in the background
+	manager.Go("idempotency key purge", func(ctx context.Context) {
+		purgeIdempotencyKeys(ctx, cfg.IdempotencyKeyPurgeInterval())
+	})
+
+	// Deliver the inventory events to the registered webhooks in the background
+	manager.Go("webhook delivery", func(ctx context.Context) {
+		webhook.Run(ctx, webhook.Options{
+			PollInterval: cfg.WebhookPollInterval(),
+			MaxAttempts:  cfg.WebhookMaxAttempts(),
+			Backoff:      cfg.WebhookBackoff(),
+			MaxBackoff:   cfg.WebhookMaxBackoff(),
+			Timeout:      cfg.WebhookTimeout(),
+		})
+	})
+
+	// Stream the committed events to the connected clients
+	streamOptions := stream.Options{
+		PollInterval:  cfg.EventStreamPollInterval(),
+		BufferSize:    cfg.EventStreamBufferSize(),
+		ClientBuffer:  cfg.EventStreamClientBuffer(),
+		Heartbeat:     cfg.EventStreamHeartbeat(),
+		CommitTimeout: cfg.EventStreamCommitTimeout(),
+	}
 
-		// Attempt to shutdown the server gracefully
-		err := server.Shutdown(ctx)
-		if err != nil {
-			log.Error(err, "failed to shutdown the server")
-		}
-	}()
+	stream.Start(streamOptions)
+	manager.Go("event stream", func(ctx context.Context) { stream.Run(ctx, streamOptions) })
+
+	// Wait for interrupt signal to gracefully shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Start the server
 	trail.Info("Initializing %s Server at %s\n%s", cfg.AppName(), serverAddress, message)
 
-	err = server.ListenAndServe()
-	if err != nil && errors.Is(err, http.ErrServerClosed) {
-		panic(err)
+	err = manager.Serve(ctx)
+	if err != nil {
+		return err
+	}
+
+	trail.Info("Server stopped.")
+
+	return nil
+}
+
+// routes returns the handlers of the server.
+func routes() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Register the applications handler
+	mux.HandleFunc("/api/", api.Handler)
+
+	// Register the liveness and readiness probes
+	mux.HandleFunc("/healthz", health.Liveness)
+	mux.HandleFunc("/readyz", health.Readiness)
+
+	// Expose the metrics in the Prometheus text format
+	mux.Handle("/metrics", metrics.Handler())
+
+	return mux
+}
+
+// purgeIdempotencyKeys deletes the expired idempotency keys on every interval
+// until the context is cancelled.
+func purgeIdempotencyKeys(ctx context.Context, interval time.Duration) {
+	defer log.Panic()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-ticker.C:
+			deleted, err := mysql.PurgeIdempotencyKeys(now.UTC())
+			if err != nil {
+				log.Error(err, "failed to purge expired idempotency keys")
+				continue
+			}
+
+			if deleted > 0 {
+				trail.Info("Purged %d expired idempotency key(s).", deleted)
+			}
+		}
 	}
 }
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/lifecycle"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/health"
+)
+
+// client does not keep connections open, which would otherwise hold the server
+// open while it drains.
+var client = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+// get requests the path and decodes the JSON report of a probe.
+func get(t *testing.T, url string) (int, health.Report) {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	var report health.Report
+	_ = json.NewDecoder(resp.Body).Decode(&report)
+
+	return resp.StatusCode, report
+}
+
+// TestServer runs the server in-process without a database: it stays alive but
+// not ready, and its readiness fails as soon as the shutdown begins while it
+// still accepts requests.
+func TestServer(t *testing.T) {
+	manager := lifecycle.New(&http.Server{Addr: "127.0.0.1:0", Handler: routes()}, lifecycle.Options{
+		DrainDelay:     300 * time.Millisecond,
+		HTTPTimeout:    time.Second,
+		WorkersTimeout: time.Second,
+	})
+	manager.OnShutdown(health.Shutdown)
+
+	err := manager.Listen()
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	ctx, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+
+	done := make(chan error, 1)
+	go func() { done <- manager.Serve(ctx) }()
+
+	url := "http://" + manager.Addr().String()
+
+	status, report := get(t, url+"/healthz")
+	if status != http.StatusOK || report.Status != health.StatusOK {
+		t.Errorf("/healthz = %d %q, want %d %q", status, report.Status, http.StatusOK, health.StatusOK)
+	}
+
+	status, report = get(t, url+"/readyz")
+	if status != http.StatusServiceUnavailable || report.Status != health.StatusDegraded {
+		t.Errorf("/readyz = %d %q, want %d %q", status, report.Status, http.StatusServiceUnavailable, health.StatusDegraded)
+	}
+
+	if report.Checks["mysql"].Status != health.StatusFailing {
+		t.Errorf("/readyz mysql check = %+v, want it failing without a database", report.Checks["mysql"])
+	}
+
+	resp, err := client.Get(url + "/api/v1/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("/api/v1/unknown = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	shutdown()
+
+	// The server keeps accepting requests during the drain delay.
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		status, report = get(t, url+"/readyz")
+		if report.Status == health.StatusShuttingDown {
+			break
+		}
+	}
+
+	if status != http.StatusServiceUnavailable || report.Status != health.StatusShuttingDown {
+		t.Errorf("/readyz while shutting down = %d %q, want %d %q", status, report.Status, http.StatusServiceUnavailable, health.StatusShuttingDown)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return")
+	}
+}
+
+// TestStartServerError checks that a server that cannot start reports it, so
+// that the process exits with a non-zero code.
+func TestStartServerError(t *testing.T) {
+	err := StartServer(filepath.Join(t.TempDir(), "wim-config.yaml"))
+	if err == nil {
+		t.Fatal("StartServer() error = nil, want the missing configuration error")
+	}
+}
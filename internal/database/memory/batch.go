@@ -0,0 +1,402 @@
+package memory
+
+import (
+	"database/sql"
+	"slices"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+)
+
+// Batch writes the records on a copy of the store that replaces it when the
+// batch is committed. The store is locked for writing until then.
+type Batch struct {
+	store *Store
+	data  *data
+}
+
+func newBatch(store *Store) *Batch {
+	store.writer.Lock()
+
+	var clone *data
+	store.read(func(d *data) { clone = d.clone() })
+
+	return &Batch{store: store, data: clone}
+}
+
+func (b *Batch) Commit() error {
+	if b.data == nil {
+		return sql.ErrTxDone
+	}
+
+	b.store.mu.Lock()
+	b.store.data = b.data
+	b.store.mu.Unlock()
+
+	b.done()
+
+	return nil
+}
+
+func (b *Batch) Rollback() error {
+	if b.data != nil {
+		b.done()
+	}
+
+	return nil
+}
+
+func (b *Batch) done() {
+	b.data = nil
+	b.store.writer.Unlock()
+}
+
+func (b *Batch) Record(fn func() error) error {
+	if b.data == nil {
+		return sql.ErrTxDone
+	}
+
+	savepoint := b.data.clone()
+
+	err := fn()
+	if err != nil {
+		b.data = savepoint
+	}
+
+	return err
+}
+
+func (b *Batch) Storage(storage schema.Storage) (int64, error) {
+	return insertIfNotExists(&b.data.storages, storage, func(existing schema.Storage) bool {
+		return equal(existing.Code, storage.Code)
+	}, b.data.checkStorage)
+}
+
+func (b *Batch) UOM(uom schema.UOM) (int64, error) {
+	return insertIfNotExists(&b.data.uoms, uom, func(existing schema.UOM) bool {
+		return equal(existing.Code, uom.Code)
+	}, b.data.checkUOM)
+}
+
+func (b *Batch) Item(item schema.Item) (int64, error) {
+	item.UnitPrice = decimal(item.UnitPrice, 2)
+
+	return insertIfNotExists(&b.data.items, item, func(existing schema.Item) bool {
+		return equal(existing.Name, item.Name)
+	}, b.data.checkItem)
+}
+
+func (b *Batch) Role(role schema.Role) (int64, error) {
+	return insertIfNotExists(&b.data.roles, role, func(existing schema.Role) bool {
+		return equal(existing.Name, role.Name)
+	}, b.data.checkRole)
+}
+
+func (b *Batch) UOMConversion(conversion schema.UOMConversion) (int64, error) {
+	return insertIfNotExists(&b.data.conversions, conversion, func(existing schema.UOMConversion) bool {
+		return existing.ItemID == conversion.ItemID && existing.UoMID == conversion.UoMID
+	}, b.data.checkConversion)
+}
+
+func (b *Batch) ItemBarcode(barcode schema.ItemBarcode) (int64, error) {
+	return insertIfNotExists(&b.data.barcodes, barcode, func(existing schema.ItemBarcode) bool {
+		return existing.GTIN == barcode.GTIN
+	}, b.data.checkBarcode)
+}
+
+func (b *Batch) UpdateStorage(storage schema.Storage) error {
+	return updateChecked(&b.data.storages, storage.ID, storage, b.data.checkStorage, "code", "name", "description")
+}
+
+func (b *Batch) UpdateUOM(uom schema.UOM) error {
+	return updateChecked(&b.data.uoms, uom.ID, uom, b.data.checkUOM, "code", "name")
+}
+
+func (b *Batch) UpdateRole(role schema.Role) error {
+	return updateChecked(&b.data.roles, role.ID, role, b.data.checkRole, "name")
+}
+
+func (b *Batch) UpdateUOMConversion(conversion schema.UOMConversion) error {
+	return updateChecked(&b.data.conversions, conversion.ID, conversion, b.data.checkConversion, "factor")
+}
+
+func (b *Batch) UpdateItem(item schema.Item) error {
+	item.UnitPrice = decimal(item.UnitPrice, 2)
+
+	columns := []string{"sku", "name", "description", "category", "unit_price", "storage_id", "uom_id"}
+	return updateChecked(&b.data.items, item.ID, item, b.data.checkItem, columns...)
+}
+
+func (b *Batch) PatchItem(item schema.Item, columns ...string) error {
+	item.UnitPrice = decimal(item.UnitPrice, 2)
+
+	return patchChecked(&b.data.items, item.ID, item, b.data.checkItem, columns...)
+}
+
+func (b *Batch) User(user schema.User) (int64, error) {
+	return insertIfNotExists(&b.data.users, user, func(existing schema.User) bool {
+		return equal(existing.FirstName, user.FirstName) && equal(existing.LastName, user.LastName)
+	}, b.data.checkUser)
+}
+
+func (b *Batch) StorageByCode(code string) (schema.Storage, error) {
+	storage, _ := b.data.storages.find(func(s schema.Storage) bool { return equal(s.Code, code) })
+	return storage, nil
+}
+
+func (b *Batch) UOMByCode(code string) (schema.UOM, error) {
+	uom, _ := b.data.uoms.find(func(u schema.UOM) bool { return equal(u.Code, code) })
+	return uom, nil
+}
+
+func (b *Batch) ItemByID(id int) (schema.Item, error) {
+	return b.data.items.get(int64(id)), nil
+}
+
+func (b *Batch) ItemBySKU(sku string) (schema.Item, error) {
+	item, _ := b.data.items.find(func(i schema.Item) bool { return i.SKU.Valid && equal(i.SKU.String, sku) })
+	return item, nil
+}
+
+func (b *Batch) ItemByName(name string) (schema.Item, error) {
+	item, _ := b.data.items.find(func(i schema.Item) bool { return equal(i.Name, name) })
+	return item, nil
+}
+
+// ItemForUpdate retrieves the item. It is already locked, since the batch holds
+// the store until it is done.
+func (b *Batch) ItemForUpdate(id int) (schema.Item, error) {
+	return b.ItemByID(id)
+}
+
+func (b *Batch) UpdateItemQuantity(item schema.Item) error {
+	existing := b.data.items.get(int64(item.ID))
+	if existing.ID != 0 {
+		existing.Quantity = item.Quantity
+		b.data.items.replace(existing)
+	}
+
+	return nil
+}
+
+func (b *Batch) ReceiveStock(layer schema.CostLayer, storageID int) error {
+	return b.receiveStock(layer, storageID)
+}
+
+func (b *Batch) OpeningStock(item schema.Item, quantity int, unitCost float64) error {
+	existing := b.data.items.get(int64(item.ID))
+	if existing.ID != 0 {
+		existing.Quantity += quantity
+		existing.StockStatus = "in_stock"
+		b.data.items.replace(existing)
+	}
+
+	return b.receiveStock(schema.CostLayer{
+		ItemID:   item.ID,
+		Quantity: quantity,
+		UnitCost: unitCost,
+	}, item.StorageID)
+}
+
+func (b *Batch) receiveStock(layer schema.CostLayer, storageID int) error {
+	layer.RemainingQuantity = layer.Quantity
+	layer.UnitCost = schema.RoundAmount(layer.UnitCost)
+
+	err := b.insertCostLayer(layer)
+	if err != nil {
+		return err
+	}
+
+	return b.insertLedgerEntry(schema.LedgerEntry{
+		ItemID:      layer.ItemID,
+		StorageID:   storageID,
+		OrderlineID: layer.OrderlineID,
+		Quantity:    layer.Quantity,
+		Amount:      schema.RoundAmount(float64(layer.Quantity) * layer.UnitCost),
+	})
+}
+
+func (b *Batch) IssueStock(method string, orderline schema.Orderline, storageID int) (float64, error) {
+	cost := b.consumeCostLayers(b.openCostLayers(orderline.ItemID), orderline.Quantity, method)
+
+	return cost, b.insertLedgerEntry(schema.LedgerEntry{
+		ItemID:      orderline.ItemID,
+		StorageID:   storageID,
+		OrderlineID: dbutils.SetInt(int32(orderline.ID)),
+		Quantity:    -orderline.Quantity,
+		Amount:      -cost,
+	})
+}
+
+func (b *Batch) ReverseReceipt(method string, orderline schema.Orderline, storageID int) error {
+	// Orderlines received before the valuation was recorded have nothing
+	// to reverse.
+	if !b.data.ledger.exists(func(entry schema.LedgerEntry) bool {
+		return int(entry.OrderlineID.Int32) == orderline.ID && entry.Quantity > 0
+	}) {
+		return nil
+	}
+
+	// Move the cost layer of the orderline to the front.
+	var ordered []schema.CostLayer
+	for _, layer := range b.openCostLayers(orderline.ItemID) {
+		if int(layer.OrderlineID.Int32) == orderline.ID {
+			ordered = append([]schema.CostLayer{layer}, ordered...)
+			continue
+		}
+
+		ordered = append(ordered, layer)
+	}
+
+	cost := b.consumeCostLayers(ordered, orderline.Quantity, method)
+
+	return b.insertLedgerEntry(schema.LedgerEntry{
+		ItemID:      orderline.ItemID,
+		StorageID:   storageID,
+		OrderlineID: dbutils.SetInt(int32(orderline.ID)),
+		Quantity:    -orderline.Quantity,
+		Amount:      -cost,
+	})
+}
+
+func (b *Batch) ReverseIssue(orderline schema.Orderline, storageID int) error {
+	issued, i := b.data.ledger.find(func(entry schema.LedgerEntry) bool {
+		return int(entry.OrderlineID.Int32) == orderline.ID && entry.Quantity < 0
+	})
+
+	// Orderlines issued before the valuation was recorded have nothing
+	// to reverse.
+	if i < 0 {
+		return nil
+	}
+
+	var unitCost float64
+	if issued.Quantity != 0 {
+		unitCost = schema.RoundAmount(issued.Amount / float64(issued.Quantity))
+	}
+
+	err := b.insertCostLayer(schema.CostLayer{
+		ItemID:            orderline.ItemID,
+		OrderlineID:       dbutils.SetInt(int32(orderline.ID)),
+		Quantity:          orderline.Quantity,
+		RemainingQuantity: orderline.Quantity,
+		UnitCost:          unitCost,
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.insertLedgerEntry(schema.LedgerEntry{
+		ItemID:      orderline.ItemID,
+		StorageID:   storageID,
+		OrderlineID: dbutils.SetInt(int32(orderline.ID)),
+		Quantity:    orderline.Quantity,
+		Amount:      -issued.Amount,
+	})
+}
+
+// openCostLayers returns the cost layers of the item that have a remaining
+// quantity, oldest first.
+func (b *Batch) openCostLayers(itemID int) []schema.CostLayer {
+	layers := b.data.layers.filter(func(layer schema.CostLayer) bool {
+		return layer.ItemID == itemID && layer.RemainingQuantity > 0
+	})
+
+	slices.SortStableFunc(layers, func(x, y schema.CostLayer) int { return x.DateCreated.Compare(y.DateCreated) })
+
+	return layers
+}
+
+func (b *Batch) consumeCostLayers(layers []schema.CostLayer, quantity int, method string) float64 {
+	cost, _ := schema.ConsumeLayers(layers, quantity, method)
+
+	for _, layer := range layers {
+		b.data.layers.set(layer)
+	}
+
+	return cost
+}
+
+func (b *Batch) insertCostLayer(layer schema.CostLayer) error {
+	if b.data.items.get(int64(layer.ItemID)).ID == 0 {
+		return missingReference("cost_layer.item_id", layer.ItemID)
+	}
+
+	layer.UnitCost = decimal(layer.UnitCost, 4)
+	b.data.layers.insert(layer)
+
+	return nil
+}
+
+func (b *Batch) insertLedgerEntry(entry schema.LedgerEntry) error {
+	switch {
+	case b.data.items.get(int64(entry.ItemID)).ID == 0:
+		return missingReference("inventory_ledger.item_id", entry.ItemID)
+
+	case b.data.storages.get(int64(entry.StorageID)).ID == 0:
+		return missingReference("inventory_ledger.storage_id", entry.StorageID)
+	}
+
+	entry.Amount = decimal(entry.Amount, 4)
+	b.data.ledger.insert(entry)
+
+	return nil
+}
+
+func (b *Batch) NewTransaction(transaction schema.Transaction) (int64, error) {
+	transaction.Orderlines = nil
+	transaction.Amount.Float64 = decimal(transaction.Amount.Float64, 2)
+	transaction.IsCancelled = dbutils.SetBool(false)
+
+	err := b.data.checkTransaction(transaction)
+	if err != nil {
+		return 0, err
+	}
+
+	return b.data.transactions.insert(transaction), nil
+}
+
+func (b *Batch) CancelTransaction(transaction schema.Transaction) error {
+	existing := b.data.transactions.get(int64(transaction.ID))
+	if existing.ID == 0 {
+		return sql.ErrNoRows
+	}
+
+	if dbutils.GetBool(existing.IsCancelled) {
+		return repository.ErrAlreadyCancelled
+	}
+
+	transaction.IsCancelled = dbutils.SetBool(true)
+	b.data.transactions.update(int64(transaction.ID), transaction, "is_cancelled", "updated_by")
+
+	return nil
+}
+
+func (b *Batch) NewOrderline(_ string, orderline schema.Orderline) (int64, error) {
+	orderline.BaseUoMID = sql.NullInt32{}
+	orderline.UnitPrice.Float64 = decimal(orderline.UnitPrice.Float64, 2)
+	orderline.TotalAmount.Float64 = decimal(orderline.TotalAmount.Float64, 2)
+
+	err := b.data.checkOrderline(orderline)
+	if err != nil {
+		return 0, err
+	}
+
+	return b.data.orderlines.insert(orderline), nil
+}
+
+func (b *Batch) CancelOrderline(orderline schema.Orderline) error {
+	b.data.orderlines.update(int64(orderline.ID), orderline, "is_voided", "updated_by")
+	return nil
+}
+
+func (b *Batch) Event(event schema.OutboxEvent) (int64, error) {
+	if b.data.events.exists(func(e schema.OutboxEvent) bool { return e.EventID == event.EventID }) {
+		return 0, duplicate(event.EventID, "outbox_event.event_id")
+	}
+
+	event.IsDispatched = false
+
+	return b.data.events.insert(event), nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+type idempotencyKeys struct{ store *Store }
+
+func (k idempotencyKeys) Get(key, path string) (schema.IdempotencyKey, error) {
+	return get(k.store, func(d *data) schema.IdempotencyKey {
+		record, _ := d.idempotencyKeys.find(func(record schema.IdempotencyKey) bool { return record.Key == key && record.Path == path })
+		return record
+	})
+}
+
+// Reserve reserves the key like mysql.ReserveIdempotencyKey.
+func (k idempotencyKeys) Reserve(key schema.IdempotencyKey, now time.Time) (reserved bool, err error) {
+	err = k.store.write(func(d *data) error {
+		existing, _ := d.idempotencyKeys.find(func(record schema.IdempotencyKey) bool {
+			return record.Key == key.Key && record.Path == key.Path
+		})
+
+		switch {
+		case existing.ID == 0:
+			d.idempotencyKeys.insert(schema.IdempotencyKey{
+				Key:         key.Key,
+				Path:        key.Path,
+				RequestHash: key.RequestHash,
+				ExpiresAt:   key.ExpiresAt,
+			})
+
+		case existing.IsExpired(now):
+			d.idempotencyKeys.set(schema.IdempotencyKey{
+				ID:          existing.ID,
+				Key:         key.Key,
+				Path:        key.Path,
+				RequestHash: key.RequestHash,
+				DateCreated: now,
+				ExpiresAt:   key.ExpiresAt,
+			})
+
+		default:
+			return nil
+		}
+
+		reserved = true
+
+		return nil
+	})
+
+	return reserved, err
+}
+
+func (k idempotencyKeys) Save(key schema.IdempotencyKey) error {
+	return k.store.write(func(d *data) error {
+		existing, i := d.idempotencyKeys.find(func(record schema.IdempotencyKey) bool {
+			return record.Key == key.Key && record.Path == key.Path
+		})
+
+		if i >= 0 {
+			existing.StatusCode, existing.ContentType, existing.ResponseBody = key.StatusCode, key.ContentType, key.ResponseBody
+			d.idempotencyKeys.set(existing)
+		}
+
+		return nil
+	})
+}
+
+func (k idempotencyKeys) Release(key, path string) error {
+	return k.store.write(func(d *data) error {
+		existing, i := d.idempotencyKeys.find(func(record schema.IdempotencyKey) bool { return record.Key == key && record.Path == path })
+		if i >= 0 {
+			d.idempotencyKeys.delete(int64(existing.ID))
+		}
+
+		return nil
+	})
+}
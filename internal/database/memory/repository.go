@@ -0,0 +1,476 @@
+package memory
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+type (
+	users        struct{ store *Store }
+	roles        struct{ store *Store }
+	items        struct{ store *Store }
+	storages     struct{ store *Store }
+	uoms         struct{ store *Store }
+	conversions  struct{ store *Store }
+	barcodes     struct{ store *Store }
+	currencies   struct{ store *Store }
+	transactions struct{ store *Store }
+	ledger       struct{ store *Store }
+	batches      struct{ store *Store }
+)
+
+// get returns the record that fn reads from the committed records.
+func get[T any](s *Store, fn func(d *data) T) (T, error) {
+	var result T
+	s.read(func(d *data) { result = fn(d) })
+
+	return result, nil
+}
+
+func (u users) Get(id int) (schema.User, error) {
+	return get(u.store, func(d *data) schema.User { return d.users.get(int64(id)) })
+}
+
+func (u users) List() ([]schema.User, error) {
+	return get(u.store, func(d *data) []schema.User { return d.users.list() })
+}
+
+func (u users) GetByEmail(email string) (schema.User, error) {
+	return get(u.store, func(d *data) schema.User {
+		user, _ := d.users.find(func(user schema.User) bool { return user.Email.Valid && equal(user.Email.String, email) })
+		return user
+	})
+}
+
+func (u users) ListByActive(active bool) ([]schema.User, error) {
+	return get(u.store, func(d *data) []schema.User {
+		return d.users.filter(func(user schema.User) bool { return user.Active == active })
+	})
+}
+
+func (u users) Update(user schema.User) error {
+	return u.store.write(func(d *data) error {
+		return updateChecked(&d.users, user.ID, user, d.checkUser, "role_id", "first_name", "last_name", "email", "password")
+	})
+}
+
+func (u users) Patch(user schema.User, columns ...string) error {
+	return u.store.write(func(d *data) error { return patchChecked(&d.users, user.ID, user, d.checkUser, columns...) })
+}
+
+func (u users) Activate(id int) error            { return u.setActive(id, 0, true) }
+func (u users) Deactivate(id, version int) error { return u.setActive(id, version, false) }
+
+func (u users) setActive(id, version int, active bool) error {
+	return u.store.write(func(d *data) error {
+		if version != 0 && !d.users.hasVersion(int64(id), version) {
+			return repository.ErrVersionMismatch
+		}
+
+		user := d.users.get(int64(id))
+		if user.ID != 0 {
+			user.Active = active
+			d.users.replace(user)
+		}
+
+		// Like mysql.DeleteUser, a deactivated user's tokens are revoked.
+		if !active {
+			d.revokeTokens(id, "", 0)
+		}
+
+		return nil
+	})
+}
+
+func (r roles) Get(id int) (schema.Role, error) {
+	return get(r.store, func(d *data) schema.Role { return d.roles.get(int64(id)) })
+}
+
+func (r roles) List() ([]schema.Role, error) {
+	return get(r.store, func(d *data) []schema.Role { return d.roles.list() })
+}
+
+func (r roles) Create(role schema.Role) (id int64, err error) {
+	err = r.store.write(func(d *data) error {
+		id, err = insertIfNotExists(&d.roles, role, func(existing schema.Role) bool {
+			return equal(existing.Name, role.Name)
+		}, d.checkRole)
+
+		return err
+	})
+
+	return id, err
+}
+
+func (r roles) Update(role schema.Role) error {
+	return r.store.write(func(d *data) error { return updateChecked(&d.roles, role.ID, role, d.checkRole, "name") })
+}
+
+func (r roles) Patch(role schema.Role, columns ...string) error {
+	return r.store.write(func(d *data) error { return patchChecked(&d.roles, role.ID, role, d.checkRole, columns...) })
+}
+
+func (r roles) Delete(id, version int) (affected int64, err error) {
+	err = r.store.write(func(d *data) error {
+		affected, err = d.deleteRole(id, version)
+		return err
+	})
+
+	return affected, err
+}
+
+func (i items) Get(id int) (schema.Item, error) {
+	return get(i.store, func(d *data) schema.Item { return d.items.get(int64(id)) })
+}
+
+func (i items) GetBySKU(sku string) (schema.Item, error) {
+	return get(i.store, func(d *data) schema.Item {
+		item, _ := d.items.find(func(item schema.Item) bool { return item.SKU.Valid && equal(item.SKU.String, sku) })
+		return item
+	})
+}
+
+func (i items) List() ([]schema.Item, error) {
+	return get(i.store, func(d *data) []schema.Item {
+		return d.items.filter(func(i schema.Item) bool { return !i.ArchivedAt.Valid })
+	})
+}
+
+func (i items) Archived() ([]schema.Item, error) {
+	return get(i.store, func(d *data) []schema.Item {
+		return d.items.filter(func(i schema.Item) bool { return i.ArchivedAt.Valid })
+	})
+}
+
+func (i items) Archive(id, userID, version int) error {
+	return i.store.write(func(d *data) error { return archive(d, &d.items, "item.archived_by", id, userID, version) })
+}
+
+func (i items) Restore(id, version int) error {
+	return i.store.write(func(d *data) error { return archive(d, &d.items, "item.archived_by", id, 0, version) })
+}
+
+func (i items) Delete(id, version int) (affected int64, err error) {
+	err = i.store.write(func(d *data) error {
+		affected, err = d.deleteItem(id, version)
+		return err
+	})
+
+	return affected, err
+}
+
+func (s storages) Get(id int) (schema.Storage, error) {
+	return get(s.store, func(d *data) schema.Storage { return d.storages.get(int64(id)) })
+}
+
+func (s storages) List() ([]schema.Storage, error) {
+	return get(s.store, func(d *data) []schema.Storage {
+		return d.storages.filter(func(s schema.Storage) bool { return !s.ArchivedAt.Valid })
+	})
+}
+
+func (s storages) Archived() ([]schema.Storage, error) {
+	return get(s.store, func(d *data) []schema.Storage {
+		return d.storages.filter(func(s schema.Storage) bool { return s.ArchivedAt.Valid })
+	})
+}
+
+func (s storages) Archive(id, userID, version int) error {
+	return s.store.write(func(d *data) error { return archive(d, &d.storages, "storage.archived_by", id, userID, version) })
+}
+
+func (s storages) Restore(id, version int) error {
+	return s.store.write(func(d *data) error { return archive(d, &d.storages, "storage.archived_by", id, 0, version) })
+}
+
+func (s storages) Create(storage schema.Storage) (id int64, err error) {
+	err = s.store.write(func(d *data) error {
+		id, err = insertIfNotExists(&d.storages, storage, func(existing schema.Storage) bool {
+			return equal(existing.Name, storage.Name)
+		}, d.checkStorage)
+
+		return err
+	})
+
+	return id, err
+}
+
+func (s storages) Update(storage schema.Storage) error {
+	return s.store.write(func(d *data) error {
+		return updateChecked(&d.storages, storage.ID, storage, d.checkStorage, "code", "name", "description")
+	})
+}
+
+func (s storages) Patch(storage schema.Storage, columns ...string) error {
+	return s.store.write(func(d *data) error {
+		return patchChecked(&d.storages, storage.ID, storage, d.checkStorage, columns...)
+	})
+}
+
+func (s storages) Delete(id, version int) (affected int64, err error) {
+	err = s.store.write(func(d *data) error {
+		affected, err = d.deleteStorage(id, version)
+		return err
+	})
+
+	return affected, err
+}
+
+func (u uoms) Get(id int) (schema.UOM, error) {
+	return get(u.store, func(d *data) schema.UOM { return d.uoms.get(int64(id)) })
+}
+
+func (u uoms) List() ([]schema.UOM, error) {
+	return get(u.store, func(d *data) []schema.UOM {
+		return d.uoms.filter(func(u schema.UOM) bool { return !u.ArchivedAt.Valid })
+	})
+}
+
+func (u uoms) Archived() ([]schema.UOM, error) {
+	return get(u.store, func(d *data) []schema.UOM {
+		return d.uoms.filter(func(u schema.UOM) bool { return u.ArchivedAt.Valid })
+	})
+}
+
+func (u uoms) Archive(id, userID, version int) error {
+	return u.store.write(func(d *data) error {
+		return archive(d, &d.uoms, "unit_of_measurement.archived_by", id, userID, version)
+	})
+}
+
+func (u uoms) Restore(id, version int) error {
+	return u.store.write(func(d *data) error { return archive(d, &d.uoms, "unit_of_measurement.archived_by", id, 0, version) })
+}
+
+func (u uoms) Create(uom schema.UOM) (id int64, err error) {
+	err = u.store.write(func(d *data) error {
+		id, err = insertIfNotExists(&d.uoms, uom, func(existing schema.UOM) bool {
+			return equal(existing.Name, uom.Name)
+		}, d.checkUOM)
+
+		return err
+	})
+
+	return id, err
+}
+
+func (u uoms) Update(uom schema.UOM) error {
+	return u.store.write(func(d *data) error { return updateChecked(&d.uoms, uom.ID, uom, d.checkUOM, "code", "name") })
+}
+
+func (u uoms) Patch(uom schema.UOM, columns ...string) error {
+	return u.store.write(func(d *data) error { return patchChecked(&d.uoms, uom.ID, uom, d.checkUOM, columns...) })
+}
+
+func (u uoms) Delete(id, version int) (affected int64, err error) {
+	err = u.store.write(func(d *data) error {
+		affected, err = d.deleteUOM(id, version)
+		return err
+	})
+
+	return affected, err
+}
+
+func (u uoms) Conversion(itemID, uomID int) (schema.UOMConversion, error) {
+	return get(u.store, func(d *data) schema.UOMConversion {
+		conversion, _ := d.conversions.find(func(c schema.UOMConversion) bool { return c.ItemID == itemID && c.UoMID == uomID })
+		return conversion
+	})
+}
+
+func (c conversions) Get(id int) (schema.UOMConversion, error) {
+	return get(c.store, func(d *data) schema.UOMConversion { return d.conversions.get(int64(id)) })
+}
+
+func (c conversions) List(itemID int) ([]schema.UOMConversion, error) {
+	return get(c.store, func(d *data) []schema.UOMConversion {
+		return d.conversions.filter(func(c schema.UOMConversion) bool { return c.ItemID == itemID })
+	})
+}
+
+func (c conversions) Patch(conversion schema.UOMConversion, columns ...string) error {
+	return c.store.write(func(d *data) error {
+		return patchChecked(&d.conversions, conversion.ID, conversion, d.checkConversion, columns...)
+	})
+}
+
+func (c conversions) Delete(id int) (affected int64, err error) {
+	err = c.store.write(func(d *data) error {
+		affected = d.conversions.delete(int64(id))
+		return nil
+	})
+
+	return affected, err
+}
+
+func (b barcodes) Get(id int) (schema.ItemBarcode, error) {
+	return get(b.store, func(d *data) schema.ItemBarcode { return d.barcodes.get(int64(id)) })
+}
+
+func (b barcodes) List(itemID int) ([]schema.ItemBarcode, error) {
+	return get(b.store, func(d *data) []schema.ItemBarcode {
+		return d.barcodes.filter(func(b schema.ItemBarcode) bool { return b.ItemID == itemID })
+	})
+}
+
+func (b barcodes) GetByGTIN(gtin string) (schema.ItemBarcode, error) {
+	return get(b.store, func(d *data) schema.ItemBarcode {
+		barcode, _ := d.barcodes.find(func(b schema.ItemBarcode) bool { return b.GTIN == gtin })
+		return barcode
+	})
+}
+
+func (b barcodes) Delete(id int) (affected int64, err error) {
+	err = b.store.write(func(d *data) error {
+		affected = d.barcodes.delete(int64(id))
+		return nil
+	})
+
+	return affected, err
+}
+
+func (c currencies) Get(id int) (schema.Currency, error) {
+	return get(c.store, func(d *data) schema.Currency { return d.currencies.get(int64(id)) })
+}
+
+func (c currencies) List() ([]schema.Currency, error) {
+	return get(c.store, func(d *data) []schema.Currency { return d.currencies.list() })
+}
+
+func (c currencies) Active() (schema.Currency, error) {
+	return get(c.store, func(d *data) schema.Currency {
+		currency, _ := d.currencies.find(func(currency schema.Currency) bool { return currency.Active })
+		return currency
+	})
+}
+
+// Activate disables the active currency and enables the currency of the code
+// like mysql.ActivateCurrency, so no currency is active if the code does not
+// exist.
+func (c currencies) Activate(code string) error {
+	return c.store.write(func(d *data) error {
+		for i, currency := range d.currencies.rows {
+			d.currencies.rows[i].Active = equal(currency.Code, code)
+		}
+
+		return nil
+	})
+}
+
+func (t transactions) Get(id int) (schema.Transaction, error) {
+	return get(t.store, func(d *data) schema.Transaction {
+		transaction := d.transactions.get(int64(id))
+		if transaction.ID == 0 {
+			return transaction
+		}
+
+		return d.withOrderlines(transaction)
+	})
+}
+
+func (t transactions) List() ([]schema.Transaction, error) {
+	return t.Search("", "", "")
+}
+
+func (t transactions) Search(partner, transactionType, externalReference string) ([]schema.Transaction, error) {
+	return get(t.store, func(d *data) []schema.Transaction {
+		list := d.transactions.filter(func(transaction schema.Transaction) bool {
+			if externalReference != "" && (!transaction.ExternalReference.Valid ||
+				!strings.HasPrefix(strings.ToLower(transaction.ExternalReference.String), strings.ToLower(externalReference))) {
+				return false
+			}
+
+			return (partner == "" || equal(transaction.Partner, partner)) &&
+				(transactionType == "" || equal(transaction.Type, transactionType))
+		})
+
+		for i, transaction := range list {
+			list[i] = d.withOrderlines(transaction)
+		}
+
+		return list
+	})
+}
+
+func (t transactions) GetByExternalReference(partner, transactionType, externalReference string) (schema.Transaction, error) {
+	return get(t.store, func(d *data) schema.Transaction {
+		transaction, _ := d.transactions.find(func(transaction schema.Transaction) bool {
+			return equal(transaction.Partner, partner) && equal(transaction.Type, transactionType) &&
+				transaction.ExternalReference.Valid && equal(transaction.ExternalReference.String, externalReference)
+		})
+
+		return transaction
+	})
+}
+
+func (t transactions) UpdateNote(transaction schema.Transaction) error {
+	return t.store.write(func(d *data) error {
+		d.transactions.update(int64(transaction.ID), transaction, "note", "updated_by")
+		return nil
+	})
+}
+
+func (t transactions) UpdateOrderlineNote(orderline schema.Orderline) error {
+	return t.store.write(func(d *data) error {
+		d.orderlines.update(int64(orderline.ID), orderline, "note", "updated_by")
+		return nil
+	})
+}
+
+func (t transactions) Orderline(id int) (schema.Orderline, error) {
+	return get(t.store, func(d *data) schema.Orderline { return d.orderlines.get(int64(id)) })
+}
+
+func (t transactions) Patch(transaction schema.Transaction, columns ...string) error {
+	return t.store.write(func(d *data) error {
+		return patchChecked(&d.transactions, transaction.ID, transaction, d.checkTransaction, columns...)
+	})
+}
+
+func (t transactions) PatchOrderline(orderline schema.Orderline, columns ...string) error {
+	return t.store.write(func(d *data) error {
+		return patchChecked(&d.orderlines, orderline.ID, orderline, d.checkOrderline, columns...)
+	})
+}
+
+// Valuation sums the inventory ledger per item and storage like
+// mysql.GetValuation.
+func (l ledger) Valuation(before time.Time) ([]schema.Valuation, error) {
+	return get(l.store, func(d *data) []schema.Valuation {
+		var list []schema.Valuation
+
+		for _, entry := range d.ledger.rows {
+			if !entry.DateCreated.Before(before) {
+				continue
+			}
+
+			i := slices.IndexFunc(list, func(v schema.Valuation) bool {
+				return v.ItemID == entry.ItemID && v.StorageID == entry.StorageID
+			})
+
+			if i < 0 {
+				item := d.items.get(int64(entry.ItemID))
+				list = append(list, schema.Valuation{ItemID: item.ID, Name: item.Name, Category: item.Category, StorageID: entry.StorageID})
+				i = len(list) - 1
+			}
+
+			list[i].Quantity += entry.Quantity
+			list[i].Value = decimal(list[i].Value+entry.Amount, 4)
+		}
+
+		slices.SortFunc(list, func(x, y schema.Valuation) int {
+			return cmp.Or(cmp.Compare(x.ItemID, y.ItemID), cmp.Compare(x.StorageID, y.StorageID))
+		})
+
+		return list
+	})
+}
+
+func (b batches) Begin() (repository.Batch, error) {
+	return newBatch(b.store), nil
+}
@@ -0,0 +1,506 @@
+// Package memory is an in-memory implementation of the repository with the
+// same semantics as the MySQL one, including its unique keys and foreign keys,
+// so that the handlers can be tested without a database.
+package memory
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+var (
+	// ErrDuplicate is returned when a value of a unique key is already used.
+	ErrDuplicate = errors.New("duplicate entry")
+
+	// ErrReference is returned when a record references a record that does not
+	// exist, or when a record that is still referenced is deleted.
+	ErrReference = errors.New("foreign key constraint fails")
+)
+
+// Store keeps the records in memory. Writes are applied one at a time, like
+// the row locks of the batches serialize them in MySQL, while reads see the
+// last committed records.
+type Store struct {
+	// writer is held by a batch or a single write until it is done.
+	writer sync.Mutex
+
+	mu   sync.RWMutex
+	data *data
+}
+
+type data struct {
+	users           table[schema.User]
+	roles           table[schema.Role]
+	items           table[schema.Item]
+	storages        table[schema.Storage]
+	uoms            table[schema.UOM]
+	conversions     table[schema.UOMConversion]
+	barcodes        table[schema.ItemBarcode]
+	currencies      table[schema.Currency]
+	transactions    table[schema.Transaction]
+	orderlines      table[schema.Orderline]
+	layers          table[schema.CostLayer]
+	ledger          table[schema.LedgerEntry]
+	events          table[schema.OutboxEvent]
+	webhooks        table[schema.Webhook]
+	deliveries      table[schema.WebhookDelivery]
+	tokens          table[schema.UserToken]
+	idempotencyKeys table[schema.IdempotencyKey]
+}
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{data: &data{}}
+}
+
+// Repository returns the data access of the API handlers backed by the store.
+func (s *Store) Repository() repository.Store {
+	return repository.Store{
+		Users:           users{s},
+		Roles:           roles{s},
+		Items:           items{s},
+		Storages:        storages{s},
+		UOMs:            uoms{s},
+		Conversions:     conversions{s},
+		Barcodes:        barcodes{s},
+		Currencies:      currencies{s},
+		Transactions:    transactions{s},
+		Ledger:          ledger{s},
+		Webhooks:        webhooks{s},
+		Batches:         batches{s},
+		Tokens:          tokens{s},
+		IdempotencyKeys: idempotencyKeys{s},
+	}
+}
+
+// read runs fn on the committed records.
+func (s *Store) read(fn func(d *data)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fn(s.data)
+}
+
+// write runs fn on the committed records like a statement outside of a
+// transaction.
+func (s *Store) write(fn func(d *data) error) error {
+	s.writer.Lock()
+	defer s.writer.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clone := s.data.clone()
+
+	err := fn(clone)
+	if err != nil {
+		return err
+	}
+
+	s.data = clone
+
+	return nil
+}
+
+// Currency adds a currency, which can only be seeded from the configuration.
+func (s *Store) Currency(currency schema.Currency) int64 {
+	var id int64
+
+	_ = s.write(func(d *data) error {
+		id = d.currencies.insert(currency)
+		return nil
+	})
+
+	return id
+}
+
+// Conversion adds the conversion of a unit of measurement of an item.
+func (s *Store) Conversion(conversion schema.UOMConversion) (id int64, err error) {
+	err = s.write(func(d *data) error {
+		if d.conversions.exists(func(c schema.UOMConversion) bool {
+			return c.ItemID == conversion.ItemID && c.UoMID == conversion.UoMID
+		}) {
+			return duplicate(conversion.UoMID, "uom_conversion.idx_item_uom")
+		}
+
+		err := d.checkConversion(conversion)
+		if err != nil {
+			return err
+		}
+
+		id = d.conversions.insert(conversion)
+
+		return nil
+	})
+
+	return id, err
+}
+
+// CostLayers returns the cost layers of the item.
+func (s *Store) CostLayers(itemID int) []schema.CostLayer {
+	var layers []schema.CostLayer
+	s.read(func(d *data) {
+		layers = d.layers.filter(func(layer schema.CostLayer) bool { return layer.ItemID == itemID })
+	})
+
+	return layers
+}
+
+// Ledger returns the entries of the item in the inventory ledger.
+func (s *Store) Ledger(itemID int) []schema.LedgerEntry {
+	var entries []schema.LedgerEntry
+	s.read(func(d *data) {
+		entries = d.ledger.filter(func(entry schema.LedgerEntry) bool { return entry.ItemID == itemID })
+	})
+
+	return entries
+}
+
+// Events returns the events written to the outbox.
+func (s *Store) Events() []schema.OutboxEvent {
+	var events []schema.OutboxEvent
+	s.read(func(d *data) { events = d.events.list() })
+
+	return events
+}
+
+func (d *data) clone() *data {
+	return &data{
+		users:           d.users.clone(),
+		roles:           d.roles.clone(),
+		items:           d.items.clone(),
+		storages:        d.storages.clone(),
+		uoms:            d.uoms.clone(),
+		conversions:     d.conversions.clone(),
+		barcodes:        d.barcodes.clone(),
+		currencies:      d.currencies.clone(),
+		transactions:    d.transactions.clone(),
+		orderlines:      d.orderlines.clone(),
+		layers:          d.layers.clone(),
+		ledger:          d.ledger.clone(),
+		events:          d.events.clone(),
+		webhooks:        d.webhooks.clone(),
+		deliveries:      d.deliveries.clone(),
+		tokens:          d.tokens.clone(),
+		idempotencyKeys: d.idempotencyKeys.clone(),
+	}
+}
+
+// equal compares the values like the case-insensitive collation of MySQL.
+func equal(a, b string) bool { return strings.EqualFold(a, b) }
+
+func duplicate(value any, key string) error {
+	return fmt.Errorf("%w '%v' for key '%s'", ErrDuplicate, value, key)
+}
+
+func missingReference(column string, id int) error {
+	return fmt.Errorf("cannot add or update a child row: %w (%s = %d)", ErrReference, column, id)
+}
+
+func referenced(table, column string) error {
+	return fmt.Errorf("cannot delete or update a parent row: %w (%s.%s)", ErrReference, table, column)
+}
+
+func (d *data) checkUser(user schema.User) error {
+	if user.Email.Valid && d.users.exists(func(u schema.User) bool {
+		return u.ID != user.ID && u.Email.Valid && equal(u.Email.String, user.Email.String)
+	}) {
+		return duplicate(user.Email.String, "users.email")
+	}
+
+	if d.roles.get(int64(user.RoleID)).ID == 0 {
+		return missingReference("users.role_id", user.RoleID)
+	}
+
+	return nil
+}
+
+func (d *data) checkRole(role schema.Role) error {
+	if d.roles.exists(func(r schema.Role) bool { return r.ID != role.ID && equal(r.Name, role.Name) }) {
+		return duplicate(role.Name, "role.name")
+	}
+
+	return nil
+}
+
+func (d *data) checkStorage(storage schema.Storage) error {
+	if d.storages.exists(func(s schema.Storage) bool { return s.ID != storage.ID && equal(s.Code, storage.Code) }) {
+		return duplicate(storage.Code, "storage.code")
+	}
+
+	return nil
+}
+
+func (d *data) checkUOM(uom schema.UOM) error {
+	if d.uoms.exists(func(u schema.UOM) bool { return u.ID != uom.ID && equal(u.Code, uom.Code) }) {
+		return duplicate(uom.Code, "unit_of_measurement.code")
+	}
+
+	return nil
+}
+
+func (d *data) checkConversion(conversion schema.UOMConversion) error {
+	switch {
+	case d.items.get(int64(conversion.ItemID)).ID == 0:
+		return missingReference("uom_conversion.item_id", conversion.ItemID)
+
+	case d.uoms.get(int64(conversion.UoMID)).ID == 0:
+		return missingReference("uom_conversion.uom_id", conversion.UoMID)
+	}
+
+	return nil
+}
+
+func (d *data) checkBarcode(barcode schema.ItemBarcode) error {
+	switch {
+	case d.items.get(int64(barcode.ItemID)).ID == 0:
+		return missingReference("item_barcode.item_id", barcode.ItemID)
+
+	case barcode.UoMID.Valid && d.uoms.get(int64(barcode.UoMID.Int32)).ID == 0:
+		return missingReference("item_barcode.uom_id", int(barcode.UoMID.Int32))
+	}
+
+	return nil
+}
+
+func (d *data) checkItem(item schema.Item) error {
+	if item.SKU.Valid && d.items.exists(func(i schema.Item) bool {
+		return i.ID != item.ID && i.SKU.Valid && equal(i.SKU.String, item.SKU.String)
+	}) {
+		return duplicate(item.SKU.String, "item.sku")
+	}
+
+	switch {
+	case d.uoms.get(int64(item.UoMID)).ID == 0:
+		return missingReference("item.uom_id", item.UoMID)
+
+	case d.storages.get(int64(item.StorageID)).ID == 0:
+		return missingReference("item.storage_id", item.StorageID)
+
+	case d.users.get(int64(item.CreatedBy)).ID == 0:
+		return missingReference("item.created_by", item.CreatedBy)
+	}
+
+	return nil
+}
+
+func (d *data) checkTransaction(transaction schema.Transaction) error {
+	if d.transactions.exists(func(t schema.Transaction) bool {
+		return t.ID != transaction.ID && equal(t.Reference, transaction.Reference)
+	}) {
+		return duplicate(transaction.Reference, "transactions.reference")
+	}
+
+	if transaction.ExternalReference.Valid && d.transactions.exists(func(t schema.Transaction) bool {
+		return t.ID != transaction.ID && equal(t.Partner, transaction.Partner) && equal(t.Type, transaction.Type) &&
+			t.ExternalReference.Valid && equal(t.ExternalReference.String, transaction.ExternalReference.String)
+	}) {
+		return duplicate(transaction.ExternalReference.String, "transactions.external_reference")
+	}
+
+	if d.users.get(int64(transaction.CreatedBy)).ID == 0 {
+		return missingReference("transactions.created_by", transaction.CreatedBy)
+	}
+
+	if transaction.UpdatedBy.Valid && d.users.get(int64(transaction.UpdatedBy.Int32)).ID == 0 {
+		return missingReference("transactions.updated_by", int(transaction.UpdatedBy.Int32))
+	}
+
+	return nil
+}
+
+func (d *data) checkOrderline(orderline schema.Orderline) error {
+	switch {
+	case d.transactions.get(int64(orderline.TransactionID)).ID == 0:
+		return missingReference("orderline.transaction_id", orderline.TransactionID)
+
+	case d.items.get(int64(orderline.ItemID)).ID == 0:
+		return missingReference("orderline.item_id", orderline.ItemID)
+
+	case orderline.UoMID.Valid && d.uoms.get(int64(orderline.UoMID.Int32)).ID == 0:
+		return missingReference("orderline.uom_id", int(orderline.UoMID.Int32))
+
+	case d.users.get(int64(orderline.CreatedBy)).ID == 0:
+		return missingReference("orderline.created_by", orderline.CreatedBy)
+
+	case orderline.UpdatedBy.Valid && d.users.get(int64(orderline.UpdatedBy.Int32)).ID == 0:
+		return missingReference("orderline.updated_by", int(orderline.UpdatedBy.Int32))
+	}
+
+	return nil
+}
+
+// deleteRole deletes the role unless it is still referenced. A version other
+// than 0 must be the version of the record.
+func (d *data) deleteRole(id, version int) (int64, error) {
+	switch {
+	case !d.roles.hasVersion(int64(id), version):
+		return 0, repository.ErrVersionMismatch
+
+	case d.users.exists(func(u schema.User) bool { return u.RoleID == id }):
+		return 0, referenced("users", "role_id")
+	}
+
+	return d.roles.delete(int64(id)), nil
+}
+
+// deleteStorage, deleteUOM and deleteItem delete the record like
+// mysql.DeleteStorage, mysql.DeleteUOM and mysql.DeleteItem: the records that
+// refer to it, and the stock of an item, are checked after its version.
+func (d *data) deleteStorage(id, version int) (int64, error) {
+	if version != 0 && !d.storages.hasVersion(int64(id), version) {
+		return 0, repository.ErrVersionMismatch
+	}
+
+	err := referencedBy(map[string]int{
+		"item":             len(d.items.filter(func(i schema.Item) bool { return i.StorageID == id })),
+		"inventory_ledger": len(d.ledger.filter(func(e schema.LedgerEntry) bool { return e.StorageID == id })),
+	}, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return d.storages.delete(int64(id)), nil
+}
+
+func (d *data) deleteUOM(id, version int) (int64, error) {
+	if version != 0 && !d.uoms.hasVersion(int64(id), version) {
+		return 0, repository.ErrVersionMismatch
+	}
+
+	err := referencedBy(map[string]int{
+		"item":           len(d.items.filter(func(i schema.Item) bool { return i.UoMID == id })),
+		"uom_conversion": len(d.conversions.filter(func(c schema.UOMConversion) bool { return c.UoMID == id })),
+		"orderline":      len(d.orderlines.filter(func(o schema.Orderline) bool { return int(o.UoMID.Int32) == id })),
+	}, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return d.uoms.delete(int64(id)), nil
+}
+
+func (d *data) deleteItem(id, version int) (int64, error) {
+	if version != 0 && !d.items.hasVersion(int64(id), version) {
+		return 0, repository.ErrVersionMismatch
+	}
+
+	err := referencedBy(map[string]int{
+		"uom_conversion":   len(d.conversions.filter(func(c schema.UOMConversion) bool { return c.ItemID == id })),
+		"orderline":        len(d.orderlines.filter(func(o schema.Orderline) bool { return o.ItemID == id })),
+		"cost_layer":       len(d.layers.filter(func(l schema.CostLayer) bool { return l.ItemID == id })),
+		"inventory_ledger": len(d.ledger.filter(func(e schema.LedgerEntry) bool { return e.ItemID == id })),
+	}, d.items.get(int64(id)).Quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	return d.items.delete(int64(id)), nil
+}
+
+// referencedBy returns a *repository.ReferencedError of the number of records of
+// each table that refer to a record and its stock, or nil if neither keeps it
+// from being deleted.
+func referencedBy(references map[string]int, quantity int) error {
+	maps.DeleteFunc(references, func(_ string, count int) bool { return count == 0 })
+
+	if len(references) == 0 && quantity == 0 {
+		return nil
+	}
+
+	return &repository.ReferencedError{References: references, Quantity: quantity}
+}
+
+// archive sets the row of the ID as archived by the user like
+// mysql.ArchiveRecordByID, or as not archived if the user is 0. The column is
+// the 'archived_by' column of the table, for the error of a user that does not
+// exist.
+func archive[T any](d *data, t *table[T], column string, id, userID, version int) error {
+	if userID != 0 && d.users.get(int64(userID)).ID == 0 {
+		return missingReference(column, userID)
+	}
+
+	var changes T
+
+	row := reflect.ValueOf(&changes).Elem()
+	setColumn(row, "version", reflect.ValueOf(version))
+
+	if userID != 0 {
+		setColumn(row, "archived_at", reflect.ValueOf(sql.NullTime{Time: time.Now().UTC(), Valid: true}))
+		setColumn(row, "archived_by", reflect.ValueOf(sql.NullInt32{Int32: int32(userID), Valid: true}))
+	}
+
+	return patchChecked(t, id, changes, func(T) error { return nil }, "archived_at", "archived_by")
+}
+
+// insertIfNotExists inserts the row if no row matches, like
+// mysql.InsertIfNotExists. It returns 0 if a row already matches.
+func insertIfNotExists[T any](t *table[T], row T, match func(T) bool, check func(T) error) (int64, error) {
+	if t.exists(match) {
+		return 0, nil
+	}
+
+	err := check(row)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.insert(row), nil
+}
+
+// updateChecked updates the row like table.update. The row is left unchanged if
+// the updated row fails the check.
+func updateChecked[T any](t *table[T], id int, changes T, check func(T) error, columns ...string) error {
+	return checked(t, id, versionOf(changes), check, func() (T, bool) { return t.update(int64(id), changes, columns...) })
+}
+
+// patchChecked patches the row like table.patch. The row is left unchanged if
+// the patched row fails the check.
+func patchChecked[T any](t *table[T], id int, changes T, check func(T) error, columns ...string) error {
+	return checked(t, id, versionOf(changes), check, func() (T, bool) { return t.patch(int64(id), changes, columns...) })
+}
+
+// checked writes the row of the ID and restores it if the written row fails the
+// check. If the version is not 0, the row is only written if it still has that
+// version.
+func checked[T any](t *table[T], id int, version int, check func(T) error, write func() (T, bool)) error {
+	if version != 0 && !t.hasVersion(int64(id), version) {
+		return repository.ErrVersionMismatch
+	}
+
+	previous := t.get(int64(id))
+
+	row, ok := write()
+	if !ok {
+		return nil
+	}
+
+	err := check(row)
+	if err != nil {
+		t.set(previous)
+	}
+
+	return err
+}
+
+// withOrderlines returns the transaction along with its orderlines and the base
+// unit of measurement of their items.
+func (d *data) withOrderlines(transaction schema.Transaction) schema.Transaction {
+	transaction.Orderlines = d.orderlines.filter(func(o schema.Orderline) bool { return o.TransactionID == transaction.ID })
+
+	for i, orderline := range transaction.Orderlines {
+		item := d.items.get(int64(orderline.ItemID))
+		transaction.Orderlines[i].BaseUoMID.Int32, transaction.Orderlines[i].BaseUoMID.Valid = int32(item.UoMID), true
+	}
+
+	return transaction
+}
@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"math"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// table is the rows of a table ordered by their ID, which is assigned on insert
+// like an AUTO_INCREMENT column.
+type table[T any] struct {
+	rows   []T
+	lastID int64
+}
+
+func (t table[T]) clone() table[T] {
+	return table[T]{rows: slices.Clone(t.rows), lastID: t.lastID}
+}
+
+// insert adds the row with the next ID, the first version and the current date
+// as its date of creation and modification, and returns its ID.
+func (t *table[T]) insert(row T) int64 {
+	t.lastID++
+
+	value := reflect.ValueOf(&row).Elem()
+	value.FieldByName("ID").SetInt(t.lastID)
+
+	now := time.Now().UTC()
+	setColumn(value, "version", reflect.ValueOf(1))
+	setColumn(value, "date_created", reflect.ValueOf(now))
+	setColumn(value, "date_modified", reflect.ValueOf(sql.NullTime{Time: now, Valid: true}))
+
+	t.rows = append(t.rows, row)
+
+	return t.lastID
+}
+
+// get returns the row of the ID, or the zero value if there is none.
+func (t *table[T]) get(id int64) T {
+	row, _ := t.find(func(row T) bool { return idOf(row) == id })
+	return row
+}
+
+// find returns the first row that matches and its index, or the zero value and
+// -1 if there is none.
+func (t *table[T]) find(match func(T) bool) (T, int) {
+	i := slices.IndexFunc(t.rows, match)
+	if i < 0 {
+		var zero T
+		return zero, -1
+	}
+
+	return t.rows[i], i
+}
+
+func (t *table[T]) exists(match func(T) bool) bool {
+	_, i := t.find(match)
+	return i >= 0
+}
+
+// filter returns the rows that match, or nil if there are none like a query
+// that returns no rows.
+func (t *table[T]) filter(match func(T) bool) []T {
+	var rows []T
+	for _, row := range t.rows {
+		if match(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// list returns all the rows, or nil if there are none.
+func (t *table[T]) list() []T {
+	return t.filter(func(T) bool { return true })
+}
+
+// set replaces the row that has the same ID as it is.
+func (t *table[T]) set(row T) {
+	_, i := t.find(func(existing T) bool { return idOf(existing) == idOf(row) })
+	if i >= 0 {
+		t.rows[i] = row
+	}
+}
+
+// replace replaces the row that has the same ID like an UPDATE of all its
+// columns, which increments its version and sets its date of modification.
+func (t *table[T]) replace(row T) {
+	modified(reflect.ValueOf(&row).Elem())
+	t.set(row)
+}
+
+// update sets the columns of the row of the ID to the values of the changes
+// like mysql.UpdateRecordByID: a NULL, empty or zero value leaves the column
+// unchanged. It returns the updated row, or false if there is no row with the
+// ID.
+func (t *table[T]) update(id int64, changes T, columns ...string) (T, bool) {
+	return t.assign(id, changes, isEmpty, columns...)
+}
+
+// patch sets the columns of the row of the ID to the values of the changes like
+// mysql.PatchRecordByID, including NULL, empty and zero values. It returns the
+// updated row, or false if there is no row with the ID.
+func (t *table[T]) patch(id int64, changes T, columns ...string) (T, bool) {
+	return t.assign(id, changes, func(reflect.Value) bool { return false }, columns...)
+}
+
+// assign sets the columns of the row of the ID to the values of the changes
+// that are not skipped.
+func (t *table[T]) assign(id int64, changes T, skip func(reflect.Value) bool, columns ...string) (T, bool) {
+	row, i := t.find(func(row T) bool { return idOf(row) == id })
+	if i < 0 {
+		return row, false
+	}
+
+	target := reflect.ValueOf(&row).Elem()
+	source := reflect.ValueOf(changes)
+
+	for j := range target.NumField() {
+		column := target.Type().Field(j).Tag.Get("db")
+		if slices.Contains(columns, column) && !skip(source.Field(j)) {
+			target.Field(j).Set(source.Field(j))
+		}
+	}
+
+	modified(target)
+	t.rows[i] = row
+
+	return row, true
+}
+
+// hasVersion reports whether the row of the ID has the version, or whether
+// there is a row of the ID if the version is 0.
+func (t *table[T]) hasVersion(id int64, version int) bool {
+	row, i := t.find(func(row T) bool { return idOf(row) == id })
+	return i >= 0 && (version == 0 || versionOf(row) == version)
+}
+
+// delete deletes the row of the ID and returns the number of rows deleted.
+func (t *table[T]) delete(id int64) int64 {
+	before := len(t.rows)
+	t.rows = slices.DeleteFunc(t.rows, func(row T) bool { return idOf(row) == id })
+
+	return int64(before - len(t.rows))
+}
+
+func idOf[T any](row T) int64 {
+	return reflect.ValueOf(row).FieldByName("ID").Int()
+}
+
+// versionOf returns the version of the row, or 0 if it has none.
+func versionOf[T any](row T) int {
+	if field := column(reflect.ValueOf(row), "version"); field.IsValid() {
+		return int(field.Int())
+	}
+
+	return 0
+}
+
+// modified increments the version of the row and sets its date of
+// modification, if it has them.
+func modified(row reflect.Value) {
+	if field := column(row, "version"); field.IsValid() {
+		field.SetInt(field.Int() + 1)
+	}
+
+	setColumn(row, "date_modified", reflect.ValueOf(sql.NullTime{Time: time.Now().UTC(), Valid: true}))
+}
+
+// setColumn sets the field of the column if the row has it.
+func setColumn(row reflect.Value, name string, value reflect.Value) {
+	if field := column(row, name); field.IsValid() {
+		field.Set(value)
+	}
+}
+
+// column returns the field of the column, or the zero value if the row does not
+// have it.
+func column(row reflect.Value, name string) reflect.Value {
+	for i := range row.NumField() {
+		if row.Type().Field(i).Tag.Get("db") == name {
+			return row.Field(i)
+		}
+	}
+
+	return reflect.Value{}
+}
+
+// isEmpty reports whether the value is NULL or an empty string in MySQL, which
+// 0 and false also compare equal to.
+func isEmpty(value reflect.Value) bool {
+	if valuer, ok := value.Interface().(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil || v == nil {
+			return true
+		}
+
+		return reflect.ValueOf(v).IsZero()
+	}
+
+	return value.IsZero()
+}
+
+// decimal rounds the amount to the scale of a DECIMAL column.
+func decimal(amount float64, scale int) float64 {
+	factor := math.Pow10(scale)
+	return math.Round(amount*factor) / factor
+}
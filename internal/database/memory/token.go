@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+type tokens struct{ store *Store }
+
+func (t tokens) Get(hash string) (schema.UserToken, error) {
+	return get(t.store, func(d *data) schema.UserToken {
+		token, _ := d.tokens.find(func(token schema.UserToken) bool { return token.TokenHash == hash })
+		return token
+	})
+}
+
+func (t tokens) Issue(token schema.UserToken) error {
+	return t.store.write(func(d *data) error {
+		if d.tokens.exists(func(existing schema.UserToken) bool { return existing.TokenHash == token.TokenHash }) {
+			return duplicate(token.TokenHash, "user_token.idx_token_hash")
+		}
+
+		if d.users.get(int64(token.UserID)).ID == 0 {
+			return missingReference("user_token.user_id", token.UserID)
+		}
+
+		d.revokeTokens(token.UserID, token.Purpose, 0)
+		d.tokens.insert(token)
+
+		return nil
+	})
+}
+
+// Redeem redeems the token like mysql.RedeemUserToken.
+func (t tokens) Redeem(token schema.UserToken, now time.Time, user schema.User, columns ...string) error {
+	return t.store.write(func(d *data) error {
+		current := d.tokens.get(int64(token.ID))
+		if current.ID == 0 || current.UsedAt.Valid || current.IsExpired(now) {
+			return repository.ErrInvalidToken
+		}
+
+		current.UsedAt = sql.NullTime{Time: now, Valid: true}
+		d.tokens.set(current)
+		d.revokeTokens(token.UserID, token.Purpose, token.ID)
+
+		return patchChecked(&d.users, user.ID, user, d.checkUser, columns...)
+	})
+}
+
+// revokeTokens marks the unused tokens of the user as used, other than the token
+// of the ID. An empty purpose revokes the tokens of every purpose.
+func (d *data) revokeTokens(userID int, purpose string, id int) {
+	now := sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	for i, token := range d.tokens.rows {
+		if token.UserID == userID && (purpose == "" || token.Purpose == purpose) && token.ID != id && !token.UsedAt.Valid {
+			d.tokens.rows[i].UsedAt = now
+		}
+	}
+}
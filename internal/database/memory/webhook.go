@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"slices"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+type webhooks struct{ store *Store }
+
+func (w webhooks) Get(id int) (schema.Webhook, error) {
+	return get(w.store, func(d *data) schema.Webhook { return d.webhooks.get(int64(id)) })
+}
+
+func (w webhooks) List() ([]schema.Webhook, error) {
+	return get(w.store, func(d *data) []schema.Webhook { return d.webhooks.list() })
+}
+
+func (w webhooks) Create(webhook schema.Webhook) (id int64, err error) {
+	err = w.store.write(func(d *data) error {
+		id = d.webhooks.insert(webhook)
+		return nil
+	})
+
+	return id, err
+}
+
+// Delete deletes the webhook along with its deliveries like the cascading
+// foreign key of the deliveries.
+func (w webhooks) Delete(id int) (affected int64, err error) {
+	err = w.store.write(func(d *data) error {
+		d.deliveries.rows = slices.DeleteFunc(d.deliveries.rows, func(delivery schema.WebhookDelivery) bool {
+			return delivery.WebhookID == id
+		})
+
+		affected = d.webhooks.delete(int64(id))
+
+		return nil
+	})
+
+	return affected, err
+}
+
+func (w webhooks) Deliveries(status string, webhookID int) ([]schema.WebhookDelivery, error) {
+	return get(w.store, func(d *data) []schema.WebhookDelivery {
+		list := d.deliveries.filter(func(delivery schema.WebhookDelivery) bool {
+			return (status == "" || delivery.Status == status) && (webhookID == 0 || delivery.WebhookID == webhookID)
+		})
+
+		slices.Reverse(list)
+
+		return list
+	})
+}
+
+// Retry queues the delivery like mysql.RetryWebhookDelivery if it is dead.
+func (w webhooks) Retry(id int64) (affected int64, err error) {
+	err = w.store.write(func(d *data) error {
+		delivery := d.deliveries.get(id)
+		if delivery.ID == 0 || delivery.Status != schema.DeliveryDead {
+			return nil
+		}
+
+		delivery.Status = schema.DeliveryPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = time.Now().UTC()
+		d.deliveries.replace(delivery)
+		affected = 1
+
+		return nil
+	})
+
+	return affected, err
+}
@@ -0,0 +1,29 @@
+package mysql
+
+import (
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/query"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+func ListItemBarcode(itemID int) ([]schema.ItemBarcode, error) {
+	return FetchItemsByFields[schema.ItemBarcode](ItemBarcodeTable, query.Eq("item_id", itemID))
+}
+
+func GetItemBarcodeByID(id int) (schema.ItemBarcode, error) {
+	return RetrieveItemByField[schema.ItemBarcode](ItemBarcodeTable, "id", id)
+}
+
+// GetItemBarcodeByGTIN retrieves a barcode by its 14-digit GTIN.
+func GetItemBarcodeByGTIN(gtin string) (schema.ItemBarcode, error) {
+	return RetrieveItemByField[schema.ItemBarcode](ItemBarcodeTable, "gtin", gtin)
+}
+
+func NewItemBarcode(barcode schema.ItemBarcode) (int64, error) {
+	return InsertRecord(ItemBarcodeTable, barcode, "item_id", "uom_id", "barcode", "gtin", "type")
+}
+
+func DeleteItemBarcode(id int) (int64, error) { return DeleteRecordByID(ItemBarcodeTable, id) }
+
+func ItemBarcodeExists(gtin string) (bool, error) {
+	return exists(func() (schema.ItemBarcode, error) { return GetItemBarcodeByGTIN(gtin) })
+}
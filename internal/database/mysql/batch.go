@@ -0,0 +1,305 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// batchSavepoint is the savepoint that each record of a batch is applied in.
+const batchSavepoint string = "batch_record"
+
+// Batch writes the records of a bulk request or an import in a single database
+// transaction. Each record is applied in its own savepoint (see Record) so that
+// a failed record is undone without discarding the others, and the batch is
+// then either committed or rolled back as a whole. Records written earlier in
+// the batch are visible to the later ones.
+type Batch struct {
+	tx *sqlx.Tx
+}
+
+// NewBatch begins the database transaction of a batch.
+func NewBatch() (*Batch, error) {
+	tx, err := database.Beginx()
+	if err != nil {
+		trail.Error("[batch] failed to begin transaction: %s", err.Error())
+		return nil, err
+	}
+
+	return &Batch{tx: tx}, nil
+}
+
+// Commit saves all the records of the batch.
+func (b *Batch) Commit() error { return b.tx.Commit() }
+
+// Rollback discards all the records of the batch. It is a no-op if the batch
+// was already committed or rolled back.
+func (b *Batch) Rollback() error {
+	err := b.tx.Rollback()
+	if errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+
+	return err
+}
+
+// Record applies a single record of the batch. If fn fails, everything it wrote
+// is undone and its error is returned.
+func (b *Batch) Record(fn func() error) error {
+	_, err := b.tx.Exec("SAVEPOINT " + batchSavepoint)
+	if err != nil {
+		trail.Error("[batch] failed to create savepoint: %s", err.Error())
+		return err
+	}
+
+	err = fn()
+	if err != nil {
+		_, rollbackErr := b.tx.Exec("ROLLBACK TO SAVEPOINT " + batchSavepoint)
+		if rollbackErr != nil {
+			trail.Error("[batch] failed to rollback to savepoint: %s", rollbackErr.Error())
+			return rollbackErr
+		}
+
+		return err
+	}
+
+	_, err = b.tx.Exec("RELEASE SAVEPOINT " + batchSavepoint)
+	if err != nil {
+		trail.Error("[batch] failed to release savepoint: %s", err.Error())
+	}
+
+	return err
+}
+
+// Storage inserts the storage if its code does not exist yet. It returns 0 if
+// the storage already exists.
+func (b *Batch) Storage(storage schema.Storage) (int64, error) {
+	return insertIfNotExists(b.tx, StorageTable, storage, "code", "code", "name", "description")
+}
+
+// UOM inserts the unit of measurement if its code does not exist yet. It returns
+// 0 if the unit of measurement already exists.
+func (b *Batch) UOM(uom schema.UOM) (int64, error) {
+	return insertIfNotExists(b.tx, UoMTable, uom, "code", "code", "name")
+}
+
+// Item inserts the item if its name does not exist yet. It returns 0 if the
+// item already exists.
+func (b *Batch) Item(item schema.Item) (int64, error) {
+	fields := []string{
+		"sku",
+		"name",
+		"description",
+		"category",
+		"quantity",
+		"unit_price",
+		"uom_id",
+		"stock_status",
+		"storage_id",
+		"created_by",
+	}
+
+	return insertIfNotExists(b.tx, ItemTable, item, "name", fields...)
+}
+
+// Role inserts the role if its name does not exist yet. It returns 0 if the
+// role already exists.
+func (b *Batch) Role(role schema.Role) (int64, error) {
+	return insertIfNotExists(b.tx, RoleTable, role, "name", "name")
+}
+
+// UOMConversion inserts the conversion unless the item already has one of the
+// unit of measurement. It returns 0 if the conversion already exists.
+func (b *Batch) UOMConversion(conversion schema.UOMConversion) (int64, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE item_id = ? AND uom_id = ?;", UoMConversionTable)
+
+	existing, err := retrieveWith[schema.UOMConversion](b.tx, query, conversion.ItemID, conversion.UoMID)
+	if err != nil || existing.ID != 0 {
+		return 0, err
+	}
+
+	return insertRecord(b.tx, UoMConversionTable, conversion, "item_id", "uom_id", "factor")
+}
+
+// ItemBarcode inserts the barcode if its GTIN is not assigned yet. It returns 0
+// if the barcode already exists.
+func (b *Batch) ItemBarcode(barcode schema.ItemBarcode) (int64, error) {
+	return insertIfNotExists(b.tx, ItemBarcodeTable, barcode, "gtin", "item_id", "uom_id", "barcode", "gtin", "type")
+}
+
+// UpdateStorage updates the storage like UpdateStorage.
+func (b *Batch) UpdateStorage(storage schema.Storage) error {
+	return updateRecordByID(b.tx, StorageTable, storage, "code", "name", "description")
+}
+
+// UpdateUOM updates the unit of measurement like UpdateUOM.
+func (b *Batch) UpdateUOM(uom schema.UOM) error {
+	return updateRecordByID(b.tx, UoMTable, uom, "code", "name")
+}
+
+// UpdateRole updates the role like UpdateRole.
+func (b *Batch) UpdateRole(role schema.Role) error {
+	return updateRecordByID(b.tx, RoleTable, role, "name")
+}
+
+// UpdateUOMConversion updates the factor of the conversion like
+// UpdateUOMConversion.
+func (b *Batch) UpdateUOMConversion(conversion schema.UOMConversion) error {
+	return updateRecordByID(b.tx, UoMConversionTable, conversion, "factor")
+}
+
+// UpdateItem updates the item like UpdateItem. The quantity is left as is,
+// since only the stock movements that are recorded in the ledger change it.
+func (b *Batch) UpdateItem(item schema.Item) error {
+	fields := []string{
+		"sku",
+		"name",
+		"description",
+		"category",
+		"unit_price",
+		"storage_id",
+		"uom_id",
+	}
+
+	return updateRecordByID(b.tx, ItemTable, item, fields...)
+}
+
+// PatchItem sets the columns of the item to its values, including empty ones.
+func (b *Batch) PatchItem(item schema.Item, columns ...string) error {
+	return patchRecordByID(b.tx, ItemTable, item, columns...)
+}
+
+// User inserts the user if there is no user with the same name yet. It returns
+// 0 if the user already exists.
+func (b *Batch) User(user schema.User) (int64, error) {
+	existing, err := userByName(b.tx, user.FirstName, user.LastName)
+	if err != nil {
+		return 0, err
+	}
+
+	if existing.ID != 0 {
+		return 0, nil
+	}
+
+	return insertRecord(
+		b.tx,
+		UserTable,
+		user,
+		"role_id",
+		"first_name",
+		"last_name",
+		"email",
+		"password",
+		"is_active",
+	)
+}
+
+// ReceiveStock records the stock received as a cost layer like ReceiveStock.
+func (b *Batch) ReceiveStock(layer schema.CostLayer, storageID int) error {
+	return receiveStock(b.tx, layer, storageID)
+}
+
+// OpeningStock adds the quantity to the stock on hand of the item and records
+// it as a cost layer at the unit cost.
+func (b *Batch) OpeningStock(item schema.Item, quantity int, unitCost float64) error {
+	query := fmt.Sprintf("UPDATE %s SET quantity = quantity + ?, stock_status = ?, version = version + 1 WHERE id = ?;", ItemTable)
+
+	_, err := b.tx.Exec(rebind(query), quantity, "in_stock", item.ID)
+	if err != nil {
+		trail.Error("[batch] %s: %s", err.Error(), query)
+		return err
+	}
+
+	layer := schema.CostLayer{
+		ItemID:   item.ID,
+		Quantity: quantity,
+		UnitCost: unitCost,
+	}
+
+	return receiveStock(b.tx, layer, item.StorageID)
+}
+
+func (b *Batch) StorageByCode(code string) (schema.Storage, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE code = ?;", StorageTable)
+	return retrieveWith[schema.Storage](b.tx, query, code)
+}
+
+func (b *Batch) UOMByCode(code string) (schema.UOM, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE code = ?;", UoMTable)
+	return retrieveWith[schema.UOM](b.tx, query, code)
+}
+
+func (b *Batch) ItemByID(id int) (schema.Item, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = ?;", ItemTable)
+	return retrieveWith[schema.Item](b.tx, query, id)
+}
+
+func (b *Batch) ItemBySKU(sku string) (schema.Item, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE sku = ?;", ItemTable)
+	return retrieveWith[schema.Item](b.tx, query, sku)
+}
+
+func (b *Batch) ItemByName(name string) (schema.Item, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE LOWER(name) = LOWER(?);", ItemTable)
+	return retrieveWith[schema.Item](b.tx, query, name)
+}
+
+// ItemForUpdate retrieves the item and locks it until the batch is committed,
+// so that concurrent stock movements of the item are applied one at a time.
+func (b *Batch) ItemForUpdate(id int) (schema.Item, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = ?%s;", ItemTable, forUpdate())
+	return retrieveWith[schema.Item](b.tx, query, id)
+}
+
+// UpdateItemQuantity sets the stock on hand of the item.
+func (b *Batch) UpdateItemQuantity(item schema.Item) error {
+	query := fmt.Sprintf("UPDATE %s SET quantity = ?, version = version + 1 WHERE id = ?;", ItemTable)
+
+	_, err := b.tx.Exec(rebind(query), item.Quantity, item.ID)
+	if err != nil {
+		trail.Error("[batch] %s: %s", err.Error(), query)
+	}
+
+	return err
+}
+
+func (b *Batch) NewTransaction(transaction schema.Transaction) (int64, error) {
+	return newTransaction(b.tx, transaction.Type, transaction)
+}
+
+func (b *Batch) CancelTransaction(transaction schema.Transaction) error {
+	return cancelTransaction(b.tx, transaction)
+}
+
+func (b *Batch) NewOrderline(transactionType string, orderline schema.Orderline) (int64, error) {
+	return newOrderline(b.tx, transactionType, orderline)
+}
+
+func (b *Batch) CancelOrderline(orderline schema.Orderline) error {
+	return cancelOrderline(b.tx, orderline)
+}
+
+// IssueStock consumes the cost layers of the item like IssueStock.
+func (b *Batch) IssueStock(method string, orderline schema.Orderline, storageID int) (float64, error) {
+	return issueStock(b.tx, method, orderline, storageID)
+}
+
+// ReverseReceipt reverses the inbound movement like ReverseReceipt.
+func (b *Batch) ReverseReceipt(method string, orderline schema.Orderline, storageID int) error {
+	return reverseReceipt(b.tx, method, orderline, storageID)
+}
+
+// ReverseIssue reverses the outbound movement like ReverseIssue.
+func (b *Batch) ReverseIssue(orderline schema.Orderline, storageID int) error {
+	return reverseIssue(b.tx, orderline, storageID)
+}
+
+// Event writes a domain event to the outbox. It is only dispatched if the batch
+// is committed.
+func (b *Batch) Event(event schema.OutboxEvent) (int64, error) {
+	return insertEvent(b.tx, event)
+}
@@ -1,13 +1,13 @@
 package mysql
 
 import (
-	"errors"
 	"fmt"
-	"time"
 
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/postgres"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/sqlite"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/metrics"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
 )
 
@@ -16,61 +16,71 @@ var (
 	mysql    string = "mysql"
 )
 
-// Connect opens a connection to MySQL using the specified DSN (data source name)
-// with a default configuration for MaxOpenConns, MaxIdleConns and MaxLifetime.
-// If the DSN username is not defined, it defaults to 'root'.
-func Connect() {
+// Connect opens the pool of connections to the database of the loaded
+// configuration, MySQL, SQLite or PostgreSQL depending on its driver, retrying
+// while the server cannot be reached. It returns an error if the database cannot
+// be reached.
+func Connect() error {
 	if database == nil {
-		trail.Info("Establishing MySQL connection...")
+		cfg := config.Get()
+		name := driverName(cfg.DatabaseDriver())
 
-		dbname, ok := config.GetCache(config.DBNameKey).(string)
-		if !ok {
-			panic(errors.New("expected string for database name but got a different type"))
-		}
+		trail.Info("Establishing %s connection...", name)
 
-		dbuser, ok := config.GetCache(config.DBUserKey).(string)
-		if !ok {
-			panic(errors.New("expected string for database user but got a different type"))
+		db, err := openDatabase(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", name, err)
 		}
 
-		dbpassword, ok := config.GetCache(config.DBPasswordKey).(string)
-		if !ok {
-			panic(errors.New("expected string for database password but got a different type"))
-		}
+		// Expose the statistics of the connection pool.
+		metrics.RegisterDB(db.DB, cfg.DatabaseName())
 
-		// Data Source Name
-		var dsn = fmt.Sprintf("%s:%s@/%s?parseTime=true", dbuser, dbpassword, dbname)
+		database = db
+		trail.OK("%s connection established.", name)
+	}
 
-		// Connects to the database and attempts a ping.
-		db, err := sqlx.Connect(mysql, dsn)
-		if err != nil {
-			panic(err)
-		}
+	return nil
+}
 
-		// Limit the number of connection used by the application.
-		db.SetMaxOpenConns(5)
+// openDatabase opens a pool of connections to the database of the
+// configuration, MySQL, SQLite or PostgreSQL depending on its driver.
+func openDatabase(cfg *config.Config) (*sqlx.DB, error) {
+	switch cfg.DatabaseDriver() {
+	case config.DriverSQLite:
+		return sqlite.Open(cfg.SQLiteDatabase())
 
-		// Recommended to be set the same as SetMaxOpenConns.
-		db.SetMaxIdleConns(5)
+	case config.DriverPostgres:
+		return postgres.Open(cfg.PostgresDatabase(), cfg.DatabaseName())
+	}
 
-		// Ensure connections are closed by the driver safely before
-		// connection is closed by MySQL.
-		db.SetConnMaxLifetime(time.Second * 270)
+	return Open(cfg.Database(), cfg.DatabaseName())
+}
 
-		database = db
-		trail.OK("MySQL connection established.")
+// driverName returns the name of the database of the driver in the messages.
+func driverName(driver string) string {
+	switch driver {
+	case config.DriverSQLite:
+		return "SQLite"
+
+	case config.DriverPostgres:
+		return "PostgreSQL"
 	}
+
+	return "MySQL"
 }
 
-// Close closes the database connection.
-func Close() {
+// Close closes the database connection. It waits for the queries that are
+// still running to finish.
+func Close() error {
 	if database != nil {
 		err := database.Close()
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		database = nil
-		trail.Info("MySQL connection closed.")
+		trail.Info("%s connection closed.", driverName(config.Get().DatabaseDriver()))
 	}
+
+	return nil
 }
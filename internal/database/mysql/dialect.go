@@ -0,0 +1,128 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/postgres"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/sqlite"
+)
+
+// The queries of this package are written for MySQL and run as they are on
+// SQLite and PostgreSQL, except for the few differences of the dialects below.
+// The '?' placeholders are rebound to '$n' for PostgreSQL with rebind, and the
+// named queries by sqlx itself.
+
+// MySQL error numbers.
+const (
+	errDuplicateEntry uint16 = 1062
+	errMissingTable   uint16 = 1146
+)
+
+// isSQLite reports whether the connection is to a SQLite database.
+func isSQLite() bool {
+	return database != nil && database.DriverName() == sqlite.DriverName
+}
+
+// isPostgres reports whether the connection is to a PostgreSQL database.
+func isPostgres() bool {
+	return database != nil && database.DriverName() == postgres.DriverName
+}
+
+// rebind returns the query with the '?' placeholders in the bind type of the
+// driver.
+func rebind(query string) string {
+	if database == nil {
+		return query
+	}
+
+	return database.Rebind(query)
+}
+
+// insertReturningID runs the named INSERT query and returns the ID of the
+// inserted row, or 0 if no row was inserted. PostgreSQL has no LastInsertId,
+// so the query returns the ID instead.
+func insertReturningID(db sqlx.Ext, query string, record any) (int64, error) {
+	if isPostgres() {
+		rows, err := sqlx.NamedQuery(db, query+" RETURNING id;", record)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+
+		var id int64
+		if rows.Next() {
+			err = rows.Scan(&id)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		return id, rows.Err()
+	}
+
+	result, err := sqlx.NamedExec(db, query+";", record)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// forUpdate returns the clause that locks the selected rows until the end of
+// the transaction. SQLite has no row locks; its transactions hold the write
+// lock of the whole database from the start instead (see sqlite.DSN).
+func forUpdate() string {
+	if isSQLite() {
+		return ""
+	}
+
+	return " FOR UPDATE"
+}
+
+// setIfNotEmpty returns the SET clause of UpdateRecordByID that leaves the
+// column unchanged if the value is NULL, an empty string or zero. MySQL
+// compares 0 and false equal to an empty string, which SQLite does not, and
+// PostgreSQL cannot compare a value of another type to a string at all, so the
+// value is compared as text there.
+func setIfNotEmpty(field string) string {
+	if isPostgres() {
+		return fmt.Sprintf("%s = CASE WHEN CAST(:%s AS TEXT) IN ('', '0', 'false') THEN %s ELSE COALESCE(:%s, %s) END",
+			field, field, field, field, field)
+	}
+
+	if isSQLite() {
+		return fmt.Sprintf("%s = CASE WHEN :%s = '' OR :%s = 0 THEN %s ELSE COALESCE(:%s, %s) END",
+			field, field, field, field, field, field)
+	}
+
+	return fmt.Sprintf("%s = CASE WHEN :%s = '' THEN %s ELSE COALESCE(:%s, %s) END", field, field, field, field, field)
+}
+
+// IsDuplicateEntry reports whether the error is a duplicate of a unique key.
+func IsDuplicateEntry(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == errDuplicateEntry
+	}
+
+	return sqlite.IsUniqueViolation(err) || postgres.IsUniqueViolation(err)
+}
+
+// IsMissingTable reports whether the error is a query on a table that does not
+// exist.
+func IsMissingTable(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == errMissingTable
+	}
+
+	return sqlite.IsMissingTable(err) || postgres.IsMissingTable(err)
+}
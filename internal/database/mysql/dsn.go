@@ -0,0 +1,225 @@
+package mysql
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// DriverConfig returns the driver configuration of a connection to the database
+// with the settings, or to the server only if no database name is given. It is
+// the single place where the connection options are built.
+//
+// Options:
+//   - charset and collation: Set on every connection (utf8mb4 by default, for emojis and special characters).
+//   - parseTime: Converts the DATE, DATETIME and TIMESTAMP columns into Go's time.Time.
+//   - loc and time_zone: Read and write the timestamps in the same timezone (UTC by default) in Go and MySQL.
+func DriverConfig(settings config.MySQL, name string) (*mysqldriver.Config, error) {
+	cfg := mysqldriver.NewConfig()
+
+	cfg.User = settings.Username
+	cfg.Passwd = settings.Password
+	cfg.DBName = name
+
+	if settings.Socket != "" {
+		cfg.Net = "unix"
+		cfg.Addr = settings.Socket
+	} else {
+		cfg.Net = "tcp"
+		cfg.Addr = net.JoinHostPort(settings.Host, strconv.Itoa(settings.Port))
+	}
+
+	err := cfg.Apply(mysqldriver.Charset(settings.Charset, settings.Collation))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Loc, err = time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone '%s': %w", settings.Timezone, err)
+	}
+
+	timezone, err := sessionTimezone(cfg.Loc)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ParseTime = true
+	cfg.Params = map[string]string{"time_zone": timezone}
+
+	cfg.Timeout = time.Duration(settings.Timeout.Connect)
+	cfg.ReadTimeout = time.Duration(settings.Timeout.Read)
+	cfg.WriteTimeout = time.Duration(settings.Timeout.Write)
+
+	cfg.TLS, err = tlsConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// The driver falls back to plaintext only in the preferred mode.
+	cfg.AllowFallbackToPlaintext = settings.TLS.Mode == config.TLSPreferred
+
+	return cfg, nil
+}
+
+// Open opens a pool of connections with the settings, to the database or to
+// the server only if no database name is given, and pings it. The server is
+// retried with an exponential backoff while it cannot be reached, but not when
+// it refuses the connection (e.g. wrong credentials or unknown database).
+func Open(settings config.MySQL, name string) (*sqlx.DB, error) {
+	cfg, err := DriverConfig(settings, name)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, err := mysqldriver.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db := sqlx.NewDb(sql.OpenDB(connector), mysql)
+
+	// Limit the number of connection used by the application.
+	db.SetMaxOpenConns(settings.Pool.MaxOpenConns)
+	db.SetMaxIdleConns(settings.Pool.MaxIdleConns)
+
+	// Ensure connections are closed by the driver safely before
+	// connection is closed by MySQL.
+	db.SetConnMaxLifetime(time.Duration(settings.Pool.ConnMaxLifetime))
+	db.SetConnMaxIdleTime(time.Duration(settings.Pool.ConnMaxIdleTime))
+
+	backoff := time.Duration(settings.Retry.Backoff)
+
+	for attempt := 1; ; attempt++ {
+		err = ping(db, time.Duration(settings.Timeout.Connect))
+		if err == nil {
+			return db, nil
+		}
+
+		var mysqlErr *mysqldriver.MySQLError
+		if errors.As(err, &mysqlErr) || attempt >= settings.Retry.Attempts {
+			break
+		}
+
+		trail.Warn("MySQL cannot be reached (attempt %d of %d), retrying in %s: %s", attempt, settings.Retry.Attempts, backoff, err.Error())
+		time.Sleep(backoff)
+
+		backoff = min(2*backoff, time.Duration(settings.Retry.MaxBackoff))
+	}
+
+	db.Close()
+
+	return nil, err
+}
+
+// ping verifies that the server can be reached within the timeout.
+func ping(db *sqlx.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return db.PingContext(ctx)
+}
+
+// sessionTimezone returns the MySQL 'time_zone' of the location. A named
+// timezone other than UTC requires the time zone tables to be loaded in MySQL.
+// The local timezone is passed by its IANA name rather than its current
+// offset, so that the session follows the daylight saving time changes.
+func sessionTimezone(loc *time.Location) (string, error) {
+	name := loc.String()
+
+	if loc == time.Local {
+		name = localTimezone()
+		if name == "" {
+			return "", errors.New("cannot find the IANA name of the local timezone; set the timezone to UTC or to an Area/City name")
+		}
+	}
+
+	if name == time.UTC.String() {
+		return "'+00:00'", nil
+	}
+
+	return "'" + name + "'", nil
+}
+
+// localTimezone returns the IANA name of the local timezone, from the TZ
+// environment variable like Go or else from the /etc/localtime link, or none
+// if it cannot be found.
+func localTimezone() string {
+	name, ok := os.LookupEnv("TZ")
+	if ok {
+		if name == "" {
+			return time.UTC.String()
+		}
+	} else {
+		name, _ = os.Readlink("/etc/localtime")
+	}
+
+	name = strings.TrimPrefix(name, ":")
+
+	_, zone, found := strings.Cut(name, "zoneinfo/")
+	if found {
+		name = zone
+	}
+
+	if _, err := time.LoadLocation(name); err != nil || filepath.IsAbs(name) {
+		return ""
+	}
+
+	return name
+}
+
+// tlsConfig returns the TLS configuration of the TLS mode of the settings, or
+// none if it is disabled.
+func tlsConfig(settings config.MySQL) (*tls.Config, error) {
+	mode := settings.TLS.Mode
+	if mode == "" || mode == config.TLSDisabled {
+		return nil, nil
+	}
+
+	serverName := settings.TLS.ServerName
+	if serverName == "" {
+		serverName = settings.Host
+	}
+
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: mode != config.TLSVerifyFull,
+	}
+
+	if settings.TLS.CAFile != "" {
+		pem, err := os.ReadFile(settings.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the MySQL CA: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in the MySQL CA '%s'", settings.TLS.CAFile)
+		}
+	}
+
+	if settings.TLS.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(settings.TLS.CertFile, settings.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the MySQL client certificate: %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{certificate}
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,234 @@
+package mysql
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+)
+
+func TestDriverConfigAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		socket string
+		net    string
+		addr   string
+	}{
+		{name: "tcp", net: "tcp", addr: "db.internal:3307"},
+		{name: "socket", socket: "/var/run/mysqld/mysqld.sock", net: "unix", addr: "/var/run/mysqld/mysqld.sock"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := config.Default().MySQL
+			settings.Host = "db.internal"
+			settings.Port = 3307
+			settings.Socket = test.socket
+
+			cfg, err := DriverConfig(settings, "wim")
+			if err != nil {
+				t.Fatalf("DriverConfig() error = %v", err)
+			}
+
+			if cfg.Net != test.net || cfg.Addr != test.addr {
+				t.Errorf("address = %s(%s), want %s(%s)", cfg.Net, cfg.Addr, test.net, test.addr)
+			}
+
+			if cfg.DBName != "wim" || !cfg.ParseTime {
+				t.Errorf("DBName = %q, ParseTime = %t, want wim and true", cfg.DBName, cfg.ParseTime)
+			}
+		})
+	}
+}
+
+func TestDriverConfigCharset(t *testing.T) {
+	settings := config.Default().MySQL
+
+	cfg, err := DriverConfig(settings, "")
+	if err != nil {
+		t.Fatalf("DriverConfig() error = %v", err)
+	}
+
+	if dsn := cfg.FormatDSN(); !strings.Contains(dsn, "charset=utf8mb4") {
+		t.Errorf("FormatDSN() = %s, want the default charset utf8mb4", dsn)
+	}
+
+	settings.Charset = "latin1"
+	settings.Collation = "latin1_swedish_ci"
+
+	cfg, err = DriverConfig(settings, "")
+	if err != nil {
+		t.Fatalf("DriverConfig() error = %v", err)
+	}
+
+	dsn := cfg.FormatDSN()
+	if !strings.Contains(dsn, "charset=latin1") || !strings.Contains(dsn, "collation=latin1_swedish_ci") {
+		t.Errorf("FormatDSN() = %s, want the charset latin1 and the collation latin1_swedish_ci", dsn)
+	}
+}
+
+func TestDriverConfigTimezone(t *testing.T) {
+	tests := []struct {
+		timezone string
+		want     string
+		err      bool
+	}{
+		{timezone: "UTC", want: "'+00:00'"},
+		{timezone: "", want: "'+00:00'"},
+		{timezone: "Asia/Manila", want: "'Asia/Manila'"},
+		{timezone: "Mars/Olympus", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.timezone, func(t *testing.T) {
+			settings := config.Default().MySQL
+			settings.Timezone = test.timezone
+
+			cfg, err := DriverConfig(settings, "")
+			if test.err {
+				if err == nil {
+					t.Fatalf("DriverConfig() error = nil, want an invalid timezone")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("DriverConfig() error = %v", err)
+			}
+
+			if got := cfg.Params["time_zone"]; got != test.want {
+				t.Errorf("time_zone = %s, want %s", got, test.want)
+			}
+
+			if name := cfg.Loc.String(); name != time.UTC.String() && name != test.timezone {
+				t.Errorf("Loc = %s, want %s", name, test.timezone)
+			}
+		})
+	}
+}
+
+func TestSessionTimezoneLocal(t *testing.T) {
+	tests := []struct {
+		tz   string
+		want string
+		err  bool
+	}{
+		{tz: "Europe/Paris", want: "'Europe/Paris'"},
+		{tz: ":America/New_York", want: "'America/New_York'"},
+		{tz: "/usr/share/zoneinfo/Asia/Manila", want: "'Asia/Manila'"},
+		{tz: "", want: "'+00:00'"},
+		{tz: "/etc/custom-zone", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tz, func(t *testing.T) {
+			t.Setenv("TZ", test.tz)
+
+			got, err := sessionTimezone(time.Local)
+			if test.err {
+				if err == nil {
+					t.Fatalf("sessionTimezone(Local) = %s, want an error without an IANA name", got)
+				}
+
+				return
+			}
+
+			if err != nil || got != test.want {
+				t.Errorf("sessionTimezone(Local) = %s, %v, want %s", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestDriverConfigTLS(t *testing.T) {
+	tests := []struct {
+		mode     string
+		tls      bool
+		verify   bool
+		fallback bool
+	}{
+		{mode: config.TLSDisabled},
+		{mode: config.TLSPreferred, tls: true, fallback: true},
+		{mode: config.TLSSkipVerify, tls: true},
+		{mode: config.TLSVerifyFull, tls: true, verify: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			settings := config.Default().MySQL
+			settings.Host = "db.internal"
+			settings.TLS.Mode = test.mode
+
+			cfg, err := DriverConfig(settings, "")
+			if err != nil {
+				t.Fatalf("DriverConfig() error = %v", err)
+			}
+
+			if (cfg.TLS != nil) != test.tls {
+				t.Fatalf("TLS = %v, want TLS %t", cfg.TLS, test.tls)
+			}
+
+			if cfg.AllowFallbackToPlaintext != test.fallback {
+				t.Errorf("AllowFallbackToPlaintext = %t, want %t", cfg.AllowFallbackToPlaintext, test.fallback)
+			}
+
+			if cfg.TLS == nil {
+				return
+			}
+
+			if cfg.TLS.InsecureSkipVerify == test.verify {
+				t.Errorf("InsecureSkipVerify = %t, want %t", cfg.TLS.InsecureSkipVerify, !test.verify)
+			}
+
+			if cfg.TLS.ServerName != "db.internal" {
+				t.Errorf("ServerName = %s, want the host db.internal", cfg.TLS.ServerName)
+			}
+		})
+	}
+}
+
+func TestDriverConfigTLSFiles(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	empty := filepath.Join(dir, "empty.pem")
+
+	block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(ca, block, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := config.Default().MySQL
+	settings.TLS = config.MySQLTLS{Mode: config.TLSVerifyFull, CAFile: ca, ServerName: "mysql.example.com"}
+
+	cfg, err := DriverConfig(settings, "")
+	if err != nil {
+		t.Fatalf("DriverConfig() error = %v", err)
+	}
+
+	if cfg.TLS.RootCAs == nil || cfg.TLS.ServerName != "mysql.example.com" {
+		t.Errorf("TLS = %+v, want the CA and the server name mysql.example.com", cfg.TLS)
+	}
+
+	for _, file := range []string{empty, filepath.Join(dir, "missing.pem")} {
+		settings.TLS.CAFile = file
+
+		_, err = DriverConfig(settings, "")
+		if err == nil {
+			t.Errorf("DriverConfig() with the CA %s error = nil, want an error", filepath.Base(file))
+		}
+	}
+}
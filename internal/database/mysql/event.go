@@ -0,0 +1,185 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/query"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// maxDeliveryError is the length of the 'last_error' column.
+const maxDeliveryError int = 255
+
+// ListEventsAfter retrieves the committed outbox events with an ID after the
+// given one, lowest first. The IDs are assigned when the events are written, so
+// an event that is committed later can still have a lower ID than those that
+// were retrieved before.
+func ListEventsAfter(id int64, limit int) ([]schema.OutboxEvent, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id > ? ORDER BY id LIMIT ?;", OutboxTable)
+	return fetch[schema.OutboxEvent](query, id, limit)
+}
+
+func ListWebhook() ([]schema.Webhook, error) { return FetchItems[schema.Webhook](WebhookTable) }
+
+func GetWebhookByID(id int) (schema.Webhook, error) {
+	return RetrieveItemByField[schema.Webhook](WebhookTable, "id", id)
+}
+
+func NewWebhook(webhook schema.Webhook) (int64, error) {
+	return InsertRecord(WebhookTable, webhook, "url", "secret", "event_types", "is_active")
+}
+
+// DeleteWebhook deletes the webhook along with its deliveries.
+func DeleteWebhook(id int) (int64, error) { return DeleteRecordByID(WebhookTable, id) }
+
+// ListWebhookDelivery retrieves the deliveries, newest first, optionally of a
+// specific status and webhook. The dead deliveries are the dead-letter queue.
+func ListWebhookDelivery(status string, webhookID int) ([]schema.WebhookDelivery, error) {
+	var conditions []query.Condition
+
+	if status != "" {
+		conditions = append(conditions, query.Eq("status", status))
+	}
+
+	if webhookID != 0 {
+		conditions = append(conditions, query.Eq("webhook_id", webhookID))
+	}
+
+	return FetchQuery[schema.WebhookDelivery](DeliveryTable, func(statement *query.Select) {
+		statement.Where(conditions...).OrderBy(query.Desc("id"))
+	})
+}
+
+// RetryWebhookDelivery queues a dead delivery to be attempted again. It returns
+// the number of deliveries that were queued.
+func RetryWebhookDelivery(id int64) (int64, error) {
+	query := fmt.Sprintf(
+		"UPDATE %s SET status = ?, attempts = 0, next_attempt_at = ? WHERE id = ? AND status = ?;",
+		DeliveryTable,
+	)
+
+	result, err := Exec(query, schema.DeliveryPending, time.Now().UTC(), id, schema.DeliveryDead)
+	if err != nil {
+		trail.Error("[webhook] %s: %s", err.Error(), query)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// FanOutEvents queues a delivery of each event that was not dispatched yet to
+// every active webhook that subscribes to it. It returns the number of events
+// that were dispatched.
+func FanOutEvents(limit int) (int, error) {
+	var dispatched int
+
+	err := transact(func(tx *sqlx.Tx) error {
+		var events []schema.OutboxEvent
+
+		query := fmt.Sprintf("SELECT * FROM %s WHERE is_dispatched = FALSE ORDER BY id LIMIT ?%s;", OutboxTable, forUpdate())
+		err := tx.Select(&events, rebind(query), limit)
+		if err != nil {
+			trail.Error("[fan-out] %s: %s", err.Error(), query)
+			return err
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		var webhooks []schema.Webhook
+
+		query = fmt.Sprintf("SELECT * FROM %s WHERE is_active = TRUE;", WebhookTable)
+		err = tx.Select(&webhooks, query)
+		if err != nil {
+			trail.Error("[fan-out] %s: %s", err.Error(), query)
+			return err
+		}
+
+		insert := fmt.Sprintf("INSERT INTO %s (webhook_id, event_id, status, next_attempt_at) VALUES (?, ?, ?, ?);", DeliveryTable)
+		update := fmt.Sprintf("UPDATE %s SET is_dispatched = TRUE WHERE id = ?;", OutboxTable)
+
+		for _, event := range events {
+			for _, webhook := range webhooks {
+				if !webhook.Subscribes(event.Type) {
+					continue
+				}
+
+				_, err = tx.Exec(rebind(insert), webhook.ID, event.ID, schema.DeliveryPending, time.Now().UTC())
+				if err != nil {
+					trail.Error("[fan-out] %s: %s", err.Error(), insert)
+					return err
+				}
+			}
+
+			_, err = tx.Exec(rebind(update), event.ID)
+			if err != nil {
+				trail.Error("[fan-out] %s: %s", err.Error(), update)
+				return err
+			}
+		}
+
+		dispatched = len(events)
+
+		return nil
+	})
+
+	return dispatched, err
+}
+
+// DueDeliveries retrieves the pending deliveries that are due to be attempted.
+func DueDeliveries(now time.Time, limit int) ([]schema.DeliveryAttempt, error) {
+	query := fmt.Sprintf(`SELECT d.*, w.url, w.secret, e.event_id AS event_uuid, e.type AS event_type,
+								e.payload, e.date_created AS event_date_created
+							FROM %s d
+							INNER JOIN %s w ON w.id = d.webhook_id
+							INNER JOIN %s e ON e.id = d.event_id
+							WHERE d.status = ? AND d.next_attempt_at <= ?
+							ORDER BY d.next_attempt_at, d.id
+							LIMIT ?;`, DeliveryTable, WebhookTable, OutboxTable)
+
+	return fetch[schema.DeliveryAttempt](query, schema.DeliveryPending, now, limit)
+}
+
+// RecordDeliveryAttempt saves the outcome of an attempt to deliver an event.
+func RecordDeliveryAttempt(delivery schema.WebhookDelivery) error {
+	if delivery.LastError.Valid && len(delivery.LastError.String) > maxDeliveryError {
+		delivery.LastError.String = delivery.LastError.String[:maxDeliveryError]
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE %s SET status = :status, attempts = :attempts, next_attempt_at = :next_attempt_at,
+			response_status = :response_status, last_error = :last_error WHERE id = :id;`,
+		DeliveryTable,
+	)
+
+	_, err := database.NamedExec(query, delivery)
+	if err != nil {
+		trail.Error("[webhook] %s: %s", err.Error(), query)
+		return err
+	}
+
+	return nil
+}
+
+func insertEvent(db sqlx.Ext, event schema.OutboxEvent) (int64, error) {
+	return insertRecord(db, OutboxTable, event, "event_id", "type", "item_id", "storage_id", "payload")
+}
+
+// LatestEventID returns the ID of the latest outbox event, or 0 if there are no
+// events yet.
+func LatestEventID() (int64, error) {
+	var id int64
+
+	query := fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s;", OutboxTable)
+	err := database.Get(&id, query)
+	if err != nil {
+		trail.Error("[event] %s: %s", err.Error(), query)
+		return 0, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,44 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+// errNotConnected is returned when the database connection is not open.
+var errNotConnected = errors.New("database connection is not open")
+
+// Ping verifies that the database can still be reached through the connection.
+func Ping(ctx context.Context) error {
+	if database == nil {
+		return errNotConnected
+	}
+
+	return database.PingContext(ctx)
+}
+
+// ListMigrations retrieves the schema migrations that were applied to the
+// database, oldest first. It returns none if no migration was ever applied.
+func ListMigrations(ctx context.Context) ([]schema.Migration, error) {
+	if database == nil {
+		return nil, errNotConnected
+	}
+
+	var migrations []schema.Migration
+
+	query := fmt.Sprintf("SELECT * FROM %s ORDER BY version;", MigrationTable)
+
+	err := database.SelectContext(ctx, &migrations, query)
+	if err != nil {
+		if IsMissingTable(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return migrations, nil
+}
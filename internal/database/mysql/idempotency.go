@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// ReserveIdempotencyKey records the key of a request that is about to be handled.
+// A key of the same path that expired by the given time is taken over in a
+// single conditional update, so that only one of the concurrent requests with
+// the key reserves it. It returns false if the key is still in use for the path.
+func ReserveIdempotencyKey(key schema.IdempotencyKey, now time.Time) (bool, error) {
+	_, err := InsertRecord(IdempotencyKeyTable, key, "idempotency_key", "path", "request_hash", "expires_at")
+	if err == nil {
+		return true, nil
+	}
+
+	if !IsDuplicateEntry(err) {
+		return false, err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET request_hash = ?, status_code = NULL, content_type = NULL, response_body = NULL,
+							date_created = ?, expires_at = ?
+							WHERE idempotency_key = ? AND path = ? AND expires_at <= ?;`, IdempotencyKeyTable)
+
+	result, err := Exec(query, key.RequestHash, now, key.ExpiresAt, key.Key, key.Path, now)
+	if err != nil {
+		trail.Error("[idempotency] %s: %s", err.Error(), query)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func GetIdempotencyKey(key, path string) (schema.IdempotencyKey, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE idempotency_key = ? AND path = ?;", IdempotencyKeyTable)
+	return retrieve[schema.IdempotencyKey](query, key, path)
+}
+
+// SaveIdempotentResponse stores the response to the request of the key so that
+// it can be replayed.
+func SaveIdempotentResponse(key schema.IdempotencyKey) error {
+	query := fmt.Sprintf(
+		"UPDATE %s SET status_code = :status_code, content_type = :content_type, response_body = :response_body WHERE idempotency_key = :idempotency_key AND path = :path;",
+		IdempotencyKeyTable,
+	)
+
+	_, err := database.NamedExec(query, key)
+	if err != nil {
+		trail.Error("[idempotency] %s: %s", err.Error(), query)
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes the key so that the request can be retried.
+func ReleaseIdempotencyKey(key, path string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE idempotency_key = ? AND path = ?;", IdempotencyKeyTable)
+
+	_, err := Exec(query, key, path)
+	if err != nil {
+		trail.Error("[idempotency] %s: %s", err.Error(), query)
+	}
+
+	return err
+}
+
+// PurgeIdempotencyKeys deletes the keys that expired before the given time.
+func PurgeIdempotencyKeys(now time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE expires_at <= ?;", IdempotencyKeyTable)
+	return delete(query, now)
+}
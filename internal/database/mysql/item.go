@@ -1,23 +1,46 @@
 package mysql
 
 import (
+	"fmt"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/query"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 )
 
+// itemReferences are the columns that refer to an item.
+var itemReferences = []reference{
+	{UoMConversionTable, "item_id"},
+	{ItemBarcodeTable, "item_id"},
+	{OrderlineTable, "item_id"},
+	{CostLayerTable, "item_id"},
+	{LedgerTable, "item_id"},
+}
+
+// ListItem retrieves all the items, including the archived ones.
 func ListItem() ([]schema.Item, error) { return FetchItems[schema.Item](ItemTable) }
 
+// ListItemByArchive retrieves either the archived items or the ones that are
+// not.
+func ListItemByArchive(isArchived bool) ([]schema.Item, error) {
+	return FetchItemsByFields[schema.Item](ItemTable, archived(isArchived))
+}
+
 func GetItemByID(id int) (schema.Item, error) {
 	return RetrieveItemByField[schema.Item](ItemTable, "id", id)
 }
 
+// GetItemByName retrieves the item of the name, which is compared regardless of
+// its case.
 func GetItemByName(name string) (schema.Item, error) {
-	return RetrieveItemByField[schema.Item](ItemTable, "name", name, "LOWER(?)")
+	return RetrieveItemByFields[schema.Item](ItemTable, query.EqFold("name", name))
 }
 
 func NewItem(item schema.Item) (int64, error) {
 	fields := []string{
+		"sku",
 		"name",
 		"description",
+		"category",
 		"quantity",
 		"unit_price",
 		"uom_id",
@@ -31,8 +54,10 @@ func NewItem(item schema.Item) (int64, error) {
 
 func NewItemIfNotExists(item schema.Item) (int64, error) {
 	fields := []string{
+		"sku",
 		"name",
 		"description",
+		"category",
 		"quantity",
 		"unit_price",
 		"uom_id",
@@ -46,10 +71,10 @@ func NewItemIfNotExists(item schema.Item) (int64, error) {
 
 func UpdateItem(item schema.Item) error {
 	fields := []string{
+		"sku",
 		"name",
 		"description",
-		"quantity",
-		"quantity",
+		"category",
 		"unit_price",
 		"storage_id",
 		"uom_id",
@@ -58,7 +83,20 @@ func UpdateItem(item schema.Item) error {
 	return UpdateRecordByID(ItemTable, item, fields...)
 }
 
-func DeleteItem(id int) (int64, error) { return DeleteRecordByID(ItemTable, id) }
+func GetItemBySKU(sku string) (schema.Item, error) {
+	return RetrieveItemByField[schema.Item](ItemTable, "sku", sku)
+}
+
+// DeleteItem deletes the item unless it is referenced or has stock on hand.
+func DeleteItem(id, version int) (int64, error) {
+	return deleteUnreferenced(ItemTable, id, version, "quantity", itemReferences...)
+}
+
+// ArchiveItem sets the item as archived by the user, or restores it if the user
+// is 0.
+func ArchiveItem(id, userID, version int) error {
+	return ArchiveRecordByID(ItemTable, id, userID, version)
+}
 
 func ItemIDExists(id int) (bool, error) {
 	return exists(func() (schema.Item, error) { return GetItemByID(id) })
@@ -67,3 +105,39 @@ func ItemIDExists(id int) (bool, error) {
 func ItemNameExists(name string) (bool, error) {
 	return exists(func() (schema.Item, error) { return GetItemByName(name) })
 }
+
+func ItemSKUExists(sku string) (bool, error) {
+	return exists(func() (schema.Item, error) { return GetItemBySKU(sku) })
+}
+
+// stockSummary is the total stock units and the number of low-stock items.
+type stockSummary struct {
+	Units    float64 `db:"units"`
+	LowStock float64 `db:"low_stock"`
+}
+
+// StockSummary returns the total stock units on hand of all the items and the
+// number of items at or below the low-stock threshold.
+func StockSummary(threshold int) (float64, float64, error) {
+	query := fmt.Sprintf(`SELECT COALESCE(SUM(quantity), 0) AS units,
+								COALESCE(SUM(CASE WHEN quantity <= ? THEN 1 ELSE 0 END), 0) AS low_stock
+							FROM %s;`, ItemTable)
+
+	summary, err := retrieve[stockSummary](query, threshold)
+
+	return summary.Units, summary.LowStock, err
+}
+
+// StockDiscrepancies returns the items whose stock on hand is not the sum of
+// their movements in the inventory ledger.
+func StockDiscrepancies() ([]schema.StockDiscrepancy, error) {
+	query := fmt.Sprintf(`SELECT i.id AS item_id, i.name, i.quantity,
+								COALESCE(SUM(l.quantity), 0) AS ledger_quantity
+							FROM %s i
+							LEFT JOIN %s l ON l.item_id = i.id
+							GROUP BY i.id, i.name, i.quantity
+							HAVING i.quantity <> COALESCE(SUM(l.quantity), 0)
+							ORDER BY i.id;`, ItemTable, LedgerTable)
+
+	return fetch[schema.StockDiscrepancy](query)
+}
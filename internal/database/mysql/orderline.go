@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"github.com/jmoiron/sqlx"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 )
 
@@ -9,6 +10,10 @@ func GetOrderlineByID(id int) (schema.Orderline, error) {
 }
 
 func NewOrderline(transactionType string, orderline schema.Orderline) (int64, error) {
+	return newOrderline(database, transactionType, orderline)
+}
+
+func newOrderline(db sqlx.Ext, transactionType string, orderline schema.Orderline) (int64, error) {
 	var fields []string
 
 	if transactionType == "inbound" {
@@ -16,6 +21,10 @@ func NewOrderline(transactionType string, orderline schema.Orderline) (int64, er
 			"transaction_id",
 			"item_id",
 			"quantity",
+			"uom_id",
+			"uom_quantity",
+			"unit_price",
+			"total_amount",
 			"note",
 			"is_voided",
 			"created_by",
@@ -27,6 +36,8 @@ func NewOrderline(transactionType string, orderline schema.Orderline) (int64, er
 			"transaction_id",
 			"item_id",
 			"quantity",
+			"uom_id",
+			"uom_quantity",
 			"unit_price",
 			"total_amount",
 			"note",
@@ -35,13 +46,23 @@ func NewOrderline(transactionType string, orderline schema.Orderline) (int64, er
 		}
 	}
 
-	return InsertRecord(OrderlineTable, orderline, fields...)
+	return insertRecord(db, OrderlineTable, orderline, fields...)
 }
 
 func CancelOrderline(orderline schema.Orderline) error {
-	return UpdateRecordByID(OrderlineTable, orderline, "is_voided", "updated_by")
+	return cancelOrderline(database, orderline)
+}
+
+func cancelOrderline(db sqlx.Ext, orderline schema.Orderline) error {
+	return updateRecordByID(db, OrderlineTable, orderline, "is_voided", "updated_by")
 }
 
 func UpdateOrderlineNote(orderline schema.Orderline) error {
 	return UpdateRecordByID(OrderlineTable, orderline, "note", "updated_by")
 }
+
+// PatchOrderline sets the columns of the orderline to its values, including
+// empty ones.
+func PatchOrderline(orderline schema.Orderline, columns ...string) error {
+	return PatchRecordByID(OrderlineTable, orderline, columns...)
+}
@@ -4,8 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
 
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/query"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/metrics"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
 )
 
@@ -19,80 +25,83 @@ import (
 //
 //	data, err := FetchItems[schema.MySchema](TableName)
 func FetchItems[T any](table string) ([]T, error) {
-	query := fmt.Sprintf("SELECT * FROM %s;", table)
-	return fetch[T](query)
+	return FetchItemsByFields[T](table)
 }
 
-func FetchItemsByFields[T any](table string, conditions map[string]any, args ...any) ([]T, error) {
-	var whereClauses []string
-
-	// Build the WHERE clause dynamically
-	for field, transformation := range conditions {
-		if transformation == "" {
-			// Default to no transformation
-			transformation = "?"
-		}
+// FetchItemsByFields retrieves the records of the specified table that match all the conditions, in the
+// order they are given. The columns of the conditions are checked against the columns of the table.
+//
+// Usage:
+//
+//	data, err := FetchItemsByFields[schema.MySchema](TableName, query.Eq("field_name", value))
+func FetchItemsByFields[T any](table string, conditions ...query.Condition) ([]T, error) {
+	return FetchQuery[T](table, func(statement *query.Select) { statement.Where(conditions...) })
+}
 
-		whereClauses = append(whereClauses, fmt.Sprintf("%s = %s", field, transformation))
+// FetchQuery retrieves the records of the specified table selected by the query that build adds the
+// conditions, ordering and paging to.
+//
+// Usage:
+//
+//	data, err := FetchQuery[schema.MySchema](TableName, func(statement *query.Select) {
+//	  statement.Where(query.AnyOf("field_name", 1, 2)).OrderBy(query.Desc("id")).Limit(10)
+//	})
+func FetchQuery[T any](table string, build func(statement *query.Select)) ([]T, error) {
+	selectQuery, args, err := buildSelect(table, build)
+	if err != nil {
+		return nil, err
 	}
 
-	query := fmt.Sprintf(
-		"SELECT * FROM %s WHERE %s;",
-		table,
-		strings.Join(whereClauses, " AND "),
-	)
-
-	return fetch[T](query, args...)
+	return fetch[T](selectQuery, args...)
 }
 
-// RetrieveItemByField is a generic function to retrieve single record from a database by a single field
-// with an optional transformation. If no transformation is provided, the default is no transformation ("?").
+// RetrieveItemByField is a generic function to retrieve single record from a database by a single field.
 //
 // Usage:
 //
 //	RetrieveItemByField[schema.MySchema](TableName, "field_name", field_value)
-//	RetrieveItemByField[schema.MySchema](TableName, "field_name", field_value, "LOWER(?)")
-func RetrieveItemByField[T any](table string, field string, value any, transformation ...string) (T, error) {
-	// Default transformation to "?" if none provided
-	trans := "?"
-	if len(transformation) > 0 {
-		trans = transformation[0]
-	}
-
-	return RetrieveItemByFields[T](table, map[string]any{field: trans}, value)
+func RetrieveItemByField[T any](table string, field string, value any) (T, error) {
+	return RetrieveItemByFields[T](table, query.Eq(field, value))
 }
 
-// RetrieveItemByFields retrieves a single record based on multiple conditions with optional transformations.
-// Conditions map field names to transformations, defaulting to "?" if no transformation is provided.
+// RetrieveItemByFields retrieves a single record that matches all the conditions. The columns of the
+// conditions are checked against the columns of the table.
 //
 // Usage:
 //
-//	conditions := map[string]any{
-//	  "field_name_1": "?",        // Without transformation
-//	  "field_name_2": "LOWER(?)", // With transformation
-//	}
-//
-//	data, err := RetrieveItemByFields[schema.MySchema](TableName, conditions, fieldOne, fieldTwo)
-func RetrieveItemByFields[T any](table string, conditions map[string]any, args ...any) (T, error) {
-	var whereClauses []string
-
-	// Build the WHERE clause dynamically
-	for field, transformation := range conditions {
-		if transformation == "" {
-			// Default to no transformation
-			transformation = "?"
-		}
+//	data, err := RetrieveItemByFields[schema.MySchema](
+//	  TableName,
+//	  query.Eq("field_name_1", fieldOne),
+//	  query.Null("field_name_2"),
+//	)
+func RetrieveItemByFields[T any](table string, conditions ...query.Condition) (T, error) {
+	selectQuery, args, err := buildSelect(table, func(statement *query.Select) { statement.Where(conditions...) })
+	if err != nil {
+		var data T
+		return data, err
+	}
 
-		whereClauses = append(whereClauses, fmt.Sprintf("%s = %s", field, transformation))
+	return retrieve[T](selectQuery, args...)
+}
+
+// buildSelect returns the SQL and arguments of the query of all the columns of the table.
+func buildSelect(table string, build func(statement *query.Select)) (string, []any, error) {
+	columns, err := lookupTable(table)
+	if err != nil {
+		trail.Error("[select] %s", err.Error())
+		return "", nil, err
 	}
 
-	query := fmt.Sprintf(
-		"SELECT * FROM %s WHERE %s;",
-		table,
-		strings.Join(whereClauses, " AND "),
-	)
+	statement := query.From(columns)
+	build(statement)
+
+	selectQuery, args, err := statement.Build()
+	if err != nil {
+		trail.Error("[select] %s", err.Error())
+		return "", nil, err
+	}
 
-	return retrieve[T](query, args...)
+	return selectQuery, args, nil
 }
 
 // InsertRecord creates a new record in the specified table using the provided data and field names.
@@ -111,10 +120,24 @@ func RetrieveItemByFields[T any](table string, conditions map[string]any, args .
 //
 //	err := InsertRecord(TableName, record, "name", "email")
 func InsertRecord(table string, record any, fields ...string) (int64, error) {
+	return insertRecord(database, table, record, fields...)
+}
+
+// insertRecord is InsertRecord that runs the query on the given connection or
+// transaction.
+func insertRecord(db sqlx.Ext, table string, record any, fields ...string) (int64, error) {
+	defer metrics.ObserveQuery("InsertRecord", time.Now())
+
 	if len(fields) == 0 {
 		return 0, fmt.Errorf("must specify at least one field to perform insert operation")
 	}
 
+	_, err := lookupTable(table, fields...)
+	if err != nil {
+		trail.Error("[insert] %s", err.Error())
+		return 0, err
+	}
+
 	var values []string
 
 	// Build the VALUES clause dynamically
@@ -130,16 +153,17 @@ func InsertRecord(table string, record any, fields ...string) (int64, error) {
 		strings.Join(values, ", "),
 	)
 
-	result, err := database.NamedExec(query, record)
+	id, err := insertReturningID(db, query, record)
 	if err != nil {
 		trail.Error("[insert] %s: %s", err.Error(), query)
 		return 0, err
 	}
 
-	return result.LastInsertId()
+	return id, nil
 }
 
-// InsertIfNotExists inserts a record into a table if a specific field value does not already exist.
+// InsertIfNotExists inserts a record into a table if a specific field value does not already exist,
+// regardless of its case.
 //
 // Parameters:
 //   - table: The name of the database to update.
@@ -147,6 +171,14 @@ func InsertRecord(table string, record any, fields ...string) (int64, error) {
 //   - uniqueField: The field to check for existence (e.g., "name").
 //   - fields: The columns to insert (must include uniqueField).
 func InsertIfNotExists(table string, record any, uniqueField string, fields ...string) (int64, error) {
+	return insertIfNotExists(database, table, record, uniqueField, fields...)
+}
+
+// insertIfNotExists is InsertIfNotExists that runs the query on the given
+// connection or transaction.
+func insertIfNotExists(db sqlx.Ext, table string, record any, uniqueField string, fields ...string) (int64, error) {
+	defer metrics.ObserveQuery("InsertIfNotExists", time.Now())
+
 	if len(fields) == 0 {
 		return 0, fmt.Errorf("must specify at least one field")
 	}
@@ -155,6 +187,12 @@ func InsertIfNotExists(table string, record any, uniqueField string, fields ...s
 		return 0, fmt.Errorf("uniqueField must be specified")
 	}
 
+	_, err := lookupTable(table, append([]string{uniqueField}, fields...)...)
+	if err != nil {
+		trail.Error("[insert-if-not-exists] %s", err.Error())
+		return 0, err
+	}
+
 	// Build column names and placeholders
 	columns := strings.Join(fields, ", ")
 
@@ -168,8 +206,8 @@ func InsertIfNotExists(table string, record any, uniqueField string, fields ...s
 		`INSERT INTO %s (%s)
 		 SELECT %s
 		 WHERE NOT EXISTS (
-		   SELECT 1 FROM %s WHERE %s = :%s
-		 );`,
+		   SELECT 1 FROM %s WHERE LOWER(%s) = LOWER(:%s)
+		 )`,
 		table,
 		columns,
 		strings.Join(placeholders, ", "),
@@ -180,24 +218,18 @@ func InsertIfNotExists(table string, record any, uniqueField string, fields ...s
 
 	trail.Info("query: %v", query)
 
-	result, err := database.NamedExec(query, record)
+	id, err := insertReturningID(db, query, record)
 	if err != nil {
 		trail.Error("[insert-if-not-exists] %s: %s", err.Error(), query)
 		return 0, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-
-	if rowsAffected == 0 {
+	if id == 0 {
 		// Record already exists
 		trail.Info("record already exist")
-		return 0, nil
 	}
 
-	return result.LastInsertId()
+	return id, nil
 }
 
 // UpdateRecordByID updates a specific record in the given table by its ID. The fields are
@@ -217,37 +249,272 @@ func InsertIfNotExists(table string, record any, uniqueField string, fields ...s
 //
 //	err := UpdateRecordByID(TableName, record, "email")
 func UpdateRecordByID(table string, record any, fields ...string) error {
+	return updateRecordByID(database, table, record, fields...)
+}
+
+// updateRecordByID is UpdateRecordByID that runs the query on the given
+// connection or transaction.
+func updateRecordByID(db sqlx.Ext, table string, record any, fields ...string) error {
+	defer metrics.ObserveQuery("UpdateRecordByID", time.Now())
+
+	return updateByID(db, table, record, setIfNotEmpty, fields...)
+}
+
+// PatchRecordByID sets the fields of a specific record in the given table by its ID to the values
+// of the record, including NULL, empty and zero values, unlike UpdateRecordByID. The fields that are
+// not given are left unchanged.
+//
+// Usage:
+//
+//	record := Item{
+//	  ID:          1,
+//	  Quantity:    0,
+//	  Description: sql.NullString{},
+//	}
+//
+//	err := PatchRecordByID(TableName, record, "quantity", "description")
+func PatchRecordByID(table string, record any, fields ...string) error {
+	return patchRecordByID(database, table, record, fields...)
+}
+
+// patchRecordByID is PatchRecordByID that runs the query on the given
+// connection or transaction.
+func patchRecordByID(db sqlx.Ext, table string, record any, fields ...string) error {
+	defer metrics.ObserveQuery("PatchRecordByID", time.Now())
+
+	return updateByID(db, table, record, func(field string) string { return fmt.Sprintf("%s = :%s", field, field) }, fields...)
+}
+
+// updateByID updates the fields of the record by its ID with the SET clause
+// that set returns for each field. The version of a table that has one is
+// incremented, and if the record has a version, it is only updated if that is
+// still its current version.
+func updateByID(db sqlx.Ext, table string, record any, set func(field string) string, fields ...string) error {
 	if len(fields) == 0 {
 		return fmt.Errorf("must specify at least one field to perform update operation")
 	}
 
+	columns, err := lookupTable(table, append([]string{"id"}, fields...)...)
+	if err != nil {
+		trail.Error("[update] %s", err.Error())
+		return err
+	}
+
 	var setClause []string
 
 	// Build the SET clause dynamically for fields to update
 	for _, field := range fields {
-		clause := fmt.Sprintf("%s = CASE WHEN :%s = '' THEN %s ELSE COALESCE(:%s, %s) END", field, field, field, field, field)
-		setClause = append(setClause, clause)
+		setClause = append(setClause, set(field))
+	}
+
+	condition := "id = :id"
+	version := versionOf(record)
+
+	if columns.HasColumn("version") {
+		setClause = append(setClause, "version = version + 1")
+
+		if version > 0 {
+			condition += " AND version = :version"
+		}
 	}
 
 	// Construct the UPDATE query
 	query := fmt.Sprintf(
-		"UPDATE %s SET %s WHERE id = :id;",
+		"UPDATE %s SET %s WHERE %s;",
 		table,
 		strings.Join(setClause, ", "),
+		condition,
 	)
 
-	_, err := database.NamedExec(query, record)
+	result, err := sqlx.NamedExec(db, query, record)
 	if err != nil {
 		trail.Error("[update] %s: %s", err.Error(), query)
 		return err
 	}
 
+	if version > 0 && columns.HasColumn("version") {
+		return checkVersion(result)
+	}
+
 	return nil
 }
 
+// DeleteRecordByID deletes the record of the given table by its ID. It returns the number of deleted
+// records.
 func DeleteRecordByID(table string, id int) (int64, error) {
-	query := fmt.Sprintf("DELETE FROM %s WHERE id = ?;", table)
-	return delete(query, id)
+	return DeleteRecordByVersion(table, id, 0)
+}
+
+// DeleteRecordByVersion deletes the record of the given table by its ID if its version is still the
+// given one, or regardless of its version if it is 0. It returns repository.ErrVersionMismatch if there
+// is no record of the ID and version.
+func DeleteRecordByVersion(table string, id, version int) (int64, error) {
+	return deleteRecordByVersion(database, table, id, version)
+}
+
+// deleteRecordByVersion is DeleteRecordByVersion that runs the query on the given connection or
+// transaction.
+func deleteRecordByVersion(db sqlx.ExecerContext, table string, id, version int) (int64, error) {
+	columns := []string{"id"}
+	if version > 0 {
+		columns = append(columns, "version")
+	}
+
+	_, err := lookupTable(table, columns...)
+	if err != nil {
+		trail.Error("[delete] %s", err.Error())
+		return 0, err
+	}
+
+	if version == 0 {
+		query := fmt.Sprintf("DELETE FROM %s WHERE id = ?;", table)
+		return deleteWith(db, query, id)
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = ? AND version = ?;", table)
+
+	affected, err := deleteWith(db, query, id, version)
+	if err == nil && affected == 0 {
+		return 0, repository.ErrVersionMismatch
+	}
+
+	return affected, err
+}
+
+// reference is a column of a table that refers to the records of another table.
+type reference struct {
+	table  string
+	column string
+}
+
+// deleteUnreferenced deletes the record of the given table by its ID and version like
+// DeleteRecordByVersion, unless the records of the references still refer to it or its stock column,
+// if given, is not 0. It returns a *repository.ReferencedError with what keeps it from being deleted
+// then, instead of failing on a foreign key. The version is checked first.
+func deleteUnreferenced(table string, id, version int, stock string, references ...reference) (int64, error) {
+	var affected int64
+
+	err := transact(func(tx *sqlx.Tx) error {
+		if version > 0 {
+			_, err := lookupTable(table, "version")
+			if err != nil {
+				trail.Error("[delete] %s", err.Error())
+				return err
+			}
+
+			count, err := retrieveWith[int](tx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id = ? AND version = ?;", table), id, version)
+			if err != nil {
+				return err
+			}
+
+			if count == 0 {
+				return repository.ErrVersionMismatch
+			}
+		}
+
+		blocked := &repository.ReferencedError{References: make(map[string]int)}
+
+		for _, ref := range references {
+			_, err := lookupTable(ref.table, ref.column)
+			if err != nil {
+				trail.Error("[delete] %s", err.Error())
+				return err
+			}
+
+			count, err := retrieveWith[int](tx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?;", ref.table, ref.column), id)
+			if err != nil {
+				return err
+			}
+
+			if count > 0 {
+				blocked.References[ref.table] += count
+			}
+		}
+
+		if stock != "" {
+			_, err := lookupTable(table, stock)
+			if err != nil {
+				trail.Error("[delete] %s", err.Error())
+				return err
+			}
+
+			blocked.Quantity, err = retrieveWith[int](tx, fmt.Sprintf("SELECT %s FROM %s WHERE id = ?;", stock, table), id)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(blocked.References) > 0 || blocked.Quantity != 0 {
+			return blocked
+		}
+
+		var err error
+		affected, err = deleteRecordByVersion(tx, table, id, version)
+
+		return err
+	})
+
+	return affected, err
+}
+
+// ArchiveRecordByID sets the record of the given table by its ID as archived by the user at the current
+// time, or as not archived if the user is 0. Like UpdateRecordByID, a version other than 0 must still be
+// the version of the record.
+func ArchiveRecordByID(table string, id, userID, version int) error {
+	record := struct {
+		ID         int           `db:"id"`
+		ArchivedAt sql.NullTime  `db:"archived_at"`
+		ArchivedBy sql.NullInt32 `db:"archived_by"`
+		Version    int           `db:"version"`
+	}{ID: id, Version: version}
+
+	if userID != 0 {
+		record.ArchivedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		record.ArchivedBy = sql.NullInt32{Int32: int32(userID), Valid: true}
+	}
+
+	return PatchRecordByID(table, record, "archived_at", "archived_by")
+}
+
+// archived returns the condition of the records that are archived, or of those
+// that are not.
+func archived(archived bool) query.Condition {
+	if archived {
+		return query.NotNull("archived_at")
+	}
+
+	return query.Null("archived_at")
+}
+
+// versionOf returns the version of the record, or 0 if it has none.
+func versionOf(record any) int {
+	value := reflect.Indirect(reflect.ValueOf(record))
+	if value.Kind() != reflect.Struct {
+		return 0
+	}
+
+	for i := range value.NumField() {
+		if value.Type().Field(i).Tag.Get("db") == "version" {
+			return int(value.Field(i).Int())
+		}
+	}
+
+	return 0
+}
+
+// checkVersion returns repository.ErrVersionMismatch if the update of a record
+// by its ID and version did not find it.
+func checkVersion(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return repository.ErrVersionMismatch
+	}
+
+	return nil
 }
 
 // Exec executes a query using the provided arguments.
@@ -267,5 +534,7 @@ func DeleteRecordByID(table string, id int) (int64, error) {
 //
 //	affected, err := result.RowsAffected()
 func Exec(query string, args ...any) (sql.Result, error) {
-	return database.ExecContext(context.Background(), query, args...)
+	defer metrics.ObserveQuery("Exec", time.Now())
+
+	return database.ExecContext(context.Background(), rebind(query), args...)
 }
@@ -0,0 +1,188 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+// Store returns the data access of the API handlers backed by the database.
+func Store() repository.Store {
+	return repository.Store{
+		Users:           users{},
+		Roles:           roles{},
+		Items:           items{},
+		Storages:        storages{},
+		UOMs:            uoms{},
+		Conversions:     conversions{},
+		Barcodes:        barcodes{},
+		Currencies:      currencies{},
+		Transactions:    transactions{},
+		Ledger:          ledger{},
+		Webhooks:        webhooks{},
+		Batches:         batches{},
+		Tokens:          tokens{},
+		IdempotencyKeys: idempotencyKeys{},
+	}
+}
+
+type (
+	users           struct{}
+	roles           struct{}
+	items           struct{}
+	storages        struct{}
+	uoms            struct{}
+	conversions     struct{}
+	barcodes        struct{}
+	currencies      struct{}
+	transactions    struct{}
+	ledger          struct{}
+	webhooks        struct{}
+	batches         struct{}
+	tokens          struct{}
+	idempotencyKeys struct{}
+)
+
+func (users) Get(id int) (schema.User, error)                 { return GetUserByID(id) }
+func (users) List() ([]schema.User, error)                    { return ListUser() }
+func (users) GetByEmail(email string) (schema.User, error)    { return GetUserByEmail(email) }
+func (users) ListByActive(active bool) ([]schema.User, error) { return ListUserByActive(active) }
+func (users) Update(user schema.User) error                   { return UpdateUser(user) }
+func (users) Activate(id int) error                           { return ActivateUser(id) }
+func (users) Deactivate(id, version int) error                { return DeleteUser(id, version) }
+
+func (users) Patch(user schema.User, columns ...string) error { return PatchUser(user, columns...) }
+
+func (tokens) Get(hash string) (schema.UserToken, error) { return GetUserToken(hash) }
+func (tokens) Issue(token schema.UserToken) error        { return IssueUserToken(token) }
+
+func (tokens) Redeem(token schema.UserToken, now time.Time, user schema.User, columns ...string) error {
+	return RedeemUserToken(token, now, user, columns...)
+}
+
+func (roles) Get(id int) (schema.Role, error)        { return GetRoleByID(id) }
+func (roles) List() ([]schema.Role, error)           { return ListRole() }
+func (roles) Create(role schema.Role) (int64, error) { return NewRoleIfNotExists(role) }
+func (roles) Update(role schema.Role) error          { return UpdateRole(role) }
+func (roles) Delete(id, version int) (int64, error)  { return DeleteRole(id, version) }
+
+func (roles) Patch(role schema.Role, columns ...string) error { return PatchRole(role, columns...) }
+
+func (items) Get(id int) (schema.Item, error)          { return GetItemByID(id) }
+func (items) GetBySKU(sku string) (schema.Item, error) { return GetItemBySKU(sku) }
+func (items) List() ([]schema.Item, error)             { return ListItemByArchive(false) }
+func (items) Archived() ([]schema.Item, error)         { return ListItemByArchive(true) }
+func (items) Archive(id, userID, version int) error    { return ArchiveItem(id, userID, version) }
+func (items) Restore(id, version int) error            { return ArchiveItem(id, 0, version) }
+func (items) Delete(id, version int) (int64, error)    { return DeleteItem(id, version) }
+
+func (storages) Get(id int) (schema.Storage, error)    { return GetStorageByID(id) }
+func (storages) List() ([]schema.Storage, error)       { return ListStorageByArchive(false) }
+func (storages) Archived() ([]schema.Storage, error)   { return ListStorageByArchive(true) }
+func (storages) Archive(id, userID, version int) error { return ArchiveStorage(id, userID, version) }
+func (storages) Restore(id, version int) error         { return ArchiveStorage(id, 0, version) }
+func (storages) Update(storage schema.Storage) error   { return UpdateStorage(storage) }
+func (storages) Delete(id, version int) (int64, error) { return DeleteStorage(id, version) }
+
+func (storages) Create(storage schema.Storage) (int64, error) {
+	return NewStorageIfNotExists(storage)
+}
+
+func (storages) Patch(storage schema.Storage, columns ...string) error {
+	return PatchStorage(storage, columns...)
+}
+
+func (uoms) Get(id int) (schema.UOM, error)        { return GetUOMByID(id) }
+func (uoms) List() ([]schema.UOM, error)           { return ListUOMByArchive(false) }
+func (uoms) Archived() ([]schema.UOM, error)       { return ListUOMByArchive(true) }
+func (uoms) Archive(id, userID, version int) error { return ArchiveUOM(id, userID, version) }
+func (uoms) Restore(id, version int) error         { return ArchiveUOM(id, 0, version) }
+func (uoms) Create(uom schema.UOM) (int64, error)  { return NewUOMIfNotExists(uom) }
+func (uoms) Update(uom schema.UOM) error           { return UpdateUOM(uom) }
+func (uoms) Delete(id, version int) (int64, error) { return DeleteUOM(id, version) }
+
+func (uoms) Patch(uom schema.UOM, columns ...string) error { return PatchUOM(uom, columns...) }
+
+func (uoms) Conversion(itemID, uomID int) (schema.UOMConversion, error) {
+	return GetUOMConversion(itemID, uomID)
+}
+
+func (conversions) Get(id int) (schema.UOMConversion, error)        { return GetUOMConversionByID(id) }
+func (conversions) List(itemID int) ([]schema.UOMConversion, error) { return ListUOMConversion(itemID) }
+func (conversions) Delete(id int) (int64, error)                    { return DeleteUOMConversion(id) }
+
+func (conversions) Patch(conversion schema.UOMConversion, columns ...string) error {
+	return PatchUOMConversion(conversion, columns...)
+}
+
+func (barcodes) Get(id int) (schema.ItemBarcode, error)            { return GetItemBarcodeByID(id) }
+func (barcodes) List(itemID int) ([]schema.ItemBarcode, error)     { return ListItemBarcode(itemID) }
+func (barcodes) GetByGTIN(gtin string) (schema.ItemBarcode, error) { return GetItemBarcodeByGTIN(gtin) }
+func (barcodes) Delete(id int) (int64, error)                      { return DeleteItemBarcode(id) }
+
+func (currencies) Get(id int) (schema.Currency, error) { return GetCurrency(id) }
+func (currencies) List() ([]schema.Currency, error)    { return ListCurrency() }
+func (currencies) Active() (schema.Currency, error)    { return GetActiveCurrency() }
+func (currencies) Activate(code string) error          { return ActivateCurrency(code) }
+
+func (transactions) Get(id int) (schema.Transaction, error) { return GetTransactionByID(id) }
+func (transactions) List() ([]schema.Transaction, error)    { return ListTransaction() }
+
+func (transactions) Search(partner, transactionType, externalReference string) ([]schema.Transaction, error) {
+	return SearchTransaction(partner, transactionType, externalReference)
+}
+
+func (transactions) GetByExternalReference(partner, transactionType, externalReference string) (schema.Transaction, error) {
+	return GetTransactionByExternalReference(partner, transactionType, externalReference)
+}
+
+func (transactions) UpdateNote(transaction schema.Transaction) error {
+	return UpdateTransactionNote(transaction)
+}
+
+func (transactions) UpdateOrderlineNote(orderline schema.Orderline) error {
+	return UpdateOrderlineNote(orderline)
+}
+
+func (transactions) Orderline(id int) (schema.Orderline, error) { return GetOrderlineByID(id) }
+
+func (transactions) Patch(transaction schema.Transaction, columns ...string) error {
+	return PatchTransaction(transaction, columns...)
+}
+
+func (transactions) PatchOrderline(orderline schema.Orderline, columns ...string) error {
+	return PatchOrderline(orderline, columns...)
+}
+
+func (ledger) Valuation(before time.Time) ([]schema.Valuation, error) { return GetValuation(before) }
+
+func (webhooks) Get(id int) (schema.Webhook, error)           { return GetWebhookByID(id) }
+func (webhooks) List() ([]schema.Webhook, error)              { return ListWebhook() }
+func (webhooks) Create(webhook schema.Webhook) (int64, error) { return NewWebhook(webhook) }
+func (webhooks) Delete(id int) (int64, error)                 { return DeleteWebhook(id) }
+func (webhooks) Retry(id int64) (int64, error)                { return RetryWebhookDelivery(id) }
+
+func (webhooks) Deliveries(status string, webhookID int) ([]schema.WebhookDelivery, error) {
+	return ListWebhookDelivery(status, webhookID)
+}
+
+func (idempotencyKeys) Get(key, path string) (schema.IdempotencyKey, error) {
+	return GetIdempotencyKey(key, path)
+}
+
+func (idempotencyKeys) Reserve(key schema.IdempotencyKey, now time.Time) (bool, error) {
+	return ReserveIdempotencyKey(key, now)
+}
+
+func (idempotencyKeys) Save(key schema.IdempotencyKey) error { return SaveIdempotentResponse(key) }
+func (idempotencyKeys) Release(key, path string) error       { return ReleaseIdempotencyKey(key, path) }
+
+func (batches) Begin() (repository.Batch, error) {
+	batch, err := NewBatch()
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
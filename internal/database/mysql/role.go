@@ -14,7 +14,7 @@ func GetRoleByID(id int) (schema.Role, error) {
 }
 
 func GetRoleByName(name string) (schema.Role, error) {
-	return RetrieveItemByField[schema.Role](RoleTable, "name", name, "LOWER(?)")
+	return RetrieveItemByField[schema.Role](RoleTable, "name", name)
 }
 
 // NewRoleIfNotExists inserts a new role information into the 'role' table
@@ -36,11 +36,17 @@ func UpdateRole(role schema.Role) error {
 	return UpdateRecordByID(RoleTable, role, "name")
 }
 
+// PatchRole sets the columns of the role to its values, including empty ones.
+func PatchRole(role schema.Role, columns ...string) error {
+	return PatchRecordByID(RoleTable, role, columns...)
+}
+
 // DeleteRole deletes existing role in the 'role' table.
 //
 // Parameter:
 //   - id: The unique role id in the 'role' table.
-func DeleteRole(id int) (int64, error) { return DeleteRecordByID(RoleTable, id) }
+//   - version: The version of the role, or 0 to delete it regardless of its version.
+func DeleteRole(id, version int) (int64, error) { return DeleteRecordByVersion(RoleTable, id, version) }
 
 // RoleIDExists checks if a specific role id exists in the 'role' table.
 //
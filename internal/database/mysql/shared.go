@@ -5,14 +5,25 @@ import (
 	"database/sql"
 	"errors"
 	"reflect"
+	"time"
 
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/metrics"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
 )
 
 func retrieve[T any](query string, args ...any) (T, error) {
+	return retrieveWith[T](database, query, args...)
+}
+
+// retrieveWith is retrieve that runs the query on the given connection or
+// transaction.
+func retrieveWith[T any](db sqlx.Queryer, query string, args ...any) (T, error) {
+	defer metrics.ObserveQuery("retrieve", time.Now())
+
 	var data T
 
-	err := database.Get(&data, query, args...)
+	err := sqlx.Get(db, &data, rebind(query), args...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			trail.Warn("[retrieve] %s: %s", err.Error(), query)
@@ -28,9 +39,11 @@ func retrieve[T any](query string, args ...any) (T, error) {
 }
 
 func fetch[T any](query string, args ...any) ([]T, error) {
+	defer metrics.ObserveQuery("fetch", time.Now())
+
 	var list []T
 
-	err := database.Select(&list, query, args...)
+	err := database.Select(&list, rebind(query), args...)
 	if err != nil {
 		trail.Error("[fetch] %s: %s", err.Error(), query)
 		return nil, err
@@ -65,8 +78,16 @@ func exists[T any](fn func() (T, error)) (bool, error) {
 	}
 }
 
-func delete[T any](query string, param T) (int64, error) {
-	result, err := database.ExecContext(context.Background(), query, param)
+func delete(query string, args ...any) (int64, error) {
+	return deleteWith(database, query, args...)
+}
+
+// deleteWith is delete that runs the query on the given connection or
+// transaction.
+func deleteWith(db sqlx.ExecerContext, query string, args ...any) (int64, error) {
+	defer metrics.ObserveQuery("delete", time.Now())
+
+	result, err := db.ExecContext(context.Background(), rebind(query), args...)
 	if err != nil {
 		trail.Error("[delete] %s: %s", err.Error(), query)
 		return 0, err
@@ -74,3 +95,27 @@ func delete[T any](query string, param T) (int64, error) {
 
 	return result.RowsAffected()
 }
+
+// transact runs fn inside a database transaction. The transaction is committed
+// if fn succeeds and rolled back otherwise.
+func transact(fn func(tx *sqlx.Tx) error) error {
+	defer metrics.ObserveQuery("transact", time.Now())
+
+	tx, err := database.Beginx()
+	if err != nil {
+		trail.Error("[transact] failed to begin transaction: %s", err.Error())
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			trail.Error("[transact] failed to rollback transaction: %s", rollbackErr.Error())
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -2,14 +2,27 @@ package mysql
 
 import "github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 
+// storageReferences are the columns that refer to a storage.
+var storageReferences = []reference{
+	{ItemTable, "storage_id"},
+	{LedgerTable, "storage_id"},
+}
+
+// ListStorage retrieves all the storages, including the archived ones.
 func ListStorage() ([]schema.Storage, error) { return FetchItems[schema.Storage](StorageTable) }
 
+// ListStorageByArchive retrieves either the archived storages or the ones that
+// are not.
+func ListStorageByArchive(isArchived bool) ([]schema.Storage, error) {
+	return FetchItemsByFields[schema.Storage](StorageTable, archived(isArchived))
+}
+
 func GetStorageByID(id int) (schema.Storage, error) {
 	return RetrieveItemByField[schema.Storage](StorageTable, "id", id)
 }
 
 func GetStorageByName(name string) (schema.Storage, error) {
-	return RetrieveItemByField[schema.Storage](StorageTable, "name", name, "LOWER(?)")
+	return RetrieveItemByField[schema.Storage](StorageTable, "name", name)
 }
 
 func NewStorageIfNotExists(storage schema.Storage) (int64, error) {
@@ -21,7 +34,22 @@ func UpdateStorage(storage schema.Storage) error {
 	return UpdateRecordByID(StorageTable, storage, "code", "name", "description")
 }
 
-func DeleteStorage(id int) (int64, error) { return DeleteRecordByID(StorageTable, id) }
+// PatchStorage sets the columns of the storage to its values, including empty
+// ones.
+func PatchStorage(storage schema.Storage, columns ...string) error {
+	return PatchRecordByID(StorageTable, storage, columns...)
+}
+
+// DeleteStorage deletes the storage unless it is referenced.
+func DeleteStorage(id, version int) (int64, error) {
+	return deleteUnreferenced(StorageTable, id, version, "", storageReferences...)
+}
+
+// ArchiveStorage sets the storage as archived by the user, or restores it if
+// the user is 0.
+func ArchiveStorage(id, userID, version int) error {
+	return ArchiveRecordByID(StorageTable, id, userID, version)
+}
 
 func StorageIDExists(id int) (bool, error) {
 	return exists(func() (schema.Storage, error) { return GetStorageByID(id) })
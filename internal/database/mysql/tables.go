@@ -1,12 +1,64 @@
 package mysql
 
+import (
+	"fmt"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/query"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
 const (
-	CurrencyTable    string = "currency"
-	ItemTable        string = "item"
-	RoleTable        string = "role"
-	StorageTable     string = "storage"
-	TransactionTable string = "transactions"
-	OrderlineTable   string = "orderline"
-	UoMTable         string = "unit_of_measurement"
-	UserTable        string = "users"
+	CurrencyTable       string = "currency"
+	ItemTable           string = "item"
+	ItemBarcodeTable    string = "item_barcode"
+	RoleTable           string = "role"
+	StorageTable        string = "storage"
+	TransactionTable    string = "transactions"
+	OrderlineTable      string = "orderline"
+	UoMTable            string = "unit_of_measurement"
+	UoMConversionTable  string = "uom_conversion"
+	UserTable           string = "users"
+	UserTokenTable      string = "user_token"
+	CostLayerTable      string = "cost_layer"
+	LedgerTable         string = "inventory_ledger"
+	IdempotencyKeyTable string = "idempotency_key"
+	OutboxTable         string = "outbox_event"
+	WebhookTable        string = "webhook"
+	DeliveryTable       string = "webhook_delivery"
+	MigrationTable      string = "schema_migrations"
 )
+
+// tables are the columns of each table, read from the 'db' tags of its record.
+// The table and field names of the generic queries are checked against them, so
+// that only known identifiers are written in the SQL.
+var tables = map[string]query.Table{
+	CurrencyTable:       query.NewTable(CurrencyTable, schema.Currency{}),
+	ItemTable:           query.NewTable(ItemTable, schema.Item{}),
+	ItemBarcodeTable:    query.NewTable(ItemBarcodeTable, schema.ItemBarcode{}),
+	RoleTable:           query.NewTable(RoleTable, schema.Role{}),
+	StorageTable:        query.NewTable(StorageTable, schema.Storage{}),
+	TransactionTable:    query.NewTable(TransactionTable, schema.Transaction{}),
+	OrderlineTable:      query.NewTable(OrderlineTable, schema.Orderline{}).Without("base_uom_id"), // Joined from the item.
+	UoMTable:            query.NewTable(UoMTable, schema.UOM{}),
+	UoMConversionTable:  query.NewTable(UoMConversionTable, schema.UOMConversion{}),
+	UserTable:           query.NewTable(UserTable, schema.User{}),
+	UserTokenTable:      query.NewTable(UserTokenTable, schema.UserToken{}),
+	CostLayerTable:      query.NewTable(CostLayerTable, schema.CostLayer{}),
+	LedgerTable:         query.NewTable(LedgerTable, schema.LedgerEntry{}),
+	IdempotencyKeyTable: query.NewTable(IdempotencyKeyTable, schema.IdempotencyKey{}),
+	OutboxTable:         query.NewTable(OutboxTable, schema.OutboxEvent{}),
+	WebhookTable:        query.NewTable(WebhookTable, schema.Webhook{}),
+	DeliveryTable:       query.NewTable(DeliveryTable, schema.WebhookDelivery{}),
+	MigrationTable:      query.NewTable(MigrationTable, schema.Migration{}),
+}
+
+// lookupTable returns the table with the name, or an error if it is unknown or
+// does not have one of the columns.
+func lookupTable(name string, columns ...string) (query.Table, error) {
+	table, ok := tables[name]
+	if !ok {
+		return query.Table{}, fmt.Errorf("unknown table '%s'", name)
+	}
+
+	return table, table.Check(columns...)
+}
@@ -0,0 +1,82 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// GetUserToken retrieves the user token by the hash of the token.
+func GetUserToken(hash string) (schema.UserToken, error) {
+	return RetrieveItemByField[schema.UserToken](UserTokenTable, "token_hash", hash)
+}
+
+// IssueUserToken inserts the token and revokes the unused tokens of the user
+// that have the same purpose.
+func IssueUserToken(token schema.UserToken) error {
+	return transact(func(tx *sqlx.Tx) error {
+		err := revokeUserTokens(tx, token.UserID, token.Purpose, 0)
+		if err != nil {
+			return err
+		}
+
+		_, err = insertRecord(tx, UserTokenTable, token, "user_id", "purpose", "token_hash", "email", "expires_at")
+
+		return err
+	})
+}
+
+// RedeemUserToken marks the token as used and sets the columns of the user. The
+// token is only marked if it is still unused and unexpired at the given time,
+// which the UPDATE checks so that two requests cannot both redeem it.
+func RedeemUserToken(token schema.UserToken, now time.Time, user schema.User, columns ...string) error {
+	return transact(func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET used_at = ? WHERE id = ? AND used_at IS NULL AND expires_at > ?;", UserTokenTable)
+
+		result, err := tx.Exec(rebind(query), now, token.ID, now)
+		if err != nil {
+			trail.Error("[token] %s: %s", err.Error(), query)
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return repository.ErrInvalidToken
+		}
+
+		err = revokeUserTokens(tx, token.UserID, token.Purpose, token.ID)
+		if err != nil {
+			return err
+		}
+
+		return patchRecordByID(tx, UserTable, user, columns...)
+	})
+}
+
+// revokeUserTokens revokes the unused tokens of the user with the purpose, or
+// of any purpose if it is empty, other than the token of the ID, by marking
+// them as used.
+func revokeUserTokens(tx *sqlx.Tx, userID int, purpose string, id int) error {
+	query := fmt.Sprintf("UPDATE %s SET used_at = ? WHERE user_id = ? AND id <> ? AND used_at IS NULL", UserTokenTable)
+	args := []any{time.Now().UTC(), userID, id}
+
+	if purpose != "" {
+		query += " AND purpose = ?"
+		args = append(args, purpose)
+	}
+
+	_, err := tx.Exec(rebind(query), args...)
+	if err != nil {
+		trail.Error("[token] %s: %s", err.Error(), query)
+	}
+
+	return err
+}
@@ -1,6 +1,12 @@
 package mysql
 
 import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/query"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/repository"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
 )
 
@@ -40,18 +46,76 @@ func GetTransactionByID(id int) (schema.Transaction, error) {
 	return transaction, nil
 }
 
+// GetTransactionByExternalReference retrieves the transaction of a partner and
+// type by the reference the partner gave it (e.g. a delivery note or invoice
+// number).
+func GetTransactionByExternalReference(partner, transactionType, externalReference string) (schema.Transaction, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE partner = ? AND type = ? AND external_reference = ?;", TransactionTable)
+	return retrieve[schema.Transaction](query, partner, transactionType, externalReference)
+}
+
+// SearchTransaction retrieves the transactions whose external reference starts
+// with the given value, optionally of a specific partner and type. Empty values
+// are not filtered on.
+func SearchTransaction(partner, transactionType, externalReference string) ([]schema.Transaction, error) {
+	var conditions []query.Condition
+
+	if externalReference != "" {
+		conditions = append(conditions, query.HasPrefix("external_reference", externalReference))
+	}
+
+	if partner != "" {
+		conditions = append(conditions, query.Eq("partner", partner))
+	}
+
+	if transactionType != "" {
+		conditions = append(conditions, query.Eq("type", transactionType))
+	}
+
+	transactions, err := FetchQuery[schema.Transaction](TransactionTable, func(statement *query.Select) {
+		statement.Where(conditions...).OrderBy(query.Asc("id"))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range transactions {
+		orderlines, err := GetOrderlineByTransactionID(transactions[i].ID)
+		if err != nil {
+			return nil, err
+		}
+
+		transactions[i].Orderlines = orderlines
+	}
+
+	return transactions, nil
+}
+
+// GetOrderlineByTransactionID retrieves the orderlines of a transaction along
+// with the base unit of measurement of their items.
 func GetOrderlineByTransactionID(id int) ([]schema.Orderline, error) {
-	condition := map[string]any{"transaction_id": "?"}
-	return FetchItemsByFields[schema.Orderline](OrderlineTable, condition, id)
+	query := fmt.Sprintf(`SELECT o.*, i.uom_id AS base_uom_id
+							FROM %s o
+							INNER JOIN %s i ON i.id = o.item_id
+							WHERE o.transaction_id = ?;`, OrderlineTable, ItemTable)
+
+	return fetch[schema.Orderline](query, id)
 }
 
 func NewTransaction(_type string, transaction schema.Transaction) (int64, error) {
+	return newTransaction(database, _type, transaction)
+}
+
+func newTransaction(db sqlx.Ext, _type string, transaction schema.Transaction) (int64, error) {
 	var fields []string
 
 	if _type == "inbound" {
 		fields = []string{
 			"reference",
+			"partner",
+			"external_reference",
 			"type",
+			"amount",
 			"note",
 			"created_by",
 		}
@@ -60,6 +124,8 @@ func NewTransaction(_type string, transaction schema.Transaction) (int64, error)
 	if _type == "outbound" {
 		fields = []string{
 			"reference",
+			"partner",
+			"external_reference",
 			"type",
 			"amount",
 			"note",
@@ -67,13 +133,53 @@ func NewTransaction(_type string, transaction schema.Transaction) (int64, error)
 		}
 	}
 
-	return InsertRecord(TransactionTable, transaction, fields...)
+	return insertRecord(db, TransactionTable, transaction, fields...)
 }
 
 func CancelTransaction(transaction schema.Transaction) error {
-	return UpdateRecordByID(TransactionTable, transaction, "is_cancelled", "updated_by")
+	return cancelTransaction(database, transaction)
+}
+
+// cancelTransaction marks the transaction cancelled by its 'updated_by' user.
+// It returns repository.ErrAlreadyCancelled if the transaction was cancelled
+// already, e.g. by a concurrent request, and sql.ErrNoRows if it does not exist.
+func cancelTransaction(db sqlx.Ext, transaction schema.Transaction) error {
+	query := fmt.Sprintf("UPDATE %s SET is_cancelled = TRUE, updated_by = ? WHERE id = ? AND COALESCE(is_cancelled, FALSE) = FALSE;", TransactionTable)
+
+	result, err := db.Exec(rebind(query), transaction.UpdatedBy, transaction.ID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected > 0 {
+		return nil
+	}
+
+	var count int
+
+	err = sqlx.Get(db, &count, rebind(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id = ?;", TransactionTable)), transaction.ID)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return repository.ErrAlreadyCancelled
 }
 
 func UpdateTransactionNote(transaction schema.Transaction) error {
 	return UpdateRecordByID(TransactionTable, transaction, "note", "updated_by")
 }
+
+// PatchTransaction sets the columns of the transaction to its values, including
+// empty ones.
+func PatchTransaction(transaction schema.Transaction, columns ...string) error {
+	return PatchRecordByID(TransactionTable, transaction, columns...)
+}
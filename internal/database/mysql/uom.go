@@ -1,15 +1,35 @@
 package mysql
 
-import "github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+import (
+	"fmt"
 
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/query"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+// uomReferences are the columns that refer to a unit of measurement.
+var uomReferences = []reference{
+	{ItemTable, "uom_id"},
+	{UoMConversionTable, "uom_id"},
+	{ItemBarcodeTable, "uom_id"},
+	{OrderlineTable, "uom_id"},
+}
+
+// ListUOM retrieves all the units of measurement, including the archived ones.
 func ListUOM() ([]schema.UOM, error) { return FetchItems[schema.UOM](UoMTable) }
 
+// ListUOMByArchive retrieves either the archived units of measurement or the
+// ones that are not.
+func ListUOMByArchive(isArchived bool) ([]schema.UOM, error) {
+	return FetchItemsByFields[schema.UOM](UoMTable, archived(isArchived))
+}
+
 func GetUOMByID(id int) (schema.UOM, error) {
 	return RetrieveItemByField[schema.UOM](UoMTable, "id", id)
 }
 
 func GetUOMByName(name string) (schema.UOM, error) {
-	return RetrieveItemByField[schema.UOM](UoMTable, "name", name, "LOWER(?)")
+	return RetrieveItemByField[schema.UOM](UoMTable, "name", name)
 }
 
 func NewUOMIfNotExists(uom schema.UOM) (int64, error) {
@@ -19,7 +39,22 @@ func NewUOMIfNotExists(uom schema.UOM) (int64, error) {
 
 func UpdateUOM(uom schema.UOM) error { return UpdateRecordByID(UoMTable, uom, "code", "name") }
 
-func DeleteUOM(id int) (int64, error) { return DeleteRecordByID(UoMTable, id) }
+// PatchUOM sets the columns of the unit of measurement to its values, including
+// empty ones.
+func PatchUOM(uom schema.UOM, columns ...string) error {
+	return PatchRecordByID(UoMTable, uom, columns...)
+}
+
+// DeleteUOM deletes the unit of measurement unless it is referenced.
+func DeleteUOM(id, version int) (int64, error) {
+	return deleteUnreferenced(UoMTable, id, version, "", uomReferences...)
+}
+
+// ArchiveUOM sets the unit of measurement as archived by the user, or restores
+// it if the user is 0.
+func ArchiveUOM(id, userID, version int) error {
+	return ArchiveRecordByID(UoMTable, id, userID, version)
+}
 
 func UOMIDExists(id int) (bool, error) {
 	return exists(func() (schema.UOM, error) { return GetUOMByID(id) })
@@ -28,3 +63,33 @@ func UOMIDExists(id int) (bool, error) {
 func UOMNameExists(name string) (bool, error) {
 	return exists(func() (schema.UOM, error) { return GetUOMByName(name) })
 }
+
+func ListUOMConversion(itemID int) ([]schema.UOMConversion, error) {
+	return FetchItemsByFields[schema.UOMConversion](UoMConversionTable, query.Eq("item_id", itemID))
+}
+
+func GetUOMConversionByID(id int) (schema.UOMConversion, error) {
+	return RetrieveItemByField[schema.UOMConversion](UoMConversionTable, "id", id)
+}
+
+// GetUOMConversion retrieves the conversion of an item's unit of measurement
+// to its base unit of measurement.
+func GetUOMConversion(itemID, uomID int) (schema.UOMConversion, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE item_id = ? AND uom_id = ?;", UoMConversionTable)
+	return retrieve[schema.UOMConversion](query, itemID, uomID)
+}
+
+func NewUOMConversion(conversion schema.UOMConversion) (int64, error) {
+	return InsertRecord(UoMConversionTable, conversion, "item_id", "uom_id", "factor")
+}
+
+func UpdateUOMConversion(conversion schema.UOMConversion) error {
+	return UpdateRecordByID(UoMConversionTable, conversion, "factor")
+}
+
+// PatchUOMConversion sets the columns of the conversion to its values.
+func PatchUOMConversion(conversion schema.UOMConversion, columns ...string) error {
+	return PatchRecordByID(UoMConversionTable, conversion, columns...)
+}
+
+func DeleteUOMConversion(id int) (int64, error) { return DeleteRecordByID(UoMConversionTable, id) }
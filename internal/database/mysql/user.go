@@ -3,23 +3,37 @@ package mysql
 import (
 	"fmt"
 
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/query"
 	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
 )
 
 // ListUser retrieves a list of users.
 func ListUser() ([]schema.User, error) { return FetchItems[schema.User](UserTable) }
 
+// ListUserByActive retrieves either the active users or the inactive ones.
+func ListUserByActive(active bool) ([]schema.User, error) {
+	return FetchItemsByFields[schema.User](UserTable, query.Eq("is_active", active))
+}
+
 func GetUserByID(id int) (schema.User, error) {
 	return RetrieveItemByField[schema.User](UserTable, "id", id)
 }
 
 func GetUserByName(firstName, lastName string) (schema.User, error) {
-	conditions := map[string]any{
-		"first_name": "?",
-		"last_name":  "?",
-	}
+	return userByName(database, firstName, lastName)
+}
+
+func GetUserByEmail(email string) (schema.User, error) {
+	return RetrieveItemByField[schema.User](UserTable, "email", email)
+}
 
-	return RetrieveItemByFields[schema.User](UserTable, conditions, firstName, lastName)
+// userByName retrieves the user by name. The conditions are written out since
+// the order of the arguments must match the order of the placeholders.
+func userByName(db sqlx.Queryer, firstName, lastName string) (schema.User, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE first_name = ? AND last_name = ?;", UserTable)
+	return retrieveWith[schema.User](db, query, firstName, lastName)
 }
 
 // NewUser inserts new user information into the 'user' table.
@@ -56,8 +70,21 @@ func UpdateUser(user schema.User) error {
 	)
 }
 
+// PatchUser sets the columns of the user to its values, including empty ones.
+func PatchUser(user schema.User, columns ...string) error {
+	return PatchRecordByID(UserTable, user, columns...)
+}
+
+// UpdateUserPassword sets the password hash of the user.
+func UpdateUserPassword(id int, password string) error {
+	query := fmt.Sprintf("UPDATE %s SET password = ?, version = version + 1 WHERE id = ?", UserTable)
+	_, err := Exec(query, password, id)
+
+	return err
+}
+
 func ActivateUser(id int) error {
-	query := fmt.Sprintf("UPDATE %s SET is_active = true WHERE id = ?", UserTable)
+	query := fmt.Sprintf("UPDATE %s SET is_active = true, version = version + 1 WHERE id = ?", UserTable)
 	_, err := Exec(query, id)
 
 	return err
@@ -67,11 +94,33 @@ func ActivateUser(id int) error {
 //
 // Parameter:
 //   - id: The unique user id that will be deactivated.
-func DeleteUser(id int) error {
-	query := fmt.Sprintf("UPDATE %s SET is_active = false WHERE id = ?", UserTable)
-	_, err := Exec(query, id)
+//   - version: The version of the user, or 0 to deactivate it regardless of its version.
+func DeleteUser(id, version int) error {
+	return transact(func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET is_active = false, version = version + 1 WHERE id = ?", UserTable)
+		args := []any{id}
 
-	return err
+		if version != 0 {
+			query += " AND version = ?"
+			args = append(args, version)
+		}
+
+		result, err := tx.Exec(rebind(query), args...)
+		if err != nil {
+			trail.Error("[user] %s: %s", err.Error(), query)
+			return err
+		}
+
+		if version != 0 {
+			err = checkVersion(result)
+			if err != nil {
+				return err
+			}
+		}
+
+		// A deactivated user can no longer use the tokens they were emailed.
+		return revokeUserTokens(tx, id, "", 0)
+	})
 }
 
 // UserExists checks if a specific user exists in the 'user' table.
@@ -0,0 +1,265 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// ReceiveStock adds a new cost layer for the received quantity of an item and
+// records the inbound movement in the inventory ledger.
+//
+// Parameters:
+//   - layer: The received quantity and its unit cost.
+//   - storageID: The storage where the item is kept.
+func ReceiveStock(layer schema.CostLayer, storageID int) error {
+	return transact(func(tx *sqlx.Tx) error { return receiveStock(tx, layer, storageID) })
+}
+
+func receiveStock(tx *sqlx.Tx, layer schema.CostLayer, storageID int) error {
+	layer.RemainingQuantity = layer.Quantity
+	layer.UnitCost = schema.RoundAmount(layer.UnitCost)
+
+	err := insertCostLayer(tx, layer)
+	if err != nil {
+		return err
+	}
+
+	return insertLedgerEntry(tx, schema.LedgerEntry{
+		ItemID:      layer.ItemID,
+		StorageID:   storageID,
+		OrderlineID: layer.OrderlineID,
+		Quantity:    layer.Quantity,
+		Amount:      schema.RoundAmount(float64(layer.Quantity) * layer.UnitCost),
+	})
+}
+
+// IssueStock consumes the cost layers of an item for the issued quantity using
+// the valuation method and records the outbound movement in the inventory ledger.
+// It returns the cost of the issued quantity.
+//
+// Parameters:
+//   - method: The valuation method, either 'fifo' or 'average'.
+//   - orderline: The outbound orderline that issued the quantity.
+//   - storageID: The storage where the item is kept.
+func IssueStock(method string, orderline schema.Orderline, storageID int) (float64, error) {
+	var cost float64
+
+	err := transact(func(tx *sqlx.Tx) (err error) {
+		cost, err = issueStock(tx, method, orderline, storageID)
+		return err
+	})
+
+	return cost, err
+}
+
+func issueStock(tx *sqlx.Tx, method string, orderline schema.Orderline, storageID int) (float64, error) {
+	layers, err := openCostLayers(tx, orderline.ItemID)
+	if err != nil {
+		return 0, err
+	}
+
+	cost, err := consumeCostLayers(tx, layers, orderline.ItemID, orderline.Quantity, method)
+	if err != nil {
+		return 0, err
+	}
+
+	return cost, insertLedgerEntry(tx, schema.LedgerEntry{
+		ItemID:      orderline.ItemID,
+		StorageID:   storageID,
+		OrderlineID: dbutils.SetInt(int32(orderline.ID)),
+		Quantity:    -orderline.Quantity,
+		Amount:      -cost,
+	})
+}
+
+// ReverseReceipt reverses the inbound movement of a cancelled orderline. The
+// quantity is taken from the cost layer the orderline created first and from
+// the other cost layers of the item if it was already consumed.
+//
+// Parameters:
+//   - method: The valuation method, either 'fifo' or 'average'.
+//   - orderline: The cancelled inbound orderline.
+//   - storageID: The storage where the item is kept.
+func ReverseReceipt(method string, orderline schema.Orderline, storageID int) error {
+	return transact(func(tx *sqlx.Tx) error { return reverseReceipt(tx, method, orderline, storageID) })
+}
+
+func reverseReceipt(tx *sqlx.Tx, method string, orderline schema.Orderline, storageID int) error {
+	// Orderlines received before the valuation was recorded have nothing
+	// to reverse.
+	var received int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE orderline_id = ? AND quantity > 0;", LedgerTable)
+	err := tx.Get(&received, rebind(query), orderline.ID)
+	if err != nil {
+		trail.Error("[reverse-receipt] %s: %s", err.Error(), query)
+		return err
+	}
+
+	if received == 0 {
+		return nil
+	}
+
+	layers, err := openCostLayers(tx, orderline.ItemID)
+	if err != nil {
+		return err
+	}
+
+	// Move the cost layer of the orderline to the front.
+	var ordered []schema.CostLayer
+	for _, layer := range layers {
+		if int(layer.OrderlineID.Int32) == orderline.ID {
+			ordered = append([]schema.CostLayer{layer}, ordered...)
+			continue
+		}
+
+		ordered = append(ordered, layer)
+	}
+
+	cost, err := consumeCostLayers(tx, ordered, orderline.ItemID, orderline.Quantity, method)
+	if err != nil {
+		return err
+	}
+
+	return insertLedgerEntry(tx, schema.LedgerEntry{
+		ItemID:      orderline.ItemID,
+		StorageID:   storageID,
+		OrderlineID: dbutils.SetInt(int32(orderline.ID)),
+		Quantity:    -orderline.Quantity,
+		Amount:      -cost,
+	})
+}
+
+// ReverseIssue reverses the outbound movement of a cancelled orderline. The
+// returned quantity is added back as a new cost layer valued at the cost it
+// was issued with.
+//
+// Parameters:
+//   - orderline: The cancelled outbound orderline.
+//   - storageID: The storage where the item is kept.
+func ReverseIssue(orderline schema.Orderline, storageID int) error {
+	return transact(func(tx *sqlx.Tx) error { return reverseIssue(tx, orderline, storageID) })
+}
+
+func reverseIssue(tx *sqlx.Tx, orderline schema.Orderline, storageID int) error {
+	var issued schema.LedgerEntry
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE orderline_id = ? AND quantity < 0 LIMIT 1;", LedgerTable)
+	err := tx.Get(&issued, rebind(query), orderline.ID)
+	if err != nil {
+		// Orderlines issued before the valuation was recorded have nothing
+		// to reverse.
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		trail.Error("[reverse-issue] %s: %s", err.Error(), query)
+		return err
+	}
+
+	var unitCost float64
+	if issued.Quantity != 0 {
+		unitCost = schema.RoundAmount(issued.Amount / float64(issued.Quantity))
+	}
+
+	err = insertCostLayer(tx, schema.CostLayer{
+		ItemID:            orderline.ItemID,
+		OrderlineID:       dbutils.SetInt(int32(orderline.ID)),
+		Quantity:          orderline.Quantity,
+		RemainingQuantity: orderline.Quantity,
+		UnitCost:          unitCost,
+	})
+	if err != nil {
+		return err
+	}
+
+	return insertLedgerEntry(tx, schema.LedgerEntry{
+		ItemID:      orderline.ItemID,
+		StorageID:   storageID,
+		OrderlineID: dbutils.SetInt(int32(orderline.ID)),
+		Quantity:    orderline.Quantity,
+		Amount:      -issued.Amount,
+	})
+}
+
+// GetValuation returns the stock on hand and its value per item and storage
+// from the movements recorded in the inventory ledger before the given time.
+func GetValuation(before time.Time) ([]schema.Valuation, error) {
+	query := fmt.Sprintf(`SELECT l.item_id, i.name, i.category, l.storage_id,
+								SUM(l.quantity) AS quantity, SUM(l.amount) AS value
+							FROM %s l
+							INNER JOIN %s i ON i.id = l.item_id
+							WHERE l.date_created < ?
+							GROUP BY l.item_id, i.name, i.category, l.storage_id
+							ORDER BY l.item_id, l.storage_id;`, LedgerTable, ItemTable)
+
+	return fetch[schema.Valuation](query, before)
+}
+
+func openCostLayers(tx *sqlx.Tx, itemID int) ([]schema.CostLayer, error) {
+	var layers []schema.CostLayer
+
+	// Lock the layers so that concurrent movements of the same item consume
+	// them one after the other.
+	query := fmt.Sprintf(`SELECT * FROM %s
+							WHERE item_id = ? AND remaining_quantity > 0
+							ORDER BY date_created, id%s;`, CostLayerTable, forUpdate())
+
+	err := tx.Select(&layers, rebind(query), itemID)
+	if err != nil {
+		trail.Error("[cost-layer] %s: %s", err.Error(), query)
+		return nil, err
+	}
+
+	return layers, nil
+}
+
+func consumeCostLayers(tx *sqlx.Tx, layers []schema.CostLayer, itemID, quantity int, method string) (float64, error) {
+	cost, uncovered := schema.ConsumeLayers(layers, quantity, method)
+	if uncovered > 0 {
+		trail.Warn("[cost-layer] %d unit(s) of item %d have no cost layer and are valued at zero", uncovered, itemID)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET remaining_quantity = ?, unit_cost = ? WHERE id = ?;", CostLayerTable)
+	for _, layer := range layers {
+		_, err := tx.Exec(rebind(query), layer.RemainingQuantity, layer.UnitCost, layer.ID)
+		if err != nil {
+			trail.Error("[cost-layer] %s: %s", err.Error(), query)
+			return 0, err
+		}
+	}
+
+	return cost, nil
+}
+
+func insertCostLayer(tx *sqlx.Tx, layer schema.CostLayer) error {
+	query := fmt.Sprintf(`INSERT INTO %s (item_id, orderline_id, quantity, remaining_quantity, unit_cost)
+							VALUES (:item_id, :orderline_id, :quantity, :remaining_quantity, :unit_cost);`, CostLayerTable)
+
+	_, err := tx.NamedExec(query, layer)
+	if err != nil {
+		trail.Error("[cost-layer] %s: %s", err.Error(), query)
+		return err
+	}
+
+	return nil
+}
+
+func insertLedgerEntry(tx *sqlx.Tx, entry schema.LedgerEntry) error {
+	query := fmt.Sprintf(`INSERT INTO %s (item_id, storage_id, orderline_id, quantity, amount)
+							VALUES (:item_id, :storage_id, :orderline_id, :quantity, :amount);`, LedgerTable)
+
+	_, err := tx.NamedExec(query, entry)
+	if err != nil {
+		trail.Error("[ledger] %s: %s", err.Error(), query)
+		return err
+	}
+
+	return nil
+}
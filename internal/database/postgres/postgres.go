@@ -0,0 +1,147 @@
+// Package postgres opens the connection to a PostgreSQL database. The queries
+// of the 'mysql' package run on it once their '?' placeholders are rebound to
+// '$n', with the other differences of the dialect handled there.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// DriverName is the name the PostgreSQL driver is registered with in
+// database/sql.
+const DriverName string = "postgres"
+
+// MaintenanceDatabase is the database that is connected to when no database
+// name is given, e.g. to create the database of the application.
+const MaintenanceDatabase string = "postgres"
+
+// PostgreSQL error codes.
+const (
+	errUniqueViolation pq.ErrorCode = "23505"
+	errUndefinedTable  pq.ErrorCode = "42P01"
+)
+
+// DSN returns the data source name of the database with the settings, or of
+// the maintenance database if no database name is given. It is the single
+// place where the connection options are built.
+//
+// Options:
+//   - sslmode and sslrootcert: Encrypt the connection and verify the certificate of the server.
+//   - connect_timeout: Limits how long a connection can take to be established, in seconds.
+//   - timezone: Reads and writes the timestamps in UTC, like MySQL and SQLite.
+func DSN(settings config.Postgres, name string) string {
+	if name == "" {
+		name = MaintenanceDatabase
+	}
+
+	params := url.Values{}
+	params.Set("sslmode", settings.SSLMode)
+	params.Set("connect_timeout", strconv.Itoa(int(time.Duration(settings.ConnectTimeout).Seconds())))
+	params.Set("timezone", "UTC")
+
+	if settings.SSLRootCert != "" {
+		params.Set("sslrootcert", settings.SSLRootCert)
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(settings.Username, settings.Password),
+		Host:     net.JoinHostPort(settings.Host, strconv.Itoa(settings.Port)),
+		Path:     "/" + name,
+		RawQuery: params.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// Open opens a pool of connections with the settings, to the database or to
+// the maintenance database if no database name is given, and pings it. The
+// server is retried with an exponential backoff while it cannot be reached,
+// but not when it refuses the connection (e.g. wrong credentials or unknown
+// database).
+func Open(settings config.Postgres, name string) (*sqlx.DB, error) {
+	db, err := sqlx.Open(DriverName, DSN(settings, name))
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(settings.Pool.MaxOpenConns)
+	db.SetMaxIdleConns(settings.Pool.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(settings.Pool.ConnMaxLifetime))
+	db.SetConnMaxIdleTime(time.Duration(settings.Pool.ConnMaxIdleTime))
+
+	backoff := time.Duration(settings.Retry.Backoff)
+
+	for attempt := 1; ; attempt++ {
+		err = ping(db, time.Duration(settings.ConnectTimeout))
+		if err == nil {
+			return db, nil
+		}
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) || attempt >= settings.Retry.Attempts {
+			break
+		}
+
+		trail.Warn("PostgreSQL cannot be reached (attempt %d of %d), retrying in %s: %s", attempt, settings.Retry.Attempts, backoff, err.Error())
+		time.Sleep(backoff)
+
+		backoff = min(2*backoff, time.Duration(settings.Retry.MaxBackoff))
+	}
+
+	db.Close()
+
+	return nil, err
+}
+
+// ping verifies that the server can be reached within the timeout.
+func ping(db *sqlx.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return db.PingContext(ctx)
+}
+
+// CreateDatabase creates the database on the server if it does not exist.
+// PostgreSQL has no 'CREATE DATABASE IF NOT EXISTS'.
+func CreateDatabase(ctx context.Context, db *sqlx.DB, name string) error {
+	var exists bool
+
+	err := db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1);", name)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	// The name is validated by the configuration, as it cannot be bound.
+	_, err = db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s;", pq.QuoteIdentifier(name)))
+
+	return err
+}
+
+// IsUniqueViolation reports whether the error is a duplicate of a unique key.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == errUniqueViolation
+}
+
+// IsMissingTable reports whether the error is a query on a table that does not
+// exist.
+func IsMissingTable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == errUndefinedTable
+}
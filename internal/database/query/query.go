@@ -0,0 +1,364 @@
+// Package query builds the SQL of the dynamic queries: the filters, ordering
+// and paging that depend on the request. The table and column names are
+// checked against the columns of the table, so that no identifier from a
+// request ends up in the SQL, and the values are always bound as arguments
+// ('?' placeholders) in the order of their clause.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Table is a table and the columns a query on it can use.
+type Table struct {
+	name    string
+	columns []string
+}
+
+// NewTable returns the table with the columns of the 'db' tags of the record's
+// struct. The fields tagged 'db:"-"' are not columns.
+//
+// Usage:
+//
+//	items := query.NewTable("item", schema.Item{})
+func NewTable(name string, record any) Table {
+	t := reflect.TypeOf(record)
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("query: the record of table %s must be a struct, got %s", name, t.Kind()))
+	}
+
+	table := Table{name: name}
+
+	for i := range t.NumField() {
+		column, _, _ := strings.Cut(t.Field(i).Tag.Get("db"), ",")
+		if column == "" || column == "-" {
+			continue
+		}
+
+		table.columns = append(table.columns, column)
+	}
+
+	return table
+}
+
+// Without returns the table without the columns, for the fields of its record
+// that are read from other tables (e.g. through a join).
+func (t Table) Without(columns ...string) Table {
+	table := Table{name: t.name}
+
+	for _, column := range t.columns {
+		if !slices.Contains(columns, column) {
+			table.columns = append(table.columns, column)
+		}
+	}
+
+	return table
+}
+
+// Name returns the name of the table.
+func (t Table) Name() string { return t.name }
+
+// Columns returns the columns of the table, in the order of its record.
+func (t Table) Columns() []string { return slices.Clone(t.columns) }
+
+// HasColumn reports whether the table has the column.
+func (t Table) HasColumn(column string) bool { return slices.Contains(t.columns, column) }
+
+// Check returns an error that lists the columns the table does not have.
+func (t Table) Check(columns ...string) error {
+	var unknown []string
+
+	for _, column := range columns {
+		if !t.HasColumn(column) {
+			unknown = append(unknown, fmt.Sprintf("'%s'", column))
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown column %s of table %s", strings.Join(unknown, ", "), t.name)
+	}
+
+	return nil
+}
+
+// Operator compares a column with the values of a condition.
+type Operator string
+
+const (
+	Equal          Operator = "="
+	EqualFold      Operator = "LOWER ="
+	NotEqual       Operator = "<>"
+	Less           Operator = "<"
+	LessOrEqual    Operator = "<="
+	Greater        Operator = ">"
+	GreaterOrEqual Operator = ">="
+	In             Operator = "IN"
+	Between        Operator = "BETWEEN"
+	Like           Operator = "LIKE"
+	IsNull         Operator = "IS NULL"
+	IsNotNull      Operator = "IS NOT NULL"
+)
+
+// Condition is a comparison of a column in a WHERE clause.
+type Condition struct {
+	Column   string
+	Operator Operator
+	Values   []any
+}
+
+// Eq matches the rows whose column equals the value.
+func Eq(column string, value any) Condition { return Condition{column, Equal, []any{value}} }
+
+// EqFold matches the rows whose column equals the value regardless of their
+// case, whatever the collation of the column is.
+func EqFold(column, value string) Condition { return Condition{column, EqualFold, []any{value}} }
+
+// Ne matches the rows whose column does not equal the value.
+func Ne(column string, value any) Condition { return Condition{column, NotEqual, []any{value}} }
+
+// Lt matches the rows whose column is less than the value.
+func Lt(column string, value any) Condition { return Condition{column, Less, []any{value}} }
+
+// Lte matches the rows whose column is less than or equal to the value.
+func Lte(column string, value any) Condition { return Condition{column, LessOrEqual, []any{value}} }
+
+// Gt matches the rows whose column is greater than the value.
+func Gt(column string, value any) Condition { return Condition{column, Greater, []any{value}} }
+
+// Gte matches the rows whose column is greater than or equal to the value.
+func Gte(column string, value any) Condition {
+	return Condition{column, GreaterOrEqual, []any{value}}
+}
+
+// AnyOf matches the rows whose column is one of the values. It matches no row
+// if there are no values.
+func AnyOf[T any](column string, values ...T) Condition {
+	condition := Condition{Column: column, Operator: In}
+	for _, value := range values {
+		condition.Values = append(condition.Values, value)
+	}
+
+	return condition
+}
+
+// Range matches the rows whose column is between the values, inclusive.
+func Range(column string, from, to any) Condition {
+	return Condition{column, Between, []any{from, to}}
+}
+
+// Matches matches the rows whose column is like the pattern, where '%' is any
+// sequence of characters, '_' any single character, and '!' escapes them.
+func Matches(column, pattern string) Condition { return Condition{column, Like, []any{pattern}} }
+
+// HasPrefix matches the rows whose column starts with the value, taken
+// literally.
+func HasPrefix(column, value string) Condition {
+	return Matches(column, EscapeLike(value)+"%")
+}
+
+// Null matches the rows whose column is NULL.
+func Null(column string) Condition { return Condition{Column: column, Operator: IsNull} }
+
+// NotNull matches the rows whose column is not NULL.
+func NotNull(column string) Condition { return Condition{Column: column, Operator: IsNotNull} }
+
+// EscapeLike escapes the wildcards of a LIKE pattern so that the value is
+// matched literally. The escape character is '!' rather than the backslash,
+// which MySQL, PostgreSQL and SQLite do not quote the same way.
+func EscapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+// sql returns the SQL of the condition and its arguments.
+func (c Condition) sql() (string, []any, error) {
+	arity := map[Operator]int{In: -1, Between: 2, IsNull: 0, IsNotNull: 0}
+
+	want, ok := arity[c.Operator]
+	switch {
+	case !ok && !slices.Contains([]Operator{Equal, EqualFold, NotEqual, Less, LessOrEqual, Greater, GreaterOrEqual, Like}, c.Operator):
+		return "", nil, fmt.Errorf("unknown operator '%s' on column '%s'", c.Operator, c.Column)
+
+	case !ok:
+		want = 1
+	}
+
+	if want >= 0 && len(c.Values) != want {
+		return "", nil, fmt.Errorf("operator %s on column '%s' takes %d value(s), got %d", c.Operator, c.Column, want, len(c.Values))
+	}
+
+	switch c.Operator {
+	case IsNull, IsNotNull:
+		return fmt.Sprintf("%s %s", c.Column, c.Operator), nil, nil
+
+	case In:
+		// An empty list is not valid SQL; it matches no row.
+		if len(c.Values) == 0 {
+			return "1 = 0", nil, nil
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(c.Values)), ", ")
+		return fmt.Sprintf("%s IN (%s)", c.Column, placeholders), c.Values, nil
+
+	case EqualFold:
+		return fmt.Sprintf("LOWER(%s) = LOWER(?)", c.Column), c.Values, nil
+
+	case Between:
+		return fmt.Sprintf("%s BETWEEN ? AND ?", c.Column), c.Values, nil
+
+	case Like:
+		return fmt.Sprintf("%s LIKE ? ESCAPE '!'", c.Column), c.Values, nil
+	}
+
+	return fmt.Sprintf("%s %s ?", c.Column, c.Operator), c.Values, nil
+}
+
+// Order sorts the rows by a column.
+type Order struct {
+	Column     string
+	Descending bool
+}
+
+// Asc sorts the rows by the column in ascending order.
+func Asc(column string) Order { return Order{Column: column} }
+
+// Desc sorts the rows by the column in descending order.
+func Desc(column string) Order { return Order{Column: column, Descending: true} }
+
+// Select is a query of the rows of a table. Its clauses are always written in
+// the same order, WHERE, ORDER BY, LIMIT and OFFSET, with the conditions in the
+// order they were added, so that the arguments are in the order of their
+// placeholders.
+//
+// Usage:
+//
+//	query, args, err := query.From(items).
+//		Where(query.AnyOf("storage_id", 1, 2), query.Range("quantity", 1, 10)).
+//		OrderBy(query.Desc("date_created")).
+//		Limit(20).
+//		Build()
+type Select struct {
+	table  Table
+	where  []Condition
+	order  []Order
+	limit  int
+	offset int
+}
+
+// From starts a query of all the rows and columns of the table.
+func From(table Table) *Select { return &Select{table: table} }
+
+// Where adds the conditions, which the rows must all match.
+func (s *Select) Where(conditions ...Condition) *Select {
+	s.where = append(s.where, conditions...)
+	return s
+}
+
+// OrderBy adds the columns the rows are sorted by.
+func (s *Select) OrderBy(orders ...Order) *Select {
+	s.order = append(s.order, orders...)
+	return s
+}
+
+// Limit limits the number of rows. Zero has no limit.
+func (s *Select) Limit(n int) *Select {
+	s.limit = n
+	return s
+}
+
+// Offset skips the first rows.
+func (s *Select) Offset(n int) *Select {
+	s.offset = n
+	return s
+}
+
+// Build returns the SQL of the query and its arguments, or an error that lists
+// the unknown columns and invalid conditions.
+func (s *Select) Build() (string, []any, error) {
+	var (
+		errs    []error
+		clauses []string
+		args    []any
+	)
+
+	if s.table.name == "" {
+		return "", nil, errors.New("query has no table")
+	}
+
+	for _, condition := range s.where {
+		err := s.table.Check(condition.Column)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		clause, values, err := condition.sql()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		clauses = append(clauses, clause)
+		args = append(args, values...)
+	}
+
+	var orders []string
+	for _, order := range s.order {
+		err := s.table.Check(order.Column)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if order.Descending {
+			orders = append(orders, order.Column+" DESC")
+		} else {
+			orders = append(orders, order.Column)
+		}
+	}
+
+	if s.limit < 0 || s.offset < 0 {
+		errs = append(errs, fmt.Errorf("limit and offset cannot be negative, got %d and %d", s.limit, s.offset))
+	}
+
+	if len(errs) > 0 {
+		return "", nil, errors.Join(errs...)
+	}
+
+	var sql strings.Builder
+	fmt.Fprintf(&sql, "SELECT * FROM %s", s.table.name)
+
+	if len(clauses) > 0 {
+		sql.WriteString(" WHERE " + strings.Join(clauses, " AND "))
+	}
+
+	if len(orders) > 0 {
+		sql.WriteString(" ORDER BY " + strings.Join(orders, ", "))
+	}
+
+	if s.limit > 0 {
+		sql.WriteString(" LIMIT ?")
+		args = append(args, s.limit)
+	}
+
+	if s.offset > 0 {
+		// MySQL and SQLite only take an OFFSET after a LIMIT.
+		if s.limit == 0 {
+			sql.WriteString(" LIMIT ?")
+			args = append(args, maxRows)
+		}
+
+		sql.WriteString(" OFFSET ?")
+		args = append(args, s.offset)
+	}
+
+	sql.WriteString(";")
+
+	return sql.String(), args, nil
+}
+
+// maxRows is the LIMIT of a query that has an OFFSET without a limit.
+const maxRows int64 = 1<<63 - 1
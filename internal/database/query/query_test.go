@@ -0,0 +1,208 @@
+package query
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type record struct {
+	ID        int     `db:"id"`
+	Name      string  `db:"name"`
+	Quantity  int     `db:"quantity"`
+	StorageID int     `db:"storage_id"`
+	Note      *string `db:"note"`
+	Children  []int   `db:"-"`
+	Joined    int     `db:"joined"`
+	Untagged  int
+}
+
+var records = NewTable("record", record{}).Without("joined")
+
+func TestNewTable(t *testing.T) {
+	want := []string{"id", "name", "quantity", "storage_id", "note"}
+	if got := records.Columns(); !slices.Equal(got, want) {
+		t.Errorf("Columns() = %v, want %v", got, want)
+	}
+
+	if records.HasColumn("joined") || records.HasColumn("-") || records.HasColumn("Untagged") {
+		t.Errorf("HasColumn() is true for a field that is not a column")
+	}
+
+	err := records.Check("name", "nope", "joined")
+	if err == nil || !strings.Contains(err.Error(), "'nope', 'joined'") {
+		t.Errorf("Check() error = %v, want the unknown columns", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement *Select
+		query     string
+		args      []any
+	}{
+		{
+			name:      "all rows",
+			statement: From(records),
+			query:     "SELECT * FROM record;",
+		},
+		{
+			name:      "conditions in the order given",
+			statement: From(records).Where(Eq("storage_id", 2), Gte("quantity", 5), Ne("name", "x")),
+			query:     "SELECT * FROM record WHERE storage_id = ? AND quantity >= ? AND name <> ?;",
+			args:      []any{2, 5, "x"},
+		},
+		{
+			name:      "in, between and null",
+			statement: From(records).Where(AnyOf("id", 1, 2, 3), Range("quantity", 10, 20), Null("note"), Lt("storage_id", 9)),
+			query:     "SELECT * FROM record WHERE id IN (?, ?, ?) AND quantity BETWEEN ? AND ? AND note IS NULL AND storage_id < ?;",
+			args:      []any{1, 2, 3, 10, 20, 9},
+		},
+		{
+			name:      "empty in",
+			statement: From(records).Where(AnyOf[int]("id"), Eq("name", "x")),
+			query:     "SELECT * FROM record WHERE 1 = 0 AND name = ?;",
+			args:      []any{"x"},
+		},
+		{
+			name:      "escaped prefix",
+			statement: From(records).Where(HasPrefix("name", "50%_off!"), NotNull("note")),
+			query:     "SELECT * FROM record WHERE name LIKE ? ESCAPE '!' AND note IS NOT NULL;",
+			args:      []any{"50!%!_off!!%"},
+		},
+		{
+			name:      "equal regardless of case",
+			statement: From(records).Where(EqFold("name", "Bolt"), Eq("storage_id", 1)),
+			query:     "SELECT * FROM record WHERE LOWER(name) = LOWER(?) AND storage_id = ?;",
+			args:      []any{"Bolt", 1},
+		},
+		{
+			name:      "ordering and paging after the conditions",
+			statement: From(records).Limit(10).Offset(20).OrderBy(Desc("quantity"), Asc("id")).Where(Gt("quantity", 0)),
+			query:     "SELECT * FROM record WHERE quantity > ? ORDER BY quantity DESC, id LIMIT ? OFFSET ?;",
+			args:      []any{0, 10, 20},
+		},
+		{
+			name:      "offset without limit",
+			statement: From(records).Offset(5),
+			query:     "SELECT * FROM record LIMIT ? OFFSET ?;",
+			args:      []any{maxRows, 5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The same query is built the same way every time.
+			for range 10 {
+				query, args, err := test.statement.Build()
+				if err != nil {
+					t.Fatalf("Build() error = %v", err)
+				}
+
+				if query != test.query {
+					t.Fatalf("Build() query = %q, want %q", query, test.query)
+				}
+
+				if !reflect.DeepEqual(args, test.args) {
+					t.Fatalf("Build() args = %v, want %v", args, test.args)
+				}
+
+				if placeholders := strings.Count(query, "?"); placeholders != len(args) {
+					t.Fatalf("Build() has %d placeholders and %d args", placeholders, len(args))
+				}
+			}
+		})
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement *Select
+		want      string
+	}{
+		{"unknown column", From(records).Where(Eq("name; DROP TABLE record", 1)), "unknown column"},
+		{"column of a join", From(records).Where(Eq("joined", 1)), "unknown column 'joined'"},
+		{"unknown order", From(records).OrderBy(Asc("name DESC, (SELECT 1)")), "unknown column"},
+		{"unknown operator", From(records).Where(Condition{"id", "= 1 OR 1 =", []any{1}}), "unknown operator"},
+		{"values of between", From(records).Where(Condition{"id", Between, []any{1}}), "takes 2 value(s), got 1"},
+		{"negative limit", From(records).Limit(-1), "cannot be negative"},
+		{"no table", From(Table{}), "no table"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, args, err := test.statement.Build()
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("Build() error = %v, want %q", err, test.want)
+			}
+
+			if query != "" || args != nil {
+				t.Errorf("Build() = %q, %v, want no query", query, args)
+			}
+		})
+	}
+}
+
+// TestBuildAlignment runs the queries on SQLite, so that each value is proven
+// to be bound to the placeholder of its own condition.
+func TestBuildAlignment(t *testing.T) {
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	db.MustExec(`CREATE TABLE record (
+		id INTEGER PRIMARY KEY, name TEXT NOT NULL, quantity INTEGER NOT NULL,
+		storage_id INTEGER NOT NULL, note TEXT NULL, joined INTEGER NOT NULL DEFAULT 0)`)
+
+	db.MustExec(`INSERT INTO record (id, name, quantity, storage_id, note) VALUES
+		(1, 'bolt', 5, 1, NULL), (2, 'bolt_m6', 15, 2, 'x'), (3, 'boltm6', 25, 2, NULL),
+		(4, 'nut', 15, 1, NULL), (5, 'washer', 35, 3, 'y'), (6, '50% off', 1, 3, NULL)`)
+
+	tests := []struct {
+		name      string
+		statement *Select
+		ids       []int
+	}{
+		{"equal and range", From(records).Where(Eq("storage_id", 2), Range("quantity", 10, 20)), []int{2}},
+		{"in and greater", From(records).Where(AnyOf("storage_id", 1, 3), Gt("quantity", 10)).OrderBy(Asc("id")), []int{4, 5}},
+		{"equal regardless of case", From(records).Where(EqFold("name", "BOLT")), []int{1}},
+		{"literal prefix", From(records).Where(HasPrefix("name", "bolt_")), []int{2}},
+		{"literal percent", From(records).Where(HasPrefix("name", "50%")), []int{6}},
+		{"null and order", From(records).Where(Null("note"), Lte("quantity", 25)).OrderBy(Desc("quantity")), []int{3, 4, 1, 6}},
+		{"empty in", From(records).Where(AnyOf[int]("id")), nil},
+		{"paging", From(records).Where(Ne("name", "nut")).OrderBy(Asc("id")).Limit(2).Offset(1), []int{2, 3}},
+		{"offset without limit", From(records).OrderBy(Desc("id")).Offset(4), []int{2, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, args, err := test.statement.Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			var rows []record
+			err = db.Select(&rows, query, args...)
+			if err != nil {
+				t.Fatalf("Select(%q) error = %v", query, err)
+			}
+
+			var ids []int
+			for _, row := range rows {
+				ids = append(ids, row.ID)
+			}
+
+			if !slices.Equal(ids, test.ids) {
+				t.Errorf("%s %v = ids %v, want %v", query, args, ids, test.ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+// Package repository defines the data access of the API handlers, so that they
+// do not depend on a specific database. The MySQL implementation is in the
+// 'mysql' package and the in-memory one, used by the tests, in 'memory'.
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+// ErrVersionMismatch is returned when a record is updated or deleted by its
+// version, and it does not exist or was changed since then.
+var ErrVersionMismatch = errors.New("the record was changed by another request")
+
+// ErrAlreadyCancelled is returned when a transaction is cancelled after it was
+// cancelled already.
+var ErrAlreadyCancelled = errors.New("the transaction is already cancelled")
+
+// ErrInvalidToken is returned when a user token is redeemed after it was used,
+// revoked or expired.
+var ErrInvalidToken = errors.New("the token is invalid or has expired")
+
+// ReferencedError is returned when an item, storage or unit of measurement
+// cannot be deleted because other records still refer to it, or an item still
+// has stock on hand. Such a record can be archived instead.
+type ReferencedError struct {
+	// References are the number of records of each table that refer to it.
+	References map[string]int
+
+	// Quantity is the stock on hand of an item.
+	Quantity int
+}
+
+func (e *ReferencedError) Error() string {
+	var reasons []string
+
+	for _, table := range slices.Sorted(maps.Keys(e.References)) {
+		reasons = append(reasons, fmt.Sprintf("%d %s record(s) refer to it", e.References[table], table))
+	}
+
+	if e.Quantity != 0 {
+		reasons = append(reasons, fmt.Sprintf("it has a stock on hand of %d", e.Quantity))
+	}
+
+	return "the record cannot be deleted: " + strings.Join(reasons, ", ")
+}
+
+// Store is the data access of the API handlers. Records that do not exist are
+// returned as the zero value without an error, so callers check their ID.
+//
+// The users, roles, items, storages and units of measurement have a version
+// that every update increments. A record with a version other than 0 is only
+// updated if that is still its version, and likewise for the version given to
+// Delete, so that a client does not overwrite the changes it has not seen.
+//
+// The items, storages and units of measurement are archived rather than deleted
+// once they are in use. List leaves the archived records out, while Get still
+// returns them.
+type Store struct {
+	Users           Users
+	Roles           Roles
+	Items           Items
+	Storages        Storages
+	UOMs            UOMs
+	Conversions     Conversions
+	Barcodes        Barcodes
+	Currencies      Currencies
+	Transactions    Transactions
+	Ledger          Ledger
+	Webhooks        Webhooks
+	Batches         Batches
+	Tokens          Tokens
+	IdempotencyKeys IdempotencyKeys
+}
+
+type Users interface {
+	Get(id int) (schema.User, error)
+	List() ([]schema.User, error)
+
+	// GetByEmail returns the user of the email, which is compared regardless of
+	// its case.
+	GetByEmail(email string) (schema.User, error)
+
+	// ListByActive returns either the active users or the inactive ones.
+	ListByActive(active bool) ([]schema.User, error)
+
+	// Update sets the role, name, email and password of the user. Empty values
+	// are left unchanged.
+	Update(user schema.User) error
+
+	// Patch sets the columns of the user to its values, including empty ones.
+	Patch(user schema.User, columns ...string) error
+	Activate(id int) error
+
+	// Deactivate sets the user as inactive and revokes their unused tokens in
+	// the same database transaction. Users are never deleted.
+	Deactivate(id, version int) error
+}
+
+// Tokens are the single-use tokens emailed to the users. Issuing a token revokes
+// the unused ones of the same user and purpose, so that only the last email
+// works.
+type Tokens interface {
+	// Get returns the token of the SHA-256 hash, whether it is usable or not.
+	Get(hash string) (schema.UserToken, error)
+	Issue(token schema.UserToken) error
+
+	// Redeem marks the token as used at the given time and sets the columns of
+	// the user to its values in the same database transaction, which also
+	// revokes the other unused tokens of the user with the same purpose. It
+	// returns ErrInvalidToken if the token is no longer usable by then.
+	Redeem(token schema.UserToken, now time.Time, user schema.User, columns ...string) error
+}
+
+type Roles interface {
+	Get(id int) (schema.Role, error)
+	List() ([]schema.Role, error)
+
+	// Create inserts the role if its name does not exist yet. It returns 0 if
+	// the role already exists.
+	Create(role schema.Role) (int64, error)
+	Update(role schema.Role) error
+	Patch(role schema.Role, columns ...string) error
+
+	// Delete deletes the role and returns the number of roles deleted.
+	Delete(id, version int) (int64, error)
+}
+
+type Items interface {
+	Get(id int) (schema.Item, error)
+
+	// GetBySKU returns the item of the SKU, which is compared regardless of its
+	// case.
+	GetBySKU(sku string) (schema.Item, error)
+	List() ([]schema.Item, error)
+	Archived() ([]schema.Item, error)
+
+	// Archive sets the item as archived by the user, and Restore sets it as
+	// not archived again.
+	Archive(id, userID, version int) error
+	Restore(id, version int) error
+
+	// Delete deletes the item and returns the number of items deleted. It
+	// returns a *ReferencedError if the item is referenced or has stock.
+	Delete(id, version int) (int64, error)
+}
+
+type Storages interface {
+	Get(id int) (schema.Storage, error)
+	List() ([]schema.Storage, error)
+
+	// Create inserts the storage if its name does not exist yet. It returns 0
+	// if the storage already exists.
+	Create(storage schema.Storage) (int64, error)
+	Update(storage schema.Storage) error
+	Patch(storage schema.Storage, columns ...string) error
+	Archived() ([]schema.Storage, error)
+	Archive(id, userID, version int) error
+	Restore(id, version int) error
+
+	// Delete deletes the storage and returns the number of storages deleted.
+	// It returns a *ReferencedError if the storage is referenced.
+	Delete(id, version int) (int64, error)
+}
+
+type UOMs interface {
+	Get(id int) (schema.UOM, error)
+	List() ([]schema.UOM, error)
+
+	// Create inserts the unit of measurement if its name does not exist yet.
+	// It returns 0 if the unit of measurement already exists.
+	Create(uom schema.UOM) (int64, error)
+	Update(uom schema.UOM) error
+	Patch(uom schema.UOM, columns ...string) error
+	Archived() ([]schema.UOM, error)
+	Archive(id, userID, version int) error
+	Restore(id, version int) error
+
+	// Delete deletes the unit of measurement and returns the number of units
+	// of measurement deleted. It returns a *ReferencedError if the unit of
+	// measurement is referenced.
+	Delete(id, version int) (int64, error)
+
+	// Conversion returns the conversion of the unit of measurement to the base
+	// unit of measurement of the item.
+	Conversion(itemID, uomID int) (schema.UOMConversion, error)
+}
+
+// Conversions are the conversions of the units of measurement of the items to
+// their base unit of measurement. They are created and updated in a Batch.
+type Conversions interface {
+	Get(id int) (schema.UOMConversion, error)
+
+	// List returns the conversions of the item.
+	List(itemID int) ([]schema.UOMConversion, error)
+	Patch(conversion schema.UOMConversion, columns ...string) error
+
+	// Delete deletes the conversion and returns the number of conversions
+	// deleted.
+	Delete(id int) (int64, error)
+}
+
+// Barcodes are the barcodes assigned to the items. They are created in a Batch.
+type Barcodes interface {
+	Get(id int) (schema.ItemBarcode, error)
+
+	// List returns the barcodes of the item.
+	List(itemID int) ([]schema.ItemBarcode, error)
+
+	// GetByGTIN returns the barcode of the 14-digit GTIN.
+	GetByGTIN(gtin string) (schema.ItemBarcode, error)
+
+	// Delete deletes the barcode and returns the number of barcodes deleted.
+	Delete(id int) (int64, error)
+}
+
+type Currencies interface {
+	Get(id int) (schema.Currency, error)
+	List() ([]schema.Currency, error)
+	Active() (schema.Currency, error)
+
+	// Activate sets the currency of the code as the active one.
+	Activate(code string) error
+}
+
+// Transactions are the inventory transactions along with their orderlines.
+// They are recorded and cancelled in a Batch.
+type Transactions interface {
+	Get(id int) (schema.Transaction, error)
+	List() ([]schema.Transaction, error)
+
+	// Search returns the transactions whose external reference starts with the
+	// given value, optionally of a specific partner and type. Empty values are
+	// not filtered on.
+	Search(partner, transactionType, externalReference string) ([]schema.Transaction, error)
+
+	// GetByExternalReference returns the transaction of a partner and type by
+	// the reference the partner gave it.
+	GetByExternalReference(partner, transactionType, externalReference string) (schema.Transaction, error)
+
+	UpdateNote(transaction schema.Transaction) error
+	UpdateOrderlineNote(orderline schema.Orderline) error
+
+	// Orderline returns the orderline without the base unit of measurement of
+	// its item.
+	Orderline(id int) (schema.Orderline, error)
+
+	// Patch and PatchOrderline set the columns of the transaction or orderline
+	// to its values, including empty ones.
+	Patch(transaction schema.Transaction, columns ...string) error
+	PatchOrderline(orderline schema.Orderline, columns ...string) error
+}
+
+// Ledger is the inventory ledger that the stock movements are recorded in.
+type Ledger interface {
+	// Valuation returns the stock on hand and its value of the items per
+	// storage from the movements recorded before the given time, ordered by
+	// item and storage.
+	Valuation(before time.Time) ([]schema.Valuation, error)
+}
+
+// Webhooks are the URLs that the events are delivered to, along with their
+// deliveries.
+type Webhooks interface {
+	Get(id int) (schema.Webhook, error)
+	List() ([]schema.Webhook, error)
+	Create(webhook schema.Webhook) (int64, error)
+
+	// Delete deletes the webhook along with its deliveries and returns the
+	// number of webhooks deleted.
+	Delete(id int) (int64, error)
+
+	// Deliveries returns the deliveries, newest first, optionally of a specific
+	// status and webhook. Empty values are not filtered on.
+	Deliveries(status string, webhookID int) ([]schema.WebhookDelivery, error)
+
+	// Retry queues a dead delivery to be attempted again. It returns the number
+	// of deliveries that were queued.
+	Retry(id int64) (int64, error)
+}
+
+// IdempotencyKeys are the keys of the requests that are handled at most once,
+// along with the responses that are replayed to their retries.
+type IdempotencyKeys interface {
+	// Reserve records the key of a request that is about to be handled. A key
+	// of the same path that expired by the given time is replaced at once, so
+	// that only one of the concurrent requests reserves it. It returns false
+	// if the key is still in use for the path.
+	Reserve(key schema.IdempotencyKey, now time.Time) (bool, error)
+	Get(key, path string) (schema.IdempotencyKey, error)
+
+	// Save stores the response to the request of the key.
+	Save(key schema.IdempotencyKey) error
+
+	// Release deletes the key so that the request can be retried.
+	Release(key, path string) error
+}
+
+// Batches begin the batches that the records of a request are written in.
+type Batches interface {
+	Begin() (Batch, error)
+}
+
+// Batch writes the records of a request in a single database transaction.
+// Each record is applied with Record so that a failed record is undone without
+// discarding the others, and the batch is then either committed or rolled back
+// as a whole. Records written earlier in the batch are visible to the later
+// ones.
+type Batch interface {
+	Commit() error
+
+	// Rollback discards all the records of the batch. It is a no-op if the
+	// batch was already committed or rolled back.
+	Rollback() error
+
+	// Record applies a single record of the batch. If fn fails, everything it
+	// wrote is undone and its error is returned.
+	Record(fn func() error) error
+
+	// Storage, UOM and Item insert the record if its code, or name for an item,
+	// does not exist yet. They return 0 if the record already exists.
+	Storage(storage schema.Storage) (int64, error)
+	UOM(uom schema.UOM) (int64, error)
+	Item(item schema.Item) (int64, error)
+
+	// Role inserts the role if its name does not exist yet. It returns 0 if
+	// the role already exists.
+	Role(role schema.Role) (int64, error)
+
+	// UOMConversion and ItemBarcode insert the record unless the item already
+	// has a conversion of the unit of measurement, or the barcode is already
+	// assigned. They return 0 if the record already exists.
+	UOMConversion(conversion schema.UOMConversion) (int64, error)
+	ItemBarcode(barcode schema.ItemBarcode) (int64, error)
+
+	// UpdateStorage, UpdateUOM, UpdateRole and UpdateUOMConversion update the
+	// record like the Update of its repository.
+	UpdateStorage(storage schema.Storage) error
+	UpdateUOM(uom schema.UOM) error
+	UpdateRole(role schema.Role) error
+	UpdateUOMConversion(conversion schema.UOMConversion) error
+
+	// UpdateItem updates the item but not its quantity, which only the stock
+	// movements change.
+	UpdateItem(item schema.Item) error
+
+	// PatchItem sets the columns of the item to its values, including empty
+	// ones, unlike UpdateItem which leaves them unchanged.
+	PatchItem(item schema.Item, columns ...string) error
+
+	// User inserts the user if there is no user with the same name yet. It
+	// returns 0 if the user already exists.
+	User(user schema.User) (int64, error)
+
+	StorageByCode(code string) (schema.Storage, error)
+	UOMByCode(code string) (schema.UOM, error)
+	ItemByID(id int) (schema.Item, error)
+	ItemBySKU(sku string) (schema.Item, error)
+	ItemByName(name string) (schema.Item, error)
+
+	// ItemForUpdate retrieves the item and locks it until the batch is
+	// committed, so that concurrent stock movements of the item are applied
+	// one at a time.
+	ItemForUpdate(id int) (schema.Item, error)
+
+	// UpdateItemQuantity sets the stock on hand of the item.
+	UpdateItemQuantity(item schema.Item) error
+
+	// ReceiveStock adds a cost layer for the received quantity of an item and
+	// records the inbound movement in the inventory ledger.
+	ReceiveStock(layer schema.CostLayer, storageID int) error
+
+	// OpeningStock adds the quantity to the stock on hand of the item and
+	// records it as a cost layer at the unit cost.
+	OpeningStock(item schema.Item, quantity int, unitCost float64) error
+
+	// IssueStock consumes the cost layers of the item for the orderline with
+	// the valuation method, records the outbound movement in the inventory
+	// ledger and returns its cost.
+	IssueStock(method string, orderline schema.Orderline, storageID int) (float64, error)
+
+	// ReverseReceipt and ReverseIssue reverse the stock movement of a cancelled
+	// inbound or outbound orderline.
+	ReverseReceipt(method string, orderline schema.Orderline, storageID int) error
+	ReverseIssue(orderline schema.Orderline, storageID int) error
+
+	NewTransaction(transaction schema.Transaction) (int64, error)
+
+	// CancelTransaction marks the transaction cancelled. It returns
+	// ErrAlreadyCancelled if it was cancelled already, so that two requests
+	// cannot both reverse its stock movements.
+	CancelTransaction(transaction schema.Transaction) error
+	NewOrderline(transactionType string, orderline schema.Orderline) (int64, error)
+	CancelOrderline(orderline schema.Orderline) error
+
+	// Event writes a domain event to the outbox. It is only dispatched if the
+	// batch is committed.
+	Event(event schema.OutboxEvent) (int64, error)
+}
@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Types of the domain events written to the outbox.
+const (
+	EventTransactionCreated   string = "transaction.created"
+	EventTransactionCancelled string = "transaction.cancelled"
+	EventItemStockChanged     string = "item.stock_changed"
+	EventItemLowStock         string = "item.low_stock"
+)
+
+// Statuses of the delivery of an event to a webhook.
+const (
+	DeliveryPending   string = "pending"
+	DeliveryDelivered string = "delivered"
+	DeliveryDead      string = "dead"
+)
+
+// EventTypes are the types of the domain events.
+var EventTypes = []string{
+	EventTransactionCreated,
+	EventTransactionCancelled,
+	EventItemStockChanged,
+	EventItemLowStock,
+}
+
+type (
+	// OutboxEvent is a domain event that is written in the same database
+	// transaction as the change it describes, and dispatched afterwards.
+	OutboxEvent struct {
+		ID           int64         `db:"id"`
+		EventID      string        `db:"event_id"`
+		Type         string        `db:"type"`
+		ItemID       sql.NullInt32 `db:"item_id"`
+		StorageID    sql.NullInt32 `db:"storage_id"`
+		Payload      []byte        `db:"payload"`
+		IsDispatched bool          `db:"is_dispatched"`
+		DateCreated  time.Time     `db:"date_created"`
+	}
+
+	// Webhook is a URL that the events are POSTed to. The event types are
+	// comma-separated; a webhook without event types receives all events.
+	Webhook struct {
+		ID          int            `db:"id"`
+		URL         string         `db:"url"`
+		Secret      string         `db:"secret"`
+		EventTypes  sql.NullString `db:"event_types"`
+		IsActive    bool           `db:"is_active"`
+		DateCreated time.Time      `db:"date_created"`
+	}
+
+	// WebhookDelivery is the delivery of an event to a webhook. A delivery that
+	// failed too many times is dead and is kept until it is retried.
+	WebhookDelivery struct {
+		ID             int64          `db:"id"`
+		WebhookID      int            `db:"webhook_id"`
+		EventID        int64          `db:"event_id"`
+		Status         string         `db:"status"`
+		Attempts       int            `db:"attempts"`
+		NextAttemptAt  time.Time      `db:"next_attempt_at"`
+		ResponseStatus sql.NullInt32  `db:"response_status"`
+		LastError      sql.NullString `db:"last_error"`
+		DateCreated    time.Time      `db:"date_created"`
+		DateModified   sql.NullTime   `db:"date_modified"`
+	}
+)
+
+// IsValidEventType returns true if the event type is a known domain event.
+func IsValidEventType(eventType string) bool {
+	return slices.Contains(EventTypes, eventType)
+}
+
+// Subscribes returns true if the webhook receives events of the given type.
+func (w Webhook) Subscribes(eventType string) bool {
+	if !w.EventTypes.Valid || strings.TrimSpace(w.EventTypes.String) == "" {
+		return true
+	}
+
+	for _, subscribed := range strings.Split(w.EventTypes.String, ",") {
+		if strings.TrimSpace(subscribed) == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeliveryAttempt is a delivery that is due, with the webhook and the event it
+// delivers.
+type DeliveryAttempt struct {
+	WebhookDelivery
+	URL              string    `db:"url"`
+	Secret           string    `db:"secret"`
+	EventUUID        string    `db:"event_uuid"`
+	EventType        string    `db:"event_type"`
+	Payload          []byte    `db:"payload"`
+	EventDateCreated time.Time `db:"event_date_created"`
+}
@@ -0,0 +1,26 @@
+package schema
+
+import (
+	"database/sql"
+	"time"
+)
+
+// IdempotencyKey is a request that was sent with an 'Idempotency-Key' header
+// and the response to it. The status code is not set while the request is still
+// in progress.
+type IdempotencyKey struct {
+	ID           int            `db:"id"`
+	Key          string         `db:"idempotency_key"`
+	Path         string         `db:"path"`
+	RequestHash  string         `db:"request_hash"`
+	StatusCode   sql.NullInt32  `db:"status_code"`
+	ContentType  sql.NullString `db:"content_type"`
+	ResponseBody []byte         `db:"response_body"`
+	DateCreated  time.Time      `db:"date_created"`
+	ExpiresAt    time.Time      `db:"expires_at"`
+}
+
+// IsExpired returns true if the key can no longer be replayed.
+func (k IdempotencyKey) IsExpired(now time.Time) bool {
+	return !now.Before(k.ExpiresAt)
+}
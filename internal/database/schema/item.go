@@ -7,8 +7,10 @@ import (
 
 type Item struct {
 	ID           int            `db:"id"`
+	SKU          sql.NullString `db:"sku"`
 	Name         string         `db:"name"`
 	Description  sql.NullString `db:"description"`
+	Category     sql.NullString `db:"category"`
 	Quantity     int            `db:"quantity"`
 	UnitPrice    float64        `db:"unit_price"`
 	UoMID        int            `db:"uom_id"`
@@ -17,6 +19,20 @@ type Item struct {
 	CreatedBy    int            `db:"created_by"`
 	DateCreated  time.Time      `db:"date_created"`
 	DateModified sql.NullTime   `db:"date_modified"`
+	Version      int            `db:"version"`
+	ArchivedAt   sql.NullTime   `db:"archived_at"`
+	ArchivedBy   sql.NullInt32  `db:"archived_by"`
+}
+
+// ItemBarcode is a barcode of an item, optionally for a specific unit of
+// measurement (e.g. the barcode printed on a case).
+type ItemBarcode struct {
+	ID      int           `db:"id"`
+	ItemID  int           `db:"item_id"`
+	UoMID   sql.NullInt32 `db:"uom_id"`
+	Barcode string        `db:"barcode"`
+	GTIN    string        `db:"gtin"`
+	Type    string        `db:"type"`
 }
 
 func (i *Item) UpdateQuantity(transactionType string, quantity int) {
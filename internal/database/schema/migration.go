@@ -0,0 +1,13 @@
+package schema
+
+import "time"
+
+// Migration is a schema migration that was applied to the database. A dirty
+// migration failed part way through and must be repaired by hand.
+type Migration struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	Dirty     bool      `db:"dirty"`
+	AppliedAt time.Time `db:"applied_at"`
+}
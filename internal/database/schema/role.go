@@ -1,6 +1,7 @@
 package schema
 
 type Role struct {
-	ID   int    `db:"id"`
-	Name string `db:"name"`
+	ID      int    `db:"id"`
+	Name    string `db:"name"`
+	Version int    `db:"version"`
 }
@@ -9,4 +9,7 @@ type Storage struct {
 	Code        string         `db:"code"`
 	Name        string         `db:"name"`
 	Description sql.NullString `db:"description"`
+	Version     int            `db:"version"`
+	ArchivedAt  sql.NullTime   `db:"archived_at"`
+	ArchivedBy  sql.NullInt32  `db:"archived_by"`
 }
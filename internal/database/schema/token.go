@@ -0,0 +1,32 @@
+package schema
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Purposes of a user token.
+const (
+	TokenInvite       string = "invite"
+	TokenVerification string = "verify_email"
+	TokenReset        string = "reset_password"
+)
+
+// UserToken is a single-use token that was emailed to a user, for them to
+// accept an invite, verify their email or reset their password. Only the
+// SHA-256 hash of the token is stored, and the email it was sent to.
+type UserToken struct {
+	ID          int          `db:"id"`
+	UserID      int          `db:"user_id"`
+	Purpose     string       `db:"purpose"`
+	TokenHash   string       `db:"token_hash"`
+	Email       string       `db:"email"`
+	ExpiresAt   time.Time    `db:"expires_at"`
+	UsedAt      sql.NullTime `db:"used_at"`
+	DateCreated time.Time    `db:"date_created"`
+}
+
+// IsExpired returns true if the token can no longer be used.
+func (t UserToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -7,17 +7,19 @@ import (
 
 type (
 	Transaction struct {
-		ID           int             `db:"id"`
-		Reference    string          `db:"reference"`
-		Orderlines   []Orderline     `db:"-"`
-		Amount       sql.NullFloat64 `db:"amount"`
-		Type         string          `db:"type"`
-		IsCancelled  sql.NullBool    `db:"is_cancelled"`
-		Note         sql.NullString  `db:"note"`
-		CreatedBy    int             `db:"created_by"`
-		UpdatedBy    sql.NullInt32   `db:"updated_by"`
-		DateCreated  time.Time       `db:"date_created"`
-		DateModified sql.NullTime    `db:"date_modified"`
+		ID                int             `db:"id"`
+		Reference         string          `db:"reference"`
+		Partner           string          `db:"partner"`
+		ExternalReference sql.NullString  `db:"external_reference"`
+		Orderlines        []Orderline     `db:"-"`
+		Amount            sql.NullFloat64 `db:"amount"`
+		Type              string          `db:"type"`
+		IsCancelled       sql.NullBool    `db:"is_cancelled"`
+		Note              sql.NullString  `db:"note"`
+		CreatedBy         int             `db:"created_by"`
+		UpdatedBy         sql.NullInt32   `db:"updated_by"`
+		DateCreated       time.Time       `db:"date_created"`
+		DateModified      sql.NullTime    `db:"date_modified"`
 	}
 
 	Orderline struct {
@@ -25,6 +27,9 @@ type (
 		TransactionID int             `db:"transaction_id"`
 		ItemID        int             `db:"item_id"`
 		Quantity      int             `db:"quantity"`
+		UoMID         sql.NullInt32   `db:"uom_id"`
+		UoMQuantity   sql.NullInt32   `db:"uom_quantity"`
+		BaseUoMID     sql.NullInt32   `db:"base_uom_id"`
 		UnitPrice     sql.NullFloat64 `db:"unit_price"`
 		TotalAmount   sql.NullFloat64 `db:"total_amount"`
 		Note          sql.NullString  `db:"note"`
@@ -1,7 +1,43 @@
 package schema
 
+import (
+	"database/sql"
+	"errors"
+	"math"
+)
+
+var (
+	ErrUOMNotConvertible  = errors.New("unit of measurement is not convertible for the item")
+	ErrFractionalQuantity = errors.New("conversion would create a fractional base quantity")
+)
+
 type UOM struct {
-	ID   int    `db:"id"`
-	Code string `db:"code"`
-	Name string `db:"name"`
+	ID         int           `db:"id"`
+	Code       string        `db:"code"`
+	Name       string        `db:"name"`
+	Version    int           `db:"version"`
+	ArchivedAt sql.NullTime  `db:"archived_at"`
+	ArchivedBy sql.NullInt32 `db:"archived_by"`
+}
+
+// UOMConversion is the number of the item's base unit of measurement contained
+// in one unit of another unit of measurement (e.g. 1 CS = 144 EA).
+type UOMConversion struct {
+	ID     int     `db:"id"`
+	ItemID int     `db:"item_id"`
+	UoMID  int     `db:"uom_id"`
+	Factor float64 `db:"factor"`
+}
+
+// ToBase converts the quantity to the item's base unit of measurement. It
+// returns an error if the converted quantity is not a whole number.
+func (c UOMConversion) ToBase(quantity int) (int, error) {
+	base := float64(quantity) * c.Factor
+	rounded := math.Round(base)
+
+	if math.Abs(base-rounded) > 1e-6 || rounded < 1 {
+		return 0, ErrFractionalQuantity
+	}
+
+	return int(rounded), nil
 }
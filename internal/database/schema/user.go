@@ -2,18 +2,28 @@ package schema
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 )
 
 type User struct {
-	ID           int            `db:"id"`
-	RoleID       int            `db:"role_id"`
-	FirstName    string         `db:"first_name"`
-	LastName     string         `db:"last_name"`
-	Email        sql.NullString `db:"email"`
-	Password     string         `db:"password"`
-	LastLogin    sql.NullString `db:"last_login"`
-	Active       bool           `db:"is_active"`
-	DateCreated  time.Time      `db:"date_created"`
-	DateModified sql.NullTime   `db:"date_modified"`
+	ID            int            `db:"id"`
+	RoleID        int            `db:"role_id"`
+	FirstName     string         `db:"first_name"`
+	LastName      string         `db:"last_name"`
+	Email         sql.NullString `db:"email"`
+	VerifiedEmail sql.NullString `db:"verified_email"`
+	Password      string         `db:"password"`
+	LastLogin     sql.NullString `db:"last_login"`
+	Active        bool           `db:"is_active"`
+	DateCreated   time.Time      `db:"date_created"`
+	DateModified  sql.NullTime   `db:"date_modified"`
+	Version       int            `db:"version"`
+}
+
+// EmailVerified returns true if the user confirmed that they own their current
+// email. The address that was confirmed is kept rather than a flag, so that a
+// changed email is no longer verified.
+func (u User) EmailVerified() bool {
+	return u.Email.Valid && u.VerifiedEmail.Valid && strings.EqualFold(u.Email.String, u.VerifiedEmail.String)
 }
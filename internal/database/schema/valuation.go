@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"database/sql"
+	"math"
+	"time"
+)
+
+// Inventory valuation methods.
+const (
+	FIFO          string = "fifo"
+	MovingAverage string = "average"
+)
+
+type (
+	// CostLayer is a quantity of an item received at a specific unit cost.
+	// Outbound movements consume the remaining quantity of the layers.
+	CostLayer struct {
+		ID                int           `db:"id"`
+		ItemID            int           `db:"item_id"`
+		OrderlineID       sql.NullInt32 `db:"orderline_id"`
+		Quantity          int           `db:"quantity"`
+		RemainingQuantity int           `db:"remaining_quantity"`
+		UnitCost          float64       `db:"unit_cost"`
+		DateCreated       time.Time     `db:"date_created"`
+	}
+
+	// LedgerEntry records the quantity and value that moved in or out of
+	// the stock. Inbound entries are positive and outbound are negative.
+	LedgerEntry struct {
+		ID          int           `db:"id"`
+		ItemID      int           `db:"item_id"`
+		StorageID   int           `db:"storage_id"`
+		OrderlineID sql.NullInt32 `db:"orderline_id"`
+		Quantity    int           `db:"quantity"`
+		Amount      float64       `db:"amount"`
+		DateCreated time.Time     `db:"date_created"`
+	}
+
+	// StockDiscrepancy is an item whose stock on hand is not the sum of its
+	// movements in the inventory ledger.
+	StockDiscrepancy struct {
+		ItemID         int    `db:"item_id"`
+		Name           string `db:"name"`
+		Quantity       int    `db:"quantity"`
+		LedgerQuantity int    `db:"ledger_quantity"`
+	}
+
+	// Valuation is the stock on hand and its value of an item in a storage.
+	Valuation struct {
+		ItemID    int            `db:"item_id"`
+		Name      string         `db:"name"`
+		Category  sql.NullString `db:"category"`
+		StorageID int            `db:"storage_id"`
+		Quantity  int            `db:"quantity"`
+		Value     float64        `db:"value"`
+	}
+)
+
+// IsValidValuationMethod checks if the method is either FIFO or moving average.
+func IsValidValuationMethod(method string) bool {
+	return (method == FIFO) || (method == MovingAverage)
+}
+
+// RoundAmount rounds the amount to the 4 decimal places kept by the cost
+// layers and the ledger.
+func RoundAmount(amount float64) float64 {
+	return math.Round(amount*10000) / 10000
+}
+
+// ConsumeLayers removes the quantity from the remaining quantity of the layers
+// in the order they are given and returns the cost of the consumed quantity
+// and the quantity that could not be covered by the layers.
+//
+// With FIFO, the consumed quantity is valued at the unit cost of each layer it
+// was taken from. With moving average, it is valued at the average unit cost of
+// all the layers and the layers left are re-priced to that average.
+func ConsumeLayers(layers []CostLayer, quantity int, method string) (float64, int) {
+	var (
+		cost          float64
+		totalQuantity int
+		totalValue    float64
+		averageCost   float64
+		uncovered     = quantity
+	)
+
+	for _, layer := range layers {
+		totalQuantity += layer.RemainingQuantity
+		totalValue += float64(layer.RemainingQuantity) * layer.UnitCost
+	}
+
+	if totalQuantity > 0 {
+		averageCost = RoundAmount(totalValue / float64(totalQuantity))
+	}
+
+	for i := range layers {
+		if uncovered == 0 {
+			break
+		}
+
+		taken := min(layers[i].RemainingQuantity, uncovered)
+		layers[i].RemainingQuantity -= taken
+		uncovered -= taken
+
+		if method == FIFO {
+			cost += float64(taken) * layers[i].UnitCost
+		}
+	}
+
+	if method == MovingAverage {
+		cost = float64(quantity-uncovered) * averageCost
+
+		for i := range layers {
+			layers[i].UnitCost = averageCost
+		}
+	}
+
+	return RoundAmount(cost), uncovered
+}
@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// layers returns cost layers of the quantities remaining at the unit costs,
+// which are given in pairs.
+func layers(pairs ...float64) []CostLayer {
+	var list []CostLayer
+
+	for i := 0; i < len(pairs); i += 2 {
+		quantity := int(pairs[i])
+		list = append(list, CostLayer{ID: i/2 + 1, Quantity: quantity, RemainingQuantity: quantity, UnitCost: pairs[i+1]})
+	}
+
+	return list
+}
+
+func TestConsumeLayers(t *testing.T) {
+	tests := []struct {
+		name      string
+		layers    []CostLayer
+		quantity  int
+		method    string
+		cost      float64
+		uncovered int
+		remaining []int
+		unitCosts []float64
+	}{
+		{
+			name:      "FIFO within the first layer",
+			layers:    layers(10, 2, 10, 4),
+			quantity:  4,
+			method:    FIFO,
+			cost:      8,
+			remaining: []int{6, 10},
+			unitCosts: []float64{2, 4},
+		},
+		{
+			name:      "FIFO across layers",
+			layers:    layers(10, 2, 10, 4),
+			quantity:  15,
+			method:    FIFO,
+			cost:      40,
+			remaining: []int{0, 5},
+			unitCosts: []float64{2, 4},
+		},
+		{
+			name:      "FIFO skips the consumed layers",
+			layers:    []CostLayer{{ID: 1, Quantity: 10, UnitCost: 2}, {ID: 2, Quantity: 10, RemainingQuantity: 3, UnitCost: 4}},
+			quantity:  2,
+			method:    FIFO,
+			cost:      8,
+			remaining: []int{0, 1},
+			unitCosts: []float64{2, 4},
+		},
+		{
+			name:      "FIFO out of layers",
+			layers:    layers(10, 2, 5, 4),
+			quantity:  18,
+			method:    FIFO,
+			cost:      40,
+			uncovered: 3,
+			remaining: []int{0, 0},
+			unitCosts: []float64{2, 4},
+		},
+		{
+			name:      "FIFO without layers",
+			quantity:  5,
+			method:    FIFO,
+			uncovered: 5,
+		},
+		{
+			name:      "average within the first layer",
+			layers:    layers(10, 2, 10, 4),
+			quantity:  4,
+			method:    MovingAverage,
+			cost:      12,
+			remaining: []int{6, 10},
+			unitCosts: []float64{3, 3},
+		},
+		{
+			name:      "average across layers",
+			layers:    layers(10, 2, 20, 3.5),
+			quantity:  15,
+			method:    MovingAverage,
+			cost:      45,
+			remaining: []int{0, 15},
+			unitCosts: []float64{3, 3},
+		},
+		{
+			name:      "average rounded to 4 decimal places",
+			layers:    layers(1, 1, 2, 2),
+			quantity:  1,
+			method:    MovingAverage,
+			cost:      1.6667,
+			remaining: []int{0, 2},
+			unitCosts: []float64{1.6667, 1.6667},
+		},
+		{
+			name:      "average out of layers",
+			layers:    layers(10, 2, 10, 4),
+			quantity:  25,
+			method:    MovingAverage,
+			cost:      60,
+			uncovered: 5,
+			remaining: []int{0, 0},
+			unitCosts: []float64{3, 3},
+		},
+		{
+			name:      "nothing to consume",
+			layers:    layers(10, 2),
+			quantity:  0,
+			method:    FIFO,
+			remaining: []int{10},
+			unitCosts: []float64{2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cost, uncovered := ConsumeLayers(test.layers, test.quantity, test.method)
+			if math.Abs(cost-test.cost) > 1e-9 || uncovered != test.uncovered {
+				t.Errorf("ConsumeLayers() = %v, %d, want %v, %d", cost, uncovered, test.cost, test.uncovered)
+			}
+
+			var (
+				remaining []int
+				unitCosts []float64
+			)
+
+			for _, layer := range test.layers {
+				remaining = append(remaining, layer.RemainingQuantity)
+				unitCosts = append(unitCosts, layer.UnitCost)
+			}
+
+			if !slices.Equal(remaining, test.remaining) || !slices.Equal(unitCosts, test.unitCosts) {
+				t.Errorf("layers = %v at %v, want %v at %v", remaining, unitCosts, test.remaining, test.unitCosts)
+			}
+		})
+	}
+}
+
+func TestRoundAmount(t *testing.T) {
+	for amount, want := range map[float64]float64{
+		1.23454:  1.2345,
+		1.23455:  1.2346,
+		-2.00004: -2,
+		10:       10,
+	} {
+		if got := RoundAmount(amount); got != want {
+			t.Errorf("RoundAmount(%v) = %v, want %v", amount, got, want)
+		}
+	}
+}
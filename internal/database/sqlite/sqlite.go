@@ -0,0 +1,75 @@
+// Package sqlite opens the connection to a SQLite database file, the
+// alternative to MySQL for small sites and local development. The queries of
+// the 'mysql' package run on it as they are, with the differences of the
+// dialect handled there.
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+)
+
+// DriverName is the name the SQLite driver is registered with in database/sql.
+const DriverName string = "sqlite3"
+
+// DSN returns the data source name of the database file with the settings. It
+// is the single place where the connection options are built.
+//
+// Options:
+//   - _foreign_keys: Enforces the foreign keys, which SQLite does not by default.
+//   - _busy_timeout: Waits for the write lock held by another connection instead of failing right away.
+//   - _journal_mode: WAL, so that the reads are not blocked by a write.
+//   - _txlock: Begins every transaction with the write lock (BEGIN IMMEDIATE), in place of the row locks of MySQL.
+//   - _loc: Reads and writes the timestamps in UTC, like CURRENT_TIMESTAMP.
+func DSN(settings config.SQLite) string {
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	params.Set("_busy_timeout", fmt.Sprint(time.Duration(settings.BusyTimeout).Milliseconds()))
+	params.Set("_journal_mode", "WAL")
+	params.Set("_txlock", "immediate")
+	params.Set("_loc", "UTC")
+
+	return "file:" + settings.Path + "?" + params.Encode()
+}
+
+// Open opens a pool of connections to the database file with the settings and
+// pings it. The file is created if it does not exist.
+func Open(settings config.SQLite) (*sqlx.DB, error) {
+	db, err := sqlx.Open(DriverName, DSN(settings))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(settings.BusyTimeout)+time.Second)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// IsUniqueViolation reports whether the error is a duplicate of a unique key.
+func IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// IsMissingTable reports whether the error is a query on a table that does not
+// exist. SQLite has no error code of its own for it.
+func IsMissingTable(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrError &&
+		strings.HasPrefix(sqliteErr.Error(), "no such table")
+}
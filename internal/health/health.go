@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/api/response"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/config"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/db"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+)
+
+// Status of the application and of each of its checks.
+const (
+	StatusOK           string = "ok"
+	StatusDegraded     string = "degraded"
+	StatusFailing      string = "failing"
+	StatusShuttingDown string = "shutting_down"
+)
+
+// checkTimeout is how long the readiness checks can take altogether, so that a
+// database that hangs is reported instead of holding the probe.
+const checkTimeout time.Duration = 2 * time.Second
+
+// Report is the result of a probe.
+type Report struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks,omitempty"`
+}
+
+// Check is the result of the check of a dependency.
+type Check struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency,omitempty"`
+}
+
+// shuttingDown is set once the shutdown begins.
+var shuttingDown atomic.Bool
+
+// Shutdown marks the application as shutting down, which fails the readiness
+// probe so that the load balancers stop routing new traffic to it.
+func Shutdown() { shuttingDown.Store(true) }
+
+// Liveness handles the '/healthz' probe. It only reports that the process is
+// serving requests; the dependencies are checked by the readiness probe.
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	response.Success(w, Report{Status: StatusOK})
+}
+
+// Readiness handles the '/readyz' probe. It pings the database, reported under
+// the name of its driver, and checks that all the schema migrations were
+// applied cleanly. It reports the status of each check and responds with 503
+// if any fails or if the shutdown has begun.
+func Readiness(w http.ResponseWriter, r *http.Request) {
+	if shuttingDown.Load() {
+		response.ServiceUnavailable(w, Report{Status: StatusShuttingDown})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: map[string]Check{
+			config.Get().DatabaseDriver(): check(ctx, mysql.Ping),
+			"schema":                      check(ctx, db.CheckMigrations),
+		},
+	}
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	if report.Status != StatusOK {
+		response.ServiceUnavailable(w, report)
+		return
+	}
+
+	response.Success(w, report)
+}
+
+// check runs the check of a dependency and measures how long it took.
+func check(ctx context.Context, fn func(context.Context) error) Check {
+	start := time.Now()
+
+	err := fn(ctx)
+	if err != nil {
+		return Check{Status: StatusFailing, Error: err.Error(), Latency: time.Since(start).String()}
+	}
+
+	return Check{Status: StatusOK, Latency: time.Since(start).String()}
+}
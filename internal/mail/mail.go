@@ -0,0 +1,126 @@
+// Package mail sends the emails of the application through a Sender: an SMTP
+// server in production, or a file or the log in development.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+// Senders of the emails.
+const (
+	SenderLog  string = "log"
+	SenderFile string = "file"
+	SenderSMTP string = "smtp"
+)
+
+// Message is a plain text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender sends the emails. An error means that the email was not accepted for
+// delivery.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// Options holds how the emails are sent: the sender, the address they are sent
+// from, the file that the file sender appends them to and the SMTP server.
+type Options struct {
+	Sender string
+	From   string
+	Path   string
+	SMTP   SMTPOptions
+}
+
+// New returns the sender of the options.
+func New(options Options) (Sender, error) {
+	switch options.Sender {
+	case SenderLog:
+		return NewLog(options.From), nil
+
+	case SenderFile:
+		return NewFile(options.Path, options.From), nil
+
+	case SenderSMTP:
+		return NewSMTP(options.SMTP, options.From), nil
+	}
+
+	return nil, fmt.Errorf("unknown mail sender '%s'; must be %s, %s or %s", options.Sender, SenderLog, SenderFile, SenderSMTP)
+}
+
+// address returns the bare address (e.g. 'jane@example.com') of an address that
+// can have a name (e.g. 'Jane <jane@example.com>').
+func address(value string) (string, error) {
+	parsed, err := netmail.ParseAddress(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid address '%s': %w", value, err)
+	}
+
+	return parsed.Address, nil
+}
+
+// format returns the message from the address as it is sent: its headers and
+// its body encoded as quoted-printable. It returns an error if the recipient is
+// not a valid address, or if the subject would add a header.
+func format(message Message, from string, now time.Time) ([]byte, error) {
+	to, err := netmail.ParseAddress(message.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address '%s': %w", message.To, err)
+	}
+
+	if strings.ContainsAny(message.Subject, "\r\n") {
+		return nil, errors.New("the subject cannot have a line break")
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], ">")
+	}
+
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "From: %s\r\n", from)
+	fmt.Fprintf(&buffer, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buffer, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	// The writer ends the lines of the body with CRLF.
+	writer := quotedprintable.NewWriter(&buffer)
+
+	_, err = writer.Write([]byte(message.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// The email ends with a line break, even if the body does not.
+	if !bytes.HasSuffix(buffer.Bytes(), []byte("\r\n")) {
+		buffer.WriteString("\r\n")
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,239 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envelope is an email that the SMTP stand-in accepted.
+type envelope struct {
+	from, to, auth string
+	data           []byte
+}
+
+// standIn is a local SMTP server that accepts every email and records it. It
+// advertises the extensions in its EHLO response.
+type standIn struct {
+	listener   net.Listener
+	extensions []string
+	received   chan envelope
+}
+
+func newStandIn(t *testing.T, extensions ...string) *standIn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	s := &standIn{listener: listener, extensions: extensions, received: make(chan envelope, 1)}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *standIn) options() SMTPOptions {
+	address := s.listener.Addr().(*net.TCPAddr)
+	return SMTPOptions{Host: "127.0.0.1", Port: address.Port, TLS: TLSNone, Timeout: 5 * time.Second}
+}
+
+// serve runs the server side of the SMTP conversation.
+func (s *standIn) serve(conn net.Conn) {
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 stand-in ESMTP")
+
+	var mail envelope
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, argument, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			for _, extension := range s.extensions {
+				_ = text.PrintfLine("250-%s", extension)
+			}
+
+			_ = text.PrintfLine("250 stand-in")
+
+		case "AUTH":
+			_, credentials, _ := strings.Cut(argument, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(credentials)
+			mail.auth = string(decoded)
+
+			_ = text.PrintfLine("235 authenticated")
+
+		case "MAIL":
+			mail.from = strings.Trim(strings.TrimPrefix(argument, "FROM:"), "<>")
+			_ = text.PrintfLine("250 OK")
+
+		case "RCPT":
+			mail.to = strings.Trim(strings.TrimPrefix(argument, "TO:"), "<>")
+			_ = text.PrintfLine("250 OK")
+
+		case "DATA":
+			_ = text.PrintfLine("354 go ahead")
+
+			mail.data, err = text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			_ = text.PrintfLine("250 queued")
+			s.received <- mail
+
+		case "QUIT":
+			_ = text.PrintfLine("221 bye")
+			return
+
+		default:
+			_ = text.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// read parses the email and returns its subject and decoded body.
+func read(t *testing.T, data []byte) (string, string) {
+	t.Helper()
+
+	message, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to parse the email: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("failed to decode the subject: %v", err)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(message.Body))
+	if err != nil {
+		t.Fatalf("failed to decode the body: %v", err)
+	}
+
+	return subject, string(body)
+}
+
+func TestSMTP(t *testing.T) {
+	server := newStandIn(t, "AUTH PLAIN")
+
+	options := server.options()
+	options.Username, options.Password = "wim", "s3cret"
+
+	sender := NewSMTP(options, "Warehouse <wim@example.com>")
+
+	message := Message{
+		To:      "Jane Doe <jane.doe@example.com>",
+		Subject: "Réinitialisez votre mot de passe",
+		Body:    "Hello Jane,\n\nUse this token: " + strings.Repeat("x", 100) + "\n",
+	}
+
+	err := sender.Send(context.Background(), message)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	mail := <-server.received
+
+	if mail.from != "wim@example.com" || mail.to != "jane.doe@example.com" {
+		t.Errorf("envelope from %s to %s, want from wim@example.com to jane.doe@example.com", mail.from, mail.to)
+	}
+
+	if mail.auth != "\x00wim\x00s3cret" {
+		t.Errorf("auth = %q, want the PLAIN credentials", mail.auth)
+	}
+
+	subject, body := read(t, mail.data)
+	if subject != message.Subject {
+		t.Errorf("subject = %q, want %q", subject, message.Subject)
+	}
+
+	// The stand-in reads the lines of the data without their CR.
+	if body != message.Body {
+		t.Errorf("body = %q, want %q", body, message.Body)
+	}
+}
+
+func TestSMTPRequiresStartTLS(t *testing.T) {
+	server := newStandIn(t)
+
+	options := server.options()
+	options.TLS = TLSStartTLS
+
+	err := NewSMTP(options, "wim@example.com").Send(context.Background(), Message{To: "jane.doe@example.com", Subject: "Hello"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("Send() error = %v, want the missing STARTTLS", err)
+	}
+
+	select {
+	case mail := <-server.received:
+		t.Errorf("received %+v, want nothing sent in plain text", mail)
+
+	default:
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.txt")
+	sender := NewFile(path, "wim@example.com")
+
+	for _, subject := range []string{"First", "Second"} {
+		err := sender.Send(context.Background(), Message{To: "jane.doe@example.com", Subject: subject, Body: "Hello"})
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the file: %v", err)
+	}
+
+	if !bytes.Contains(data, []byte("Subject: First")) || !bytes.Contains(data, []byte("Subject: Second")) {
+		t.Errorf("file = %s, want both emails", data)
+	}
+}
+
+func TestInvalidMessage(t *testing.T) {
+	sender := NewFile(filepath.Join(t.TempDir(), "mail.txt"), "wim@example.com")
+
+	for _, message := range []Message{
+		{To: "jane.doe", Subject: "Hello"},
+		{To: "jane.doe@example.com", Subject: "Hello\r\nBcc: eve@example.com"},
+	} {
+		err := sender.Send(context.Background(), message)
+		if err == nil {
+			t.Errorf("Send(%+v) error = nil, want an error", message)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// File appends the emails to a file as they would be sent, for development.
+type File struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+// NewFile returns the sender that appends the emails from the address to the
+// file, which is created if it does not exist.
+func NewFile(path, from string) *File {
+	return &File{path: path, from: from}
+}
+
+func (f *File) Send(_ context.Context, message Message) error {
+	data, err := format(message, f.from, time.Now())
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(data, "\r\n"...))
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Log writes the emails to the log instead of sending them, for development.
+// The body is written as it is, so the tokens it has are in the log too.
+type Log struct {
+	from string
+}
+
+// NewLog returns the sender that writes the emails from the address to the log.
+func NewLog(from string) *Log {
+	return &Log{from: from}
+}
+
+func (l *Log) Send(_ context.Context, message Message) error {
+	_, err := address(message.To)
+	if err != nil {
+		return err
+	}
+
+	trail.Info("Mail from %s to %s: %s\n%s", l.from, message.To, message.Subject, message.Body)
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// TLS modes of the SMTP connection.
+const (
+	TLSNone     string = "none"
+	TLSStartTLS string = "starttls"
+	TLSImplicit string = "tls"
+)
+
+// SMTPOptions holds the SMTP server that the emails are sent through. The TLS
+// mode is one of none (plain text, e.g. a relay on the same host), starttls
+// (upgraded with STARTTLS, which the server must support) or tls (encrypted
+// from the start, usually on port 465). The user is authenticated with PLAIN if
+// a username is given, which is only sent encrypted unless the server is on
+// localhost. The timeout limits the whole conversation.
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLS      string
+	Timeout  time.Duration
+}
+
+// SMTP sends the emails through an SMTP server, one connection per email.
+type SMTP struct {
+	options SMTPOptions
+	from    string
+}
+
+// NewSMTP returns the sender through the SMTP server from the address.
+func NewSMTP(options SMTPOptions, from string) *SMTP {
+	return &SMTP{options: options, from: from}
+}
+
+func (s *SMTP) Send(ctx context.Context, message Message) error {
+	data, err := format(message, s.from, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if s.options.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, s.options.Timeout)
+		defer cancel()
+	}
+
+	address := net.JoinHostPort(s.options.Host, strconv.Itoa(s.options.Port))
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the SMTP server: %w", err)
+	}
+
+	// The deadline of the context also limits every read and write.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if s.options.TLS == TLSImplicit {
+		conn = tls.Client(conn, &tls.Config{ServerName: s.options.Host})
+	}
+
+	client, err := smtp.NewClient(conn, s.options.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to greet the SMTP server: %w", err)
+	}
+
+	defer client.Close()
+
+	err = s.send(client, message.To, data)
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// send runs the SMTP conversation of the message on the client.
+func (s *SMTP) send(client *smtp.Client, to string, data []byte) error {
+	if s.options.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("the SMTP server does not support STARTTLS")
+		}
+
+		err := client.StartTLS(&tls.Config{ServerName: s.options.Host})
+		if err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if s.options.Username != "" {
+		err := client.Auth(smtp.PlainAuth("", s.options.Username, s.options.Password, s.options.Host))
+		if err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	from, err := address(s.from)
+	if err != nil {
+		return err
+	}
+
+	recipient, err := address(to)
+	if err != nil {
+		return err
+	}
+
+	err = client.Mail(from)
+	if err != nil {
+		return fmt.Errorf("the SMTP server refused the sender: %w", err)
+	}
+
+	err = client.Rcpt(recipient)
+	if err != nil {
+		return fmt.Errorf("the SMTP server refused the recipient: %w", err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(data)
+	if err != nil {
+		return err
+	}
+
+	// The server accepts the email once the data is closed.
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("the SMTP server refused the email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the name of every metric of the application.
+const namespace string = "wim"
+
+var (
+	requests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route segment, method and status.",
+		},
+		[]string{"segment", "method", "status"},
+	)
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of the HTTP requests by route segment, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"segment", "method", "status"},
+	)
+
+	queryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Latency of the database queries by helper.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		},
+		[]string{"helper"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(requests, requestDuration, queryDuration)
+}
+
+// Handler returns the handler of the '/metrics' endpoint in the Prometheus text
+// format.
+func Handler() http.Handler { return promhttp.Handler() }
+
+// ObserveRequest records an HTTP request that started at the given time. The
+// segment must be a known route (or a fixed placeholder) to keep the number of
+// series bounded.
+func ObserveRequest(segment, method string, status int, start time.Time) {
+	labels := prometheus.Labels{
+		"segment": segment,
+		"method":  method,
+		"status":  strconv.Itoa(status),
+	}
+
+	requests.With(labels).Inc()
+	requestDuration.With(labels).Observe(time.Since(start).Seconds())
+}
+
+// ObserveQuery records a database query of the helper that started at the
+// given time.
+//
+// Usage:
+//
+//	defer metrics.ObserveQuery("fetch", time.Now())
+func ObserveQuery(helper string, start time.Time) {
+	queryDuration.WithLabelValues(helper).Observe(time.Since(start).Seconds())
+}
+
+// RegisterDB exposes the statistics of the connection pool (open, in-use and
+// idle connections, wait count and duration, and closed connections).
+func RegisterDB(db *sql.DB, name string) {
+	var already prometheus.AlreadyRegisteredError
+
+	// The pool of a reconnected database replaces the previous one.
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, name))
+	if errors.As(err, &already) {
+		prometheus.Unregister(already.ExistingCollector)
+		prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+	}
+}
+
+// StockSummary returns the total stock units on hand and the number of items
+// that are low on stock.
+type StockSummary func() (units, lowStock float64, err error)
+
+// stockCollector collects the stock gauges when the metrics are scraped.
+type stockCollector struct {
+	summary  StockSummary
+	units    *prometheus.Desc
+	lowStock *prometheus.Desc
+}
+
+// RegisterStock exposes the total stock units and the low-stock item count,
+// which are computed on every scrape.
+func RegisterStock(summary StockSummary) {
+	prometheus.MustRegister(&stockCollector{
+		summary: summary,
+		units: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "stock", "units"),
+			"Total stock units on hand of all the items.", nil, nil,
+		),
+		lowStock: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "stock", "low_items"),
+			"Number of items at or below the low-stock threshold.", nil, nil,
+		),
+	})
+}
+
+func (c *stockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.units
+	ch <- c.lowStock
+}
+
+func (c *stockCollector) Collect(ch chan<- prometheus.Metric) {
+	units, lowStock, err := c.summary()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.units, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.units, prometheus.GaugeValue, units)
+	ch <- prometheus.MustNewConstMetric(c.lowStock, prometheus.GaugeValue, lowStock)
+}
@@ -0,0 +1,367 @@
+package stream
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+)
+
+// Options holds how the committed events are streamed: how often the outbox is
+// polled, how many of the latest events are kept to resume a stream from, how
+// many events can be queued for a connection before it is dropped, how often an
+// idle stream is kept alive, and how long an event ID that is skipped is waited
+// for, as the transaction that wrote it may commit after the later ones.
+type Options struct {
+	PollInterval  time.Duration
+	BufferSize    int
+	ClientBuffer  int
+	Heartbeat     time.Duration
+	CommitTimeout time.Duration
+}
+
+// Filter selects the events of a stream. Zero values are not filtered on. The
+// transaction events are not about a single item or storage, so they are not
+// selected by an item or storage filter.
+type Filter struct {
+	ItemID    int
+	StorageID int
+	Types     []string
+}
+
+// Matches returns true if the event is selected by the filter.
+func (f Filter) Matches(event schema.OutboxEvent) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type) {
+		return false
+	}
+
+	if f.ItemID != 0 && (!event.ItemID.Valid || int(event.ItemID.Int32) != f.ItemID) {
+		return false
+	}
+
+	if f.StorageID != 0 && (!event.StorageID.Valid || int(event.StorageID.Int32) != f.StorageID) {
+		return false
+	}
+
+	return true
+}
+
+// Subscriber receives the events of a stream. Events is closed when the
+// subscriber is dropped for not keeping up, or when it unsubscribes.
+type Subscriber struct {
+	Events  chan schema.OutboxEvent
+	Dropped bool
+	filter  Filter
+}
+
+// Broker keeps the latest events in a bounded buffer and publishes the new
+// events to its subscribers. The events are buffered and published in the order
+// they are committed, which is not always the order of their IDs.
+type Broker struct {
+	mu           sync.Mutex
+	buffer       []schema.OutboxEvent
+	evicted      int64
+	size         int
+	clientBuffer int
+	subscribers  map[*Subscriber]struct{}
+	closed       bool
+}
+
+var (
+	// broker is the broker of the committed events, started by Start.
+	broker *Broker
+
+	// heartbeat is how often an idle stream is kept alive.
+	heartbeat time.Duration
+)
+
+// NewBroker returns a broker that keeps the given number of the latest events
+// and queues at most clientBuffer events for each subscriber.
+func NewBroker(size, clientBuffer int) *Broker {
+	return &Broker{
+		size:         size,
+		clientBuffer: clientBuffer,
+		subscribers:  make(map[*Subscriber]struct{}),
+	}
+}
+
+// Subscribe adds a subscriber of the events selected by the filter. If
+// lastEventID is not zero, it also returns the buffered events that were
+// published after it to resume the stream from. It returns false if the events
+// after lastEventID are no longer buffered, so that some of them cannot be
+// resumed. It returns a nil subscriber if the broker is closed.
+func (b *Broker) Subscribe(filter Filter, lastEventID int64) (*Subscriber, []schema.OutboxEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, false
+	}
+
+	subscriber := &Subscriber{
+		Events: make(chan schema.OutboxEvent, b.clientBuffer),
+		filter: filter,
+	}
+
+	b.subscribers[subscriber] = struct{}{}
+
+	if lastEventID == 0 {
+		return subscriber, nil, true
+	}
+
+	// The events that were published after the last one the subscriber
+	// received follow it in the buffer, including those with a lower ID that
+	// were committed later.
+	received := slices.IndexFunc(b.buffer, func(event schema.OutboxEvent) bool { return event.ID == lastEventID })
+
+	// An event that is not buffered was received before the broker started, so
+	// the events after it are all buffered unless some of them were evicted.
+	complete := received >= 0 || lastEventID >= b.evicted
+
+	var backlog []schema.OutboxEvent
+
+	for i, event := range b.buffer {
+		after := i > received
+		if received < 0 {
+			after = event.ID > lastEventID
+		}
+
+		if after && filter.Matches(event) {
+			backlog = append(backlog, event)
+		}
+	}
+
+	return subscriber, backlog, complete
+}
+
+// Unsubscribe removes the subscriber and closes its events.
+func (b *Broker) Unsubscribe(subscriber *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(subscriber)
+}
+
+// Publish buffers the events and queues them for every subscriber they are
+// selected for. A subscriber whose queue is full is dropped rather than
+// blocking the others; it can resume from the buffer when it reconnects.
+func (b *Broker) Publish(events ...schema.OutboxEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, event := range events {
+		b.buffer = append(b.buffer, event)
+		if len(b.buffer) > b.size {
+			evicted := len(b.buffer) - b.size
+
+			for _, old := range b.buffer[:evicted] {
+				b.evicted = max(b.evicted, old.ID)
+			}
+
+			b.buffer = slices.Delete(b.buffer, 0, evicted)
+		}
+
+		for subscriber := range b.subscribers {
+			if !subscriber.filter.Matches(event) {
+				continue
+			}
+
+			select {
+			case subscriber.Events <- event:
+
+			default:
+				subscriber.Dropped = true
+				b.remove(subscriber)
+			}
+		}
+	}
+}
+
+// Close removes all the subscribers, which ends their streams, and refuses the
+// new ones.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for subscriber := range b.subscribers {
+		b.remove(subscriber)
+	}
+}
+
+func (b *Broker) remove(subscriber *Subscriber) {
+	_, exists := b.subscribers[subscriber]
+	if !exists {
+		return
+	}
+
+	delete(b.subscribers, subscriber)
+	close(subscriber.Events)
+}
+
+// Subscribe adds a subscriber to the broker started by Start. It returns nil if
+// the broker is not running or is closed.
+func Subscribe(filter Filter, lastEventID int64) (*Subscriber, []schema.OutboxEvent, bool) {
+	if broker == nil {
+		return nil, nil, false
+	}
+
+	return broker.Subscribe(filter, lastEventID)
+}
+
+// Unsubscribe removes the subscriber from the broker started by Start.
+func Unsubscribe(subscriber *Subscriber) {
+	if broker != nil {
+		broker.Unsubscribe(subscriber)
+	}
+}
+
+// Heartbeat returns how often an idle stream is kept alive.
+func Heartbeat() time.Duration { return heartbeat }
+
+// Start creates the broker of the committed events. The events are published
+// by Run.
+func Start(options Options) {
+	broker = NewBroker(options.BufferSize, options.ClientBuffer)
+	heartbeat = options.Heartbeat
+}
+
+// Close ends the streams of all the subscribers of the broker started by Start,
+// so that they do not hold the HTTP server open on shutdown.
+func Close() {
+	if broker != nil {
+		broker.Close()
+	}
+}
+
+// cursor tracks which events of the outbox are published. The IDs of the events
+// are assigned when they are written rather than when they are committed, so an
+// event can be committed after the events with a greater ID. The outbox is read
+// from the lowest ID that may still be committed, and the events that are
+// already published are skipped. An ID that is skipped is waited for until the
+// timeout, after which its transaction is taken to be rolled back.
+type cursor struct {
+	after     int64
+	last      int64
+	timeout   time.Duration
+	published map[int64]struct{}
+	missing   map[int64]time.Time
+}
+
+// newCursor returns a cursor of the events after the given ID.
+func newCursor(after int64, timeout time.Duration) *cursor {
+	return &cursor{
+		after:     after,
+		last:      after,
+		timeout:   timeout,
+		published: make(map[int64]struct{}),
+		missing:   make(map[int64]time.Time),
+	}
+}
+
+// next returns the events that are not published yet, in the order they were
+// read, and moves the cursor past the IDs that are published or that were
+// missing for longer than the timeout.
+func (c *cursor) next(events []schema.OutboxEvent, now time.Time) []schema.OutboxEvent {
+	var fresh []schema.OutboxEvent
+
+	for _, event := range events {
+		_, published := c.published[event.ID]
+		if event.ID <= c.after || published {
+			continue
+		}
+
+		c.published[event.ID] = struct{}{}
+		delete(c.missing, event.ID)
+
+		fresh = append(fresh, event)
+	}
+
+	// The IDs before the latest event that are not committed yet are missing
+	// since they were first skipped.
+	latest := c.last
+	for id := range c.published {
+		latest = max(latest, id)
+	}
+
+	for id := c.last + 1; id < latest; id++ {
+		if _, published := c.published[id]; !published {
+			c.missing[id] = now
+		}
+	}
+
+	c.last = latest
+
+	for c.after < c.last {
+		id := c.after + 1
+
+		if _, published := c.published[id]; published {
+			delete(c.published, id)
+		} else if since, missing := c.missing[id]; !missing || now.Sub(since) < c.timeout {
+			break
+		}
+
+		delete(c.missing, id)
+		c.after = id
+	}
+
+	return fresh
+}
+
+// pending returns the number of events after the cursor that are published.
+func (c *cursor) pending() int { return len(c.published) }
+
+// Run fills the buffer of the broker started by Start with the latest events of
+// the outbox and publishes the events that are committed after, on every poll
+// interval until the context is cancelled.
+func Run(ctx context.Context, options Options) {
+	defer log.Panic()
+
+	lastEventID, err := mysql.LatestEventID()
+	if err != nil {
+		log.Error(err, "failed to retrieve the latest event")
+	}
+
+	// The events before the buffer were committed before the start.
+	after := max(lastEventID-int64(options.BufferSize), 0)
+	published := newCursor(after, options.CommitTimeout)
+
+	broker.mu.Lock()
+	broker.evicted = after
+	broker.mu.Unlock()
+
+	poll := func() {
+		// The events after the cursor that are already published are read
+		// again, so the limit leaves room for the new ones.
+		events, err := mysql.ListEventsAfter(published.after, options.BufferSize+published.pending())
+		if err != nil {
+			log.Error(err, "failed to retrieve the new events")
+			return
+		}
+
+		broker.Publish(published.next(events, time.Now())...)
+	}
+
+	if lastEventID > 0 {
+		poll()
+	}
+
+	ticker := time.NewTicker(options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			broker.Close()
+			return
+
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
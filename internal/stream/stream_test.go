@@ -0,0 +1,204 @@
+package stream
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+// events returns stock change events of item 1 with the IDs.
+func events(ids ...int64) []schema.OutboxEvent {
+	var list []schema.OutboxEvent
+
+	for _, id := range ids {
+		list = append(list, schema.OutboxEvent{ID: id, Type: schema.EventItemStockChanged, ItemID: sql.NullInt32{Int32: 1, Valid: true}})
+	}
+
+	return list
+}
+
+func ids(list []schema.OutboxEvent) []int64 {
+	var result []int64
+	for _, event := range list {
+		result = append(result, event.ID)
+	}
+
+	return result
+}
+
+// receive returns the IDs of the events queued for the subscriber.
+func receive(subscriber *Subscriber) []int64 {
+	var result []int64
+
+	for {
+		select {
+		case event, ok := <-subscriber.Events:
+			if !ok {
+				return result
+			}
+
+			result = append(result, event.ID)
+
+		default:
+			return result
+		}
+	}
+}
+
+func TestBrokerOrdering(t *testing.T) {
+	broker := NewBroker(10, 10)
+
+	subscriber, _, _ := broker.Subscribe(Filter{}, 0)
+
+	// Event 2 is committed after events 1 and 3.
+	broker.Publish(events(1, 3)...)
+	broker.Publish(events(2)...)
+
+	if got := receive(subscriber); !slices.Equal(got, []int64{1, 3, 2}) {
+		t.Errorf("received %v, want the events in the order they were published", got)
+	}
+}
+
+func TestBrokerResume(t *testing.T) {
+	broker := NewBroker(4, 10)
+	broker.Publish(events(1, 3, 2, 4)...)
+
+	tests := []struct {
+		name        string
+		lastEventID int64
+		filter      Filter
+		want        []int64
+		complete    bool
+	}{
+		{name: "after an event", lastEventID: 1, want: []int64{3, 2, 4}, complete: true},
+		{name: "event committed late", lastEventID: 3, want: []int64{2, 4}, complete: true},
+		{name: "latest event", lastEventID: 4, complete: true},
+		{name: "filtered", lastEventID: 1, filter: Filter{ItemID: 2}, complete: true},
+		{name: "new stream", complete: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, backlog, complete := broker.Subscribe(test.filter, test.lastEventID)
+			if !slices.Equal(ids(backlog), test.want) || complete != test.complete {
+				t.Errorf("Subscribe(%d) = %v, %t, want %v, %t", test.lastEventID, ids(backlog), complete, test.want, test.complete)
+			}
+		})
+	}
+
+	// Events 1 and 3 are evicted, so what followed event 1 cannot be resumed.
+	broker.Publish(events(5, 6)...)
+
+	_, backlog, complete := broker.Subscribe(Filter{}, 1)
+	if complete || !slices.Equal(ids(backlog), []int64{2, 4, 5, 6}) {
+		t.Errorf("Subscribe(1) = %v, %t, want the buffered events and a reset", ids(backlog), complete)
+	}
+
+	// An event that is not buffered and after the evicted ones was received
+	// before the broker started.
+	_, backlog, complete = broker.Subscribe(Filter{}, 5)
+	if !complete || !slices.Equal(ids(backlog), []int64{6}) {
+		t.Errorf("Subscribe(5) = %v, %t, want event 6", ids(backlog), complete)
+	}
+
+	broker.Close()
+
+	subscriber, _, _ := broker.Subscribe(Filter{}, 0)
+	if subscriber != nil {
+		t.Error("Subscribe() after Close() = a subscriber, want nil")
+	}
+}
+
+func TestBrokerBackpressure(t *testing.T) {
+	broker := NewBroker(10, 2)
+
+	slow, _, _ := broker.Subscribe(Filter{}, 0)
+	other, _, _ := broker.Subscribe(Filter{ItemID: 2}, 0)
+
+	broker.Publish(events(1, 2, 3)...)
+
+	// The queued events are still received before the events are closed.
+	if got := receive(slow); !slices.Equal(got, []int64{1, 2}) || !slow.Dropped {
+		t.Errorf("slow subscriber received %v, dropped %t, want 1 and 2 and dropped", got, slow.Dropped)
+	}
+
+	if _, ok := <-slow.Events; ok {
+		t.Error("slow subscriber events are open, want closed")
+	}
+
+	// A subscriber that is not sent the events is not held back by the slow one.
+	broker.Publish(schema.OutboxEvent{ID: 4, ItemID: sql.NullInt32{Int32: 2, Valid: true}})
+
+	if got := receive(other); !slices.Equal(got, []int64{4}) || other.Dropped {
+		t.Errorf("other subscriber received %v, dropped %t, want event 4", got, other.Dropped)
+	}
+
+	// Unsubscribing a dropped subscriber does not close its events again.
+	broker.Unsubscribe(slow)
+	broker.Unsubscribe(other)
+}
+
+func TestCursor(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newCursor(10, time.Minute)
+
+	steps := []struct {
+		name  string
+		read  []int64
+		at    time.Duration
+		fresh []int64
+		after int64
+	}{
+		{name: "event 12 before 11 commits", read: []int64{12, 13}, fresh: []int64{12, 13}, after: 10},
+		{name: "event 11 commits late", read: []int64{11, 12, 13}, at: time.Second, fresh: []int64{11}, after: 13},
+		{name: "published events are skipped", read: []int64{14, 16}, at: 2 * time.Second, fresh: []int64{14, 16}, after: 14},
+		{name: "event 15 is still waited for", read: []int64{16, 17}, at: time.Minute, fresh: []int64{17}, after: 14},
+		{name: "event 15 was rolled back", read: []int64{16, 17}, at: 2*time.Second + time.Minute, after: 17},
+		{name: "events before the cursor are ignored", read: []int64{9, 17, 18}, at: 2 * time.Minute, fresh: []int64{18}, after: 18},
+	}
+
+	for _, step := range steps {
+		fresh := c.next(events(step.read...), now.Add(step.at))
+		if !slices.Equal(ids(fresh), step.fresh) || c.after != step.after {
+			t.Errorf("%s: next(%v) = %v after %d, want %v after %d", step.name, step.read, ids(fresh), c.after, step.fresh, step.after)
+		}
+	}
+
+	if c.pending() != 0 || len(c.missing) != 0 {
+		t.Errorf("cursor = %+v, want nothing pending", c)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	event := schema.OutboxEvent{
+		Type:      schema.EventItemStockChanged,
+		ItemID:    sql.NullInt32{Int32: 1, Valid: true},
+		StorageID: sql.NullInt32{Int32: 2, Valid: true},
+	}
+
+	transaction := schema.OutboxEvent{Type: schema.EventTransactionCancelled}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		event  schema.OutboxEvent
+		want   bool
+	}{
+		{name: "no filter", event: event, want: true},
+		{name: "item", filter: Filter{ItemID: 1}, event: event, want: true},
+		{name: "other item", filter: Filter{ItemID: 2}, event: event},
+		{name: "storage", filter: Filter{StorageID: 2}, event: event, want: true},
+		{name: "type", filter: Filter{Types: []string{schema.EventTransactionCancelled}}, event: transaction, want: true},
+		{name: "other type", filter: Filter{Types: []string{schema.EventTransactionCancelled}}, event: event},
+		{name: "transaction by item", filter: Filter{ItemID: 1}, event: transaction},
+	}
+
+	for _, test := range tests {
+		if got := test.filter.Matches(test.event); got != test.want {
+			t.Errorf("%s: Matches() = %t, want %t", test.name, got, test.want)
+		}
+	}
+}
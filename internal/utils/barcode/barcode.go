@@ -0,0 +1,105 @@
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Supported barcode types.
+const (
+	UPCA   string = "upc_a"
+	EAN13  string = "ean13"
+	GTIN14 string = "gtin14"
+)
+
+var (
+	ErrInvalidBarcode    = errors.New("barcode must only contain digits")
+	ErrUnsupportedLength = errors.New("barcode must be 12 (UPC-A), 13 (EAN-13) or 14 (GTIN-14) digits")
+	ErrCheckDigit        = errors.New("barcode check digit is invalid")
+)
+
+// lengths maps the barcode types to their number of digits.
+var lengths = map[string]int{
+	UPCA:   12,
+	EAN13:  13,
+	GTIN14: 14,
+}
+
+// Validate checks the digits, length and GS1 check digit of the barcode. If the
+// type is not provided, it is detected from the length of the barcode.
+//
+// Returns:
+//   - string: The type of the barcode.
+//   - error: The reason the barcode is invalid.
+func Validate(code, kind string) (string, error) {
+	code = strings.TrimSpace(code)
+
+	for _, digit := range code {
+		if digit < '0' || digit > '9' {
+			return "", ErrInvalidBarcode
+		}
+	}
+
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		kind = Detect(code)
+	}
+
+	length, ok := lengths[kind]
+	if !ok || len(code) != length {
+		if ok {
+			return "", fmt.Errorf("%s: %w", kind, ErrUnsupportedLength)
+		}
+
+		return "", ErrUnsupportedLength
+	}
+
+	if CheckDigit(code[:len(code)-1]) != int(code[len(code)-1]-'0') {
+		return "", ErrCheckDigit
+	}
+
+	return kind, nil
+}
+
+// Detect returns the barcode type based on its number of digits.
+func Detect(code string) string {
+	for kind, length := range lengths {
+		if len(code) == length {
+			return kind
+		}
+	}
+
+	return ""
+}
+
+// CheckDigit computes the GS1 modulo 10 check digit of the digits without
+// the check digit. Starting from the rightmost digit, the digits are weighted
+// by 3 and 1 alternately.
+func CheckDigit(digits string) int {
+	var sum int
+
+	for i := range len(digits) {
+		digit := int(digits[len(digits)-1-i] - '0')
+
+		if i%2 == 0 {
+			digit *= 3
+		}
+
+		sum += digit
+	}
+
+	return (10 - sum%10) % 10
+}
+
+// GTIN returns the barcode as a 14-digit GTIN padded with leading zeros, so
+// that the same product is matched whether it is scanned as UPC-A, EAN-13 or
+// GTIN-14.
+func GTIN(code string) string {
+	code = strings.TrimSpace(code)
+	if len(code) >= 14 {
+		return code
+	}
+
+	return strings.Repeat("0", 14-len(code)) + code
+}
@@ -0,0 +1,80 @@
+package barcode
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{name: "GTIN-8", code: "96385074"},
+		{name: "GTIN-12", code: "036000291452"},
+		{name: "GTIN-13", code: "4006381333931"},
+		{name: "GTIN-13 with a zero check digit", code: "4006381333900"},
+		{name: "GTIN-14", code: "10614141000415"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			digits, want := test.code[:len(test.code)-1], int(test.code[len(test.code)-1]-'0')
+
+			if got := CheckDigit(digits); got != want {
+				t.Errorf("CheckDigit(%q) = %d, want %d", digits, got, want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		kind string
+		want string
+		err  error
+	}{
+		{name: "detects UPC-A", code: "036000291452", want: UPCA},
+		{name: "detects EAN-13", code: "4006381333931", want: EAN13},
+		{name: "detects GTIN-14", code: "10614141000415", want: GTIN14},
+		{name: "given type", code: "036000291452", kind: " UPC_A ", want: UPCA},
+		{name: "surrounding spaces", code: " 4006381333931\n", want: EAN13},
+		{name: "wrong check digit", code: "4006381333932", err: ErrCheckDigit},
+		{name: "letters", code: "40063813339A1", err: ErrInvalidBarcode},
+		{name: "GTIN-8 is not supported", code: "96385074", err: ErrUnsupportedLength},
+		{name: "length of another type", code: "036000291452", kind: EAN13, err: ErrUnsupportedLength},
+		{name: "unknown type", code: "036000291452", kind: "qr", err: ErrUnsupportedLength},
+		{name: "empty", code: "", err: ErrUnsupportedLength},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Validate(test.code, test.kind)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Validate(%q, %q) error = %v, want %v", test.code, test.kind, err, test.err)
+			}
+
+			if got != test.want {
+				t.Errorf("Validate(%q, %q) = %q, want %q", test.code, test.kind, got, test.want)
+			}
+		})
+	}
+}
+
+func TestGTIN(t *testing.T) {
+	tests := map[string]string{
+		"036000291452":    "00036000291452",
+		"4006381333931":   "04006381333931",
+		"10614141000415":  "10614141000415",
+		" 036000291452\t": "00036000291452",
+		"0036000291452":   "00036000291452",
+	}
+
+	for code, want := range tests {
+		if got := GTIN(code); got != want {
+			t.Errorf("GTIN(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
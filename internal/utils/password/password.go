@@ -0,0 +1,24 @@
+// Package password hashes the passwords of the users with bcrypt, so that only
+// their hash is stored.
+package password
+
+import "golang.org/x/crypto/bcrypt"
+
+// MaxLength is the number of bytes of a password that bcrypt can hash.
+const MaxLength int = 72
+
+// Hash returns the bcrypt hash of the password, salted so that the same
+// password has a different hash each time.
+func Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Matches returns true if the hash is the hash of the password.
+func Matches(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,23 @@
+package password
+
+import "testing"
+
+func TestHash(t *testing.T) {
+	hash, err := Hash("correct-horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	if hash == "correct-horse" || !Matches(hash, "correct-horse") {
+		t.Errorf("Hash() = %q, want a hash of the password", hash)
+	}
+
+	if Matches(hash, "battery-staple") {
+		t.Error("Matches() = true for another password, want false")
+	}
+
+	again, _ := Hash("correct-horse")
+	if again == hash {
+		t.Error("Hash() returned the same hash twice, want it salted")
+	}
+}
@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/mysql"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+	dbutils "github.com/rmarasigan/warehouse-inventory-management/internal/utils/db_utils"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/log"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/utils/trail"
+)
+
+// Headers of the request that delivers an event.
+const (
+	EventHeader     string = "X-WIM-Event"
+	EventIDHeader   string = "X-WIM-Event-ID"
+	TimestampHeader string = "X-WIM-Timestamp"
+	SignatureHeader string = "X-WIM-Signature"
+)
+
+// batchSize is the number of events and deliveries handled on every poll.
+const batchSize int = 100
+
+// Options holds how the events are delivered to the webhooks.
+type Options struct {
+	PollInterval time.Duration
+	MaxAttempts  int
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+	Timeout      time.Duration
+}
+
+// Envelope is the body of the request that delivers an event.
+type Envelope struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Run delivers the events of the outbox to the webhooks on every poll interval
+// until the context is cancelled.
+func Run(ctx context.Context, options Options) {
+	defer log.Panic()
+
+	client := &http.Client{Timeout: options.Timeout}
+
+	ticker := time.NewTicker(options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			dispatch(ctx, client, options)
+		}
+	}
+}
+
+// dispatch queues the deliveries of the new events and attempts the deliveries
+// that are due.
+func dispatch(ctx context.Context, client *http.Client, options Options) {
+	_, err := mysql.FanOutEvents(batchSize)
+	if err != nil {
+		log.Error(err, "failed to queue the webhook deliveries")
+		return
+	}
+
+	attempts, err := mysql.DueDeliveries(time.Now().UTC(), batchSize)
+	if err != nil {
+		log.Error(err, "failed to retrieve the due webhook deliveries")
+		return
+	}
+
+	for _, attempt := range attempts {
+		if ctx.Err() != nil {
+			return
+		}
+
+		status, err := Deliver(ctx, client, attempt, time.Now().UTC())
+		delivery := Outcome(attempt.WebhookDelivery, status, err, options, time.Now().UTC())
+
+		if delivery.Status == schema.DeliveryDead {
+			trail.Warn("Delivery %d of event %s to %s is dead after %d attempt(s).",
+				delivery.ID, attempt.EventUUID, attempt.URL, delivery.Attempts)
+		}
+
+		err = mysql.RecordDeliveryAttempt(delivery)
+		if err != nil {
+			log.Error(err, "failed to record the webhook delivery attempt", log.KV("delivery_id", delivery.ID))
+		}
+	}
+}
+
+// Deliver POSTs the event to the webhook. It returns the HTTP status of the
+// response, or an error if the request could not be sent.
+func Deliver(ctx context.Context, client *http.Client, attempt schema.DeliveryAttempt, now time.Time) (int, error) {
+	body, err := json.Marshal(Envelope{
+		ID:        attempt.EventUUID,
+		Type:      attempt.EventType,
+		CreatedAt: attempt.EventDateCreated,
+		Data:      attempt.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, attempt.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, attempt.EventType)
+	request.Header.Set(EventIDHeader, attempt.EventUUID)
+	request.Header.Set(TimestampHeader, timestamp)
+	request.Header.Set(SignatureHeader, "sha256="+Sign(attempt.Secret, timestamp, body))
+
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	return response.StatusCode, nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of the timestamp and the body,
+// joined by a dot, with the secret of the webhook. A receiver verifies the
+// request by computing the same signature and should reject old timestamps.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before the next attempt after the given number of
+// failed attempts. The delay doubles on every attempt up to the maximum.
+func Backoff(attempts int, initial, maximum time.Duration) time.Duration {
+	delay := initial
+
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+
+		if delay >= maximum {
+			return maximum
+		}
+	}
+
+	return min(delay, maximum)
+}
+
+// Outcome returns the delivery after an attempt that responded with the status
+// or failed with the error. A 2xx response delivers the event; otherwise the
+// delivery is retried after a backoff, or is dead after the maximum attempts.
+func Outcome(delivery schema.WebhookDelivery, status int, err error, options Options, now time.Time) schema.WebhookDelivery {
+	delivery.Attempts++
+	delivery.ResponseStatus = sql.NullInt32{}
+	delivery.LastError = sql.NullString{}
+
+	if status != 0 {
+		delivery.ResponseStatus = dbutils.SetInt(int32(status))
+	}
+
+	if err == nil && status >= http.StatusOK && status < http.StatusMultipleChoices {
+		delivery.Status = schema.DeliveryDelivered
+		return delivery
+	}
+
+	if err != nil {
+		delivery.LastError = dbutils.SetString(err.Error())
+	} else {
+		delivery.LastError = dbutils.SetString(fmt.Sprintf("unexpected response status %d", status))
+	}
+
+	if delivery.Attempts >= options.MaxAttempts {
+		delivery.Status = schema.DeliveryDead
+		return delivery
+	}
+
+	delivery.Status = schema.DeliveryPending
+	delivery.NextAttemptAt = now.Add(Backoff(delivery.Attempts, options.Backoff, options.MaxBackoff))
+
+	return delivery
+}
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rmarasigan/warehouse-inventory-management/internal/database/schema"
+)
+
+var options = Options{
+	MaxAttempts: 3,
+	Backoff:     30 * time.Second,
+	MaxBackoff:  time.Hour,
+	Timeout:     time.Second,
+}
+
+func TestDeliver(t *testing.T) {
+	var (
+		received Envelope
+		headers  http.Header
+		body     []byte
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	now := time.Unix(1700000000, 0)
+	attempt := schema.DeliveryAttempt{
+		URL:       server.URL,
+		Secret:    "s3cret",
+		EventUUID: "0190a5e2-0000-7000-8000-000000000001",
+		EventType: schema.EventItemStockChanged,
+		Payload:   []byte(`{"item_id":1,"quantity":5}`),
+	}
+
+	status, err := Deliver(context.Background(), server.Client(), attempt, now)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	if status != http.StatusAccepted {
+		t.Errorf("Deliver() status = %d, want %d", status, http.StatusAccepted)
+	}
+
+	if received.ID != attempt.EventUUID || received.Type != attempt.EventType {
+		t.Errorf("envelope = %+v, want id %s and type %s", received, attempt.EventUUID, attempt.EventType)
+	}
+
+	if string(received.Data) != string(attempt.Payload) {
+		t.Errorf("envelope data = %s, want %s", received.Data, attempt.Payload)
+	}
+
+	if headers.Get(EventHeader) != attempt.EventType || headers.Get(EventIDHeader) != attempt.EventUUID {
+		t.Errorf("event headers = %s, %s", headers.Get(EventHeader), headers.Get(EventIDHeader))
+	}
+
+	timestamp := headers.Get(TimestampHeader)
+	if timestamp != "1700000000" {
+		t.Errorf("%s = %s, want 1700000000", TimestampHeader, timestamp)
+	}
+
+	want := "sha256=" + Sign(attempt.Secret, timestamp, body)
+	if headers.Get(SignatureHeader) != want {
+		t.Errorf("%s = %s, want %s", SignatureHeader, headers.Get(SignatureHeader), want)
+	}
+}
+
+func TestDeliverUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	status, err := Deliver(context.Background(), http.DefaultClient, schema.DeliveryAttempt{URL: url}, time.Now())
+	if err == nil {
+		t.Fatalf("Deliver() status = %d, want an error", status)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	const want = "b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+
+	got := Sign("secret", "1700000000", []byte("{}"))
+	if got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+
+	if got == Sign("other", "1700000000", []byte("{}")) {
+		t.Error("Sign() does not depend on the secret")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, 60 * time.Minute},
+		{20, time.Hour},
+	}
+
+	for _, test := range tests {
+		got := Backoff(test.attempts, 30*time.Second, time.Hour)
+		if got != test.want {
+			t.Errorf("Backoff(%d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	now := time.Now().UTC()
+
+	delivered := Outcome(schema.WebhookDelivery{}, http.StatusOK, nil, options, now)
+	if delivered.Status != schema.DeliveryDelivered || delivered.Attempts != 1 || delivered.LastError.Valid {
+		t.Errorf("Outcome(200) = %+v, want delivered", delivered)
+	}
+
+	retried := Outcome(schema.WebhookDelivery{Attempts: 1}, http.StatusInternalServerError, nil, options, now)
+	if retried.Status != schema.DeliveryPending || !retried.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Outcome(500) = %+v, want pending in 1m", retried)
+	}
+
+	if retried.ResponseStatus.Int32 != http.StatusInternalServerError || !retried.LastError.Valid {
+		t.Errorf("Outcome(500) = %+v, want the response status and error", retried)
+	}
+
+	dead := Outcome(schema.WebhookDelivery{Attempts: 2}, 0, errors.New("connection refused"), options, now)
+	if dead.Status != schema.DeliveryDead || dead.ResponseStatus.Valid {
+		t.Errorf("Outcome(error) = %+v, want dead", dead)
+	}
+}
@@ -3,15 +3,9 @@ package main
 import (
 	"os"
 
-	"github.com/rmarasigan/warehouse-inventory-management/internal/app"
-	"github.com/rmarasigan/warehouse-inventory-management/internal/app/db"
+	"github.com/rmarasigan/warehouse-inventory-management/internal/app/cli"
 )
 
 func main() {
-	// Check if the argument for initialize db is set.
-	if len(os.Args) > 1 && os.Args[1] == "--db=init" {
-		db.Initialize()
-	}
-
-	app.StartServer()
+	os.Exit(cli.Run(os.Args[1:]))
 }